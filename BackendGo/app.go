@@ -1,22 +1,31 @@
 package main
 
 import (
+	"CryptoLens_Backend/config"
 	"CryptoLens_Backend/container"
-	"CryptoLens_Backend/env"
 	"CryptoLens_Backend/initialization"
+	"CryptoLens_Shared/logger"
 	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 )
 
 func main() {
+	// Служебные команды выполняются вместо запуска сервера
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+
 	initialization.Initialize()
-	
-	ctr := container.NewContainer(initialization.DB, []byte(env.GetJWTSecret()))
-	ctr.RegisterRoutes()
+	cfg := config.Get()
+
+	ctr := container.NewContainer(initialization.DB, initialization.Keyring, cfg)
+	handler := ctr.RegisterRoutes()
 
 	// Создаем контекст с возможностью отмены
 	ctx, cancel := context.WithCancel(context.Background())
@@ -27,25 +36,33 @@ func main() {
 
 	// Настраиваем graceful shutdown
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	// Запускаем сервер в отдельной горутине
+	port := strconv.Itoa(cfg.Server.Port)
+	server := &http.Server{Addr: ":" + port, Handler: handler}
 	go func() {
-		log.Println("Server starting on " + env.GetServerPort())
-		if err := http.ListenAndServe(":"+env.GetServerPort(), nil); err != nil {
+		log.Println("Server starting on " + port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatal(err)
 		}
 	}()
 
-	// Ждем сигнала для завершения
-	<-sigChan
+	// Ждем сигнала для завершения; SIGHUP перечитывает конфигурацию
+	for sig := range sigChan {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		if err := config.Reload(); err != nil {
+			logger.LogError("Ошибка перезагрузки конфигурации, продолжаем со старой: %v", err)
+		}
+	}
 	log.Println("Shutting down gracefully...")
-	
-	// Отменяем контекст, что приведет к остановке фоновых задач
-	cancel()
-	
-	// Закрываем соединения
-	if err := ctr.Close(); err != nil {
+
+	// По истечении срока остановки оставшиеся шаги прерываются
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), config.Get().Server.ShutdownTimeout.Std())
+	defer cancelShutdown()
+	if err := ctr.Shutdown(shutdownCtx, server, cancel); err != nil {
 		log.Printf("Error during shutdown: %v", err)
 	}
 }
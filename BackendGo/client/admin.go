@@ -0,0 +1,96 @@
+package client
+
+import (
+	"CryptoLens_Backend/models"
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Операции администратора
+var (
+	opGetLogLevel              = newOperation(http.MethodGet, "/api/v1/admin/log-level")
+	opSetLogLevel              = newOperation(http.MethodPut, "/api/v1/admin/log-level")
+	opGetSystemStatus          = newOperation(http.MethodGet, "/api/v1/admin/status")
+	opListUsers                = newOperation(http.MethodGet, "/api/v1/admin/users")
+	opSetUserDisabled          = newOperation(http.MethodPatch, "/api/v1/admin/users/{id}")
+	opGetAuthEvents            = newOperation(http.MethodGet, "/api/v1/admin/auth-events")
+	opGetUserStrategiesAsAdmin = newOperation(http.MethodGet, "/api/v1/admin/users/{id}/strategies")
+	opStopStrategyAsAdmin      = newOperation(http.MethodPost, "/api/v1/admin/strategies/{id}/stop")
+)
+
+func (c *client) GetLogLevel(ctx context.Context) (*models.LogLevelResponse, error) {
+	var resp models.LogLevelResponse
+	if err := c.do(ctx, opGetLogLevel, nil, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) SetLogLevel(ctx context.Context, level string) (*models.LogLevelResponse, error) {
+	var resp models.LogLevelResponse
+	req := models.LogLevelRequest{Level: level}
+	if err := c.do(ctx, opSetLogLevel, nil, nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) GetSystemStatus(ctx context.Context) (*models.SystemStatus, error) {
+	var resp models.SystemStatus
+	if err := c.do(ctx, opGetSystemStatus, nil, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) ListUsers(ctx context.Context, limit, offset int) ([]models.AdminUserResponse, error) {
+	query := url.Values{}
+	limitQuery(query, limit)
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+	var resp []models.AdminUserResponse
+	if err := c.do(ctx, opListUsers, nil, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *client) SetUserDisabled(ctx context.Context, userID string, disabled bool) (*models.AdminUserResponse, error) {
+	var resp models.AdminUserResponse
+	req := models.SetUserDisabledRequest{Disabled: disabled}
+	if err := c.do(ctx, opSetUserDisabled, []string{userID}, nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) GetAuthEvents(ctx context.Context, userID string, limit int) ([]models.AuthAuditEntry, error) {
+	query := url.Values{}
+	setIfNotEmpty(query, "user_id", userID)
+	limitQuery(query, limit)
+	var resp []models.AuthAuditEntry
+	if err := c.do(ctx, opGetAuthEvents, nil, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *client) GetUserStrategiesAsAdmin(ctx context.Context, userID string) ([]models.UserStrategyResponse, error) {
+	var resp []models.UserStrategyResponse
+	if err := c.do(ctx, opGetUserStrategiesAsAdmin, []string{userID}, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *client) StopStrategyAsAdmin(ctx context.Context, id, reason string) (*models.UserStrategyResponse, error) {
+	var resp models.UserStrategyResponse
+	req := models.AdminStopStrategyRequest{Reason: reason}
+	if err := c.do(ctx, opStopStrategyAsAdmin, []string{id}, nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,334 @@
+package client
+
+import (
+	"CryptoLens_Backend/integration/bybit"
+	"CryptoLens_Backend/models"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Операции API в том виде, в каком они описаны в openapi.json
+var (
+	opRegister             = newOperation(http.MethodPost, "/api/v1/user/register")
+	opLogin                = newOperation(http.MethodPost, "/api/v1/user/login")
+	opRefreshToken         = newOperation(http.MethodPost, "/api/v1/user/token/refresh")
+	opLogout               = newOperation(http.MethodPost, "/api/v1/user/logout")
+	opVerifyEmail          = newOperation(http.MethodPost, "/api/v1/user/email/verify")
+	opResendVerification   = newOperation(http.MethodPost, "/api/v1/user/email/resend")
+	opRequestPasswordReset = newOperation(http.MethodPost, "/api/v1/user/password/reset")
+	opConfirmPasswordReset = newOperation(http.MethodPost, "/api/v1/user/password/reset/confirm")
+
+	opGetAccount    = newOperation(http.MethodGet, "/api/v1/user/account")
+	opGetSessions   = newOperation(http.MethodGet, "/api/v1/user/sessions")
+	opRevokeSession = newOperation(http.MethodDelete, "/api/v1/user/sessions/{id}")
+
+	opGetTwoFactorStatus      = newOperation(http.MethodGet, "/api/v1/user/2fa")
+	opSetupTwoFactor          = newOperation(http.MethodPost, "/api/v1/user/2fa/setup")
+	opEnableTwoFactor         = newOperation(http.MethodPost, "/api/v1/user/2fa/enable")
+	opDisableTwoFactor        = newOperation(http.MethodPost, "/api/v1/user/2fa/disable")
+	opRegenerateRecoveryCodes = newOperation(http.MethodPost, "/api/v1/user/2fa/recovery-codes")
+
+	opGetAPIKeys   = newOperation(http.MethodGet, "/api/v1/user/api-keys")
+	opCreateAPIKey = newOperation(http.MethodPost, "/api/v1/user/api-keys")
+	opRemoveAPIKey = newOperation(http.MethodDelete, "/api/v1/user/api-keys/{id}")
+
+	opGetUserInstruments     = newOperation(http.MethodGet, "/api/v1/user/instruments")
+	opAddInstrument          = newOperation(http.MethodPost, "/api/v1/user/instruments")
+	opUpdateInstrumentStatus = newOperation(http.MethodPatch, "/api/v1/user/instruments/{id}")
+	opRemoveInstrument       = newOperation(http.MethodDelete, "/api/v1/user/instruments/{id}")
+
+	opGetUserStrategies    = newOperation(http.MethodGet, "/api/v1/user/strategies")
+	opAddStrategy          = newOperation(http.MethodPost, "/api/v1/user/strategies")
+	opUpdateStrategyStatus = newOperation(http.MethodPatch, "/api/v1/user/strategies/{id}")
+	opRemoveStrategy       = newOperation(http.MethodDelete, "/api/v1/user/strategies/{id}")
+
+	opGetWalletBalance    = newOperation(http.MethodGet, "/api/v1/bybit/wallet/balance")
+	opGetWalletBalances   = newOperation(http.MethodGet, "/api/v1/bybit/wallet/balances")
+	opGetFeeRate          = newOperation(http.MethodGet, "/api/v1/bybit/wallet/fee-rate")
+	opGetBybitInstruments = newOperation(http.MethodGet, "/api/v1/bybit/instruments")
+
+	opGetBybitAccounts              = newOperation(http.MethodGet, "/api/v1/user/bybit/accounts")
+	opAddBybitAccount               = newOperation(http.MethodPost, "/api/v1/user/bybit/accounts")
+	opRotateBybitAccountCredentials = newOperation(http.MethodPut, "/api/v1/user/bybit/accounts/{id}/credentials")
+	opSetBybitAccountStatus         = newOperation(http.MethodPatch, "/api/v1/user/bybit/accounts/{id}")
+	opRemoveBybitAccount            = newOperation(http.MethodDelete, "/api/v1/user/bybit/accounts/{id}")
+
+	opGetOpenAPI = newOperation(http.MethodGet, "/api/v1/openapi.json")
+)
+
+func (c *client) Register(ctx context.Context, req models.RegisterRequest) (*models.RegisterResponse, error) {
+	var resp models.RegisterResponse
+	if err := c.do(ctx, opRegister, nil, nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) Login(ctx context.Context, req models.LoginRequest) (*models.LoginResponse, error) {
+	var resp models.LoginResponse
+	if err := c.do(ctx, opLogin, nil, nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) RefreshToken(ctx context.Context, refreshToken string) (*models.LoginResponse, error) {
+	var resp models.LoginResponse
+	req := models.RefreshTokenRequest{RefreshToken: refreshToken}
+	if err := c.do(ctx, opRefreshToken, nil, nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) Logout(ctx context.Context) (*models.LogoutResponse, error) {
+	var resp models.LogoutResponse
+	if err := c.do(ctx, opLogout, nil, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) VerifyEmail(ctx context.Context, token string) error {
+	return c.do(ctx, opVerifyEmail, nil, nil, models.VerifyEmailRequest{Token: token}, nil)
+}
+
+func (c *client) ResendVerification(ctx context.Context) error {
+	return c.do(ctx, opResendVerification, nil, nil, nil, nil)
+}
+
+func (c *client) RequestPasswordReset(ctx context.Context, email string) error {
+	return c.do(ctx, opRequestPasswordReset, nil, nil, models.PasswordResetRequest{Email: email}, nil)
+}
+
+func (c *client) ConfirmPasswordReset(ctx context.Context, req models.ConfirmPasswordResetRequest) error {
+	return c.do(ctx, opConfirmPasswordReset, nil, nil, req, nil)
+}
+
+func (c *client) GetAccount(ctx context.Context) (*models.User, error) {
+	var resp models.User
+	if err := c.do(ctx, opGetAccount, nil, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) GetSessions(ctx context.Context) ([]models.UserSession, error) {
+	var resp []models.UserSession
+	if err := c.do(ctx, opGetSessions, nil, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *client) RevokeSession(ctx context.Context, id string) error {
+	return c.do(ctx, opRevokeSession, []string{id}, nil, nil, nil)
+}
+
+func (c *client) GetTwoFactorStatus(ctx context.Context) (*models.TwoFactorStatus, error) {
+	var resp models.TwoFactorStatus
+	if err := c.do(ctx, opGetTwoFactorStatus, nil, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) SetupTwoFactor(ctx context.Context) (*models.TwoFactorSetupResponse, error) {
+	var resp models.TwoFactorSetupResponse
+	if err := c.do(ctx, opSetupTwoFactor, nil, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) EnableTwoFactor(ctx context.Context, code string) (*models.RecoveryCodesResponse, error) {
+	var resp models.RecoveryCodesResponse
+	if err := c.do(ctx, opEnableTwoFactor, nil, nil, models.TwoFactorCodeRequest{Code: code}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) DisableTwoFactor(ctx context.Context, req models.DisableTwoFactorRequest) error {
+	return c.do(ctx, opDisableTwoFactor, nil, nil, req, nil)
+}
+
+func (c *client) RegenerateRecoveryCodes(ctx context.Context, code string) (*models.RecoveryCodesResponse, error) {
+	var resp models.RecoveryCodesResponse
+	if err := c.do(ctx, opRegenerateRecoveryCodes, nil, nil, models.TwoFactorCodeRequest{Code: code}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	var resp []models.APIKey
+	if err := c.do(ctx, opGetAPIKeys, nil, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *client) CreateAPIKey(ctx context.Context, req models.CreateAPIKeyRequest) (*models.CreateAPIKeyResponse, error) {
+	var resp models.CreateAPIKeyResponse
+	if err := c.do(ctx, opCreateAPIKey, nil, nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) RemoveAPIKey(ctx context.Context, id string) error {
+	return c.do(ctx, opRemoveAPIKey, []string{id}, nil, nil, nil)
+}
+
+func (c *client) GetUserInstruments(ctx context.Context) ([]models.UserInstrumentResponse, error) {
+	var resp []models.UserInstrumentResponse
+	if err := c.do(ctx, opGetUserInstruments, nil, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *client) AddInstrument(ctx context.Context, symbol string) (*models.UserInstrumentResponse, error) {
+	var resp models.UserInstrumentResponse
+	req := models.CreateUserInstrumentRequest{Symbol: symbol}
+	if err := c.do(ctx, opAddInstrument, nil, nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) UpdateInstrumentStatus(ctx context.Context, id string, isActive bool) error {
+	req := models.UpdateUserInstrumentRequest{IsActive: isActive}
+	return c.do(ctx, opUpdateInstrumentStatus, []string{id}, nil, req, nil)
+}
+
+func (c *client) RemoveInstrument(ctx context.Context, id string) error {
+	return c.do(ctx, opRemoveInstrument, []string{id}, nil, nil, nil)
+}
+
+func (c *client) GetUserStrategies(ctx context.Context) ([]models.UserStrategyResponse, error) {
+	var resp []models.UserStrategyResponse
+	if err := c.do(ctx, opGetUserStrategies, nil, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *client) AddStrategy(ctx context.Context, req models.CreateUserStrategyRequest) (*models.UserStrategyResponse, error) {
+	var resp models.UserStrategyResponse
+	if err := c.do(ctx, opAddStrategy, nil, nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) UpdateStrategyStatus(ctx context.Context, id string, isActive bool) error {
+	req := models.UpdateUserStrategyRequest{IsActive: isActive}
+	return c.do(ctx, opUpdateStrategyStatus, []string{id}, nil, req, nil)
+}
+
+func (c *client) RemoveStrategy(ctx context.Context, id string) error {
+	return c.do(ctx, opRemoveStrategy, []string{id}, nil, nil, nil)
+}
+
+func (c *client) GetWalletBalance(ctx context.Context, accountID int64) (*bybit.BybitWalletBalance, error) {
+	var resp bybit.BybitWalletBalance
+	if err := c.do(ctx, opGetWalletBalance, nil, accountQuery(url.Values{}, accountID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) GetWalletBalances(ctx context.Context) (*models.WalletBalancesResponse, error) {
+	var resp models.WalletBalancesResponse
+	if err := c.do(ctx, opGetWalletBalances, nil, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) GetFeeRate(ctx context.Context, params FeeRateParams) (*bybit.BybitFeeRateResponse, error) {
+	query := url.Values{}
+	setIfNotEmpty(query, "category", params.Category)
+	setIfNotEmpty(query, "symbol", params.Symbol)
+	setIfNotEmpty(query, "base_coin", params.BaseCoin)
+
+	var resp bybit.BybitFeeRateResponse
+	if err := c.do(ctx, opGetFeeRate, nil, accountQuery(query, params.AccountID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) GetBybitInstruments(ctx context.Context, category string) ([]models.BybitInstrument, error) {
+	query := url.Values{}
+	setIfNotEmpty(query, "category", category)
+
+	var resp models.BybitInstrumentResponse
+	if err := c.do(ctx, opGetBybitInstruments, nil, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+func (c *client) GetBybitAccounts(ctx context.Context) ([]models.BybitAccountResponse, error) {
+	var resp []models.BybitAccountResponse
+	if err := c.do(ctx, opGetBybitAccounts, nil, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *client) AddBybitAccount(ctx context.Context, req models.CreateBybitAccountRequest) (*models.BybitAccountResponse, error) {
+	var resp models.BybitAccountResponse
+	if err := c.do(ctx, opAddBybitAccount, nil, nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) RotateBybitAccountCredentials(ctx context.Context, id int64, req models.RotateBybitAccountRequest) (*models.BybitAccountResponse, error) {
+	var resp models.BybitAccountResponse
+	if err := c.do(ctx, opRotateBybitAccountCredentials, []string{strconv.FormatInt(id, 10)}, nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) SetBybitAccountStatus(ctx context.Context, id int64, req models.SetBybitAccountStatusRequest) (*models.BybitAccountResponse, error) {
+	var resp models.BybitAccountResponse
+	if err := c.do(ctx, opSetBybitAccountStatus, []string{strconv.FormatInt(id, 10)}, nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) RemoveBybitAccount(ctx context.Context, id int64, req models.RemoveBybitAccountRequest) error {
+	return c.do(ctx, opRemoveBybitAccount, []string{strconv.FormatInt(id, 10)}, nil, req, nil)
+}
+
+func (c *client) GetOpenAPI(ctx context.Context) (json.RawMessage, error) {
+	var resp json.RawMessage
+	if err := c.do(ctx, opGetOpenAPI, nil, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// accountQuery добавляет account_id, если аккаунт указан
+func accountQuery(query url.Values, accountID int64) url.Values {
+	if accountID > 0 {
+		query.Set("account_id", strconv.FormatInt(accountID, 10))
+	}
+	return query
+}
+
+func setIfNotEmpty(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
@@ -0,0 +1,277 @@
+// Package client типизированный клиент REST API CryptoLens. Запросы и ответы описаны теми же
+// моделями, что кодируют обработчики, а набор операций сверяется с openapi.json командой
+// check-api-contract, поэтому клиент не расходится с сервером.
+package client
+
+import (
+	"CryptoLens_Backend/httpapi"
+	"CryptoLens_Backend/integration/bybit"
+	"CryptoLens_Backend/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client клиент REST API CryptoLens
+type Client interface {
+	// WithToken возвращает клиент, который подписывает запросы другим токеном доступа или API-ключом
+	WithToken(token string) Client
+
+	Register(ctx context.Context, req models.RegisterRequest) (*models.RegisterResponse, error)
+	Login(ctx context.Context, req models.LoginRequest) (*models.LoginResponse, error)
+	RefreshToken(ctx context.Context, refreshToken string) (*models.LoginResponse, error)
+	Logout(ctx context.Context) (*models.LogoutResponse, error)
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context) error
+	RequestPasswordReset(ctx context.Context, email string) error
+	ConfirmPasswordReset(ctx context.Context, req models.ConfirmPasswordResetRequest) error
+
+	GetAccount(ctx context.Context) (*models.User, error)
+	GetSessions(ctx context.Context) ([]models.UserSession, error)
+	RevokeSession(ctx context.Context, id string) error
+
+	GetTwoFactorStatus(ctx context.Context) (*models.TwoFactorStatus, error)
+	SetupTwoFactor(ctx context.Context) (*models.TwoFactorSetupResponse, error)
+	EnableTwoFactor(ctx context.Context, code string) (*models.RecoveryCodesResponse, error)
+	DisableTwoFactor(ctx context.Context, req models.DisableTwoFactorRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, code string) (*models.RecoveryCodesResponse, error)
+
+	GetAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	CreateAPIKey(ctx context.Context, req models.CreateAPIKeyRequest) (*models.CreateAPIKeyResponse, error)
+	RemoveAPIKey(ctx context.Context, id string) error
+
+	GetUserInstruments(ctx context.Context) ([]models.UserInstrumentResponse, error)
+	AddInstrument(ctx context.Context, symbol string) (*models.UserInstrumentResponse, error)
+	UpdateInstrumentStatus(ctx context.Context, id string, isActive bool) error
+	RemoveInstrument(ctx context.Context, id string) error
+
+	GetUserStrategies(ctx context.Context) ([]models.UserStrategyResponse, error)
+	AddStrategy(ctx context.Context, req models.CreateUserStrategyRequest) (*models.UserStrategyResponse, error)
+	UpdateStrategyStatus(ctx context.Context, id string, isActive bool) error
+	RemoveStrategy(ctx context.Context, id string) error
+
+	// accountID 0 — единственный активный аккаунт пользователя
+	GetWalletBalance(ctx context.Context, accountID int64) (*bybit.BybitWalletBalance, error)
+	GetWalletBalances(ctx context.Context) (*models.WalletBalancesResponse, error)
+	GetFeeRate(ctx context.Context, params FeeRateParams) (*bybit.BybitFeeRateResponse, error)
+	GetBybitInstruments(ctx context.Context, category string) ([]models.BybitInstrument, error)
+
+	GetBybitAccounts(ctx context.Context) ([]models.BybitAccountResponse, error)
+	AddBybitAccount(ctx context.Context, req models.CreateBybitAccountRequest) (*models.BybitAccountResponse, error)
+	RotateBybitAccountCredentials(ctx context.Context, id int64, req models.RotateBybitAccountRequest) (*models.BybitAccountResponse, error)
+	SetBybitAccountStatus(ctx context.Context, id int64, req models.SetBybitAccountStatusRequest) (*models.BybitAccountResponse, error)
+	RemoveBybitAccount(ctx context.Context, id int64, req models.RemoveBybitAccountRequest) error
+
+	GetNotificationSettings(ctx context.Context) (*models.NotificationSettingsResponse, error)
+	UpsertNotificationChannel(ctx context.Context, req models.UpsertNotificationChannelRequest) (*models.NotificationChannel, error)
+	RemoveNotificationChannel(ctx context.Context, channel string) error
+	UpdateNotificationPreference(ctx context.Context, req models.UpdateNotificationPreferenceRequest) error
+	SendTestNotification(ctx context.Context) error
+
+	// scope пустой — чат получает область read
+	CreateTelegramLinkCode(ctx context.Context, scope string) (*models.TelegramLinkResponse, error)
+	GetTelegramChats(ctx context.Context) ([]models.TelegramChat, error)
+	UnlinkTelegramChat(ctx context.Context, chatID int64) error
+
+	GetWebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error)
+	CreateWebhookEndpoint(ctx context.Context, req models.CreateWebhookEndpointRequest) (*models.CreateWebhookEndpointResponse, error)
+	RemoveWebhookEndpoint(ctx context.Context, id string) error
+	SendTestWebhook(ctx context.Context, id string) error
+	// endpointID пустой — доставки на все эндпоинты; limit 0 — значение сервера по умолчанию
+	GetWebhookDeliveries(ctx context.Context, endpointID string, limit int) ([]models.WebhookDelivery, error)
+	RetryWebhookDelivery(ctx context.Context, id string) error
+
+	// Методы администратора: нужна роль admin, а API-ключу еще и область admin
+	GetLogLevel(ctx context.Context) (*models.LogLevelResponse, error)
+	SetLogLevel(ctx context.Context, level string) (*models.LogLevelResponse, error)
+	GetSystemStatus(ctx context.Context) (*models.SystemStatus, error)
+	ListUsers(ctx context.Context, limit, offset int) ([]models.AdminUserResponse, error)
+	SetUserDisabled(ctx context.Context, userID string, disabled bool) (*models.AdminUserResponse, error)
+	// userID пустой — события всех пользователей
+	GetAuthEvents(ctx context.Context, userID string, limit int) ([]models.AuthAuditEntry, error)
+	GetUserStrategiesAsAdmin(ctx context.Context, userID string) ([]models.UserStrategyResponse, error)
+	StopStrategyAsAdmin(ctx context.Context, id, reason string) (*models.UserStrategyResponse, error)
+
+	// OpenStream подключается к WebSocket-потоку событий аккаунтов и рыночных данных
+	OpenStream(ctx context.Context) (Stream, error)
+
+	// GetOpenAPI возвращает документ OpenAPI, по которому работает сервер
+	GetOpenAPI(ctx context.Context) (json.RawMessage, error)
+}
+
+// FeeRateParams параметры запроса ставок комиссии; пустые поля не передаются
+type FeeRateParams struct {
+	Category  string // spot по умолчанию
+	Symbol    string
+	BaseCoin  string
+	AccountID int64
+}
+
+// APIError ошибка, которую вернул сервер. Code — машиночитаемый код из httpapi.
+type APIError struct {
+	Status     int
+	Code       string
+	Message    string
+	RequestID  string
+	RetryAfter time.Duration // Для rate_limited: через сколько можно повторить запрос
+}
+
+func (e *APIError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("cryptolens api: %d %s: %s (request %s)", e.Status, e.Code, e.Message, e.RequestID)
+	}
+	return fmt.Sprintf("cryptolens api: %d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// client реализация Client
+type client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient создает клиент. token — токен доступа из Login или персональный API-ключ,
+// для публичных методов можно передать пустую строку.
+func NewClient(baseURL, token string) Client {
+	return &client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+func (c *client) WithToken(token string) Client {
+	clone := *c
+	clone.token = token
+	return &clone
+}
+
+// operation метод и шаблон пути из спецификации
+type operation struct {
+	method string
+	path   string
+}
+
+// operations все операции клиента; заполняется объявлениями newOperation
+var operations []operation
+
+func newOperation(method, path string) operation {
+	op := operation{method: method, path: path}
+	operations = append(operations, op)
+	return op
+}
+
+// Operations возвращает операции клиента в виде "GET /api/v1/user/strategies/{id}" для сверки со спецификацией
+func Operations() []string {
+	result := make([]string, len(operations))
+	for i, op := range operations {
+		result[i] = op.method + " " + op.path
+	}
+	sort.Strings(result)
+	return result
+}
+
+// do выполняет операцию. Параметры пути подставляются по порядку вместо {…} в шаблоне,
+// in кодируется в тело запроса, ответ декодируется в out, если он задан.
+func (c *client) do(ctx context.Context, op operation, pathParams []string, query url.Values, in, out interface{}) error {
+	path, err := expandPath(op.path, pathParams)
+	if err != nil {
+		return err
+	}
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, op.method, target, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", op.method, op.path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return decodeError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s %s response: %w", op.method, op.path, err)
+	}
+	return nil
+}
+
+// expandPath подставляет параметры в шаблон пути
+func expandPath(template string, params []string) (string, error) {
+	var b strings.Builder
+	rest := template
+	for _, param := range params {
+		start := strings.IndexByte(rest, '{')
+		end := strings.IndexByte(rest, '}')
+		if start < 0 || end < start {
+			return "", fmt.Errorf("too many path parameters for %s", template)
+		}
+		b.WriteString(rest[:start])
+		b.WriteString(url.PathEscape(param))
+		rest = rest[end+1:]
+	}
+	if strings.IndexByte(rest, '{') >= 0 {
+		return "", fmt.Errorf("missing path parameters for %s", template)
+	}
+	b.WriteString(rest)
+	return b.String(), nil
+}
+
+// decodeError разбирает конверт ошибки. Ответы не от приложения (например, прокси) сохраняют статус и текст.
+func decodeError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	apiErr := &APIError{
+		Status:    resp.StatusCode,
+		Code:      httpapi.StatusCode(resp.StatusCode),
+		Message:   strings.TrimSpace(string(data)),
+		RequestID: resp.Header.Get(httpapi.HeaderRequestID),
+	}
+	var envelope httpapi.ErrorResponse
+	if err := json.Unmarshal(data, &envelope); err == nil && envelope.Error.Code != "" {
+		apiErr.Code = envelope.Error.Code
+		apiErr.Message = envelope.Error.Message
+		if envelope.Error.RequestID != "" {
+			apiErr.RequestID = envelope.Error.RequestID
+		}
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return apiErr
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"CryptoLens_Backend/models"
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Операции уведомлений, Telegram и исходящих вебхуков
+var (
+	opGetNotificationSettings      = newOperation(http.MethodGet, "/api/v1/user/notifications/settings")
+	opUpsertNotificationChannel    = newOperation(http.MethodPut, "/api/v1/user/notifications/channels")
+	opRemoveNotificationChannel    = newOperation(http.MethodDelete, "/api/v1/user/notifications/channels/{channel}")
+	opUpdateNotificationPreference = newOperation(http.MethodPut, "/api/v1/user/notifications/preferences")
+	opSendTestNotification         = newOperation(http.MethodPost, "/api/v1/user/notifications/test")
+
+	opCreateTelegramLinkCode = newOperation(http.MethodPost, "/api/v1/user/telegram/link")
+	opGetTelegramChats       = newOperation(http.MethodGet, "/api/v1/user/telegram/chats")
+	opUnlinkTelegramChat     = newOperation(http.MethodDelete, "/api/v1/user/telegram/chats/{chat_id}")
+
+	opGetWebhookEndpoints   = newOperation(http.MethodGet, "/api/v1/user/webhooks")
+	opCreateWebhookEndpoint = newOperation(http.MethodPost, "/api/v1/user/webhooks")
+	opRemoveWebhookEndpoint = newOperation(http.MethodDelete, "/api/v1/user/webhooks/{id}")
+	opSendTestWebhook       = newOperation(http.MethodPost, "/api/v1/user/webhooks/{id}/test")
+	opGetWebhookDeliveries  = newOperation(http.MethodGet, "/api/v1/user/webhooks/deliveries")
+	opRetryWebhookDelivery  = newOperation(http.MethodPost, "/api/v1/user/webhooks/deliveries/{id}/retry")
+)
+
+func (c *client) GetNotificationSettings(ctx context.Context) (*models.NotificationSettingsResponse, error) {
+	var resp models.NotificationSettingsResponse
+	if err := c.do(ctx, opGetNotificationSettings, nil, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) UpsertNotificationChannel(ctx context.Context, req models.UpsertNotificationChannelRequest) (*models.NotificationChannel, error) {
+	var resp models.NotificationChannel
+	if err := c.do(ctx, opUpsertNotificationChannel, nil, nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) RemoveNotificationChannel(ctx context.Context, channel string) error {
+	return c.do(ctx, opRemoveNotificationChannel, []string{channel}, nil, nil, nil)
+}
+
+func (c *client) UpdateNotificationPreference(ctx context.Context, req models.UpdateNotificationPreferenceRequest) error {
+	return c.do(ctx, opUpdateNotificationPreference, nil, nil, req, nil)
+}
+
+func (c *client) SendTestNotification(ctx context.Context) error {
+	return c.do(ctx, opSendTestNotification, nil, nil, nil, nil)
+}
+
+func (c *client) CreateTelegramLinkCode(ctx context.Context, scope string) (*models.TelegramLinkResponse, error) {
+	var resp models.TelegramLinkResponse
+	req := models.CreateTelegramLinkRequest{Scope: scope}
+	if err := c.do(ctx, opCreateTelegramLinkCode, nil, nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) GetTelegramChats(ctx context.Context) ([]models.TelegramChat, error) {
+	var resp []models.TelegramChat
+	if err := c.do(ctx, opGetTelegramChats, nil, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *client) UnlinkTelegramChat(ctx context.Context, chatID int64) error {
+	return c.do(ctx, opUnlinkTelegramChat, []string{strconv.FormatInt(chatID, 10)}, nil, nil, nil)
+}
+
+func (c *client) GetWebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	var resp []models.WebhookEndpoint
+	if err := c.do(ctx, opGetWebhookEndpoints, nil, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *client) CreateWebhookEndpoint(ctx context.Context, req models.CreateWebhookEndpointRequest) (*models.CreateWebhookEndpointResponse, error) {
+	var resp models.CreateWebhookEndpointResponse
+	if err := c.do(ctx, opCreateWebhookEndpoint, nil, nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *client) RemoveWebhookEndpoint(ctx context.Context, id string) error {
+	return c.do(ctx, opRemoveWebhookEndpoint, []string{id}, nil, nil, nil)
+}
+
+func (c *client) SendTestWebhook(ctx context.Context, id string) error {
+	return c.do(ctx, opSendTestWebhook, []string{id}, nil, nil, nil)
+}
+
+func (c *client) GetWebhookDeliveries(ctx context.Context, endpointID string, limit int) ([]models.WebhookDelivery, error) {
+	query := url.Values{}
+	setIfNotEmpty(query, "endpoint_id", endpointID)
+	limitQuery(query, limit)
+	var resp []models.WebhookDelivery
+	if err := c.do(ctx, opGetWebhookDeliveries, nil, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *client) RetryWebhookDelivery(ctx context.Context, id string) error {
+	return c.do(ctx, opRetryWebhookDelivery, []string{id}, nil, nil, nil)
+}
+
+// limitQuery добавляет limit, если он задан
+func limitQuery(query url.Values, limit int) {
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+}
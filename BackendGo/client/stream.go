@@ -0,0 +1,102 @@
+package client
+
+import (
+	"CryptoLens_Backend/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var opOpenStream = newOperation(http.MethodGet, "/api/v1/stream")
+
+// Stream подключение к потоку событий /api/v1/stream
+type Stream interface {
+	// Subscribe подписывается на топики tickers.<SYMBOL> и orderbook.<SYMBOL>; ответ придет через Next
+	Subscribe(topics ...string) error
+	Unsubscribe(topics ...string) error
+	// Next ждет следующее сообщение сервера: событие или ответ на команду
+	Next() (*StreamEvent, error)
+	Close() error
+}
+
+// StreamEvent сообщение потока. Для событий заполнены Topic, AccountID, Time и Data,
+// для ответов на команды — ID, Topics, Code и Message.
+type StreamEvent struct {
+	Type      string          `json:"type"`
+	Topic     string          `json:"topic,omitempty"`
+	AccountID int64           `json:"account_id,omitempty"`
+	Time      time.Time       `json:"ts"`
+	Data      json.RawMessage `json:"data,omitempty"`
+
+	ID      string   `json:"id,omitempty"`
+	Topics  []string `json:"topics,omitempty"`
+	Code    string   `json:"code,omitempty"`
+	Message string   `json:"message,omitempty"`
+}
+
+// OpenStream подключается к потоку событий. Закрытие с кодом 1013 означает, что клиент не успевал
+// читать события: их нужно сверить через REST и подключиться заново.
+func (c *client) OpenStream(ctx context.Context) (Stream, error) {
+	target := c.baseURL + opOpenStream.path
+	switch {
+	case strings.HasPrefix(target, "https://"):
+		target = "wss://" + strings.TrimPrefix(target, "https://")
+	case strings.HasPrefix(target, "http://"):
+		target = "ws://" + strings.TrimPrefix(target, "http://")
+	}
+
+	header := http.Header{}
+	if c.token != "" {
+		header.Set("Authorization", "Bearer "+c.token)
+	}
+	dialer := websocket.Dialer{HandshakeTimeout: c.httpClient.Timeout}
+	conn, resp, err := dialer.DialContext(ctx, target, header)
+	if err != nil {
+		if errors.Is(err, websocket.ErrBadHandshake) && resp != nil {
+			return nil, decodeError(resp)
+		}
+		return nil, fmt.Errorf("%s %s: %w", opOpenStream.method, opOpenStream.path, err)
+	}
+	return &stream{conn: conn}, nil
+}
+
+// stream реализация Stream
+type stream struct {
+	conn       *websocket.Conn
+	writeMutex sync.Mutex
+}
+
+func (s *stream) Subscribe(topics ...string) error {
+	return s.send(models.StreamRequest{Op: models.StreamOpSubscribe, Topics: topics})
+}
+
+func (s *stream) Unsubscribe(topics ...string) error {
+	return s.send(models.StreamRequest{Op: models.StreamOpUnsubscribe, Topics: topics})
+}
+
+func (s *stream) send(req models.StreamRequest) error {
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+	return s.conn.WriteJSON(req)
+}
+
+func (s *stream) Next() (*StreamEvent, error) {
+	var event StreamEvent
+	if err := s.conn.ReadJSON(&event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (s *stream) Close() error {
+	s.writeMutex.Lock()
+	s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	s.writeMutex.Unlock()
+	return s.conn.Close()
+}
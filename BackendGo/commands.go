@@ -0,0 +1,127 @@
+package main
+
+import (
+	"CryptoLens_Backend/client"
+	"CryptoLens_Backend/initialization"
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/openapi"
+	"CryptoLens_Backend/repositories"
+	"CryptoLens_Backend/routes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+const commandsUsage = `Commands:
+  reencrypt-secrets     encrypt all Bybit API, TOTP and webhook secrets with the active master key
+  set-role EMAIL ROLE   assign a role (admin or user) to a user
+  check-api-contract    compare routes, models and the API client with openapi/openapi.json
+`
+
+// runCommand выполняет служебную команду и возвращает код завершения процесса
+func runCommand(name string, args []string) int {
+	switch name {
+	case "reencrypt-secrets":
+		return reencryptSecrets()
+	case "set-role":
+		if len(args) != 2 {
+			fmt.Fprint(os.Stderr, "usage: set-role EMAIL ROLE\n\n"+commandsUsage)
+			return 2
+		}
+		return setRole(args[0], args[1])
+	case "check-api-contract":
+		return checkAPIContract()
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", name, commandsUsage)
+		return 2
+	}
+}
+
+// reencryptSecrets шифрует открытые API-секреты и секреты вебхуков и переводит остальные секреты,
+// включая секреты TOTP, на активный мастер-ключ.
+// Для смены ключа новый ключ добавляется в SECRETS_MASTER_KEYS, становится активным через
+// SECRETS_ACTIVE_KEY_VERSION, сервис перезапускается, после чего запускается эта команда.
+// Старый ключ можно удалять, когда команда завершилась без ошибок.
+func reencryptSecrets() int {
+	initialization.InitializeStorage()
+	defer initialization.DB.Close()
+
+	ctx := context.Background()
+	accounts, err := repositories.NewBybitAccountRepository(initialization.DB, initialization.Keyring).ReencryptSecrets(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reencrypt-secrets: %v\n", err)
+		return 1
+	}
+	totp, err := repositories.NewUserTOTPRepository(initialization.DB, initialization.Keyring).ReencryptSecrets(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reencrypt-secrets: %v\n", err)
+		return 1
+	}
+	webhooks, err := repositories.NewWebhookRepository(initialization.DB, initialization.Keyring).ReencryptSecrets(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reencrypt-secrets: %v\n", err)
+		return 1
+	}
+
+	output, _ := json.Marshal(map[string]*models.SecretsReencryptResult{
+		"bybit_accounts":    accounts,
+		"user_totp":         totp,
+		"webhook_endpoints": webhooks,
+	})
+	fmt.Println(string(output))
+	if accounts.Failed > 0 || totp.Failed > 0 || webhooks.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// setRole назначает роль пользователю. Так появляется первый администратор: через API роли не выдаются.
+// Новая роль попадает в токены при следующем входе или обновлении токена.
+func setRole(email, role string) int {
+	if role != models.RoleAdmin && role != models.RoleUser {
+		fmt.Fprintf(os.Stderr, "set-role: unknown role %q\n", role)
+		return 2
+	}
+
+	initialization.InitializeStorage()
+	defer initialization.DB.Close()
+
+	repo := repositories.NewUserRepository(initialization.DB)
+	if err := repo.SetRole(context.Background(), email, role); err != nil {
+		fmt.Fprintf(os.Stderr, "set-role: %v\n", err)
+		return 1
+	}
+	fmt.Printf("%s is now %s\n", email, role)
+	return 0
+}
+
+// checkAPIContract сверяет спецификацию OpenAPI с маршрутами, моделями и клиентом API и завершается
+// с ошибкой при расхождении. Базы данных не требует: маршруты регистрируются без обработчиков.
+func checkAPIContract() int {
+	router := routes.NewContractRouter()
+
+	var problems []string
+	for _, check := range []func() ([]string, error){
+		func() ([]string, error) { return openapi.CheckRoutes(router.Patterns()) },
+		func() ([]string, error) { return openapi.CheckClient(client.Operations()) },
+		openapi.CheckSchemas,
+	} {
+		found, err := check()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "check-api-contract: %v\n", err)
+			return 1
+		}
+		problems = append(problems, found...)
+	}
+
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "check-api-contract: %d problems\n", len(problems))
+		return 1
+	}
+	fmt.Println("API contract is consistent")
+	return 0
+}
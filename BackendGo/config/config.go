@@ -0,0 +1,329 @@
+package config
+
+import (
+	"CryptoLens_Shared/logger"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Config настройки приложения.
+// Значения берутся из умолчаний, затем из файла CONFIG_FILE, затем из переменных окружения.
+// Поля с тегом reload:"true" применяются без перезапуска по SIGHUP.
+type Config struct {
+	Server        ServerConfig        `json:"server" yaml:"server"`
+	Log           LogConfig           `json:"log" yaml:"log"`
+	Database      DatabaseConfig      `json:"database" yaml:"database"`
+	Redis         RedisConfig         `json:"redis" yaml:"redis"`
+	Bybit         BybitConfig         `json:"bybit" yaml:"bybit"`
+	Auth          AuthConfig          `json:"auth" yaml:"auth"`
+	Secrets       SecretsConfig       `json:"secrets" yaml:"secrets"`
+	Mail          MailConfig          `json:"mail" yaml:"mail"`
+	Telegram      TelegramConfig      `json:"telegram" yaml:"telegram"`
+	Notifications NotificationsConfig `json:"notifications" yaml:"notifications"`
+	Strategies    StrategiesConfig    `json:"strategies" yaml:"strategies"`
+}
+
+type ServerConfig struct {
+	Port            int      `json:"port" yaml:"port" env:"SERVER_PORT"`
+	AllowedOrigins  string   `json:"allowed_origins" yaml:"allowed_origins" env:"SERVER_ALLOWED_ORIGINS"`    // Через запятую: браузерные клиенты потока с других доменов
+	ShutdownTimeout Duration `json:"shutdown_timeout" yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"` // Общий срок на остановку после SIGTERM
+}
+
+// Origins возвращает список разрешенных источников
+func (c ServerConfig) Origins() []string {
+	var origins []string
+	for _, origin := range strings.Split(c.AllowedOrigins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+type LogConfig struct {
+	Level      string `json:"level" yaml:"level" env:"LOG_LEVEL" reload:"true"`
+	File       string `json:"file" yaml:"file" env:"LOG_FILE"`
+	MaxSizeMB  int    `json:"max_size_mb" yaml:"max_size_mb" env:"LOG_MAX_SIZE_MB"`
+	MaxBackups int    `json:"max_backups" yaml:"max_backups" env:"LOG_MAX_BACKUPS"`
+}
+
+type DatabaseConfig struct {
+	Host     string `json:"host" yaml:"host" env:"DB_HOST"`
+	Port     int    `json:"port" yaml:"port" env:"DB_PORT_LOCAL"`
+	Name     string `json:"name" yaml:"name" env:"DB_DATABASE"`
+	User     string `json:"user" yaml:"user" env:"DB_USERNAME"`
+	Password Secret `json:"password" yaml:"password" env:"DB_PASSWORD"`
+}
+
+type RedisConfig struct {
+	Host     string `json:"host" yaml:"host" env:"REDIS_HOST"`
+	Port     int    `json:"port" yaml:"port" env:"REDIS_PORT_LOCAL"`
+	Password Secret `json:"password" yaml:"password" env:"REDIS_PASSWORD"`
+}
+
+type BybitConfig struct {
+	APIURL                    string   `json:"api_url" yaml:"api_url" env:"BYBIT_API_URL"`
+	APITestURL                string   `json:"api_test_url" yaml:"api_test_url" env:"BYBIT_API_TEST_URL"`
+	WSURL                     string   `json:"ws_url" yaml:"ws_url" env:"BYBIT_WS_URL"`
+	WSTestURL                 string   `json:"ws_test_url" yaml:"ws_test_url" env:"BYBIT_WS_TEST_URL"`
+	APIDemoURL                string   `json:"api_demo_url" yaml:"api_demo_url" env:"BYBIT_API_DEMO_URL"`
+	WSDemoURL                 string   `json:"ws_demo_url" yaml:"ws_demo_url" env:"BYBIT_WS_DEMO_URL"`
+	RecvWindow                int      `json:"recv_window" yaml:"recv_window" env:"BYBIT_RECV_WINDOW"`
+	APIMode                   string   `json:"api_mode" yaml:"api_mode" env:"BYBIT_API_MODE"` // Публичные данные и аккаунты без окружения
+	InstrumentsUpdateInterval Duration `json:"instruments_update_interval" yaml:"instruments_update_interval" env:"BYBIT_INSTRUMENTS_UPDATE_INTERVAL" reload:"true"`
+	WSTopicsPerConnection     int      `json:"ws_topics_per_connection" yaml:"ws_topics_per_connection" env:"BYBIT_WS_TOPICS_PER_CONNECTION"` // Предел публичных каналов на одно соединение
+}
+
+type AuthConfig struct {
+	JWTSecret       Secret   `json:"jwt_secret" yaml:"jwt_secret" env:"JWT_SECRET"`
+	AccessTokenTTL  Duration `json:"access_token_ttl" yaml:"access_token_ttl" env:"JWT_ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL Duration `json:"refresh_token_ttl" yaml:"refresh_token_ttl" env:"JWT_REFRESH_TOKEN_TTL"` // Срок сессии от входа, ротация его не продлевает
+
+	// Ограничения частоты хранятся в Redis и общие для всех экземпляров сервиса
+	LoginIPLimit     int      `json:"login_ip_limit" yaml:"login_ip_limit" env:"AUTH_LOGIN_IP_LIMIT" reload:"true"` // Попыток входа с одного IP за окно
+	LoginIPWindow    Duration `json:"login_ip_window" yaml:"login_ip_window" env:"AUTH_LOGIN_IP_WINDOW" reload:"true"`
+	LockoutThreshold int      `json:"lockout_threshold" yaml:"lockout_threshold" env:"AUTH_LOCKOUT_THRESHOLD" reload:"true"` // Неудачных входов подряд до блокировки аккаунта
+	LockoutBase      Duration `json:"lockout_base" yaml:"lockout_base" env:"AUTH_LOCKOUT_BASE" reload:"true"`                // Первая блокировка, каждая следующая неудача удваивает срок
+	LockoutMax       Duration `json:"lockout_max" yaml:"lockout_max" env:"AUTH_LOCKOUT_MAX" reload:"true"`
+	RegisterIPLimit  int      `json:"register_ip_limit" yaml:"register_ip_limit" env:"AUTH_REGISTER_IP_LIMIT" reload:"true"` // Регистраций с одного IP за окно
+	RegisterIPWindow Duration `json:"register_ip_window" yaml:"register_ip_window" env:"AUTH_REGISTER_IP_WINDOW" reload:"true"`
+
+	RequireEmailVerification bool `json:"require_email_verification" yaml:"require_email_verification" env:"AUTH_REQUIRE_EMAIL_VERIFICATION"` // Без подтвержденного email нельзя подключить ключи биржи
+}
+
+// SecretsConfig мастер-ключи для шифрования API-секретов Bybit.
+// Ключи задаются строкой "версия:base64" через запятую и/или файлом с одним ключом на строку.
+type SecretsConfig struct {
+	MasterKeys       Secret `json:"master_keys" yaml:"master_keys" env:"SECRETS_MASTER_KEYS"`
+	MasterKeysFile   string `json:"master_keys_file" yaml:"master_keys_file" env:"SECRETS_MASTER_KEYS_FILE"`
+	ActiveKeyVersion int    `json:"active_key_version" yaml:"active_key_version" env:"SECRETS_ACTIVE_KEY_VERSION"`
+}
+
+// MailConfig настройки отправки писем по SMTP. Без SMTP_HOST письма не отправляются.
+type MailConfig struct {
+	SMTPHost         string   `json:"smtp_host" yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort         int      `json:"smtp_port" yaml:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername     string   `json:"smtp_username" yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword     Secret   `json:"smtp_password" yaml:"smtp_password" env:"SMTP_PASSWORD"`
+	SMTPTLS          string   `json:"smtp_tls" yaml:"smtp_tls" env:"SMTP_TLS"` // starttls, tls или none для локального тестового сервера
+	From             string   `json:"from" yaml:"from" env:"MAIL_FROM"`
+	LinkBaseURL      string   `json:"link_base_url" yaml:"link_base_url" env:"MAIL_LINK_BASE_URL"` // Адрес клиента, на который ведут ссылки из писем
+	VerificationTTL  Duration `json:"verification_ttl" yaml:"verification_ttl" env:"MAIL_VERIFICATION_TTL"`
+	PasswordResetTTL Duration `json:"password_reset_ttl" yaml:"password_reset_ttl" env:"MAIL_PASSWORD_RESET_TTL"`
+}
+
+// Enabled сообщает, настроена ли отправка писем
+func (c MailConfig) Enabled() bool {
+	return c.SMTPHost != ""
+}
+
+type TelegramConfig struct {
+	APIURL      string `json:"api_url" yaml:"api_url" env:"TELEGRAM_API_URL"`
+	BotToken    Secret `json:"bot_token" yaml:"bot_token" env:"TELEGRAM_BOT_TOKEN"`
+	BotEnabled  bool   `json:"bot_enabled" yaml:"bot_enabled" env:"TELEGRAM_BOT_ENABLED"`
+	PollTimeout int    `json:"poll_timeout" yaml:"poll_timeout" env:"TELEGRAM_POLL_TIMEOUT"`
+}
+
+type NotificationsConfig struct {
+	DailySummaryHour int      `json:"daily_summary_hour" yaml:"daily_summary_hour" env:"NOTIFICATIONS_DAILY_SUMMARY_HOUR"`
+	WebhookTimeout   Duration `json:"webhook_timeout" yaml:"webhook_timeout" env:"NOTIFICATIONS_WEBHOOK_TIMEOUT"`
+}
+
+type StrategiesConfig struct {
+	ParametersUpdateInterval Duration `json:"parameters_update_interval" yaml:"parameters_update_interval" env:"STRATEGY_PARAMETERS_UPDATE_INTERVAL" reload:"true"`
+	CancelOrdersOnShutdown   bool     `json:"cancel_orders_on_shutdown" yaml:"cancel_orders_on_shutdown" env:"STRATEGY_CANCEL_ORDERS_ON_SHUTDOWN" reload:"true"` // false — ордера стратегий остаются на бирже после остановки сервера
+}
+
+// Default возвращает конфигурацию со значениями по умолчанию.
+// Уровень логирования не задается: он зависит от DEBUG и выбирается после чтения всех источников.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:            2500,
+			ShutdownTimeout: Duration(30 * time.Second),
+		},
+		Log: LogConfig{
+			File:       "logs/app.log",
+			MaxSizeMB:  100,
+			MaxBackups: 5,
+		},
+		Database: DatabaseConfig{Port: 5432},
+		Redis:    RedisConfig{Port: 6379},
+		Bybit: BybitConfig{
+			APIURL:                    "https://api.bybit.com",
+			APITestURL:                "https://api-testnet.bybit.com",
+			WSURL:                     "wss://stream.bybit.com",
+			WSTestURL:                 "wss://stream-testnet.bybit.com",
+			APIDemoURL:                "https://api-demo.bybit.com",
+			WSDemoURL:                 "wss://stream-demo.bybit.com",
+			RecvWindow:                5000,
+			APIMode:                   "main",
+			InstrumentsUpdateInterval: Duration(5 * time.Minute),
+			WSTopicsPerConnection:     30,
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:   Duration(15 * time.Minute),
+			RefreshTokenTTL:  Duration(30 * 24 * time.Hour),
+			LoginIPLimit:     20,
+			LoginIPWindow:    Duration(15 * time.Minute),
+			LockoutThreshold: 5,
+			LockoutBase:      Duration(time.Minute),
+			LockoutMax:       Duration(time.Hour),
+			RegisterIPLimit:  5,
+			RegisterIPWindow: Duration(time.Hour),
+
+			RequireEmailVerification: true,
+		},
+		Secrets: SecretsConfig{ActiveKeyVersion: 1},
+		Mail: MailConfig{
+			SMTPPort:         587,
+			SMTPTLS:          "starttls",
+			VerificationTTL:  Duration(48 * time.Hour),
+			PasswordResetTTL: Duration(time.Hour),
+		},
+		Telegram: TelegramConfig{
+			APIURL:      "https://api.telegram.org",
+			BotEnabled:  true,
+			PollTimeout: 30,
+		},
+		Notifications: NotificationsConfig{
+			DailySummaryHour: 21,
+			WebhookTimeout:   Duration(10 * time.Second),
+		},
+		Strategies: StrategiesConfig{
+			ParametersUpdateInterval: Duration(5 * time.Minute),
+			CancelOrdersOnShutdown:   true,
+		},
+	}
+}
+
+// IsTestnet сообщает, работает ли приложение с тестовой сетью Bybit
+func (c BybitConfig) IsTestnet() bool {
+	return c.APIMode == "test"
+}
+
+// StreamURL возвращает адрес WebSocket для выбранного режима без пути потока
+func (c BybitConfig) StreamURL() string {
+	if c.IsTestnet() {
+		return c.WSTestURL
+	}
+	return c.WSURL
+}
+
+// Validate проверяет конфигурацию и возвращает все найденные ошибки сразу
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(validPort(c.Server.Port), "SERVER_PORT: port must be between 1 and 65535, got %d", c.Server.Port)
+	for _, origin := range c.Server.Origins() {
+		errs = append(errs, validURL("SERVER_ALLOWED_ORIGINS", origin, "http", "https")...)
+	}
+	check(c.Server.ShutdownTimeout > 0, "SERVER_SHUTDOWN_TIMEOUT: must be positive, got %s", c.Server.ShutdownTimeout)
+
+	if _, err := logger.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL: %w (expected debug, info, warn or error)", err))
+	}
+	check(c.Log.MaxSizeMB >= 0, "LOG_MAX_SIZE_MB: must not be negative, got %d", c.Log.MaxSizeMB)
+	check(c.Log.MaxBackups >= 0, "LOG_MAX_BACKUPS: must not be negative, got %d", c.Log.MaxBackups)
+
+	check(c.Database.Host != "", "DB_HOST: is required")
+	check(validPort(c.Database.Port), "DB_PORT_LOCAL: port must be between 1 and 65535, got %d", c.Database.Port)
+	check(c.Database.Name != "", "DB_DATABASE: is required")
+	check(c.Database.User != "", "DB_USERNAME: is required")
+
+	check(c.Redis.Host != "", "REDIS_HOST: is required")
+	check(validPort(c.Redis.Port), "REDIS_PORT_LOCAL: port must be between 1 and 65535, got %d", c.Redis.Port)
+
+	check(c.Bybit.APIMode == "main" || c.Bybit.APIMode == "test", "BYBIT_API_MODE: must be main or test, got %q", c.Bybit.APIMode)
+	// Аккаунты могут работать в любом окружении, поэтому нужны адреса всех окружений
+	errs = append(errs, validURL("BYBIT_API_URL", c.Bybit.APIURL, "http", "https")...)
+	errs = append(errs, validURL("BYBIT_WS_URL", c.Bybit.WSURL, "ws", "wss")...)
+	errs = append(errs, validURL("BYBIT_API_TEST_URL", c.Bybit.APITestURL, "http", "https")...)
+	errs = append(errs, validURL("BYBIT_WS_TEST_URL", c.Bybit.WSTestURL, "ws", "wss")...)
+	errs = append(errs, validURL("BYBIT_API_DEMO_URL", c.Bybit.APIDemoURL, "http", "https")...)
+	errs = append(errs, validURL("BYBIT_WS_DEMO_URL", c.Bybit.WSDemoURL, "ws", "wss")...)
+	check(c.Bybit.RecvWindow > 0, "BYBIT_RECV_WINDOW: must be positive, got %d", c.Bybit.RecvWindow)
+	check(c.Bybit.InstrumentsUpdateInterval > 0, "BYBIT_INSTRUMENTS_UPDATE_INTERVAL: must be positive, got %s", c.Bybit.InstrumentsUpdateInterval)
+	check(c.Bybit.WSTopicsPerConnection > 0, "BYBIT_WS_TOPICS_PER_CONNECTION: must be positive, got %d", c.Bybit.WSTopicsPerConnection)
+
+	check(c.Auth.JWTSecret != "", "JWT_SECRET: is required")
+	check(c.Auth.AccessTokenTTL > 0, "JWT_ACCESS_TOKEN_TTL: must be positive, got %s", c.Auth.AccessTokenTTL)
+	check(c.Auth.RefreshTokenTTL > c.Auth.AccessTokenTTL, "JWT_REFRESH_TOKEN_TTL: must be longer than JWT_ACCESS_TOKEN_TTL, got %s", c.Auth.RefreshTokenTTL)
+	check(c.Auth.LoginIPLimit > 0, "AUTH_LOGIN_IP_LIMIT: must be positive, got %d", c.Auth.LoginIPLimit)
+	check(c.Auth.LoginIPWindow > 0, "AUTH_LOGIN_IP_WINDOW: must be positive, got %s", c.Auth.LoginIPWindow)
+	check(c.Auth.LockoutThreshold > 0, "AUTH_LOCKOUT_THRESHOLD: must be positive, got %d", c.Auth.LockoutThreshold)
+	check(c.Auth.LockoutBase > 0, "AUTH_LOCKOUT_BASE: must be positive, got %s", c.Auth.LockoutBase)
+	check(c.Auth.LockoutMax >= c.Auth.LockoutBase, "AUTH_LOCKOUT_MAX: must not be shorter than AUTH_LOCKOUT_BASE, got %s", c.Auth.LockoutMax)
+	check(c.Auth.RegisterIPLimit > 0, "AUTH_REGISTER_IP_LIMIT: must be positive, got %d", c.Auth.RegisterIPLimit)
+	check(c.Auth.RegisterIPWindow > 0, "AUTH_REGISTER_IP_WINDOW: must be positive, got %s", c.Auth.RegisterIPWindow)
+
+	// Без отправки писем подтвердить email невозможно, и никто не смог бы подключить ключи биржи
+	check(!c.Auth.RequireEmailVerification || c.Mail.Enabled(),
+		"AUTH_REQUIRE_EMAIL_VERIFICATION: requires SMTP_HOST, otherwise users cannot verify their email")
+
+	check(c.Secrets.MasterKeys != "" || c.Secrets.MasterKeysFile != "", "SECRETS_MASTER_KEYS: is required unless SECRETS_MASTER_KEYS_FILE is set")
+	check(c.Secrets.ActiveKeyVersion > 0, "SECRETS_ACTIVE_KEY_VERSION: must be positive, got %d", c.Secrets.ActiveKeyVersion)
+
+	if c.Mail.Enabled() {
+		check(validPort(c.Mail.SMTPPort), "SMTP_PORT: port must be between 1 and 65535, got %d", c.Mail.SMTPPort)
+		check(c.Mail.SMTPTLS == "starttls" || c.Mail.SMTPTLS == "tls" || c.Mail.SMTPTLS == "none",
+			"SMTP_TLS: must be starttls, tls or none, got %q", c.Mail.SMTPTLS)
+		_, err := mail.ParseAddress(c.Mail.From)
+		check(err == nil, "MAIL_FROM: must be a valid email address, got %q", c.Mail.From)
+		errs = append(errs, validURL("MAIL_LINK_BASE_URL", c.Mail.LinkBaseURL, "http", "https")...)
+	}
+	check(c.Mail.VerificationTTL > 0, "MAIL_VERIFICATION_TTL: must be positive, got %s", c.Mail.VerificationTTL)
+	check(c.Mail.PasswordResetTTL > 0, "MAIL_PASSWORD_RESET_TTL: must be positive, got %s", c.Mail.PasswordResetTTL)
+
+	if c.Telegram.BotToken != "" {
+		errs = append(errs, validURL("TELEGRAM_API_URL", c.Telegram.APIURL, "http", "https")...)
+	}
+	check(c.Telegram.PollTimeout > 0, "TELEGRAM_POLL_TIMEOUT: must be positive, got %d", c.Telegram.PollTimeout)
+
+	check(c.Notifications.DailySummaryHour >= 0 && c.Notifications.DailySummaryHour <= 23,
+		"NOTIFICATIONS_DAILY_SUMMARY_HOUR: must be between 0 and 23, got %d", c.Notifications.DailySummaryHour)
+	check(c.Notifications.WebhookTimeout > 0, "NOTIFICATIONS_WEBHOOK_TIMEOUT: must be positive, got %s", c.Notifications.WebhookTimeout)
+
+	check(c.Strategies.ParametersUpdateInterval > 0, "STRATEGY_PARAMETERS_UPDATE_INTERVAL: must be positive, got %s", c.Strategies.ParametersUpdateInterval)
+
+	return errors.Join(errs...)
+}
+
+// Redacted возвращает конфигурацию в виде JSON со скрытыми секретами
+func (c *Config) Redacted() string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Sprintf("<failed to marshal config: %v>", err)
+	}
+	return string(data)
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+func validURL(name, value string, schemes ...string) []error {
+	if value == "" {
+		return []error{fmt.Errorf("%s: is required", name)}
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" {
+		return []error{fmt.Errorf("%s: invalid URL %q", name, value)}
+	}
+	for _, scheme := range schemes {
+		if parsed.Scheme == scheme {
+			return nil
+		}
+	}
+	return []error{fmt.Errorf("%s: scheme must be one of %s, got %q", name, strings.Join(schemes, ", "), parsed.Scheme)}
+}
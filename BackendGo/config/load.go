@@ -0,0 +1,38 @@
+package config
+
+import (
+	"CryptoLens_Shared/configkit"
+	"fmt"
+)
+
+// Общие типы полей конфигурации
+type (
+	Secret   = configkit.Secret
+	Duration = configkit.Duration
+)
+
+// Load собирает конфигурацию из умолчаний, файла CONFIG_FILE и переменных окружения и проверяет ее
+func Load() (*Config, error) {
+	src, err := configkit.NewSource()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+	if err := configkit.Load(src, cfg); err != nil {
+		return nil, err
+	}
+
+	// DEBUG=true включает отладочный уровень, только если уровень не задан явно
+	if cfg.Log.Level == "" {
+		cfg.Log.Level = "info"
+		if debug, _ := src.Lookup("DEBUG"); debug == "true" {
+			cfg.Log.Level = "debug"
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"CryptoLens_Shared/configkit"
+	"CryptoLens_Shared/logger"
+	"strings"
+)
+
+var store = configkit.NewStore(Load)
+
+// Init загружает конфигурацию и делает ее текущей
+func Init() (*Config, error) {
+	return store.Init()
+}
+
+// Get возвращает текущую конфигурацию. Возвращаемое значение нельзя изменять:
+// при перезагрузке оно заменяется целиком.
+func Get() *Config {
+	return store.Get()
+}
+
+// Changed возвращает канал, который закрывается после применения перезагруженной конфигурации.
+// После срабатывания канал нужно запросить заново.
+func Changed() <-chan struct{} {
+	return store.Changed()
+}
+
+// Reload перечитывает конфигурацию и применяет поля, помеченные reload:"true".
+// Изменения остальных полей вступают в силу только после перезапуска, о них пишется предупреждение.
+// При ошибке загрузки текущая конфигурация сохраняется.
+func Reload() error {
+	applied, skipped, err := store.Reload()
+	if err != nil {
+		return err
+	}
+
+	if len(skipped) > 0 {
+		logger.LogWarn("Изменения требуют перезапуска и не применены: %s", strings.Join(skipped, ", "))
+	}
+	if len(applied) == 0 {
+		logger.LogInfo("Конфигурация перечитана, применимых изменений нет")
+		return nil
+	}
+	if err := logger.SetLevel(Get().Log.Level); err != nil {
+		return err
+	}
+	logger.LogInfo("Конфигурация перезагружена, применены: %s", strings.Join(applied, ", "))
+	return nil
+}
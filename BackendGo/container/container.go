@@ -1,20 +1,29 @@
 package container
 
 import (
-	"CryptoLens_Backend/env"
+	"CryptoLens_Backend/config"
+	"CryptoLens_Backend/encryption"
 	"CryptoLens_Backend/handlers"
 	"CryptoLens_Backend/integration/bybit"
-	"CryptoLens_Backend/logger"
+	"CryptoLens_Backend/integration/mail"
+	"CryptoLens_Backend/integration/telegram"
+	"CryptoLens_Backend/middleware"
+	"CryptoLens_Backend/notifications"
+	"CryptoLens_Backend/openapi"
 	"CryptoLens_Backend/repositories"
 	"CryptoLens_Backend/routes"
 	"CryptoLens_Backend/services"
 	"CryptoLens_Backend/storages"
+	"CryptoLens_Backend/telegrambot"
 	"CryptoLens_Backend/trading"
 	"CryptoLens_Backend/types"
+	"CryptoLens_Shared/logger"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"strconv"
+	"net/http"
+	"strings"
 )
 
 type Container struct {
@@ -23,6 +32,9 @@ type Container struct {
 	UserService           types.UserServiceInterface
 	UserHandler           *handlers.UserHandler
 	UserRoutes            *routes.UserRoutes
+	UserAPIKeyService     types.UserAPIKeyServiceInterface
+	UserAPIKeyHandler     *handlers.UserAPIKeyHandler
+	UserAPIKeyRoutes      *routes.UserAPIKeyRoutes
 	BybitClient           bybit.Client
 	BybitService          types.BybitServiceInterface
 	BybitHandler          types.BybitHandlerInterface
@@ -30,6 +42,10 @@ type Container struct {
 	UserInstrumentRepo    *repositories.UserInstrumentRepository
 	BybitInstrumentRepo   *repositories.BybitInstrumentRepository
 	BybitAccountRepo      types.BybitAccountRepositoryInterface
+	UserTOTPRepo          types.UserTOTPRepositoryInterface
+	BybitAccountService   types.BybitAccountServiceInterface
+	BybitAccountHandler   *handlers.BybitAccountHandler
+	BybitAccountRoutes    *routes.BybitAccountRoutes
 	UserInstrumentService types.UserInstrumentServiceInterface
 	UserInstrumentHandler *handlers.UserInstrumentHandler
 	UserInstrumentRoutes  *routes.UserInstrumentRoutes
@@ -37,61 +53,211 @@ type Container struct {
 	UserStrategyService   types.UserStrategyServiceInterface
 	UserStrategyHandler   *handlers.UserStrategyHandler
 	UserStrategyRoutes    *routes.UserStrategyRoutes
+	StrategyManager       types.StrategyManagerInterface
 	TradeLogRepo          types.TradeLogRepositoryInterface
 	WebSocketHandler      types.BybitWebSocketHandlerInterface
+	StreamHub             types.StreamHubInterface
+	StreamHandler         *handlers.StreamHandler
+	StreamRoutes          *routes.StreamRoutes
+	NotificationRepo      types.NotificationRepositoryInterface
+	NotificationService   types.NotificationServiceInterface
+	NotificationHandler   *handlers.NotificationHandler
+	NotificationRoutes    *routes.NotificationRoutes
+	TelegramService       types.TelegramServiceInterface
+	TelegramHandler       *handlers.TelegramHandler
+	TelegramRoutes        *routes.TelegramRoutes
+	TelegramBot           *telegrambot.Bot
+	WebhookRepo           types.WebhookRepositoryInterface
+	WebhookService        types.WebhookServiceInterface
+	WebhookHandler        *handlers.WebhookHandler
+	WebhookRoutes         *routes.WebhookRoutes
+	MetricsRoutes         *routes.MetricsRoutes
+	OpenAPIRoutes         *routes.OpenAPIRoutes
+	HealthService         types.HealthServiceInterface
+	HealthHandler         *handlers.HealthHandler
+	HealthRoutes          *routes.HealthRoutes
+	AdminService          types.AdminServiceInterface
+	AdminHandler          *handlers.AdminHandler
+	AdminRoutes           *routes.AdminRoutes
 }
 
-func NewContainer(db *sql.DB, jwtKey []byte) *Container {
+func NewContainer(db *sql.DB, keyring *encryption.Keyring, cfg *config.Config) *Container {
 	// Инициализация репозиториев
 	userRepo := repositories.NewUserRepository(db)
 	userInstrumentRepo := repositories.NewUserInstrumentRepository(db)
 	bybitInstrumentRepo := repositories.NewBybitInstrumentRepository(db)
 	userStrategyRepo := repositories.NewUserStrategyRepository(db)
-	bybitAccountRepo := repositories.NewBybitAccountRepository(db)
+	bybitAccountRepo := repositories.NewBybitAccountRepository(db, keyring)
 	tradeLogRepo := repositories.NewTradeLogRepository(db)
+	notificationRepo := repositories.NewNotificationRepository(db)
+	telegramChatRepo := repositories.NewTelegramChatRepository(db)
+	webhookRepo := repositories.NewWebhookRepository(db, keyring)
+	authAuditRepo := repositories.NewAuthAuditRepository(db)
+	userTOTPRepo := repositories.NewUserTOTPRepository(db, keyring)
 
-	// Инициализация клиента Bybit
-	recvWindow, _ := strconv.Atoi(env.GetBybitRecvWindow())
-	apiMode := env.GetBybitApiMode()
-	var apiUrl string
-	if apiMode == "test" {
-		apiUrl = env.GetBybitApiTestUrl()
-	} else {
-		apiUrl = env.GetBybitApiUrl()
+	// Инициализация клиента Bybit: каждый аккаунт работает в своем окружении,
+	// BYBIT_API_MODE задает окружение для публичных данных и аккаунтов без явного окружения
+	defaultEnvironment := bybit.EnvironmentMainnet
+	if cfg.Bybit.IsTestnet() {
+		defaultEnvironment = bybit.EnvironmentTestnet
 	}
-	bybitClient := bybit.NewClient(apiUrl, recvWindow, apiMode == "test")
+	bybitClient := bybit.NewClient(map[string]bybit.Endpoints{
+		bybit.EnvironmentMainnet: {REST: cfg.Bybit.APIURL, Stream: cfg.Bybit.WSURL},
+		bybit.EnvironmentTestnet: {REST: cfg.Bybit.APITestURL, Stream: cfg.Bybit.WSTestURL},
+		bybit.EnvironmentDemo:    {REST: cfg.Bybit.APIDemoURL, Stream: cfg.Bybit.WSDemoURL},
+	}, defaultEnvironment, cfg.Bybit.RecvWindow)
 
 	// Инициализация сервисов
-	userService := services.NewUserService(userRepo, jwtKey, db)
+	// Создаем сервис уведомлений и подключаем каналы доставки
+	webhookTimeout := cfg.Notifications.WebhookTimeout.Std()
+	notificationService := services.NewNotificationService(notificationRepo, tradeLogRepo, telegramChatRepo, cfg.Notifications.DailySummaryHour)
+	var telegramClient telegram.Client
+	if botToken := cfg.Telegram.BotToken.Value(); botToken != "" {
+		telegramClient = telegram.NewClient(cfg.Telegram.APIURL, botToken)
+		notificationService.RegisterChannel(notifications.NewTelegramChannel(telegramClient))
+	} else {
+		logger.LogWarn("TELEGRAM_BOT_TOKEN не задан, уведомления в Telegram отключены")
+	}
+	notificationService.RegisterChannel(notifications.NewWebhookChannel(webhookTimeout))
+
+	// Письма для подтверждения email и сброса пароля
+	var mailClient mail.Client
+	if cfg.Mail.Enabled() {
+		client, err := mail.NewClient(mail.Config{
+			Host:     cfg.Mail.SMTPHost,
+			Port:     cfg.Mail.SMTPPort,
+			Username: cfg.Mail.SMTPUsername,
+			Password: cfg.Mail.SMTPPassword.Value(),
+			TLSMode:  cfg.Mail.SMTPTLS,
+			From:     cfg.Mail.From,
+		})
+		if err != nil {
+			logger.LogError("Ошибка настройки отправки писем: %v", err)
+		} else {
+			mailClient = client
+		}
+	} else {
+		logger.LogWarn("SMTP_HOST не задан, письма для подтверждения email и сброса пароля не отправляются")
+	}
+
+	// Поток событий для клиентов: в него пишут обработчик WebSocket Bybit и сервис стратегий,
+	// а сервис пользователей закрывает подключения отозванных сессий
+	streamHub := services.NewStreamHub(userInstrumentRepo)
+
+	// Сервис пользователей уведомляет о подозрительных входах, поэтому создается после уведомлений
+	userService := services.NewUserService(
+		userRepo,
+		repositories.NewUserSessionRepository(db),
+		authAuditRepo,
+		userTOTPRepo,
+		notificationService,
+		streamHub,
+		mailClient,
+		[]byte(cfg.Auth.JWTSecret.Value()),
+		db,
+	)
+
+	// Персональные API-ключи принимаются AuthMiddleware наравне с JWT
+	userAPIKeyService := services.NewUserAPIKeyService(repositories.NewUserAPIKeyRepository(db), userRepo)
+	middleware.SetAPIKeyService(userAPIKeyService)
+
+	// Создаем сервис исходящих вебхуков для торговых событий
+	webhookService := services.NewWebhookService(webhookRepo, webhookTimeout)
 
 	// Создаем менеджер стратегий
-	strategyManager := trading.NewStrategyManager(bybitClient, userInstrumentRepo, bybitAccountRepo)
+	strategyManager := trading.NewStrategyManager(bybitClient, userInstrumentRepo, bybitAccountRepo, notificationService)
+	trading.RegisterStrategyMetrics(strategyManager)
 
 	// Создаем обработчик WebSocket
-	wsHandler := handlers.NewBybitWebSocketHandler(strategyManager, tradeLogRepo)
+	wsHandler := handlers.NewBybitWebSocketHandler(strategyManager, tradeLogRepo, notificationService, webhookService, streamHub)
 
 	// Создаем сервисы, зависящие от менеджера стратегий
-	userInstrumentService := services.NewUserInstrumentService(userInstrumentRepo, bybitInstrumentRepo, strategyManager)
 	userStrategyService := services.NewUserStrategyService(
 		userStrategyRepo,
 		strategyManager,
 		repositories.NewBybitInstrumentRepository(db),
+		bybitAccountRepo,
+		notificationService,
+		webhookService,
+		streamHub,
 	)
+	// Стратегии, которым Bybit отказал в ключе API, останавливаются через сервис
+	strategyManager.SetAccountStopper(userStrategyService)
 
 	// Создаем сервис Bybit
-	bybitService := services.NewBybitService(bybitClient, db, userService, wsHandler)
+	bybitService := services.NewBybitService(
+		bybitClient,
+		db,
+		bybitAccountRepo,
+		wsHandler,
+		strategyManager,
+		userStrategyService,
+		notificationService,
+	)
+
+	// Изменения инструментов сразу применяются к публичным подпискам
+	userInstrumentService := services.NewUserInstrumentService(userInstrumentRepo, bybitInstrumentRepo, strategyManager, bybitService)
+
+	// Создаем сервис управления API-ключами; изменения сразу применяются к приватным соединениям
+	bybitAccountService := services.NewBybitAccountService(bybitAccountRepo, bybitClient, bybitService, userStrategyService, userService)
+
+	// Создаем сервис проверки состояния
+	healthService := services.NewHealthService(
+		db,
+		bybitService,
+		wsHandler,
+		strategyManager,
+		userInstrumentRepo,
+		bybitInstrumentRepo,
+	)
+
+	// Создаем сервис привязки чатов и Telegram-бота
+	telegramService := services.NewTelegramService(telegramChatRepo, notificationRepo)
+	var telegramBot *telegrambot.Bot
+	if telegramClient != nil && cfg.Telegram.BotEnabled {
+		telegramBot = telegrambot.NewBot(
+			telegramClient,
+			telegramService,
+			userStrategyService,
+			userInstrumentService,
+			strategyManager,
+			tradeLogRepo,
+			cfg.Telegram.PollTimeout,
+		)
+	}
+
+	// Создаем сервис администрирования пользователей и стратегий
+	adminService := services.NewAdminService(userRepo, authAuditRepo, userService, userStrategyService, telegramService)
 
 	// Инициализация обработчиков
 	userHandler := handlers.NewUserHandler(userService)
+	userAPIKeyHandler := handlers.NewUserAPIKeyHandler(userAPIKeyService)
 	userInstrumentHandler := handlers.NewUserInstrumentHandler(userInstrumentService)
 	bybitHandler := handlers.NewBybitHandler(bybitService)
+	bybitAccountHandler := handlers.NewBybitAccountHandler(bybitAccountService)
 	userStrategyHandler := handlers.NewUserStrategyHandler(userStrategyService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	telegramHandler := handlers.NewTelegramHandler(telegramService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	healthHandler := handlers.NewHealthHandler(healthService)
+	adminHandler := handlers.NewAdminHandler(adminService)
+	streamHandler := handlers.NewStreamHandler(streamHub, cfg.Server.Origins())
 
 	// Инициализация маршрутов
 	userRoutes := routes.NewUserRoutes(userHandler)
+	userAPIKeyRoutes := routes.NewUserAPIKeyRoutes(userAPIKeyHandler)
 	userInstrumentRoutes := routes.NewUserInstrumentRoutes(userInstrumentHandler)
 	bybitRoutes := routes.NewBybitRoutes(bybitHandler)
+	bybitAccountRoutes := routes.NewBybitAccountRoutes(bybitAccountHandler)
 	userStrategyRoutes := routes.NewUserStrategyRoutes(userStrategyHandler)
+	notificationRoutes := routes.NewNotificationRoutes(notificationHandler)
+	telegramRoutes := routes.NewTelegramRoutes(telegramHandler)
+	webhookRoutes := routes.NewWebhookRoutes(webhookHandler)
+	metricsRoutes := routes.NewMetricsRoutes()
+	openAPIRoutes := routes.NewOpenAPIRoutes()
+	healthRoutes := routes.NewHealthRoutes(healthHandler)
+	adminRoutes := routes.NewAdminRoutes(adminHandler)
+	streamRoutes := routes.NewStreamRoutes(streamHandler)
 
 	return &Container{
 		DB:                    db,
@@ -99,6 +265,9 @@ func NewContainer(db *sql.DB, jwtKey []byte) *Container {
 		UserService:           userService,
 		UserHandler:           userHandler,
 		UserRoutes:            userRoutes,
+		UserAPIKeyService:     userAPIKeyService,
+		UserAPIKeyHandler:     userAPIKeyHandler,
+		UserAPIKeyRoutes:      userAPIKeyRoutes,
 		BybitClient:           bybitClient,
 		BybitService:          bybitService,
 		BybitHandler:          bybitHandler,
@@ -106,6 +275,10 @@ func NewContainer(db *sql.DB, jwtKey []byte) *Container {
 		UserInstrumentRepo:    userInstrumentRepo,
 		BybitInstrumentRepo:   bybitInstrumentRepo,
 		BybitAccountRepo:      bybitAccountRepo,
+		UserTOTPRepo:          userTOTPRepo,
+		BybitAccountService:   bybitAccountService,
+		BybitAccountHandler:   bybitAccountHandler,
+		BybitAccountRoutes:    bybitAccountRoutes,
 		UserInstrumentService: userInstrumentService,
 		UserInstrumentHandler: userInstrumentHandler,
 		UserInstrumentRoutes:  userInstrumentRoutes,
@@ -113,16 +286,60 @@ func NewContainer(db *sql.DB, jwtKey []byte) *Container {
 		UserStrategyService:   userStrategyService,
 		UserStrategyHandler:   userStrategyHandler,
 		UserStrategyRoutes:    userStrategyRoutes,
+		StrategyManager:       strategyManager,
 		TradeLogRepo:          tradeLogRepo,
 		WebSocketHandler:      wsHandler,
+		StreamHub:             streamHub,
+		StreamHandler:         streamHandler,
+		StreamRoutes:          streamRoutes,
+		NotificationRepo:      notificationRepo,
+		NotificationService:   notificationService,
+		NotificationHandler:   notificationHandler,
+		NotificationRoutes:    notificationRoutes,
+		TelegramService:       telegramService,
+		TelegramHandler:       telegramHandler,
+		TelegramRoutes:        telegramRoutes,
+		TelegramBot:           telegramBot,
+		WebhookRepo:           webhookRepo,
+		WebhookService:        webhookService,
+		WebhookHandler:        webhookHandler,
+		WebhookRoutes:         webhookRoutes,
+		MetricsRoutes:         metricsRoutes,
+		OpenAPIRoutes:         openAPIRoutes,
+		HealthService:         healthService,
+		HealthHandler:         healthHandler,
+		HealthRoutes:          healthRoutes,
+		AdminService:          adminService,
+		AdminHandler:          adminHandler,
+		AdminRoutes:           adminRoutes,
 	}
 }
 
-func (c *Container) RegisterRoutes() {
-	c.UserRoutes.Register()
-	c.UserInstrumentRoutes.Register()
-	c.UserStrategyRoutes.Register()
-	c.BybitRoutes.Register()
+// RegisterRoutes регистрирует маршруты и возвращает обработчик для HTTP-сервера
+func (c *Container) RegisterRoutes() http.Handler {
+	router := routes.NewRouter()
+	c.UserRoutes.Register(router)
+	c.UserAPIKeyRoutes.Register(router)
+	c.UserInstrumentRoutes.Register(router)
+	c.UserStrategyRoutes.Register(router)
+	c.BybitRoutes.Register(router)
+	c.BybitAccountRoutes.Register(router)
+	c.NotificationRoutes.Register(router)
+	c.TelegramRoutes.Register(router)
+	c.WebhookRoutes.Register(router)
+	c.MetricsRoutes.Register(router)
+	c.OpenAPIRoutes.Register(router)
+	c.HealthRoutes.Register(router)
+	c.AdminRoutes.Register(router)
+	c.StreamRoutes.Register(router)
+
+	// Полная сверка выполняется командой check-api-contract, здесь только напоминание при запуске
+	if problems, err := openapi.CheckRoutes(router.Patterns()); err != nil {
+		logger.LogError("Ошибка чтения спецификации OpenAPI: %v", err)
+	} else if len(problems) > 0 {
+		logger.LogWarn("Маршруты расходятся со спецификацией OpenAPI: %s", strings.Join(problems, "; "))
+	}
+	return router.Handler()
 }
 
 func (c *Container) StartBackgroundTasks(ctx context.Context) {
@@ -136,6 +353,31 @@ func (c *Container) StartBackgroundTasks(ctx context.Context) {
 		logger.LogError("Ошибка при деактивации активных стратегий: %v", err)
 	}
 
+	// Напоминаем о секретах, которые хранятся открыто или под старым мастер-ключом
+	if count, err := c.BybitAccountRepo.CountSecretsToReencrypt(ctx); err != nil {
+		logger.LogError("Ошибка проверки шифрования API-секретов: %v", err)
+	} else if count > 0 {
+		logger.LogWarn("%d API-секретов Bybit не зашифрованы активным ключом, выполните ./app reencrypt-secrets", count)
+	}
+	if count, err := c.UserTOTPRepo.CountSecretsToReencrypt(ctx); err != nil {
+		logger.LogError("Ошибка проверки шифрования секретов TOTP: %v", err)
+	} else if count > 0 {
+		logger.LogWarn("%d секретов TOTP не зашифрованы активным ключом, выполните ./app reencrypt-secrets", count)
+	}
+	if count, err := c.WebhookRepo.CountSecretsToReencrypt(ctx); err != nil {
+		logger.LogError("Ошибка проверки шифрования секретов вебхуков: %v", err)
+	} else if count > 0 {
+		logger.LogWarn("%d секретов вебхуков не зашифрованы активным ключом, выполните ./app reencrypt-secrets", count)
+	}
+
+	// Запускаем доставку уведомлений
+	c.NotificationService.Start(ctx)
+	// Запускаем доставку исходящих вебхуков
+	c.WebhookService.Start(ctx)
+	// Запускаем Telegram-бота
+	if c.TelegramBot != nil {
+		c.TelegramBot.Start(ctx)
+	}
 	// Запускаем обновление инструментов
 	go c.BybitService.StartInstrumentsUpdate(ctx)
 	// Запускаем WebSocket
@@ -144,10 +386,54 @@ func (c *Container) StartBackgroundTasks(ctx context.Context) {
 	go c.BybitService.StartPrivateWebSocket(ctx)
 }
 
+// Shutdown останавливает приложение по шагам в пределах срока ctx: сначала прекращается прием
+// HTTP-запросов и новых ордеров, затем останавливаются стратегии и фоновые задачи, дорабатываются
+// очереди WebSocket и уведомлений, и только после этого закрываются базы данных.
+// stopBackground отменяет контекст, переданный в StartBackgroundTasks.
+func (c *Container) Shutdown(ctx context.Context, server *http.Server, stopBackground context.CancelFunc) error {
+	var errs []error
+
+	// Стратегии не выставляют новых ордеров, пока останавливаются остальные части
+	c.StrategyManager.DisableOrders()
+
+	// Дожидаемся текущих HTTP-запросов; WebSocket-потоки клиентов закрываются отдельно
+	logger.LogInfo("Остановка: завершение HTTP-запросов")
+	if err := server.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("http server: %w", err))
+	}
+	if err := c.StreamHub.Shutdown(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	logger.LogInfo("Остановка: остановка стратегий, отмена ордеров: %t", config.Get().Strategies.CancelOrdersOnShutdown)
+	c.StrategyManager.Stop(ctx)
+
+	// Закрываем соединения с Bybit и останавливаем фоновые задачи
+	stopBackground()
+
+	// Дорабатываем полученные сообщения Bybit: исполнения записываются в журнал сделок
+	logger.LogInfo("Остановка: обработка очереди WebSocket")
+	if err := c.WebSocketHandler.Drain(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	c.NotificationService.Flush(ctx)
+
+	logger.LogInfo("Остановка: закрытие соединений с базой данных и Redis")
+	if err := c.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Close закрывает соединения с базой данных и Redis
 func (c *Container) Close() error {
+	var errs []error
+	if err := c.DB.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close database connection: %w", err))
+	}
 	// Закрываем соединение с Redis
 	if err := storages.Close(); err != nil {
-		return fmt.Errorf("failed to close Redis connection: %w", err)
+		errs = append(errs, fmt.Errorf("failed to close Redis connection: %w", err))
 	}
-	return nil
+	return errors.Join(errs...)
 }
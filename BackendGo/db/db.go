@@ -1,40 +1,26 @@
 package db
 
 import (
-	"CryptoLens_Backend/env"
-	"CryptoLens_Backend/logger"
+	"CryptoLens_Backend/config"
+	"CryptoLens_Shared/logger"
 	"database/sql"
 	"fmt"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 )
 
-var (
-	dbUser          string
-	dbName          string
-	dbPass          string
-	dbHost          string
-	dbConnectionStr string
-)
-
-func init() {
-	env.Init()
-	dbUser = env.GetDBUser()
-	dbName = env.GetDBName()
-	dbPass = env.GetDBPass()
-	dbHost = env.GetDBHost()
-}
-
-func InitDB() (*sql.DB, error) {
-	dbConnectionStr = fmt.Sprintf(
-		"user=%s dbname=%s sslmode=disable password=%s host=%s port=5432", dbUser, dbName, dbPass, dbHost,
+func InitDB(cfg config.DatabaseConfig) (*sql.DB, error) {
+	dbConnectionStr := fmt.Sprintf(
+		"user=%s dbname=%s sslmode=disable password=%s host=%s port=%d",
+		cfg.User, cfg.Name, cfg.Password.Value(), cfg.Host, cfg.Port,
 	)
 
-	db, err := sql.Open("postgres", dbConnectionStr)
+	db, err := openInstrumented("postgres", dbConnectionStr)
 	if err != nil {
 		logger.Log.Printf("Error connecting to database: %v", err)
 		return nil, fmt.Errorf("Error connecting to database: %v", err)
 	}
+	registerPoolMetrics(db)
 
 	if err := migrateDB(db); err != nil {
 		return nil, err
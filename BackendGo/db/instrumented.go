@@ -0,0 +1,197 @@
+package db
+
+import (
+	"CryptoLens_Backend/metrics"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"time"
+)
+
+// openInstrumented открывает пул соединений, в котором каждый запрос записывается в метрики
+func openInstrumented(driverName, dsn string) (*sql.DB, error) {
+	// sql.Open не устанавливает соединение, он нужен только чтобы получить зарегистрированный драйвер
+	base, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	drv := base.Driver()
+	base.Close()
+
+	var connector driver.Connector
+	if dc, ok := drv.(driver.DriverContext); ok {
+		if connector, err = dc.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	} else {
+		connector = dsnConnector{dsn: dsn, driver: drv}
+	}
+
+	return sql.OpenDB(instrumentedConnector{base: connector}), nil
+}
+
+// registerPoolMetrics публикует состояние пула соединений
+func registerPoolMetrics(db *sql.DB) {
+	metrics.NewGaugeVecFunc("cryptolens_db_connections", "PostgreSQL pool connections by state.", "state", func() map[string]float64 {
+		stats := db.Stats()
+		return map[string]float64{
+			"in_use": float64(stats.InUse),
+			"idle":   float64(stats.Idle),
+		}
+	})
+	metrics.NewGaugeFunc("cryptolens_db_wait_count", "Total number of connections waited for.", func() float64 {
+		return float64(db.Stats().WaitCount)
+	})
+}
+
+// dsnConnector адаптер для драйверов без driver.DriverContext
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// instrumentedConnector оборачивает соединения базового драйвера
+type instrumentedConnector struct {
+	base driver.Connector
+}
+
+func (c instrumentedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{Conn: conn}, nil
+}
+
+func (c instrumentedConnector) Driver() driver.Driver {
+	return c.base.Driver()
+}
+
+// instrumentedConn измеряет время выполнения запросов.
+// Необязательные интерфейсы драйвера проксируются, а при их отсутствии возвращается driver.ErrSkip,
+// чтобы database/sql выбрал запасной путь.
+type instrumentedConn struct {
+	driver.Conn
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	observe(operationOf(query), start, err)
+	return rows, err
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	observe(operationOf(query), start, err)
+	return result, err
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	start := time.Now()
+	var tx driver.Tx
+	var err error
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = beginner.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.Conn.Begin() // запасной путь для драйверов без BeginTx
+	}
+	observe("begin", start, err)
+	if err != nil {
+		return nil, err
+	}
+	return instrumentedTx{Tx: tx}, nil
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *instrumentedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// instrumentedTx измеряет время фиксации и отката транзакций
+type instrumentedTx struct {
+	driver.Tx
+}
+
+func (t instrumentedTx) Commit() error {
+	start := time.Now()
+	err := t.Tx.Commit()
+	observe("commit", start, err)
+	return err
+}
+
+func (t instrumentedTx) Rollback() error {
+	start := time.Now()
+	err := t.Tx.Rollback()
+	observe("rollback", start, err)
+	return err
+}
+
+func observe(operation string, start time.Time, err error) {
+	metrics.ObserveSince(metrics.DBQueryDuration.WithLabelValues(operation), start)
+	if err != nil && err != driver.ErrSkip {
+		metrics.DBErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+// operationOf определяет тип запроса по первому ключевому слову
+func operationOf(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "other"
+	}
+	switch op := strings.ToLower(fields[0]); op {
+	case "select", "insert", "update", "delete", "with", "create", "drop", "alter":
+		return op
+	default:
+		return "other"
+	}
+}
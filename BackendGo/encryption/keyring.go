@@ -0,0 +1,241 @@
+package encryption
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// masterKeySize длина мастер-ключа и ключа данных: AES-256
+const masterKeySize = 32
+
+// ErrUnknownKeyVersion возвращается, если запись зашифрована ключом, которого нет в наборе
+var ErrUnknownKeyVersion = errors.New("unknown master key version")
+
+// Envelope зашифрованное значение: данные шифруются случайным ключом данных,
+// а ключ данных шифруется мастер-ключом версии KeyVersion
+type Envelope struct {
+	KeyVersion int
+	WrappedKey []byte
+	Ciphertext []byte
+}
+
+// Keyring набор мастер-ключей по версиям. Новые значения шифруются активной версией,
+// остальные версии нужны только для чтения записей, еще не перешифрованных новым ключом.
+type Keyring struct {
+	keys   map[int]cipher.AEAD
+	active int
+}
+
+// NewKeyring создает набор ключей; ключи должны быть длиной 32 байта
+func NewKeyring(keys map[int][]byte, active int) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no master keys configured")
+	}
+	k := &Keyring{keys: make(map[int]cipher.AEAD, len(keys)), active: active}
+	for version, key := range keys {
+		if version <= 0 {
+			return nil, fmt.Errorf("master key version must be positive, got %d", version)
+		}
+		if len(key) != masterKeySize {
+			return nil, fmt.Errorf("master key %d must be %d bytes, got %d", version, masterKeySize, len(key))
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("master key %d: %w", version, err)
+		}
+		k.keys[version] = aead
+	}
+	if _, ok := k.keys[active]; !ok {
+		return nil, fmt.Errorf("active master key version %d is not configured", active)
+	}
+	return k, nil
+}
+
+// ParseKeys разбирает ключи в формате "версия:base64" через запятую или с новой строки.
+// Пустые строки и строки, начинающиеся с #, пропускаются.
+func ParseKeys(spec string) (map[int][]byte, error) {
+	keys := make(map[int][]byte)
+	fields := strings.FieldsFunc(spec, func(r rune) bool { return r == ',' || r == '\n' })
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" || strings.HasPrefix(field, "#") {
+			continue
+		}
+		versionStr, encoded, ok := strings.Cut(field, ":")
+		if !ok {
+			return nil, errors.New("master key must be in the form version:base64")
+		}
+		version, err := strconv.Atoi(strings.TrimSpace(versionStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid master key version %q", versionStr)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("master key %d is not valid base64", version)
+		}
+		if _, exists := keys[version]; exists {
+			return nil, fmt.Errorf("master key %d is defined twice", version)
+		}
+		keys[version] = key
+	}
+	return keys, nil
+}
+
+// LoadKeys объединяет ключи из строки и файла
+func LoadKeys(spec, path string) (map[int][]byte, error) {
+	keys, err := ParseKeys(spec)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return keys, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("master keys file: %w", err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("master keys file: %w", err)
+	}
+	fileKeys, err := ParseKeys(strings.Join(lines, "\n"))
+	if err != nil {
+		return nil, fmt.Errorf("master keys file: %w", err)
+	}
+	for version, key := range fileKeys {
+		if _, exists := keys[version]; exists {
+			return nil, fmt.Errorf("master key %d is defined both in config and in file", version)
+		}
+		keys[version] = key
+	}
+	return keys, nil
+}
+
+// ActiveVersion возвращает версию ключа, которым шифруются новые значения
+func (k *Keyring) ActiveVersion() int {
+	return k.active
+}
+
+// Versions возвращает все доступные версии ключей
+func (k *Keyring) Versions() []int {
+	versions := make([]int, 0, len(k.keys))
+	for version := range k.keys {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// Seal шифрует значение новым ключом данных под активным мастер-ключом.
+// aad привязывает шифротекст к записи: расшифровать его с другим aad нельзя.
+func (k *Keyring) Seal(plaintext, aad []byte) (*Envelope, error) {
+	dataKey := make([]byte, masterKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := seal(dataAEAD, plaintext, aad)
+	if err != nil {
+		return nil, err
+	}
+	wrappedKey, err := seal(k.keys[k.active], dataKey, wrapAAD(k.active))
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{KeyVersion: k.active, WrappedKey: wrappedKey, Ciphertext: ciphertext}, nil
+}
+
+// Open расшифровывает значение
+func (k *Keyring) Open(envelope *Envelope, aad []byte) ([]byte, error) {
+	dataKey, err := k.unwrap(envelope)
+	if err != nil {
+		return nil, err
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := open(dataAEAD, envelope.Ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return plaintext, nil
+}
+
+// Rewrap перешифровывает ключ данных активным мастер-ключом, не трогая сами данные
+func (k *Keyring) Rewrap(envelope *Envelope) (*Envelope, error) {
+	if envelope.KeyVersion == k.active {
+		return envelope, nil
+	}
+	dataKey, err := k.unwrap(envelope)
+	if err != nil {
+		return nil, err
+	}
+	wrappedKey, err := seal(k.keys[k.active], dataKey, wrapAAD(k.active))
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{KeyVersion: k.active, WrappedKey: wrappedKey, Ciphertext: envelope.Ciphertext}, nil
+}
+
+func (k *Keyring) unwrap(envelope *Envelope) ([]byte, error) {
+	master, ok := k.keys[envelope.KeyVersion]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownKeyVersion, envelope.KeyVersion)
+	}
+	dataKey, err := open(master, envelope.WrappedKey, wrapAAD(envelope.KeyVersion))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key with master key %d: %w", envelope.KeyVersion, err)
+	}
+	return dataKey, nil
+}
+
+// wrapAAD привязывает обернутый ключ данных к версии мастер-ключа
+func wrapAAD(version int) []byte {
+	return []byte("master-key-v" + strconv.Itoa(version))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal возвращает nonce, за которым следует шифротекст
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, data, aad []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
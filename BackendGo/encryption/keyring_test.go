@@ -0,0 +1,210 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, masterKeySize)
+}
+
+func mustKeyring(t *testing.T, keys map[int][]byte, active int) *Keyring {
+	t.Helper()
+	k, err := NewKeyring(keys, active)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestSealOpen(t *testing.T) {
+	k := mustKeyring(t, map[int][]byte{1: testKey(1)}, 1)
+	plaintext := []byte("api-secret")
+	aad := []byte("account:42")
+
+	envelope, err := k.Seal(plaintext, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if envelope.KeyVersion != 1 {
+		t.Errorf("KeyVersion = %d, want 1", envelope.KeyVersion)
+	}
+	if bytes.Contains(envelope.Ciphertext, plaintext) {
+		t.Error("ciphertext contains the plaintext")
+	}
+	got, err := k.Open(envelope, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("Open = %q, want %q", got, plaintext)
+	}
+
+	other, err := k.Seal(plaintext, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(other.Ciphertext, envelope.Ciphertext) || bytes.Equal(other.WrappedKey, envelope.WrappedKey) {
+		t.Error("sealing the same value twice gave the same envelope")
+	}
+}
+
+func TestOpenRejectsTampering(t *testing.T) {
+	k := mustKeyring(t, map[int][]byte{1: testKey(1)}, 1)
+	aad := []byte("account:42")
+	envelope, err := k.Seal([]byte("api-secret"), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := k.Open(envelope, []byte("account:43")); err == nil {
+		t.Error("Open accepted a different aad")
+	}
+
+	tampered := *envelope
+	tampered.Ciphertext = append([]byte(nil), envelope.Ciphertext...)
+	tampered.Ciphertext[len(tampered.Ciphertext)-1] ^= 1
+	if _, err := k.Open(&tampered, aad); err == nil {
+		t.Error("Open accepted a modified ciphertext")
+	}
+
+	tampered = *envelope
+	tampered.WrappedKey = append([]byte(nil), envelope.WrappedKey...)
+	tampered.WrappedKey[len(tampered.WrappedKey)-1] ^= 1
+	if _, err := k.Open(&tampered, aad); err == nil {
+		t.Error("Open accepted a modified wrapped key")
+	}
+
+	tampered = *envelope
+	tampered.Ciphertext = tampered.Ciphertext[:4]
+	if _, err := k.Open(&tampered, aad); err == nil {
+		t.Error("Open accepted a truncated ciphertext")
+	}
+}
+
+func TestOpenUnknownVersion(t *testing.T) {
+	old := mustKeyring(t, map[int][]byte{1: testKey(1)}, 1)
+	envelope, err := old.Seal([]byte("api-secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := mustKeyring(t, map[int][]byte{2: testKey(2)}, 2)
+	if _, err := k.Open(envelope, nil); !errors.Is(err, ErrUnknownKeyVersion) {
+		t.Errorf("Open error = %v, want ErrUnknownKeyVersion", err)
+	}
+}
+
+func TestRewrap(t *testing.T) {
+	aad := []byte("account:42")
+	old := mustKeyring(t, map[int][]byte{1: testKey(1)}, 1)
+	envelope, err := old.Seal([]byte("api-secret"), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := mustKeyring(t, map[int][]byte{1: testKey(1), 2: testKey(2)}, 2)
+	rewrapped, err := rotated.Rewrap(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rewrapped.KeyVersion != 2 {
+		t.Errorf("KeyVersion = %d, want 2", rewrapped.KeyVersion)
+	}
+	if !bytes.Equal(rewrapped.Ciphertext, envelope.Ciphertext) {
+		t.Error("Rewrap changed the ciphertext")
+	}
+
+	// После перешифровки старый ключ можно убрать из набора
+	current := mustKeyring(t, map[int][]byte{2: testKey(2)}, 2)
+	got, err := current.Open(rewrapped, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "api-secret" {
+		t.Errorf("Open = %q, want api-secret", got)
+	}
+
+	same, err := rotated.Rewrap(rewrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same != rewrapped {
+		t.Error("Rewrap of an envelope under the active key must return it unchanged")
+	}
+}
+
+func TestWrappedKeyBoundToVersion(t *testing.T) {
+	// Одинаковые ключи под разными версиями: обернутый ключ не должен открываться под чужой версией
+	k := mustKeyring(t, map[int][]byte{1: testKey(1), 2: testKey(1)}, 1)
+	envelope, err := k.Seal([]byte("api-secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope.KeyVersion = 2
+	if _, err := k.Open(envelope, nil); err == nil {
+		t.Error("Open accepted a wrapped key under another version")
+	}
+}
+
+func TestNewKeyringValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		keys   map[int][]byte
+		active int
+	}{
+		{"no keys", nil, 1},
+		{"short key", map[int][]byte{1: testKey(1)[:16]}, 1},
+		{"non-positive version", map[int][]byte{0: testKey(1)}, 0},
+		{"missing active", map[int][]byte{1: testKey(1)}, 2},
+	}
+	for _, tt := range tests {
+		if _, err := NewKeyring(tt.keys, tt.active); err == nil {
+			t.Errorf("%s: NewKeyring succeeded", tt.name)
+		}
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	k1 := base64.StdEncoding.EncodeToString(testKey(1))
+	k2 := base64.StdEncoding.EncodeToString(testKey(2))
+
+	keys, err := ParseKeys("# rotation\n1:" + k1 + ", 2: " + k2 + "\n\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || !bytes.Equal(keys[1], testKey(1)) || !bytes.Equal(keys[2], testKey(2)) {
+		t.Errorf("ParseKeys = %v", keys)
+	}
+
+	for _, spec := range []string{k1, "x:" + k1, "1:not-base64!", "1:" + k1 + ",1:" + k2} {
+		if _, err := ParseKeys(spec); err == nil {
+			t.Errorf("ParseKeys(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	k1 := base64.StdEncoding.EncodeToString(testKey(1))
+	k2 := base64.StdEncoding.EncodeToString(testKey(2))
+	path := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(path, []byte("2:"+k2+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := LoadKeys("1:"+k1, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("LoadKeys returned %d keys, want 2", len(keys))
+	}
+
+	if _, err := LoadKeys("2:"+k1, path); err == nil {
+		t.Error("LoadKeys accepted a version defined both in config and in file")
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"CryptoLens_Backend/httpapi"
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/types"
+	"CryptoLens_Shared/logger"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type AdminHandler struct {
+	adminService types.AdminServiceInterface
+}
+
+func NewAdminHandler(adminService types.AdminServiceInterface) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+	}
+}
+
+// GetLogLevel возвращает текущий уровень логирования
+func (h *AdminHandler) GetLogLevel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.LogLevelResponse{Level: logger.GetLevel()})
+}
+
+// SetLogLevel меняет уровень логирования без перезапуска
+func (h *AdminHandler) SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req models.LogLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	previous := logger.GetLevel()
+	if err := logger.SetLevel(req.Level); err != nil {
+		httpapi.Error(w, "level must be one of debug, info, warn, error", http.StatusBadRequest)
+		return
+	}
+	logger.InfoCtx(r.Context(), "Уровень логирования изменен: %s -> %s", previous, logger.GetLevel())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.LogLevelResponse{Level: logger.GetLevel()})
+}
+
+// ListUsers возвращает список пользователей
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	users, err := h.adminService.ListUsers(r.Context(), limit, offset)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(users)
+}
+
+// SetUserDisabled отключает пользователя {id} или снимает отключение
+func (h *AdminHandler) SetUserDisabled(w http.ResponseWriter, r *http.Request) {
+	var req models.SetUserDisabledRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.UserID = r.PathValue("id")
+
+	adminID := r.Context().Value("userID").(string)
+
+	user, err := h.adminService.SetUserDisabled(r.Context(), adminID, req.UserID, req.Disabled)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// GetUserStrategies возвращает стратегии пользователя {id}
+func (h *AdminHandler) GetUserStrategies(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("id")
+
+	strategies, err := h.adminService.GetUserStrategies(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := make([]models.UserStrategyResponse, len(strategies))
+	for i := range strategies {
+		response[i] = toUserStrategyResponse(&strategies[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// StopStrategy останавливает стратегию {id} любого пользователя
+func (h *AdminHandler) StopStrategy(w http.ResponseWriter, r *http.Request) {
+	var req models.AdminStopStrategyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.ID = r.PathValue("id")
+
+	adminID := r.Context().Value("userID").(string)
+
+	strategy, err := h.adminService.StopStrategy(r.Context(), adminID, req.ID, req.Reason)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toUserStrategyResponse(strategy))
+}
+
+// GetAuthEvents возвращает журнал аутентификации с фильтром по user_id
+func (h *AdminHandler) GetAuthEvents(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	events, err := h.adminService.GetAuthEvents(r.Context(), userID, limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+func toUserStrategyResponse(strategy *models.UserStrategy) models.UserStrategyResponse {
+	return models.UserStrategyResponse{
+		ID:             strategy.ID,
+		UserID:         strategy.UserID,
+		BybitAccountID: strategy.BybitAccountID,
+		StrategyName:   strategy.StrategyName,
+		IsActive:       strategy.IsActive,
+		CreatedAt:      strategy.CreatedAt,
+		UpdatedAt:      strategy.UpdatedAt,
+	}
+}
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"CryptoLens_Backend/httpapi"
+	"CryptoLens_Backend/models"
 	"CryptoLens_Backend/types"
 	"encoding/json"
 	"net/http"
-	"strings"
+	"strconv"
 )
 
 type BybitHandler struct {
@@ -18,18 +20,16 @@ func NewBybitHandler(bybitService types.BybitServiceInterface) *BybitHandler {
 }
 
 func (h *BybitHandler) GetWalletBalance(w http.ResponseWriter, r *http.Request) {
-	token := r.Header.Get("Authorization")
-	if token == "" {
-		http.Error(w, "Authorization header is required", http.StatusUnauthorized)
+	userID := r.Context().Value("userID").(string)
+
+	accountID, ok := parseOptionalAccountID(w, r)
+	if !ok {
 		return
 	}
 
-	// Убираем префикс "Bearer " если он есть
-	token = strings.TrimPrefix(token, "Bearer ")
-
-	balance, err := h.bybitService.GetWalletBalance(r.Context(), token)
+	balance, err := h.bybitService.GetWalletBalance(r.Context(), userID, accountID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -37,15 +37,22 @@ func (h *BybitHandler) GetWalletBalance(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(balance)
 }
 
-func (h *BybitHandler) GetFeeRate(w http.ResponseWriter, r *http.Request) {
-	token := r.Header.Get("Authorization")
-	if token == "" {
-		http.Error(w, "Authorization header is required", http.StatusUnauthorized)
+// GetWalletBalances возвращает балансы по всем аккаунтам пользователя и суммарно
+func (h *BybitHandler) GetWalletBalances(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+
+	balances, err := h.bybitService.GetWalletBalances(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	// Убираем префикс "Bearer " если он есть
-	token = strings.TrimPrefix(token, "Bearer ")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(balances)
+}
+
+func (h *BybitHandler) GetFeeRate(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
 
 	// Получаем параметры из query string
 	category := r.URL.Query().Get("category")
@@ -56,9 +63,14 @@ func (h *BybitHandler) GetFeeRate(w http.ResponseWriter, r *http.Request) {
 	symbol := r.URL.Query().Get("symbol")
 	baseCoin := r.URL.Query().Get("base_coin")
 
-	feeRate, err := h.bybitService.GetFeeRate(r.Context(), token, category, symbol, baseCoin)
+	accountID, ok := parseOptionalAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	feeRate, err := h.bybitService.GetFeeRate(r.Context(), userID, accountID, category, symbol, baseCoin)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -74,15 +86,30 @@ func (h *BybitHandler) GetInstruments(w http.ResponseWriter, r *http.Request) {
 
 	instruments, err := h.bybitService.GetInstruments(r.Context(), category)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	response := map[string]interface{}{
-		"status": "success",
-		"data":   instruments,
+	response := models.BybitInstrumentResponse{
+		Status: "success",
+		Data:   instruments,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// parseOptionalAccountID читает необязательный параметр account_id. Без него используется
+// единственный активный аккаунт пользователя.
+func parseOptionalAccountID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	idStr := r.URL.Query().Get("account_id")
+	if idStr == "" {
+		return 0, true
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		httpapi.Error(w, "Invalid account_id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
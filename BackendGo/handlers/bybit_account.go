@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"CryptoLens_Backend/httpapi"
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/types"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type BybitAccountHandler struct {
+	accountService types.BybitAccountServiceInterface
+}
+
+func NewBybitAccountHandler(accountService types.BybitAccountServiceInterface) *BybitAccountHandler {
+	return &BybitAccountHandler{
+		accountService: accountService,
+	}
+}
+
+// GetAccounts возвращает аккаунты Bybit пользователя
+func (h *BybitAccountHandler) GetAccounts(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+
+	accounts, err := h.accountService.GetAccounts(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(accounts)
+}
+
+// AddAccount проверяет и сохраняет API-ключи Bybit
+func (h *BybitAccountHandler) AddAccount(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateBybitAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(string)
+
+	response, err := h.accountService.AddAccount(r.Context(), userID, req, sessionMeta(r))
+	if err != nil {
+		writeCredentialsError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// RotateCredentials заменяет ключ и секрет аккаунта
+func (h *BybitAccountHandler) RotateCredentials(w http.ResponseWriter, r *http.Request) {
+	accountID, ok := parseAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.RotateBybitAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(string)
+
+	response, err := h.accountService.RotateCredentials(r.Context(), userID, accountID, req, sessionMeta(r))
+	if err != nil {
+		writeCredentialsError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetStatus включает или отключает аккаунт
+func (h *BybitAccountHandler) SetStatus(w http.ResponseWriter, r *http.Request) {
+	accountID, ok := parseAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.SetBybitAccountStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(string)
+
+	response, err := h.accountService.SetStatus(r.Context(), userID, accountID, req, sessionMeta(r))
+	if err != nil {
+		writeCredentialsError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RemoveAccount удаляет аккаунт и его ключи. Тело с кодом второго фактора необязательно:
+// без включенной двухфакторной аутентификации запрос можно отправить без тела.
+func (h *BybitAccountHandler) RemoveAccount(w http.ResponseWriter, r *http.Request) {
+	accountID, ok := parseAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.RemoveBybitAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(string)
+
+	if err := h.accountService.RemoveAccount(r.Context(), userID, accountID, req, sessionMeta(r)); err != nil {
+		writeCredentialsError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// writeCredentialsError отвечает 403, пока email владельца не подтвержден или не передан верный
+// код второго фактора; остальные ошибки проверки ключей отдает writeAuthError
+func writeCredentialsError(w http.ResponseWriter, r *http.Request, err error) {
+	writeAuthError(w, r, err, http.StatusForbidden)
+}
+
+// parseAccountID читает ID аккаунта из пути запроса
+func parseAccountID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		httpapi.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
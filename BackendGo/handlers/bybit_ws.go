@@ -2,31 +2,59 @@ package handlers
 
 import (
 	"CryptoLens_Backend/integration/bybit"
-	"CryptoLens_Backend/logger"
+	"CryptoLens_Backend/metrics"
+	"CryptoLens_Backend/models"
 	"CryptoLens_Backend/storages"
 	"CryptoLens_Backend/types"
+	"CryptoLens_Shared/logger"
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/shopspring/decimal"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
+// pnlStreamWindow период, за который в поток клиента отправляется PnL после исполнения
+const pnlStreamWindow = 24 * time.Hour
+
 // BybitWebSocketHandler обрабатывает WebSocket сообщения от Bybit
 type BybitWebSocketHandler struct {
 	strategyManager types.StrategyManagerInterface
 	tradeLogRepo    types.TradeLogRepositoryInterface
+	notifier        types.NotifierInterface
+	webhooks        types.WebhookPublisherInterface
+	stream          types.StreamPublisherInterface
 	msgChan         chan *bybit.WebSocketMessage
+	processed       chan struct{} // Закрывается, когда processMessages разобрал msgChan до конца
+
+	closeMutex sync.RWMutex
+	closed     bool           // Drain вызван: новые сообщения отбрасываются
+	private    sync.WaitGroup // Приватные сообщения в обработке
+
+	lastMessageMutex sync.RWMutex
+	lastMessageAt    map[string]time.Time // Время последнего публичного сообщения по символу
 }
 
 // NewBybitWebSocketHandler создает новый обработчик WebSocket сообщений
 func NewBybitWebSocketHandler(
 	strategyManager types.StrategyManagerInterface,
 	tradeLogRepo types.TradeLogRepositoryInterface,
+	notifier types.NotifierInterface,
+	webhooks types.WebhookPublisherInterface,
+	stream types.StreamPublisherInterface,
 ) *BybitWebSocketHandler {
 	handler := &BybitWebSocketHandler{
 		strategyManager: strategyManager,
 		tradeLogRepo:    tradeLogRepo,
+		notifier:        notifier,
+		webhooks:        webhooks,
+		stream:          stream,
 		msgChan:         make(chan *bybit.WebSocketMessage, 1000), // Буфер на 1000 сообщений
+		processed:       make(chan struct{}),
+		lastMessageAt:   make(map[string]time.Time),
 	}
 
 	// Запускаем обработчик сообщений в горутине
@@ -37,6 +65,7 @@ func NewBybitWebSocketHandler(
 
 // processMessages обрабатывает сообщения из канала
 func (h *BybitWebSocketHandler) processMessages() {
+	defer close(h.processed)
 	for msg := range h.msgChan {
 		ctx := context.Background()
 		h.processMessage(ctx, msg)
@@ -89,6 +118,7 @@ func (h *BybitWebSocketHandler) processMessage(ctx context.Context, msg *bybit.W
 			logger.LogError("Ошибка сохранения истории тикера: %v", err)
 		}
 		h.handleTickerMessage(ctx, tickerMsg)
+		h.stream.PublishTicker(tickerMsg)
 
 	case "orderbook":
 		var orderBookMsg bybit.OrderBookMessage
@@ -114,6 +144,7 @@ func (h *BybitWebSocketHandler) processMessage(ctx context.Context, msg *bybit.W
 		}
 
 		h.handleOrderBookMessage(ctx, orderBookMsg)
+		h.stream.PublishOrderBook(msg.Type, orderBookMsg)
 		h.strategyManager.HandleOrderBook(ctx, orderBookMsg)
 
 	case "publicTrade":
@@ -138,14 +169,75 @@ func (h *BybitWebSocketHandler) processMessage(ctx context.Context, msg *bybit.W
 // HandleMessage обрабатывает входящие WebSocket сообщения
 func (h *BybitWebSocketHandler) HandleMessage(ctx context.Context, msg bybit.WebSocketMessage) {
 	logger.LogDebug("Получено сообщение: Topic=%s", msg.Topic)
+	metrics.WSMessages.WithLabelValues("public", msg.Topic).Inc()
+	h.touchSymbol(msg.Topic)
+
+	h.closeMutex.RLock()
+	defer h.closeMutex.RUnlock()
+	if h.closed {
+		return
+	}
 	select {
 	case h.msgChan <- &msg: // Отправка в канал без блокировки
 		logger.LogDebug("Сообщение отправлено в канал: Topic=%s", msg.Topic)
 	default:
+		metrics.WSMessagesDropped.WithLabelValues(msg.Topic).Inc()
 		logger.LogWarn("Канал переполнен, сообщение отброшено: Topic=%s", msg.Topic)
 	}
 }
 
+// Drain перестает принимать сообщения и ждет, пока обработаются уже полученные: очередь публичных
+// данных и приватные сообщения с записью исполнений в журнал сделок
+func (h *BybitWebSocketHandler) Drain(ctx context.Context) error {
+	h.closeMutex.Lock()
+	if !h.closed {
+		h.closed = true
+		close(h.msgChan)
+	}
+	h.closeMutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		<-h.processed
+		h.private.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("websocket queue not drained, %d messages left: %w", len(h.msgChan), ctx.Err())
+	}
+}
+
+// touchSymbol запоминает время получения сообщения по символу из топика.
+// Учитываются и отброшенные сообщения: они показывают, что поток данных жив.
+func (h *BybitWebSocketHandler) touchSymbol(topic string) {
+	if topic == "" {
+		return
+	}
+	topicParts := strings.Split(topic, ".")
+	if len(topicParts) < 2 {
+		return
+	}
+	symbol := topicParts[len(topicParts)-1]
+
+	h.lastMessageMutex.Lock()
+	h.lastMessageAt[symbol] = time.Now()
+	h.lastMessageMutex.Unlock()
+}
+
+// GetLastMessageTimes возвращает время последнего публичного сообщения по каждому символу
+func (h *BybitWebSocketHandler) GetLastMessageTimes() map[string]time.Time {
+	h.lastMessageMutex.RLock()
+	defer h.lastMessageMutex.RUnlock()
+	times := make(map[string]time.Time, len(h.lastMessageAt))
+	for symbol, at := range h.lastMessageAt {
+		times[symbol] = at
+	}
+	return times
+}
+
 // handleTickerMessage обрабатывает сообщения тикера
 func (h *BybitWebSocketHandler) handleTickerMessage(ctx context.Context, msg bybit.TickerMessage) {
 	//logger.LogInfo("Тикер %s: цена=%s, объем=%s",
@@ -167,63 +259,140 @@ func (h *BybitWebSocketHandler) handleTradeMessage(ctx context.Context, msg bybi
 	// Здесь можно добавить дополнительную логику обработки сделок
 }
 
-// HandlePrivateMessage обрабатывает приватные WebSocket сообщения
-func (h *BybitWebSocketHandler) HandlePrivateMessage(ctx context.Context, msg bybit.WebSocketMessage, userID string) {
-	logger.LogDebug("Приватное WebSocket сообщение: Topic=%s, Data=%s", msg.Topic, string(msg.Data))
+// HandlePrivateMessage обрабатывает приватные WebSocket сообщения аккаунта и передает их его стратегиям
+func (h *BybitWebSocketHandler) HandlePrivateMessage(ctx context.Context, msg bybit.WebSocketMessage, userID string, accountID int64) {
+	// Полученное сообщение обрабатывается до конца и при закрытии соединения: исполнение должно попасть в журнал сделок
+	ctx = logger.WithFields(context.WithoutCancel(ctx), logger.FieldUserID, userID, logger.FieldAccountID, accountID)
+	logger.DebugCtx(ctx, "Приватное WebSocket сообщение: Topic=%s, Data=%s", msg.Topic, string(msg.Data))
+	metrics.WSMessages.WithLabelValues("private", msg.Topic).Inc()
+
+	h.closeMutex.RLock()
+	if h.closed {
+		h.closeMutex.RUnlock()
+		logger.WarnCtx(ctx, "Обработчик остановлен, приватное сообщение отброшено: Topic=%s", msg.Topic)
+		return
+	}
+	h.private.Add(1)
+	h.closeMutex.RUnlock()
+	defer h.private.Done()
 
 	switch msg.Topic {
 	case "order.spot":
 		var orders []bybit.OrderMessage
 		if err := json.Unmarshal(msg.Data, &orders); err != nil {
-			logger.LogError("Ошибка разбора сообщения ордера: %v", err)
+			logger.ErrorCtx(ctx, "Ошибка разбора сообщения ордера: %v", err)
 			return
 		}
 		for _, order := range orders {
-			if err := storages.SavePrivateOrder(ctx, userID, order.OrderID, order); err != nil {
-				logger.LogError("Ошибка сохранения ордера: %v", err)
+			orderCtx := logger.WithFields(ctx, logger.FieldSymbol, order.Symbol, logger.FieldOrderID, order.OrderID)
+			metrics.Orders.WithLabelValues(order.OrderStatus).Inc()
+			if err := storages.SavePrivateOrder(ctx, accountID, order.OrderID, order); err != nil {
+				logger.ErrorCtx(orderCtx, "Ошибка сохранения ордера: %v", err)
+			}
+			logger.InfoCtx(orderCtx, "Ордер: Symbol=%s, OrderID=%s, Status=%s",
+				order.Symbol, order.OrderID, order.OrderStatus)
+			h.webhooks.Publish(ctx, userID, models.WebhookEventOrder, order)
+			h.stream.Publish(userID, accountID, models.StreamEventOrder, order)
+			h.strategyManager.HandleOrder(orderCtx, accountID, order)
+			if order.OrderStatus == "Filled" {
+				h.notifyOrderFilled(ctx, userID, accountID, order)
 			}
-			logger.LogInfo("Ордер: UserID=%s, Symbol=%s, OrderID=%s, Status=%s",
-				userID, order.Symbol, order.OrderID, order.OrderStatus)
 		}
 
 	case "execution.spot":
 		//case "execution.fast.spot":
 		var executions []bybit.ExecutionMessage
 		if err := json.Unmarshal(msg.Data, &executions); err != nil {
-			logger.LogError("Ошибка разбора сообщения исполнения: %v", err)
+			logger.ErrorCtx(ctx, "Ошибка разбора сообщения исполнения: %v", err)
 			return
 		}
+		symbols := make(map[string]bool)
 		for _, exec := range executions {
-			if err := storages.SavePrivateExecution(ctx, userID, exec.ExecID, exec); err != nil {
-				logger.LogError("Ошибка сохранения исполнения: %v", err)
+			symbols[exec.Symbol] = true
+			execCtx := logger.WithFields(ctx, logger.FieldSymbol, exec.Symbol, logger.FieldOrderID, exec.OrderID)
+			if err := storages.SavePrivateExecution(ctx, accountID, exec.ExecID, exec); err != nil {
+				logger.ErrorCtx(execCtx, "Ошибка сохранения исполнения: %v", err)
 			}
-			if err := h.tradeLogRepo.SaveExecution(ctx, userID, exec); err != nil {
-				logger.LogError("Ошибка сохранения исполнения в trade_logs: %v", err)
+			if err := h.tradeLogRepo.SaveExecution(ctx, userID, accountID, exec); err != nil {
+				logger.ErrorCtx(execCtx, "Ошибка сохранения исполнения в trade_logs: %v", err)
 			}
-			logger.LogInfo("Исполнение: UserID=%s, Symbol=%s, ExecID=%s, Price=%s, Qty=%s",
-				userID, exec.Symbol, exec.ExecID, exec.ExecPrice, exec.ExecQty)
+			logger.InfoCtx(execCtx, "Исполнение: Symbol=%s, ExecID=%s, Price=%s, Qty=%s",
+				exec.Symbol, exec.ExecID, exec.ExecPrice, exec.ExecQty)
+			h.webhooks.Publish(ctx, userID, models.WebhookEventExecution, exec)
+			h.stream.Publish(userID, accountID, models.StreamEventExecution, exec)
+			h.strategyManager.HandleExecution(execCtx, accountID, exec)
 		}
+		h.publishPnL(ctx, userID, accountID, symbols)
 
 	case "wallet":
 		var wallets []bybit.WalletMessage
 		if err := json.Unmarshal(msg.Data, &wallets); err != nil {
-			logger.LogError("Ошибка разбора сообщения кошелька: %v", err)
+			logger.ErrorCtx(ctx, "Ошибка разбора сообщения кошелька: %v", err)
 			return
 		}
 		if len(wallets) == 0 {
-			logger.LogError("Получен пустой массив сообщений о кошельке")
+			logger.ErrorCtx(ctx, "Получен пустой массив сообщений о кошельке")
 			return
 		}
 		wallet := wallets[0] // Берем первое сообщение
-		if err := storages.SavePrivateWallet(ctx, userID, wallet); err != nil {
-			logger.LogError("Ошибка сохранения кошелька: %v", err)
+		if err := storages.SavePrivateWallet(ctx, accountID, wallet); err != nil {
+			logger.ErrorCtx(ctx, "Ошибка сохранения кошелька: %v", err)
 		}
 		for _, coin := range wallet.Coin {
-			logger.LogInfo("Баланс: UserID=%s, Coin=%s, WalletBalance=%s, Free=%s",
-				userID, coin.Coin, coin.WalletBalance, coin.Free)
+			logger.InfoCtx(ctx, "Баланс: Coin=%s, WalletBalance=%s, Free=%s",
+				coin.Coin, coin.WalletBalance, coin.Free)
 		}
+		h.stream.Publish(userID, accountID, models.StreamEventWallet, wallet)
+		h.strategyManager.HandleWallet(ctx, accountID, wallet)
 
 	default:
-		logger.LogInfo("Неизвестный приватный топик: %s", msg.Topic)
+		logger.InfoCtx(ctx, "Неизвестный приватный топик: %s", msg.Topic)
 	}
 }
+
+// publishPnL отправляет в поток клиента результат торговли за последние сутки по инструментам
+// из пришедших исполнений. Исполнения уже сохранены в trade_logs, поэтому входят в расчет.
+func (h *BybitWebSocketHandler) publishPnL(ctx context.Context, userID string, accountID int64, symbols map[string]bool) {
+	if len(symbols) == 0 || !h.stream.HasSubscribers(userID) {
+		return
+	}
+	to := time.Now()
+	from := to.Add(-pnlStreamWindow)
+	pnl, err := h.tradeLogRepo.GetPnL(ctx, userID, accountID, from, to)
+	if err != nil {
+		logger.ErrorCtx(ctx, "Ошибка расчета PnL для потока: %v", err)
+		return
+	}
+	for _, p := range pnl {
+		if !symbols[p.Symbol] {
+			continue
+		}
+		h.stream.Publish(userID, accountID, models.StreamEventPnL, models.StreamPnLUpdate{
+			SymbolPnL:   p,
+			RealizedPnL: p.RealizedPnL(),
+			From:        from,
+			To:          to,
+		})
+	}
+}
+
+// notifyOrderFilled отправляет уведомление о полностью исполненном ордере
+func (h *BybitWebSocketHandler) notifyOrderFilled(ctx context.Context, userID string, accountID int64, order bybit.OrderMessage) {
+	h.notifier.Notify(ctx, models.NotificationEvent{
+		Type:    models.EventOrderFilled,
+		UserID:  userID,
+		Title:   "Ордер исполнен",
+		Message: fmt.Sprintf("%s %s %s", order.Side, order.CumExecQty, order.Symbol),
+		Fields: map[string]string{
+			"symbol":     order.Symbol,
+			"order_id":   order.OrderID,
+			"side":       order.Side,
+			"price":      order.Price,
+			"qty":        order.CumExecQty,
+			"value":      order.CumExecValue,
+			"fee":        order.CumExecFee,
+			"order_type": order.OrderType,
+			"account_id": strconv.FormatInt(accountID, 10),
+		},
+	})
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"CryptoLens_Backend/httpapi"
+	"CryptoLens_Backend/models"
+	"CryptoLens_Shared/logger"
+	"errors"
+	"net/http"
+)
+
+// writeError отвечает на ошибку сервиса. Ошибки models.Error получают статус по своему виду
+// и свое сообщение, остальные пишутся в лог, а клиент получает только «Internal server error»:
+// в тексте внутренних ошибок бывают SQL, адреса и ответы внешних API.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var clientErr *models.Error
+	if errors.As(err, &clientErr) {
+		httpapi.Error(w, clientErr.Message, errorStatus(clientErr))
+		return
+	}
+	writeInternalError(w, r, err, http.StatusInternalServerError, "Internal server error")
+}
+
+// writeInternalError пишет ошибку в лог и отвечает status с общим сообщением
+func writeInternalError(w http.ResponseWriter, r *http.Request, err error, status int, message string) {
+	logger.ErrorCtx(r.Context(), "Ошибка обработки %s %s: %v", r.Method, r.URL.Path, err)
+	httpapi.Error(w, message, status)
+}
+
+// errorStatus возвращает HTTP-статус для вида ошибки
+func errorStatus(err *models.Error) int {
+	switch {
+	case errors.Is(err.Kind, models.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err.Kind, models.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err.Kind, models.ErrUnauthorized):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusBadRequest
+	}
+}
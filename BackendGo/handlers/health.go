@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/types"
+	"encoding/json"
+	"net/http"
+)
+
+type HealthHandler struct {
+	healthService types.HealthServiceInterface
+}
+
+func NewHealthHandler(healthService types.HealthServiceInterface) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+	}
+}
+
+// Healthz сообщает, что процесс жив и обрабатывает запросы
+func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": models.HealthStatusOK})
+}
+
+// Readyz сообщает, готов ли сервис принимать трафик: доступны ли база данных и Redis
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	status := h.healthService.Ready(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	if status.Status != models.HealthStatusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(status)
+}
+
+// GetStatus возвращает подробное состояние компонентов сервиса
+func (h *HealthHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	status := h.healthService.Status(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"CryptoLens_Backend/httpapi"
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/types"
+	"encoding/json"
+	"net/http"
+)
+
+type NotificationHandler struct {
+	notificationService types.NotificationServiceInterface
+}
+
+func NewNotificationHandler(notificationService types.NotificationServiceInterface) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+// GetSettings возвращает каналы и подписки пользователя
+func (h *NotificationHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+
+	settings, err := h.notificationService.GetSettings(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// UpsertChannel создает или обновляет канал доставки
+func (h *NotificationHandler) UpsertChannel(w http.ResponseWriter, r *http.Request) {
+	var req models.UpsertNotificationChannelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(string)
+
+	channel, err := h.notificationService.UpsertChannel(r.Context(), userID, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(channel)
+}
+
+// RemoveChannel удаляет канал доставки
+func (h *NotificationHandler) RemoveChannel(w http.ResponseWriter, r *http.Request) {
+	channel := r.PathValue("channel")
+
+	userID := r.Context().Value("userID").(string)
+
+	if err := h.notificationService.RemoveChannel(r.Context(), userID, channel); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// UpdatePreference включает или выключает тип события для канала
+func (h *NotificationHandler) UpdatePreference(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateNotificationPreferenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(string)
+
+	if err := h.notificationService.UpdatePreference(r.Context(), userID, req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// SendTest отправляет тестовое уведомление во все активные каналы
+func (h *NotificationHandler) SendTest(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+
+	if err := h.notificationService.SendTest(r.Context(), userID); err != nil {
+		// Причина недоставки есть в логе; клиенту не отдаются ответы внешних сервисов
+		writeInternalError(w, r, err, http.StatusBadGateway, "Failed to deliver the test notification")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
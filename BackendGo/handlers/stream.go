@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"CryptoLens_Backend/httpapi"
+	"CryptoLens_Backend/types"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// StreamHandler переводит запрос в WebSocket-поток событий пользователя
+type StreamHandler struct {
+	streamHub      types.StreamHubInterface
+	allowedOrigins map[string]bool
+	upgrader       websocket.Upgrader
+}
+
+// NewStreamHandler создает обработчик потока. allowedOrigins — источники браузерных клиентов,
+// которым можно подключаться с другого домена; без них допускается только тот же хост.
+func NewStreamHandler(streamHub types.StreamHubInterface, allowedOrigins []string) *StreamHandler {
+	h := &StreamHandler{
+		streamHub:      streamHub,
+		allowedOrigins: make(map[string]bool, len(allowedOrigins)),
+	}
+	for _, origin := range allowedOrigins {
+		h.allowedOrigins[strings.TrimRight(origin, "/")] = true
+	}
+	h.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 4096,
+		CheckOrigin:     h.checkOrigin,
+		Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+			httpapi.Error(w, reason.Error(), status)
+		},
+	}
+	return h
+}
+
+// Connect открывает поток событий аккаунтов пользователя. Поток закрывается при отзыве сессии,
+// отключении пользователя и истечении токена, с которым он открыт.
+func (h *StreamHandler) Connect(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	sessionID, _ := r.Context().Value("sessionID").(string)
+	expiresAt, _ := r.Context().Value("tokenExpiresAt").(time.Time)
+
+	// При ошибке Upgrade уже ответил клиенту
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	h.streamHub.Serve(r.Context(), conn, userID, sessionID, expiresAt)
+}
+
+// checkOrigin пропускает клиентов без Origin (не браузеры), тот же хост и разрешенные источники
+func (h *StreamHandler) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || h.allowedOrigins[origin] {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"CryptoLens_Backend/httpapi"
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/types"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type TelegramHandler struct {
+	telegramService types.TelegramServiceInterface
+}
+
+func NewTelegramHandler(telegramService types.TelegramServiceInterface) *TelegramHandler {
+	return &TelegramHandler{
+		telegramService: telegramService,
+	}
+}
+
+// CreateLinkCode выдает одноразовый код привязки чата
+func (h *TelegramHandler) CreateLinkCode(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateTelegramLinkRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	userID := r.Context().Value("userID").(string)
+
+	link, err := h.telegramService.CreateLinkCode(r.Context(), userID, req.Scope)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(link)
+}
+
+// GetChats возвращает привязанные чаты пользователя
+func (h *TelegramHandler) GetChats(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+
+	chats, err := h.telegramService.GetChats(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(chats)
+}
+
+// UnlinkChat отвязывает чат пользователя
+func (h *TelegramHandler) UnlinkChat(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.ParseInt(r.PathValue("chat_id"), 10, 64)
+	if err != nil {
+		httpapi.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(string)
+
+	if err := h.telegramService.UnlinkChat(r.Context(), userID, chatID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
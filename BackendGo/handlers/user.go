@@ -1,10 +1,16 @@
 package handlers
 
 import (
+	"CryptoLens_Backend/httpapi"
 	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/services"
 	"CryptoLens_Backend/types"
 	"encoding/json"
+	"errors"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 )
 
 type UserHandler struct {
@@ -20,13 +26,13 @@ func NewUserHandler(userService types.UserServiceInterface) *UserHandler {
 func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var req models.RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	response, err := h.userService.Register(r.Context(), req)
+	response, err := h.userService.Register(r.Context(), req, sessionMeta(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeAuthError(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -37,13 +43,13 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req models.LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	response, err := h.userService.Login(r.Context(), req)
+	response, err := h.userService.Login(r.Context(), req, sessionMeta(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		writeAuthError(w, r, err, http.StatusUnauthorized)
 		return
 	}
 
@@ -51,16 +57,17 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	token := r.Header.Get("Authorization")
-	if token == "" {
-		http.Error(w, "Authorization header is required", http.StatusUnauthorized)
+// RefreshToken обменивает refresh-токен на новую пару токенов
+func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	response, err := h.userService.Logout(r.Context(), token)
+	response, err := h.userService.RefreshToken(r.Context(), req.RefreshToken, sessionMeta(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -68,19 +75,242 @@ func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-func (h *UserHandler) GetAccount(w http.ResponseWriter, r *http.Request) {
-	token := r.Header.Get("Authorization")
-	if token == "" {
-		http.Error(w, "Authorization header is required", http.StatusUnauthorized)
+// Logout отзывает текущую сессию
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	sessionID, _ := r.Context().Value("sessionID").(string)
+
+	response, err := h.userService.Logout(r.Context(), userID, sessionID)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	user, err := h.userService.GetAccount(r.Context(), token)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *UserHandler) GetAccount(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+
+	user, err := h.userService.GetAccount(r.Context(), userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
-} 
\ No newline at end of file
+}
+
+// GetSessions возвращает активные сессии пользователя
+func (h *UserHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	sessionID, _ := r.Context().Value("sessionID").(string)
+
+	sessions, err := h.userService.GetSessions(r.Context(), userID, sessionID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// RevokeSession отзывает сессию пользователя {id}
+func (h *UserHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	userID := r.Context().Value("userID").(string)
+
+	if err := h.userService.RevokeSession(r.Context(), userID, id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// VerifyEmail подтверждает email по токену из письма
+func (h *UserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req models.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.userService.VerifyEmail(r.Context(), req.Token, sessionMeta(r)); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// ResendVerification повторно отправляет письмо для подтверждения email
+func (h *UserHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+
+	if err := h.userService.ResendVerification(r.Context(), userID); err != nil {
+		writeAuthError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// RequestPasswordReset отправляет ссылку для сброса пароля. Ответ одинаков
+// для существующих и несуществующих адресов.
+func (h *UserHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req models.PasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.userService.RequestPasswordReset(r.Context(), req.Email, sessionMeta(r)); err != nil {
+		writeAuthError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ConfirmPasswordReset задает новый пароль по токену из письма
+func (h *UserHandler) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req models.ConfirmPasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.userService.ConfirmPasswordReset(r.Context(), req, sessionMeta(r)); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetTwoFactorStatus возвращает состояние второго фактора
+func (h *UserHandler) GetTwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+
+	status, err := h.userService.GetTwoFactorStatus(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// SetupTwoFactor создает секрет TOTP и ссылку для приложения-аутентификатора
+func (h *UserHandler) SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+
+	response, err := h.userService.SetupTwoFactor(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// EnableTwoFactor включает второй фактор по коду из приложения
+func (h *UserHandler) EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(string)
+
+	response, err := h.userService.EnableTwoFactor(r.Context(), userID, req.Code, sessionMeta(r))
+	if err != nil {
+		writeAuthError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// DisableTwoFactor отключает второй фактор
+func (h *UserHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req models.DisableTwoFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(string)
+
+	if err := h.userService.DisableTwoFactor(r.Context(), userID, req, sessionMeta(r)); err != nil {
+		writeAuthError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// RegenerateRecoveryCodes выдает новые коды восстановления
+func (h *UserHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(string)
+
+	response, err := h.userService.RegenerateRecoveryCodes(r.Context(), userID, req.Code, sessionMeta(r))
+	if err != nil {
+		writeAuthError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeAuthError отвечает 429 с Retry-After на превышение лимита попыток,
+// 503 — если лимиты нельзя проверить или не настроена почта, а ошибкам второго фактора и неподтвержденного
+// email дает status и свои коды, чтобы клиент мог запросить код или письмо. Остальные ошибки уходят в writeError.
+func writeAuthError(w http.ResponseWriter, r *http.Request, err error, status int) {
+	var limited *services.RateLimitError
+	switch {
+	case errors.As(err, &limited):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+		httpapi.Error(w, limited.Error(), http.StatusTooManyRequests)
+	case errors.Is(err, services.ErrAuthUnavailable):
+		httpapi.Error(w, services.ErrAuthUnavailable.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, services.ErrMailDisabled):
+		httpapi.Error(w, services.ErrMailDisabled.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, services.ErrTwoFactorRequired):
+		httpapi.ErrorCode(w, httpapi.CodeTwoFactorRequired, services.ErrTwoFactorRequired.Error(), status)
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		httpapi.ErrorCode(w, httpapi.CodeInvalidTwoFactorCode, services.ErrInvalidTwoFactorCode.Error(), status)
+	case errors.Is(err, services.ErrEmailNotVerified):
+		httpapi.ErrorCode(w, httpapi.CodeEmailNotVerified, services.ErrEmailNotVerified.Error(), status)
+	default:
+		writeError(w, r, err)
+	}
+}
+
+// sessionMeta собирает сведения о клиенте для списка сессий
+func sessionMeta(r *http.Request) models.SessionMeta {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return models.SessionMeta{
+		UserAgent: r.UserAgent(),
+		IPAddress: ip,
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"CryptoLens_Backend/httpapi"
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/types"
+	"encoding/json"
+	"net/http"
+)
+
+type UserAPIKeyHandler struct {
+	apiKeyService types.UserAPIKeyServiceInterface
+}
+
+func NewUserAPIKeyHandler(apiKeyService types.UserAPIKeyServiceInterface) *UserAPIKeyHandler {
+	return &UserAPIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// CreateKey создает API-ключ; значение ключа есть только в этом ответе
+func (h *UserAPIKeyHandler) CreateKey(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(string)
+
+	response, err := h.apiKeyService.CreateKey(r.Context(), userID, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetKeys возвращает API-ключи пользователя
+func (h *UserAPIKeyHandler) GetKeys(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+
+	keys, err := h.apiKeyService.GetKeys(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+// RemoveKey удаляет API-ключ
+func (h *UserAPIKeyHandler) RemoveKey(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	userID := r.Context().Value("userID").(string)
+
+	if err := h.apiKeyService.RemoveKey(r.Context(), userID, id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
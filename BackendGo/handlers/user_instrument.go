@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"CryptoLens_Backend/httpapi"
 	"CryptoLens_Backend/models"
 	"CryptoLens_Backend/types"
 	"encoding/json"
@@ -21,7 +22,7 @@ func NewUserInstrumentHandler(userInstrumentService types.UserInstrumentServiceI
 func (h *UserInstrumentHandler) AddInstrument(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateUserInstrumentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
@@ -30,7 +31,7 @@ func (h *UserInstrumentHandler) AddInstrument(w http.ResponseWriter, r *http.Req
 
 	instrument, err := h.userInstrumentService.AddInstrument(r.Context(), userID, req.Symbol)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -53,7 +54,7 @@ func (h *UserInstrumentHandler) GetUserInstruments(w http.ResponseWriter, r *htt
 
 	instruments, err := h.userInstrumentService.GetUserInstruments(r.Context(), userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -78,13 +79,15 @@ func (h *UserInstrumentHandler) GetUserInstruments(w http.ResponseWriter, r *htt
 func (h *UserInstrumentHandler) UpdateInstrumentStatus(w http.ResponseWriter, r *http.Request) {
 	var req models.UpdateUserInstrumentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	req.ID = r.PathValue("id")
+	userID := r.Context().Value("userID").(string)
 
-	err := h.userInstrumentService.UpdateInstrumentStatus(r.Context(), req.ID, req.IsActive)
+	err := h.userInstrumentService.UpdateInstrumentStatus(r.Context(), userID, req.ID, req.IsActive)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -93,15 +96,12 @@ func (h *UserInstrumentHandler) UpdateInstrumentStatus(w http.ResponseWriter, r
 
 // RemoveInstrument удаляет инструмент у пользователя
 func (h *UserInstrumentHandler) RemoveInstrument(w http.ResponseWriter, r *http.Request) {
-	instrumentID := r.URL.Query().Get("id")
-	if instrumentID == "" {
-		http.Error(w, "Instrument ID is required", http.StatusBadRequest)
-		return
-	}
+	instrumentID := r.PathValue("id")
+	userID := r.Context().Value("userID").(string)
 
-	err := h.userInstrumentService.RemoveInstrument(r.Context(), instrumentID)
+	err := h.userInstrumentService.RemoveInstrument(r.Context(), userID, instrumentID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
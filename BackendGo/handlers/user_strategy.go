@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"CryptoLens_Backend/httpapi"
 	"CryptoLens_Backend/models"
 	"CryptoLens_Backend/services"
 	"encoding/json"
@@ -20,26 +21,27 @@ func NewUserStrategyHandler(userStrategyService *services.UserStrategyService) *
 func (h *UserStrategyHandler) AddStrategy(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateUserStrategyRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Получаем userID из контекста (предполагается, что middleware уже добавил его)
 	userID := r.Context().Value("userID").(string)
 
-	strategy, err := h.userStrategyService.AddStrategy(r.Context(), userID, req.StrategyName)
+	strategy, err := h.userStrategyService.AddStrategy(r.Context(), userID, req.StrategyName, req.BybitAccountID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	response := models.UserStrategyResponse{
-		ID:           strategy.ID,
-		UserID:       strategy.UserID,
-		StrategyName: strategy.StrategyName,
-		IsActive:     strategy.IsActive,
-		CreatedAt:    strategy.CreatedAt,
-		UpdatedAt:    strategy.UpdatedAt,
+		ID:             strategy.ID,
+		UserID:         strategy.UserID,
+		BybitAccountID: strategy.BybitAccountID,
+		StrategyName:   strategy.StrategyName,
+		IsActive:       strategy.IsActive,
+		CreatedAt:      strategy.CreatedAt,
+		UpdatedAt:      strategy.UpdatedAt,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -51,19 +53,20 @@ func (h *UserStrategyHandler) GetUserStrategies(w http.ResponseWriter, r *http.R
 
 	strategies, err := h.userStrategyService.GetUserStrategies(r.Context(), userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	response := make([]models.UserStrategyResponse, len(strategies))
 	for i, strategy := range strategies {
 		response[i] = models.UserStrategyResponse{
-			ID:           strategy.ID,
-			UserID:       strategy.UserID,
-			StrategyName: strategy.StrategyName,
-			IsActive:     strategy.IsActive,
-			CreatedAt:    strategy.CreatedAt,
-			UpdatedAt:    strategy.UpdatedAt,
+			ID:             strategy.ID,
+			UserID:         strategy.UserID,
+			BybitAccountID: strategy.BybitAccountID,
+			StrategyName:   strategy.StrategyName,
+			IsActive:       strategy.IsActive,
+			CreatedAt:      strategy.CreatedAt,
+			UpdatedAt:      strategy.UpdatedAt,
 		}
 	}
 
@@ -74,13 +77,15 @@ func (h *UserStrategyHandler) GetUserStrategies(w http.ResponseWriter, r *http.R
 func (h *UserStrategyHandler) UpdateStrategyStatus(w http.ResponseWriter, r *http.Request) {
 	var req models.UpdateUserStrategyRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	req.ID = r.PathValue("id")
+	userID := r.Context().Value("userID").(string)
 
-	err := h.userStrategyService.UpdateStrategyStatus(r.Context(), req.ID, req.IsActive)
+	err := h.userStrategyService.UpdateStrategyStatus(r.Context(), userID, req.ID, req.IsActive)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -88,15 +93,12 @@ func (h *UserStrategyHandler) UpdateStrategyStatus(w http.ResponseWriter, r *htt
 }
 
 func (h *UserStrategyHandler) RemoveStrategy(w http.ResponseWriter, r *http.Request) {
-	strategyID := r.URL.Query().Get("id")
-	if strategyID == "" {
-		http.Error(w, "Strategy ID is required", http.StatusBadRequest)
-		return
-	}
+	strategyID := r.PathValue("id")
+	userID := r.Context().Value("userID").(string)
 
-	err := h.userStrategyService.RemoveStrategy(r.Context(), strategyID)
+	err := h.userStrategyService.RemoveStrategy(r.Context(), userID, strategyID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"CryptoLens_Backend/httpapi"
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/types"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type WebhookHandler struct {
+	webhookService types.WebhookServiceInterface
+}
+
+func NewWebhookHandler(webhookService types.WebhookServiceInterface) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// CreateEndpoint регистрирует эндпоинт для исходящих вебхуков
+func (h *WebhookHandler) CreateEndpoint(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateWebhookEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpapi.ErrorCode(w, httpapi.CodeInvalidBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(string)
+
+	response, err := h.webhookService.CreateEndpoint(r.Context(), userID, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetEndpoints возвращает эндпоинты пользователя
+func (h *WebhookHandler) GetEndpoints(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+
+	endpoints, err := h.webhookService.GetEndpoints(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(endpoints)
+}
+
+// RemoveEndpoint удаляет эндпоинт
+func (h *WebhookHandler) RemoveEndpoint(w http.ResponseWriter, r *http.Request) {
+	endpointID := r.PathValue("id")
+
+	userID := r.Context().Value("userID").(string)
+
+	if err := h.webhookService.RemoveEndpoint(r.Context(), userID, endpointID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// SendTest ставит в очередь тестовое событие для эндпоинта
+func (h *WebhookHandler) SendTest(w http.ResponseWriter, r *http.Request) {
+	endpointID := r.PathValue("id")
+
+	userID := r.Context().Value("userID").(string)
+
+	if err := h.webhookService.SendTest(r.Context(), userID, endpointID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// GetDeliveries возвращает журнал доставки
+func (h *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	endpointID := r.URL.Query().Get("endpoint_id")
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	deliveries, err := h.webhookService.GetDeliveries(r.Context(), userID, endpointID, limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// RetryDelivery возвращает проваленную доставку в очередь
+func (h *WebhookHandler) RetryDelivery(w http.ResponseWriter, r *http.Request) {
+	deliveryID := r.PathValue("id")
+
+	userID := r.Context().Value("userID").(string)
+
+	if err := h.webhookService.RetryDelivery(r.Context(), userID, deliveryID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
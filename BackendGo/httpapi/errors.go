@@ -0,0 +1,89 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// HeaderRequestID заголовок с ID запроса; его же возвращает ошибка, чтобы найти запрос в логах
+const HeaderRequestID = "X-Request-ID"
+
+// Машиночитаемые коды ошибок. Клиенты ветвятся по коду, текст сообщения может меняться.
+const (
+	CodeBadRequest           = "bad_request"
+	CodeInvalidBody          = "invalid_body"
+	CodeUnauthorized         = "unauthorized"
+	CodeInvalidToken         = "invalid_token"
+	CodeSessionRevoked       = "session_revoked"
+	CodeForbidden            = "forbidden"
+	CodeSessionRequired      = "session_required" // маршрут недоступен по API-ключу
+	CodeInsufficientScope    = "insufficient_scope"
+	CodeEmailNotVerified     = "email_not_verified"
+	CodeTwoFactorRequired    = "two_factor_required"
+	CodeInvalidTwoFactorCode = "invalid_two_factor_code"
+	CodeNotFound             = "not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeConflict             = "conflict"
+	CodeRateLimited          = "rate_limited"
+	CodeInternal             = "internal_error"
+	CodeUnavailable          = "service_unavailable"
+)
+
+// ErrorResponse единый формат ответа с ошибкой
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody описание ошибки
+type ErrorBody struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Error отвечает ошибкой с кодом по умолчанию для HTTP-статуса.
+// Порядок аргументов совпадает с http.Error.
+func Error(w http.ResponseWriter, message string, status int) {
+	ErrorCode(w, StatusCode(status), message, status)
+}
+
+// ErrorCode отвечает ошибкой с явным машиночитаемым кодом
+func ErrorCode(w http.ResponseWriter, code, message string, status int) {
+	h := w.Header()
+	// Заголовки успешного ответа, выставленные до ошибки, к ней не относятся
+	h.Del("Content-Length")
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: ErrorBody{
+		Code:      code,
+		Message:   message,
+		RequestID: h.Get(HeaderRequestID),
+	}})
+}
+
+// StatusCode возвращает код ошибки по умолчанию для HTTP-статуса
+func StatusCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
@@ -1,9 +1,11 @@
 package initialization
 
 import (
+	"CryptoLens_Backend/config"
 	"CryptoLens_Backend/db"
+	"CryptoLens_Backend/encryption"
 	"CryptoLens_Backend/integration/redis"
-	"CryptoLens_Backend/logger"
+	"CryptoLens_Shared/logger"
 	"database/sql"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
@@ -13,30 +15,62 @@ import (
 )
 
 var (
-	DB *sql.DB
+	DB      *sql.DB
+	Keyring *encryption.Keyring
 )
 
 // Initialize выполняет инициализацию всех компонентов приложения
 func Initialize() {
-	initLogger()
-	initDB()
+	cfg := InitializeStorage()
+	initRedis(cfg.Redis)
+}
+
+// InitializeStorage загружает конфигурацию, логгер, базу данных и ключи шифрования.
+// Достаточно для служебных команд, которым не нужен Redis.
+func InitializeStorage() *config.Config {
+	cfg, err := config.Init()
+	if err != nil {
+		log.Fatal(err)
+	}
+	initLogger(cfg.Log)
+	logger.LogInfo("Конфигурация: %s", cfg.Redacted())
+	initKeyring(cfg.Secrets)
+	initDB(cfg.Database)
 	applyMigrations()
-	initRedis()
+	return cfg
+}
+
+// initKeyring загружает мастер-ключи для шифрования API-секретов
+func initKeyring(cfg config.SecretsConfig) {
+	keys, err := encryption.LoadKeys(cfg.MasterKeys.Value(), cfg.MasterKeysFile)
+	if err != nil {
+		log.Fatalf("SECRETS_MASTER_KEYS: %v", err)
+	}
+	Keyring, err = encryption.NewKeyring(keys, cfg.ActiveKeyVersion)
+	if err != nil {
+		log.Fatalf("SECRETS_MASTER_KEYS: %v", err)
+	}
+	logger.LogInfo("Загружены мастер-ключи версий %v, активная версия %d", Keyring.Versions(), Keyring.ActiveVersion())
 }
 
 // initDB инициализирует подключение к базе данных
-func initDB() {
+func initDB(cfg config.DatabaseConfig) {
 	var err error
-	DB, err = db.InitDB()
+	DB, err = db.InitDB(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
 }
 
 // initLogger инициализирует систему логирования
-func initLogger() {
-	err := logger.Init("logs/app.log")
-	if err != nil {
+func initLogger(cfg config.LogConfig) {
+	opts := logger.Options{
+		FilePath:   cfg.File,
+		Level:      cfg.Level,
+		MaxSizeMB:  cfg.MaxSizeMB,
+		MaxBackups: cfg.MaxBackups,
+	}
+	if err := logger.Init(opts); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -62,8 +96,8 @@ func applyMigrations() {
 	}
 }
 
-func initRedis() {
-	if err := redis.Init(); err != nil {
+func initRedis(cfg config.RedisConfig) {
+	if err := redis.Init(cfg); err != nil {
 		log.Fatal(err)
 	}
 }
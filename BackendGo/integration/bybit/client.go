@@ -83,6 +83,21 @@ type Client interface {
 		limit int,
 	) (*BybitOrderListResponse, error)
 
+	// GetOrderHistory получает исполненные и отмененные ордера; открытых ордеров в истории нет
+	GetOrderHistory(
+		ctx context.Context,
+		account *BybitAccount,
+		symbol string,
+		orderID *string,
+		limit int,
+	) (*BybitOrderListResponse, error)
+
+	// Endpoints возвращает адреса REST API и WebSocket окружения аккаунта
+	Endpoints(account *BybitAccount) (Endpoints, error)
+
+	// GetAPIKeyInfo получает права и срок действия API-ключа аккаунта
+	GetAPIKeyInfo(ctx context.Context, account *BybitAccount) (*BybitAPIKeyInfo, error)
+
 	// GetFeeRate получает ставки комиссии
 	GetFeeRate(
 		ctx context.Context,
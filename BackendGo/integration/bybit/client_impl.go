@@ -1,217 +1,91 @@
 package bybit
 
 import (
-	"CryptoLens_Backend/logger"
+	"CryptoLens_Shared/logger"
 	"bytes"
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"math/rand/v2"
 	"net/http"
 	"net/url"
 	"strconv"
 	"time"
 )
 
-// client реализация клиента Bybit
+const (
+	maxAttempts   = 3                      // Попыток на запрос, включая первую
+	retryMinDelay = 250 * time.Millisecond // Задержка перед первым повтором, дальше удваивается
+	retryMaxDelay = 5 * time.Second        // Предел задержки, в том числе до сброса лимита запросов
+)
+
+// client реализация клиента Bybit. Запросы аккаунта уходят в окружение этого аккаунта,
+// публичные данные рынка запрашиваются в окружении по умолчанию.
 type client struct {
-	baseURL    string
-	recvWindow int
-	isTestMode bool
-	httpClient *http.Client
+	baseURL            string
+	endpoints          map[string]Endpoints
+	defaultEnvironment string
+	recvWindow         int
+	httpClient         *http.Client
 }
 
-// NewClient создает новый клиент Bybit
-func NewClient(baseURL string, recvWindow int, isTestMode bool) Client {
+// NewClient создает новый клиент Bybit с адресами всех поддерживаемых окружений
+func NewClient(endpoints map[string]Endpoints, defaultEnvironment string, recvWindow int) Client {
 	return &client{
-		baseURL:    baseURL,
-		recvWindow: recvWindow,
-		isTestMode: isTestMode,
+		baseURL:            endpoints[defaultEnvironment].REST,
+		endpoints:          endpoints,
+		defaultEnvironment: defaultEnvironment,
+		recvWindow:         recvWindow,
 		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout:   10 * time.Second,
+			Transport: newMetricsTransport(http.DefaultTransport),
 		},
 	}
 }
 
 // GetWalletBalance получает баланс кошелька
 func (c *client) GetWalletBalance(ctx context.Context, account *BybitAccount) (*BybitWalletBalance, error) {
-	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
-	queryParams := fmt.Sprintf("accountType=%s", account.AccountType)
-
-	signature := c.generateSignature(timestamp, queryParams, account)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
-		fmt.Sprintf("%s/v5/account/wallet-balance?%s", c.baseURL, queryParams), nil)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
-	}
-
-	req.Header.Set("X-BAPI-API-KEY", account.APIKey)
-	req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
-	req.Header.Set("X-BAPI-RECV-WINDOW", strconv.Itoa(c.recvWindow))
-	req.Header.Set("X-BAPI-SIGN", signature)
-	req.Header.Set("Content-Type", "application/json")
-
-	//// Формируем curl запрос для логирования
-	//curlCmd := fmt.Sprintf("curl -X GET '%s/v5/account/wallet-balance?%s' \\\n"+
-	//	"  -H 'X-BAPI-API-KEY: %s' \\\n"+
-	//	"  -H 'X-BAPI-TIMESTAMP: %s' \\\n"+
-	//	"  -H 'X-BAPI-RECV-WINDOW: %d' \\\n"+
-	//	"  -H 'X-BAPI-SIGN: %s' \\\n"+
-	//	"  -H 'Content-Type: application/json'",
-	//	c.baseURL, queryParams, account.APIKey, timestamp, c.recvWindow, signature)
-	//logger.LogInfo("Curl запрос:\n%s", curlCmd)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Читаем тело ответа
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка чтения тела ответа: %w", err)
-	}
-
-	// Логируем ответ сервера
-	//logger.LogInfo("Ответ сервера (статус %d):\n%s", resp.StatusCode, string(body))
-
-	var bybitResp BybitResponse
-	if err := json.Unmarshal(body, &bybitResp); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования ответа: %w\nТело ответа: %s", err, string(body))
-	}
-
-	if !bybitResp.IsSuccess() {
-		return nil, fmt.Errorf("ошибка API: %s", bybitResp.RetMsg)
-	}
-
-	// Логируем ответ для отладки
-	resultBytes, err := json.Marshal(bybitResp.Result)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка маршалинга результата: %w", err)
-	}
-	logger.LogInfo("Bybit API Response: %s", string(resultBytes))
-
-	// Преобразуем result в map[string]interface{}
-	resultMap, ok := bybitResp.Result.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("неожиданный формат ответа: %v", bybitResp.Result)
-	}
-
-	// Преобразуем map обратно в JSON
-	resultBytes, err = json.Marshal(resultMap)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка маршалинга результата: %w", err)
-	}
+	query := url.Values{}
+	query.Set("accountType", account.AccountType)
 
 	var result BybitWalletBalance
-	if err := json.Unmarshal(resultBytes, &result); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования результата: %w", err)
+	raw, err := c.do(ctx, apiCall{method: http.MethodGet, path: "/v5/account/wallet-balance", query: query, account: account}, &result)
+	if err != nil {
+		return nil, err
 	}
-
+	logger.LogInfo("Bybit API Response: %s", string(raw))
 	return &result, nil
 }
 
 // GetInstruments получает список доступных для торговли пар
 func (c *client) GetInstruments(ctx context.Context, category string) (*BybitInstrumentsResponse, error) {
-	queryParams := url.Values{}
-	queryParams.Set("category", category)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
-		fmt.Sprintf("%s/v5/market/instruments-info?%s", c.baseURL, queryParams.Encode()), nil)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
-	}
-
-	// Формируем curl запрос для логирования
-	//curlCmd := fmt.Sprintf("curl -X GET '%s/v5/market/instruments-info?%s' \\\n"+
-	//	"  -H 'Content-Type: application/json'",
-	//c.baseURL, queryParams.Encode())
-	//logger.LogInfo("Curl запрос:\n%s", curlCmd)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Читаем тело ответа
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка чтения тела ответа: %w", err)
-	}
-
-	// Логируем ответ сервера
-	//logger.LogInfo("Ответ сервера (статус %d):\n%s", resp.StatusCode, string(body))
-
-	var bybitResp BybitResponse
-	if err := json.Unmarshal(body, &bybitResp); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования ответа: %w\nТело ответа: %s", err, string(body))
-	}
-
-	if !bybitResp.IsSuccess() {
-		return nil, fmt.Errorf("ошибка API: %s", bybitResp.RetMsg)
-	}
-
-	// Преобразуем result в map[string]interface{}
-	resultMap, ok := bybitResp.Result.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("неожиданный формат ответа: %v", bybitResp.Result)
-	}
-
-	// Преобразуем map обратно в JSON
-	resultBytes, err := json.Marshal(resultMap)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка маршалинга результата: %w", err)
-	}
+	query := url.Values{}
+	query.Set("category", category)
 
 	var result BybitInstrumentsResponse
-	if err := json.Unmarshal(resultBytes, &result); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования результата: %w", err)
+	if _, err := c.do(ctx, apiCall{method: http.MethodGet, path: "/v5/market/instruments-info", query: query}, &result); err != nil {
+		return nil, err
 	}
-
 	return &result, nil
 }
 
 // GetTickers получает текущие котировки
 func (c *client) GetTickers(ctx context.Context, category string, symbol *string) (*BybitTickersResponse, error) {
-	queryParams := url.Values{}
-	queryParams.Set("category", category)
+	query := url.Values{}
+	query.Set("category", category)
 	if symbol != nil {
-		queryParams.Set("symbol", *symbol)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
-		fmt.Sprintf("%s/v5/market/tickers?%s", c.baseURL, queryParams.Encode()), nil)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
-	}
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var bybitResp BybitResponse
-	if err := json.NewDecoder(resp.Body).Decode(&bybitResp); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования ответа: %w", err)
-	}
-
-	if !bybitResp.IsSuccess() {
-		return nil, fmt.Errorf("ошибка API: %s", bybitResp.RetMsg)
+		query.Set("symbol", *symbol)
 	}
 
 	var result BybitTickersResponse
-	if err := json.Unmarshal(bybitResp.Result.(json.RawMessage), &result); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования результата: %w", err)
+	if _, err := c.do(ctx, apiCall{method: http.MethodGet, path: "/v5/market/tickers", query: query}, &result); err != nil {
+		return nil, err
 	}
-
 	return &result, nil
 }
 
@@ -225,45 +99,22 @@ func (c *client) GetKlines(
 	start *time.Time,
 	end *time.Time,
 ) (*BybitKlinesResponse, error) {
-	queryParams := url.Values{}
-	queryParams.Set("category", category)
-	queryParams.Set("symbol", symbol)
-	queryParams.Set("interval", interval)
-	queryParams.Set("limit", strconv.Itoa(limit))
-
+	query := url.Values{}
+	query.Set("category", category)
+	query.Set("symbol", symbol)
+	query.Set("interval", interval)
+	query.Set("limit", strconv.Itoa(limit))
 	if start != nil {
-		queryParams.Set("start", strconv.FormatInt(start.UnixMilli(), 10))
+		query.Set("start", strconv.FormatInt(start.UnixMilli(), 10))
 	}
 	if end != nil {
-		queryParams.Set("end", strconv.FormatInt(end.UnixMilli(), 10))
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
-		fmt.Sprintf("%s/v5/market/kline?%s", c.baseURL, queryParams.Encode()), nil)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
-	}
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var bybitResp BybitResponse
-	if err := json.NewDecoder(resp.Body).Decode(&bybitResp); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования ответа: %w", err)
-	}
-
-	if !bybitResp.IsSuccess() {
-		return nil, fmt.Errorf("ошибка API: %s", bybitResp.RetMsg)
+		query.Set("end", strconv.FormatInt(end.UnixMilli(), 10))
 	}
 
 	var result BybitKlinesResponse
-	if err := json.Unmarshal(bybitResp.Result.(json.RawMessage), &result); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования результата: %w", err)
+	if _, err := c.do(ctx, apiCall{method: http.MethodGet, path: "/v5/market/kline", query: query}, &result); err != nil {
+		return nil, err
 	}
-
 	return &result, nil
 }
 
@@ -275,41 +126,18 @@ func (c *client) GetTrades(
 	limit int,
 	orderID *string,
 ) (*BybitTradesResponse, error) {
-	queryParams := url.Values{}
-	queryParams.Set("category", category)
-	queryParams.Set("symbol", symbol)
-	queryParams.Set("limit", strconv.Itoa(limit))
-
+	query := url.Values{}
+	query.Set("category", category)
+	query.Set("symbol", symbol)
+	query.Set("limit", strconv.Itoa(limit))
 	if orderID != nil {
-		queryParams.Set("orderId", *orderID)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
-		fmt.Sprintf("%s/v5/market/recent-trade?%s", c.baseURL, queryParams.Encode()), nil)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
-	}
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var bybitResp BybitResponse
-	if err := json.NewDecoder(resp.Body).Decode(&bybitResp); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования ответа: %w", err)
-	}
-
-	if !bybitResp.IsSuccess() {
-		return nil, fmt.Errorf("ошибка API: %s", bybitResp.RetMsg)
+		query.Set("orderId", *orderID)
 	}
 
 	var result BybitTradesResponse
-	if err := json.Unmarshal(bybitResp.Result.(json.RawMessage), &result); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования результата: %w", err)
+	if _, err := c.do(ctx, apiCall{method: http.MethodGet, path: "/v5/market/recent-trade", query: query}, &result); err != nil {
+		return nil, err
 	}
-
 	return &result, nil
 }
 
@@ -325,8 +153,6 @@ func (c *client) CreateOrder(
 	timeInForce string,
 	orderLinkID *string,
 ) (*BybitOrderResponse, error) {
-	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
-
 	payload := map[string]interface{}{
 		"category":    "spot",
 		"symbol":      symbol,
@@ -335,7 +161,6 @@ func (c *client) CreateOrder(
 		"qty":         qty,
 		"timeInForce": timeInForce,
 	}
-
 	if price != nil {
 		payload["price"] = *price
 	}
@@ -343,45 +168,10 @@ func (c *client) CreateOrder(
 		payload["orderLinkId"] = *orderLinkID
 	}
 
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка маршалинга payload: %w", err)
-	}
-
-	signature := c.generateSignature(timestamp, string(payloadBytes), account)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
-		fmt.Sprintf("%s/v5/order/create", c.baseURL), bytes.NewBuffer(payloadBytes))
-	if err != nil {
-		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
-	}
-
-	req.Header.Set("X-BAPI-API-KEY", account.APIKey)
-	req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
-	req.Header.Set("X-BAPI-RECV-WINDOW", strconv.Itoa(c.recvWindow))
-	req.Header.Set("X-BAPI-SIGN", signature)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var bybitResp BybitResponse
-	if err := json.NewDecoder(resp.Body).Decode(&bybitResp); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования ответа: %w", err)
-	}
-
-	if !bybitResp.IsSuccess() {
-		return nil, fmt.Errorf("ошибка API: %s", bybitResp.RetMsg)
-	}
-
 	var result BybitOrderResponse
-	if err := json.Unmarshal(bybitResp.Result.(json.RawMessage), &result); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования результата: %w", err)
+	if _, err := c.do(ctx, apiCall{method: http.MethodPost, path: "/v5/order/create", payload: payload, account: account}, &result); err != nil {
+		return nil, err
 	}
-
 	return &result, nil
 }
 
@@ -394,14 +184,11 @@ func (c *client) AmendOrder(
 	price *string,
 	qty *string,
 ) (*BybitOrderResponse, error) {
-	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
-
 	payload := map[string]interface{}{
 		"category": "spot",
 		"symbol":   symbol,
 		"orderId":  orderID,
 	}
-
 	if price != nil {
 		payload["price"] = *price
 	}
@@ -409,45 +196,10 @@ func (c *client) AmendOrder(
 		payload["qty"] = *qty
 	}
 
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка маршалинга payload: %w", err)
-	}
-
-	signature := c.generateSignature(timestamp, string(payloadBytes), account)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
-		fmt.Sprintf("%s/v5/order/amend", c.baseURL), bytes.NewBuffer(payloadBytes))
-	if err != nil {
-		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
-	}
-
-	req.Header.Set("X-BAPI-API-KEY", account.APIKey)
-	req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
-	req.Header.Set("X-BAPI-RECV-WINDOW", strconv.Itoa(c.recvWindow))
-	req.Header.Set("X-BAPI-SIGN", signature)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var bybitResp BybitResponse
-	if err := json.NewDecoder(resp.Body).Decode(&bybitResp); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования ответа: %w", err)
-	}
-
-	if !bybitResp.IsSuccess() {
-		return nil, fmt.Errorf("ошибка API: %s", bybitResp.RetMsg)
-	}
-
 	var result BybitOrderResponse
-	if err := json.Unmarshal(bybitResp.Result.(json.RawMessage), &result); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования результата: %w", err)
+	if _, err := c.do(ctx, apiCall{method: http.MethodPost, path: "/v5/order/amend", payload: payload, account: account}, &result); err != nil {
+		return nil, err
 	}
-
 	return &result, nil
 }
 
@@ -458,53 +210,16 @@ func (c *client) CancelOrder(
 	symbol string,
 	orderID string,
 ) (*BybitOrderResponse, error) {
-	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
-
 	payload := map[string]interface{}{
 		"category": "spot",
 		"symbol":   symbol,
 		"orderId":  orderID,
 	}
 
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка маршалинга payload: %w", err)
-	}
-
-	signature := c.generateSignature(timestamp, string(payloadBytes), account)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
-		fmt.Sprintf("%s/v5/order/cancel", c.baseURL), bytes.NewBuffer(payloadBytes))
-	if err != nil {
-		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
-	}
-
-	req.Header.Set("X-BAPI-API-KEY", account.APIKey)
-	req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
-	req.Header.Set("X-BAPI-RECV-WINDOW", strconv.Itoa(c.recvWindow))
-	req.Header.Set("X-BAPI-SIGN", signature)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var bybitResp BybitResponse
-	if err := json.NewDecoder(resp.Body).Decode(&bybitResp); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования ответа: %w", err)
-	}
-
-	if !bybitResp.IsSuccess() {
-		return nil, fmt.Errorf("ошибка API: %s", bybitResp.RetMsg)
-	}
-
 	var result BybitOrderResponse
-	if err := json.Unmarshal(bybitResp.Result.(json.RawMessage), &result); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования результата: %w", err)
+	if _, err := c.do(ctx, apiCall{method: http.MethodPost, path: "/v5/order/cancel", payload: payload, account: account}, &result); err != nil {
+		return nil, err
 	}
-
 	return &result, nil
 }
 
@@ -514,52 +229,15 @@ func (c *client) CancelAllOrders(
 	account *BybitAccount,
 	symbol string,
 ) (*BybitOrderResponse, error) {
-	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
-
 	payload := map[string]interface{}{
 		"category": "spot",
 		"symbol":   symbol,
 	}
 
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка маршалинга payload: %w", err)
-	}
-
-	signature := c.generateSignature(timestamp, string(payloadBytes), account)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
-		fmt.Sprintf("%s/v5/order/cancel-all", c.baseURL), bytes.NewBuffer(payloadBytes))
-	if err != nil {
-		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
-	}
-
-	req.Header.Set("X-BAPI-API-KEY", account.APIKey)
-	req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
-	req.Header.Set("X-BAPI-RECV-WINDOW", strconv.Itoa(c.recvWindow))
-	req.Header.Set("X-BAPI-SIGN", signature)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var bybitResp BybitResponse
-	if err := json.NewDecoder(resp.Body).Decode(&bybitResp); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования ответа: %w", err)
-	}
-
-	if !bybitResp.IsSuccess() {
-		return nil, fmt.Errorf("ошибка API: %s", bybitResp.RetMsg)
-	}
-
 	var result BybitOrderResponse
-	if err := json.Unmarshal(bybitResp.Result.(json.RawMessage), &result); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования результата: %w", err)
+	if _, err := c.do(ctx, apiCall{method: http.MethodPost, path: "/v5/order/cancel-all", payload: payload, account: account}, &result); err != nil {
+		return nil, err
 	}
-
 	return &result, nil
 }
 
@@ -571,50 +249,41 @@ func (c *client) GetOpenOrders(
 	orderID *string,
 	limit int,
 ) (*BybitOrderListResponse, error) {
-	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
-
-	queryParams := url.Values{}
-	queryParams.Set("category", "spot")
-	queryParams.Set("symbol", symbol)
+	query := url.Values{}
+	query.Set("category", "spot")
+	query.Set("symbol", symbol)
 	if orderID != nil {
-		queryParams.Set("orderId", *orderID)
-	}
-	queryParams.Set("limit", strconv.Itoa(limit))
-
-	signature := c.generateSignature(timestamp, queryParams.Encode(), account)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
-		fmt.Sprintf("%s/v5/order/realtime?%s", c.baseURL, queryParams.Encode()), nil)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
-	}
-
-	req.Header.Set("X-BAPI-API-KEY", account.APIKey)
-	req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
-	req.Header.Set("X-BAPI-RECV-WINDOW", strconv.Itoa(c.recvWindow))
-	req.Header.Set("X-BAPI-SIGN", signature)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+		query.Set("orderId", *orderID)
 	}
-	defer resp.Body.Close()
+	query.Set("limit", strconv.Itoa(limit))
 
-	var bybitResp BybitResponse
-	if err := json.NewDecoder(resp.Body).Decode(&bybitResp); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования ответа: %w", err)
+	var result BybitOrderListResponse
+	if _, err := c.do(ctx, apiCall{method: http.MethodGet, path: "/v5/order/realtime", query: query, account: account}, &result); err != nil {
+		return nil, err
 	}
+	return &result, nil
+}
 
-	if !bybitResp.IsSuccess() {
-		return nil, fmt.Errorf("ошибка API: %s", bybitResp.RetMsg)
+// GetOrderHistory получает исполненные и отмененные ордера
+func (c *client) GetOrderHistory(
+	ctx context.Context,
+	account *BybitAccount,
+	symbol string,
+	orderID *string,
+	limit int,
+) (*BybitOrderListResponse, error) {
+	query := url.Values{}
+	query.Set("category", "spot")
+	query.Set("symbol", symbol)
+	if orderID != nil {
+		query.Set("orderId", *orderID)
 	}
+	query.Set("limit", strconv.Itoa(limit))
 
 	var result BybitOrderListResponse
-	if err := json.Unmarshal(bybitResp.Result.(json.RawMessage), &result); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования результата: %w", err)
+	if _, err := c.do(ctx, apiCall{method: http.MethodGet, path: "/v5/order/history", query: query, account: account}, &result); err != nil {
+		return nil, err
 	}
-
 	return &result, nil
 }
 
@@ -626,30 +295,106 @@ func (c *client) GetFeeRate(
 	symbol *string,
 	baseCoin *string,
 ) (*BybitFeeRateResponse, error) {
-	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
-
-	queryParams := url.Values{}
-	queryParams.Set("category", category)
+	query := url.Values{}
+	query.Set("category", category)
 	if symbol != nil {
-		queryParams.Set("symbol", *symbol)
+		query.Set("symbol", *symbol)
 	}
 	if baseCoin != nil {
-		queryParams.Set("baseCoin", *baseCoin)
+		query.Set("baseCoin", *baseCoin)
+	}
+
+	var result BybitFeeRateResponse
+	raw, err := c.do(ctx, apiCall{method: http.MethodGet, path: "/v5/account/fee-rate", query: query, account: account}, &result)
+	if err != nil {
+		return nil, err
+	}
+	logger.LogInfo("Bybit API Response: %s", string(raw))
+	return &result, nil
+}
+
+// GetAPIKeyInfo получает права и срок действия API-ключа аккаунта
+func (c *client) GetAPIKeyInfo(ctx context.Context, account *BybitAccount) (*BybitAPIKeyInfo, error) {
+	var result BybitAPIKeyInfo
+	if _, err := c.do(ctx, apiCall{method: http.MethodGet, path: "/v5/user/query-api", account: account}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// apiCall описывает запрос к REST API. Запрос с аккаунтом подписывается его ключом
+// и уходит в окружение аккаунта, без аккаунта — в окружение по умолчанию.
+type apiCall struct {
+	method  string
+	path    string
+	query   url.Values
+	payload map[string]interface{}
+	account *BybitAccount
+}
+
+// do выполняет запрос и декодирует result ответа. Временные сбои повторяются с растущей задержкой:
+// для GET — любые, для изменяющих запросов — только отказы, после которых Bybit точно не выполнил запрос.
+// Возвращает result в исходном виде для логирования.
+func (c *client) do(ctx context.Context, call apiCall, result interface{}) (json.RawMessage, error) {
+	for attempt := 1; ; attempt++ {
+		raw, err := c.send(ctx, call)
+		if err == nil {
+			if err := json.Unmarshal(raw, result); err != nil {
+				return nil, fmt.Errorf("ошибка декодирования результата: %w", err)
+			}
+			return raw, nil
+		}
+		if attempt >= maxAttempts || !shouldRetry(call.method, err) || ctx.Err() != nil {
+			return nil, err
+		}
+
+		delay := retryDelay(attempt, err)
+		logger.LogWarn("Bybit %s %s: попытка %d не удалась (%v), повтор через %s", call.method, call.path, attempt, err, delay.Round(time.Millisecond))
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// send выполняет одну попытку запроса. Подпись создается заново, чтобы повтор не упал по recv_window.
+func (c *client) send(ctx context.Context, call apiCall) (json.RawMessage, error) {
+	baseURL := c.baseURL
+	if call.account != nil {
+		var err error
+		if baseURL, err = c.accountURL(call.account); err != nil {
+			return nil, err
+		}
 	}
 
-	signature := c.generateSignature(timestamp, queryParams.Encode(), account)
+	target := baseURL + call.path
+	signed := call.query.Encode()
+	if signed != "" {
+		target += "?" + signed
+	}
+	var body io.Reader
+	if call.payload != nil {
+		payloadBytes, err := json.Marshal(call.payload)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка маршалинга payload: %w", err)
+		}
+		signed = string(payloadBytes)
+		body = bytes.NewReader(payloadBytes)
+	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
-		fmt.Sprintf("%s/v5/account/fee-rate?%s", c.baseURL, queryParams.Encode()), nil)
+	req, err := http.NewRequestWithContext(ctx, call.method, target, body)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
 	}
-
-	req.Header.Set("X-BAPI-API-KEY", account.APIKey)
-	req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
-	req.Header.Set("X-BAPI-RECV-WINDOW", strconv.Itoa(c.recvWindow))
-	req.Header.Set("X-BAPI-SIGN", signature)
 	req.Header.Set("Content-Type", "application/json")
+	if call.account != nil {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		req.Header.Set("X-BAPI-API-KEY", call.account.APIKey)
+		req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
+		req.Header.Set("X-BAPI-RECV-WINDOW", strconv.Itoa(c.recvWindow))
+		req.Header.Set("X-BAPI-SIGN", c.generateSignature(timestamp, signed, call.account))
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -657,40 +402,72 @@ func (c *client) GetFeeRate(
 	}
 	defer resp.Body.Close()
 
-	var bybitResp BybitResponse
-	if err := json.NewDecoder(resp.Body).Decode(&bybitResp); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования ответа: %w", err)
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения тела ответа: %w", err)
 	}
 
-	if !bybitResp.IsSuccess() {
-		return nil, fmt.Errorf("ошибка API: %s", bybitResp.RetMsg)
+	var envelope struct {
+		RetCode *int            `json:"retCode"`
+		RetMsg  string          `json:"retMsg"`
+		Result  json.RawMessage `json:"result"`
+	}
+	err = json.Unmarshal(respBody, &envelope)
+	if err == nil && envelope.RetCode == nil {
+		err = errors.New("в ответе нет retCode")
 	}
-
-	// Логируем ответ для отладки
-	resultBytes, err := json.Marshal(bybitResp.Result)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка маршалинга результата: %w", err)
+		// Без тела Bybit (балансировщик, лимит IP) ошибка определяется по статусу
+		if resp.StatusCode != http.StatusOK {
+			apiErr := newAPIError(call.path, resp.StatusCode, 0, http.StatusText(resp.StatusCode))
+			apiErr.retryAfter = limitResetIn(resp.Header)
+			return nil, apiErr
+		}
+		return nil, fmt.Errorf("ошибка декодирования ответа: %w\nТело ответа: %s", err, string(respBody))
 	}
-	log.Printf("Bybit API Response: %s", string(resultBytes))
-
-	// Преобразуем result в map[string]interface{}
-	resultMap, ok := bybitResp.Result.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("неожиданный формат ответа: %v", bybitResp.Result)
+	if *envelope.RetCode != 0 {
+		apiErr := newAPIError(call.path, resp.StatusCode, *envelope.RetCode, envelope.RetMsg)
+		apiErr.retryAfter = limitResetIn(resp.Header)
+		return nil, apiErr
 	}
+	return envelope.Result, nil
+}
 
-	// Преобразуем map обратно в JSON
-	resultBytes, err = json.Marshal(resultMap)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка маршалинга результата: %w", err)
+// shouldRetry решает, можно ли повторить запрос. Лимит запросов и устаревший timestamp Bybit
+// отклоняет до исполнения, поэтому их можно повторить для любого метода. Сетевой сбой или ошибка
+// сервера оставляют исход неизвестным, и повторяются только чтения.
+func shouldRetry(method string, err error) bool {
+	if IsRetCode(err, RetCodeInvalidTimestamp) {
+		return true
+	}
+	switch ClassifyError(err) {
+	case ErrorClassRateLimited:
+		return true
+	case ErrorClassRetryable:
+		return method == http.MethodGet
+	default:
+		return false
 	}
+}
 
-	var result BybitFeeRateResponse
-	if err := json.Unmarshal(resultBytes, &result); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования результата: %w", err)
+// retryDelay возвращает задержку перед повтором: до сброса лимита, если Bybit его сообщил,
+// иначе удваивающуюся со случайным разбросом
+func retryDelay(attempt int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.retryAfter > 0 {
+		return min(apiErr.retryAfter, retryMaxDelay)
 	}
+	delay := min(retryMinDelay<<(attempt-1), retryMaxDelay)
+	return delay/2 + rand.N(delay/2+1)
+}
 
-	return &result, nil
+// limitResetIn возвращает время до сброса лимита запросов из заголовка ответа Bybit
+func limitResetIn(header http.Header) time.Duration {
+	resetAt, err := strconv.ParseInt(header.Get("X-Bapi-Limit-Reset-Timestamp"), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return max(time.Until(time.UnixMilli(resetAt)), 0)
 }
 
 // generateSignature генерирует подпись для запроса
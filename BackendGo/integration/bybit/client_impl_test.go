@@ -0,0 +1,107 @@
+package bybit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShouldRetry(t *testing.T) {
+	networkErr := &url.Error{Op: "Post", URL: "https://api.bybit.com", Err: errors.New("connection reset")}
+	tests := []struct {
+		name   string
+		method string
+		err    error
+		want   bool
+	}{
+		{"rate limit get", http.MethodGet, newAPIError("/v5/market/tickers", http.StatusOK, RetCodeRateLimit, ""), true},
+		{"rate limit post", http.MethodPost, newAPIError("/v5/order/create", http.StatusOK, RetCodeRateLimit, ""), true},
+		{"http rate limit post", http.MethodPost, newAPIError("/v5/order/create", http.StatusTooManyRequests, 0, ""), true},
+		{"invalid timestamp post", http.MethodPost, newAPIError("/v5/order/create", http.StatusOK, RetCodeInvalidTimestamp, ""), true},
+		{"server error get", http.MethodGet, newAPIError("/v5/market/tickers", http.StatusBadGateway, 0, ""), true},
+		{"network error get", http.MethodGet, networkErr, true},
+		// Повтор создания ордера после таймаута может выставить его дважды
+		{"server error post", http.MethodPost, newAPIError("/v5/order/create", http.StatusBadGateway, 0, ""), false},
+		{"server timeout post", http.MethodPost, newAPIError("/v5/order/create", http.StatusOK, RetCodeServerTimeout, ""), false},
+		{"network error post", http.MethodPost, networkErr, false},
+		{"forbidden get", http.MethodGet, newAPIError("/v5/market/tickers", http.StatusForbidden, 0, ""), false},
+		{"forbidden post", http.MethodPost, newAPIError("/v5/order/create", http.StatusForbidden, 0, ""), false},
+		{"auth", http.MethodGet, newAPIError("/v5/account/wallet-balance", http.StatusOK, RetCodeInvalidAPIKey, ""), false},
+		{"rejected", http.MethodPost, newAPIError("/v5/order/create", http.StatusOK, RetCodeInsufficientBalance, ""), false},
+		{"unknown", http.MethodGet, errors.New("unexpected end of JSON input"), false},
+	}
+	for _, tt := range tests {
+		if got := shouldRetry(tt.method, tt.err); got != tt.want {
+			t.Errorf("%s: shouldRetry() = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCreateOrderNotRetriedOnForbidden(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	c := NewClient(map[string]Endpoints{EnvironmentMainnet: {REST: server.URL}}, EnvironmentMainnet, 5000)
+	account := &BybitAccount{ID: 1, APIKey: "key", APISecret: "secret"}
+	price := "50000"
+	_, err := c.CreateOrder(context.Background(), account, "BTCUSDT", "Buy", "Limit", "0.001", &price, "GTC", nil)
+	if ClassifyError(err) != ErrorClassAuth {
+		t.Fatalf("error = %v, want an auth error", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("order create was sent %d times, want 1", n)
+	}
+}
+
+func TestRetryDelayBackoff(t *testing.T) {
+	err := newAPIError("/v5/market/tickers", http.StatusBadGateway, 0, "")
+	for attempt := 1; attempt <= 8; attempt++ {
+		base := min(retryMinDelay<<(attempt-1), retryMaxDelay)
+		for range 50 {
+			delay := retryDelay(attempt, err)
+			if delay < base/2 || delay > base {
+				t.Fatalf("retryDelay(%d) = %s, want between %s and %s", attempt, delay, base/2, base)
+			}
+		}
+	}
+}
+
+func TestRetryDelayUsesLimitReset(t *testing.T) {
+	err := newAPIError("/v5/order/create", http.StatusOK, RetCodeRateLimit, "")
+	err.retryAfter = 2 * time.Second
+	if got := retryDelay(1, err); got != 2*time.Second {
+		t.Errorf("retryDelay() = %s, want 2s", got)
+	}
+
+	err.retryAfter = time.Minute
+	if got := retryDelay(1, err); got != retryMaxDelay {
+		t.Errorf("retryDelay() = %s, want it capped at %s", got, retryMaxDelay)
+	}
+}
+
+func TestLimitResetIn(t *testing.T) {
+	header := http.Header{}
+	if got := limitResetIn(header); got != 0 {
+		t.Errorf("limitResetIn() without header = %s, want 0", got)
+	}
+
+	header.Set("X-Bapi-Limit-Reset-Timestamp", strconv.FormatInt(time.Now().Add(-time.Second).UnixMilli(), 10))
+	if got := limitResetIn(header); got != 0 {
+		t.Errorf("limitResetIn() for a past reset = %s, want 0", got)
+	}
+
+	header.Set("X-Bapi-Limit-Reset-Timestamp", strconv.FormatInt(time.Now().Add(3*time.Second).UnixMilli(), 10))
+	if got := limitResetIn(header); got <= 2*time.Second || got > 3*time.Second {
+		t.Errorf("limitResetIn() = %s, want about 3s", got)
+	}
+}
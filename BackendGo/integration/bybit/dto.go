@@ -1,6 +1,9 @@
 package bybit
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // BybitResponse представляет базовый ответ от API Bybit
 type BybitResponse struct {
@@ -21,14 +24,24 @@ type BybitAccount struct {
 	ID          int64      `json:"id"`
 	UserID      string     `json:"user_id"`
 	APIKey      string     `json:"api_key"`
-	APISecret   string     `json:"api_secret"`
+	APISecret   string     `json:"-"` // Никогда не сериализуется в ответы и логи
 	AccountType string     `json:"account_type"`
+	Label       string     `json:"label"`
+	Environment string     `json:"environment"` // mainnet, testnet или demo; пусто — окружение по умолчанию
 	IsActive    bool       `json:"is_active"`
 	CreatedAt   *time.Time `json:"-"`
 	UpdatedAt   *time.Time `json:"-"`
 	DeletedAt   *time.Time `json:"-"`
 }
 
+// DisplayName возвращает название аккаунта для сообщений пользователю
+func (a *BybitAccount) DisplayName() string {
+	if a.Label != "" {
+		return a.Label
+	}
+	return fmt.Sprintf("#%d", a.ID)
+}
+
 // BybitWalletBalance представляет баланс кошелька
 type BybitWalletBalance struct {
 	List []BybitAccountBalance `json:"list"`
@@ -222,4 +235,27 @@ type BybitFeeRate struct {
 	Symbol       string `json:"symbol"`
 	TakerFeeRate string `json:"takerFeeRate"`
 	MakerFeeRate string `json:"makerFeeRate"`
-} 
\ No newline at end of file
+} 
+// BybitAPIKeyInfo представляет сведения об API-ключе из /v5/user/query-api.
+// Поле secret в ответе Bybit всегда замаскировано и намеренно не читается.
+type BybitAPIKeyInfo struct {
+	ID          string              `json:"id"`
+	Note        string              `json:"note"`
+	APIKey      string              `json:"apiKey"`
+	ReadOnly    int                 `json:"readOnly"`
+	Permissions map[string][]string `json:"permissions"`
+	IPs         []string            `json:"ips"`
+	ExpiredAt   string              `json:"expiredAt"`
+	Unified     int                 `json:"unified"`
+	UTA         int                 `json:"uta"`
+}
+
+// HasPermission проверяет наличие права в группе, например Spot/SpotTrade или Wallet/Withdraw
+func (i *BybitAPIKeyInfo) HasPermission(group, permission string) bool {
+	for _, p := range i.Permissions[group] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
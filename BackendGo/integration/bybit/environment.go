@@ -0,0 +1,50 @@
+package bybit
+
+import "fmt"
+
+// Окружения Bybit, в которых может работать аккаунт
+const (
+	EnvironmentMainnet = "mainnet"
+	EnvironmentTestnet = "testnet"
+	EnvironmentDemo    = "demo" // Демо-торговля на основной сети с виртуальными средствами
+)
+
+// Endpoints адреса REST API и WebSocket одного окружения
+type Endpoints struct {
+	Environment string
+	REST        string
+	Stream      string // Без пути потока, например wss://stream.bybit.com
+}
+
+// IsValidEnvironment сообщает, поддерживается ли окружение
+func IsValidEnvironment(environment string) bool {
+	switch environment {
+	case EnvironmentMainnet, EnvironmentTestnet, EnvironmentDemo:
+		return true
+	}
+	return false
+}
+
+// Endpoints возвращает адреса окружения, в котором работает аккаунт.
+// Аккаунт без окружения работает в окружении по умолчанию.
+func (c *client) Endpoints(account *BybitAccount) (Endpoints, error) {
+	environment := account.Environment
+	if environment == "" {
+		environment = c.defaultEnvironment
+	}
+	endpoints, ok := c.endpoints[environment]
+	if !ok {
+		return Endpoints{}, fmt.Errorf("окружение Bybit %q не настроено", environment)
+	}
+	endpoints.Environment = environment
+	return endpoints, nil
+}
+
+// accountURL возвращает адрес REST API окружения аккаунта
+func (c *client) accountURL(account *BybitAccount) (string, error) {
+	endpoints, err := c.Endpoints(account)
+	if err != nil {
+		return "", err
+	}
+	return endpoints.REST, nil
+}
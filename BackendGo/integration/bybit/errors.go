@@ -0,0 +1,127 @@
+package bybit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Коды ответа Bybit, на которые вызывающий код реагирует отдельно
+const (
+	RetCodeServerTimeout       = 10000  // Сервер не успел обработать запрос
+	RetCodeInvalidTimestamp    = 10002  // Время запроса вне recv_window
+	RetCodeInvalidAPIKey       = 10003  // Ключ не найден или относится к другому окружению
+	RetCodeInvalidSignature    = 10004  // Неверная подпись
+	RetCodePermissionDenied    = 10005  // У ключа нет прав на операцию
+	RetCodeRateLimit           = 10006  // Превышен лимит запросов ключа
+	RetCodeAuthFailed          = 10007  // Не удалось аутентифицировать пользователя
+	RetCodeIPBanned            = 10009  // IP заблокирован
+	RetCodeUnmatchedIP         = 10010  // IP не входит в белый список ключа
+	RetCodeInternalError       = 10016  // Внутренняя ошибка сервера
+	RetCodeIPRateLimit         = 10018  // Превышен лимит запросов с IP
+	RetCodeAPIKeyExpired       = 33004  // Срок действия ключа истек
+	RetCodeOrderNotExists      = 110001 // Ордер не найден (единый аккаунт)
+	RetCodeSpotTooManyOrders   = 170005 // Слишком много новых ордеров на споте
+	RetCodeSpotBackendTimeout  = 170007 // Торговый сервер спота не ответил вовремя
+	RetCodeInsufficientBalance = 170131 // Недостаточно средств
+	RetCodeSpotOrderNotExists  = 170213 // Ордер не найден (спот)
+)
+
+// ErrorClass определяет, как вызывающему коду реагировать на ошибку API
+type ErrorClass string
+
+const (
+	ErrorClassRetryable   ErrorClass = "retryable"    // Временный сбой сети или сервера, запрос можно повторить
+	ErrorClassRateLimited ErrorClass = "rate_limited" // Лимит запросов: нужно подождать и снизить частоту
+	ErrorClassAuth        ErrorClass = "auth"         // Ключ недействителен, без прав или доступ с IP запрещен: повтор не поможет
+	ErrorClassRejected    ErrorClass = "rejected"     // Бизнес-отказ: баланс, параметры, состояние ордера
+	ErrorClassUnknown     ErrorClass = "unknown"      // Ошибка не от API: декодирование, отмена контекста
+)
+
+// APIError ошибка, которую вернул REST API Bybit: retCode в ответе или HTTP-статус без тела Bybit
+type APIError struct {
+	Endpoint   string
+	HTTPStatus int
+	RetCode    int
+	RetMsg     string
+	Class      ErrorClass
+	retryAfter time.Duration // Время до сброса лимита из заголовка X-Bapi-Limit-Reset-Timestamp
+}
+
+func (e *APIError) Error() string {
+	if e.RetCode == 0 {
+		return fmt.Sprintf("ошибка API %s: HTTP %d %s", e.Endpoint, e.HTTPStatus, e.RetMsg)
+	}
+	return fmt.Sprintf("ошибка API %s: %s (retCode %d)", e.Endpoint, e.RetMsg, e.RetCode)
+}
+
+// newAPIError создает ошибку и определяет ее класс по retCode, а без него — по HTTP-статусу
+func newAPIError(endpoint string, httpStatus, retCode int, retMsg string) *APIError {
+	return &APIError{
+		Endpoint:   endpoint,
+		HTTPStatus: httpStatus,
+		RetCode:    retCode,
+		RetMsg:     retMsg,
+		Class:      classify(httpStatus, retCode),
+	}
+}
+
+// classify относит ответ к классу ошибки. Коды вне известных списков считаются бизнес-отказом.
+func classify(httpStatus, retCode int) ErrorClass {
+	switch retCode {
+	case 0:
+	case RetCodeServerTimeout, RetCodeInvalidTimestamp, RetCodeInternalError, RetCodeSpotBackendTimeout:
+		return ErrorClassRetryable
+	case RetCodeRateLimit, RetCodeIPRateLimit, RetCodeSpotTooManyOrders:
+		return ErrorClassRateLimited
+	case RetCodeInvalidAPIKey, RetCodeInvalidSignature, RetCodePermissionDenied, RetCodeAuthFailed,
+		RetCodeIPBanned, RetCodeUnmatchedIP, RetCodeAPIKeyExpired:
+		return ErrorClassAuth
+	default:
+		return ErrorClassRejected
+	}
+
+	// 403 Bybit отвечает при блокировке IP, региона или белого списка ключа. Как и коды блокировки IP,
+	// это отказ в доступе, а не лимит: повтор не поможет, а POST мог быть уже принят.
+	switch {
+	case httpStatus == http.StatusTooManyRequests:
+		return ErrorClassRateLimited
+	case httpStatus == http.StatusUnauthorized || httpStatus == http.StatusForbidden:
+		return ErrorClassAuth
+	case httpStatus >= http.StatusInternalServerError:
+		return ErrorClassRetryable
+	default:
+		return ErrorClassRejected
+	}
+}
+
+// ClassifyError возвращает класс ошибки клиента. Сетевые ошибки считаются временными.
+func ClassifyError(err error) ErrorClass {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Class
+	}
+	// Таймаут HTTP-клиента временный, а отмена вызывающим — нет
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && !errors.Is(err, context.Canceled) {
+		return ErrorClassRetryable
+	}
+	return ErrorClassUnknown
+}
+
+// IsRetCode сообщает, что ошибка — ответ API с одним из указанных retCode
+func IsRetCode(err error, codes ...int) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	for _, code := range codes {
+		if apiErr.RetCode == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package bybit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		httpStatus int
+		retCode    int
+		want       ErrorClass
+	}{
+		{http.StatusOK, RetCodeServerTimeout, ErrorClassRetryable},
+		{http.StatusOK, RetCodeInvalidTimestamp, ErrorClassRetryable},
+		{http.StatusOK, RetCodeInternalError, ErrorClassRetryable},
+		{http.StatusOK, RetCodeSpotBackendTimeout, ErrorClassRetryable},
+		{http.StatusOK, RetCodeRateLimit, ErrorClassRateLimited},
+		{http.StatusOK, RetCodeIPRateLimit, ErrorClassRateLimited},
+		{http.StatusOK, RetCodeSpotTooManyOrders, ErrorClassRateLimited},
+		{http.StatusOK, RetCodeInvalidAPIKey, ErrorClassAuth},
+		{http.StatusOK, RetCodeInvalidSignature, ErrorClassAuth},
+		{http.StatusOK, RetCodePermissionDenied, ErrorClassAuth},
+		{http.StatusOK, RetCodeAuthFailed, ErrorClassAuth},
+		{http.StatusOK, RetCodeIPBanned, ErrorClassAuth},
+		{http.StatusOK, RetCodeUnmatchedIP, ErrorClassAuth},
+		{http.StatusOK, RetCodeAPIKeyExpired, ErrorClassAuth},
+		{http.StatusOK, RetCodeInsufficientBalance, ErrorClassRejected},
+		{http.StatusOK, RetCodeOrderNotExists, ErrorClassRejected},
+		{http.StatusOK, 999999, ErrorClassRejected},
+		// retCode важнее HTTP-статуса
+		{http.StatusBadGateway, RetCodeInvalidAPIKey, ErrorClassAuth},
+		{http.StatusTooManyRequests, 0, ErrorClassRateLimited},
+		{http.StatusForbidden, 0, ErrorClassAuth},
+		{http.StatusUnauthorized, 0, ErrorClassAuth},
+		{http.StatusInternalServerError, 0, ErrorClassRetryable},
+		{http.StatusServiceUnavailable, 0, ErrorClassRetryable},
+		{http.StatusBadRequest, 0, ErrorClassRejected},
+		{http.StatusNotFound, 0, ErrorClassRejected},
+	}
+	for _, tt := range tests {
+		if got := classify(tt.httpStatus, tt.retCode); got != tt.want {
+			t.Errorf("classify(%d, %d) = %s, want %s", tt.httpStatus, tt.retCode, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	apiErr := newAPIError("/v5/order/create", http.StatusOK, RetCodeInvalidSignature, "error sign")
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{"api error", apiErr, ErrorClassAuth},
+		{"wrapped api error", fmt.Errorf("failed to create order: %w", apiErr), ErrorClassAuth},
+		{"network error", &url.Error{Op: "Get", URL: "https://api.bybit.com", Err: errors.New("connection reset")}, ErrorClassRetryable},
+		{"timeout", &url.Error{Op: "Get", URL: "https://api.bybit.com", Err: context.DeadlineExceeded}, ErrorClassRetryable},
+		{"canceled", &url.Error{Op: "Get", URL: "https://api.bybit.com", Err: context.Canceled}, ErrorClassUnknown},
+		{"decode error", errors.New("unexpected end of JSON input"), ErrorClassUnknown},
+	}
+	for _, tt := range tests {
+		if got := ClassifyError(tt.err); got != tt.want {
+			t.Errorf("%s: ClassifyError() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsRetCode(t *testing.T) {
+	err := fmt.Errorf("cancel: %w", newAPIError("/v5/order/cancel", http.StatusOK, RetCodeOrderNotExists, "order not exists"))
+	if !IsRetCode(err, RetCodeSpotOrderNotExists, RetCodeOrderNotExists) {
+		t.Error("IsRetCode() = false for a matching code")
+	}
+	if IsRetCode(err, RetCodeInsufficientBalance) {
+		t.Error("IsRetCode() = true for another code")
+	}
+	if IsRetCode(errors.New("plain"), RetCodeOrderNotExists) {
+		t.Error("IsRetCode() = true for a non-API error")
+	}
+}
@@ -0,0 +1,71 @@
+package bybit
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// credentialNames имена переменных и полей с ключами, подписями и паролями
+var credentialNames = map[string]bool{
+	"authMsg":   true,
+	"apiKey":    true,
+	"apiSecret": true,
+	"APIKey":    true,
+	"APISecret": true,
+	"secret":    true,
+	"Secret":    true,
+	"signature": true,
+	"password":  true,
+	"Password":  true,
+}
+
+// TestCredentialsAreNotLogged проверяет во всем модуле, что учетные данные не передаются в logger
+func TestCredentialsAreNotLogged(t *testing.T) {
+	root := filepath.Join("..", "..")
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || !isLoggerCall(call) {
+				return true
+			}
+			for _, arg := range call.Args {
+				ast.Inspect(arg, func(n ast.Node) bool {
+					if ident, ok := n.(*ast.Ident); ok && credentialNames[ident.Name] {
+						t.Errorf("%s: %s is passed to the logger", fset.Position(ident.Pos()), ident.Name)
+					}
+					return true
+				})
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func isLoggerCall(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "logger"
+}
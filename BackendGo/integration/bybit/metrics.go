@@ -0,0 +1,50 @@
+package bybit
+
+import (
+	"CryptoLens_Backend/metrics"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// metricsTransport записывает задержку запросов к REST API и коды ответов Bybit.
+// retCode извлекается из тела ответа здесь, чтобы не дублировать учет в каждом методе клиента.
+type metricsTransport struct {
+	next http.RoundTripper
+}
+
+func newMetricsTransport(next http.RoundTripper) http.RoundTripper {
+	return &metricsTransport{next: next}
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := req.URL.Path
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	metrics.ObserveSince(metrics.BybitRequestDuration.WithLabelValues(req.Method, endpoint), start)
+	if err != nil {
+		metrics.BybitResponses.WithLabelValues(endpoint, "error", "").Inc()
+		return nil, err
+	}
+
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if readErr != nil {
+		metrics.BybitResponses.WithLabelValues(endpoint, strconv.Itoa(resp.StatusCode), "").Inc()
+		return resp, nil
+	}
+
+	var envelope struct {
+		RetCode *int `json:"retCode"`
+	}
+	retCode := ""
+	if json.Unmarshal(body, &envelope) == nil && envelope.RetCode != nil {
+		retCode = strconv.Itoa(*envelope.RetCode)
+	}
+	metrics.BybitResponses.WithLabelValues(endpoint, strconv.Itoa(resp.StatusCode), retCode).Inc()
+	return resp, nil
+}
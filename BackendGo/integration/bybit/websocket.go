@@ -1,7 +1,8 @@
 package bybit
 
 import (
-	"CryptoLens_Backend/logger"
+	"CryptoLens_Backend/metrics"
+	"CryptoLens_Shared/logger"
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
@@ -9,19 +10,54 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/gorilla/websocket"
+	"math/rand/v2"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// WebSocketClient представляет WebSocket-клиент для Bybit
+const (
+	wsPingInterval        = 20 * time.Second
+	wsReadTimeout         = 2*wsPingInterval + 5*time.Second // без входящих сообщений, включая pong, соединение считается потерянным
+	wsReconnectMinDelay   = time.Second
+	wsReconnectMaxDelay   = time.Minute
+	wsReconnectMaxDoubles = 6  // после стольких неудач задержка перестает удваиваться
+	wsMaxArgsPerRequest   = 10 // Bybit принимает для спота не больше 10 args в одном запросе подписки
+)
+
+// Состояния соединения в событиях ConnectionEvent
+const (
+	ConnectionDisconnected = "disconnected" // соединение потеряно, клиент переподключается
+	ConnectionRestored     = "restored"     // соединение восстановлено, подписки отправлены заново
+)
+
+// ConnectionEvent событие жизненного цикла WebSocket-соединения. Пока соединения нет, данные
+// не поступают: книги ордеров и состояние ордеров нужно сверить после восстановления.
+type ConnectionEvent struct {
+	State    string
+	Private  bool          // Приватное соединение аккаунта
+	Err      error         // Причина разрыва
+	Attempts int           // Число попыток до восстановления
+	Downtime time.Duration // Сколько соединение отсутствовало
+}
+
+// WebSocketClient представляет WebSocket-клиент для Bybit. Клиент запоминает подписки
+// и после обрыва сам переподключается, проходит аутентификацию и подписывается заново.
 type WebSocketClient struct {
-	url        string
-	conn       *websocket.Conn
-	recvWindow int
-	apiKey     string // Для приватных каналов
-	apiSecret  string // Для приватных каналов
-	mutex      sync.Mutex
+	url           string
+	conn          *websocket.Conn
+	recvWindow    int
+	apiKey        string // Для приватных каналов
+	apiSecret     string // Для приватных каналов
+	subscriptions map[string]bool
+	closed        bool // Close вызван, клиент больше не переподключается
+	mutex         sync.Mutex
+	dialMutex     sync.Mutex // Не дает двум Connect подключаться одновременно; c.mutex на время подключения не удерживается
+
+	onEvent       func(event ConnectionEvent) // Вызывается при обрыве и восстановлении соединения
+	lastMessageAt atomic.Int64                // Время последнего входящего сообщения в наносекундах Unix
 }
 
 // WebSocketMessage представляет базовое сообщение WebSocket
@@ -116,19 +152,68 @@ type WalletMessage struct {
 // NewWebSocketClient создает новый WebSocket-клиент
 func NewWebSocketClient(url string, recvWindow int, apiKey, apiSecret string) *WebSocketClient {
 	return &WebSocketClient{
-		url:        url,
-		recvWindow: recvWindow,
-		apiKey:     apiKey,
-		apiSecret:  apiSecret,
+		url:           url,
+		recvWindow:    recvWindow,
+		apiKey:        apiKey,
+		apiSecret:     apiSecret,
+		subscriptions: make(map[string]bool),
 	}
 }
 
-// Connect устанавливает соединение с WebSocket
-func (c *WebSocketClient) Connect(ctx context.Context) error {
+// SetEventHandler задает обработчик событий обрыва и восстановления соединения.
+// Обработчик вызывается из горутины чтения и не должен надолго ее блокировать.
+func (c *WebSocketClient) SetEventHandler(handler func(event ConnectionEvent)) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
+	c.onEvent = handler
+}
 
-	if c.conn != nil {
+// emit передает событие соединения обработчику
+func (c *WebSocketClient) emit(event ConnectionEvent) {
+	c.mutex.Lock()
+	onEvent := c.onEvent
+	c.mutex.Unlock()
+	event.Private = c.apiKey != ""
+	channel := "public"
+	if event.Private {
+		channel = "private"
+	}
+	metrics.WSConnectionEvents.WithLabelValues(channel, event.State).Inc()
+	if onEvent != nil {
+		onEvent(event)
+	}
+}
+
+// IsConnected сообщает, установлено ли соединение
+func (c *WebSocketClient) IsConnected() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.conn != nil
+}
+
+// LastMessageAt возвращает время последнего входящего сообщения, включая pong.
+// Нулевое время означает, что сообщений еще не было.
+func (c *WebSocketClient) LastMessageAt() time.Time {
+	ts := c.lastMessageAt.Load()
+	if ts == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ts)
+}
+
+// Connect устанавливает соединение с WebSocket. Подключение и аутентификация идут без c.mutex,
+// чтобы медленный сервер не блокировал Subscribe, IsConnected и Close.
+func (c *WebSocketClient) Connect(ctx context.Context) error {
+	c.dialMutex.Lock()
+	defer c.dialMutex.Unlock()
+
+	c.mutex.Lock()
+	closed, connected := c.closed, c.conn != nil
+	c.mutex.Unlock()
+	if closed {
+		return fmt.Errorf("WebSocket client closed")
+	}
+	if connected {
 		return nil // Уже подключены
 	}
 
@@ -137,29 +222,40 @@ func (c *WebSocketClient) Connect(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to connect to WebSocket: %w", err)
 	}
-	c.conn = conn
 
 	logger.LogInfo("Успешно подключились к WebSocket: %s", c.url)
 
+	// Соединение без входящих сообщений считается потерянным: ответы на ping продлевают срок
+	conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+	conn.SetPongHandler(func(string) error {
+		c.lastMessageAt.Store(time.Now().UnixNano())
+		return conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+	})
+
 	// Аутентификация для приватных каналов
 	if c.apiKey != "" && c.apiSecret != "" {
-		if err := c.authenticate(ctx); err != nil {
-			c.conn.Close()
-			c.conn = nil
+		if err := c.authenticate(conn); err != nil {
+			conn.Close()
 			return fmt.Errorf("failed to authenticate: %w", err)
 		}
 	}
 
-	go c.startPing(ctx)
+	c.mutex.Lock()
+	if c.closed {
+		c.mutex.Unlock()
+		conn.Close()
+		return fmt.Errorf("WebSocket client closed")
+	}
+	c.conn = conn
+	c.mutex.Unlock()
+
+	go c.startPing(ctx, conn)
 	return nil
 }
 
-// authenticate отправляет запрос на аутентификацию для приватных каналов
-func (c *WebSocketClient) authenticate(ctx context.Context) error {
-	if c.conn == nil {
-		return fmt.Errorf("WebSocket not connected")
-	}
-
+// authenticate отправляет запрос на аутентификацию для приватных каналов. Соединение conn
+// еще не опубликовано в c.conn, поэтому другие горутины в него не пишут.
+func (c *WebSocketClient) authenticate(conn *websocket.Conn) error {
 	expires := time.Now().UnixMilli() + int64(c.recvWindow)
 	authMsg := map[string]interface{}{
 		"op": "auth",
@@ -170,13 +266,12 @@ func (c *WebSocketClient) authenticate(ctx context.Context) error {
 		},
 	}
 
-	logger.LogInfo("Sending auth message: %v", authMsg)
-	if err := c.conn.WriteJSON(authMsg); err != nil {
+	if err := conn.WriteJSON(authMsg); err != nil {
 		return fmt.Errorf("failed to send auth message: %w", err)
 	}
 
 	// Ожидаем подтверждения аутентификации
-	_, msg, err := c.conn.ReadMessage()
+	_, msg, err := conn.ReadMessage()
 	if err != nil {
 		return fmt.Errorf("failed to read auth response: %w", err)
 	}
@@ -205,65 +300,138 @@ func (c *WebSocketClient) generateSignature(expires int64) string {
 	return hex.EncodeToString(h.Sum(nil))
 }
 
-// Subscribe подписывается на указанные каналы
+// Subscribe подписывается на указанные каналы и запоминает их для восстановления после переподключения.
+// Без соединения каналы только запоминаются и будут отправлены при переподключении.
 func (c *WebSocketClient) Subscribe(ctx context.Context, channels []string) error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
+	var added []string
+	for _, channel := range channels {
+		if !c.subscriptions[channel] {
+			c.subscriptions[channel] = true
+			added = append(added, channel)
+		}
+	}
+	if len(added) == 0 || c.conn == nil {
+		return nil
+	}
+	return c.send("subscribe", added)
+}
+
+// Unsubscribe отписывается от указанных каналов
+func (c *WebSocketClient) Unsubscribe(ctx context.Context, channels []string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var removed []string
+	for _, channel := range channels {
+		if c.subscriptions[channel] {
+			delete(c.subscriptions, channel)
+			removed = append(removed, channel)
+		}
+	}
+	if len(removed) == 0 || c.conn == nil {
+		return nil
+	}
+	return c.send("unsubscribe", removed)
+}
+
+// send отправляет операцию над каналами частями по wsMaxArgsPerRequest. Вызывающий должен удерживать c.mutex.
+func (c *WebSocketClient) send(op string, channels []string) error {
+	for start := 0; start < len(channels); start += wsMaxArgsPerRequest {
+		end := min(start+wsMaxArgsPerRequest, len(channels))
+		msg := map[string]interface{}{
+			"op":   op,
+			"args": channels[start:end],
+		}
+		if err := c.conn.WriteJSON(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscriptions возвращает каналы, на которые подписан клиент
+func (c *WebSocketClient) Subscriptions() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	channels := make([]string, 0, len(c.subscriptions))
+	for channel := range c.subscriptions {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+// restoreSubscriptions повторяет подписку на запомненные каналы после переподключения
+func (c *WebSocketClient) restoreSubscriptions() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
 	if c.conn == nil {
 		return fmt.Errorf("WebSocket not connected")
 	}
-
-	subscribeMsg := map[string]interface{}{
-		"op":   "subscribe",
-		"args": channels,
+	if len(c.subscriptions) == 0 {
+		return nil
 	}
-	return c.conn.WriteJSON(subscribeMsg)
+	channels := make([]string, 0, len(c.subscriptions))
+	for channel := range c.subscriptions {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	return c.send("subscribe", channels)
 }
 
-// StartMessageHandler запускает обработку входящих сообщений
+// StartMessageHandler запускает обработку входящих сообщений. При обрыве соединения клиент
+// переподключается с растущей задержкой и сообщает об этом через обработчик событий.
 func (c *WebSocketClient) StartMessageHandler(ctx context.Context, handler func(context.Context, WebSocketMessage)) {
 	messageChan := make(chan WebSocketMessage)
 	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				logger.LogInfo("WebSocket context canceled, attempting reconnect...")
-				c.Close()
+		defer close(messageChan)
+		defer c.Close()
+		for ctx.Err() == nil {
+			c.mutex.Lock()
+			conn, closed := c.conn, c.closed
+			c.mutex.Unlock()
+			if closed {
 				return
-			default:
-				if c.conn == nil {
-					logger.LogError("WebSocket connection is nil, attempting to reconnect")
-					if err := c.Connect(ctx); err != nil {
-						logger.LogError("Reconnect failed: %v", err)
-						time.Sleep(5 * time.Second)
-						continue
-					}
-				}
-
-				logger.LogDebug("Waiting for WebSocket message...")
-				_, msg, err := c.conn.ReadMessage()
-				if err != nil {
-					logger.LogError("Failed to read WebSocket message: %v", err)
-					c.Close()
-					time.Sleep(5 * time.Second)
-					continue
+			}
+			if conn == nil {
+				if !c.reconnect(ctx) {
+					return
 				}
+				continue
+			}
 
-				logger.LogDebug("Received raw WebSocket message: %s", string(msg))
-				var message WebSocketMessage
-				if err := json.Unmarshal(msg, &message); err != nil {
-					logger.LogError("Failed to parse WebSocket message: %v", err)
-					continue
+			logger.LogDebug("Waiting for WebSocket message...")
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				if ctx.Err() != nil || c.isClosed() {
+					return
 				}
+				logger.LogError("Failed to read WebSocket message: %v", err)
+				c.dropConn(conn)
+				c.emit(ConnectionEvent{State: ConnectionDisconnected, Err: err})
+				continue
+			}
 
-				if message.Topic == "" && strings.Contains(string(msg), "pong") {
-					logger.LogDebug("Received pong message")
-					continue
-				}
+			// Любое сообщение, в том числе ответ на ping, подтверждает, что соединение живо
+			conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+			c.lastMessageAt.Store(time.Now().UnixNano())
+			logger.LogDebug("Received raw WebSocket message: %s", string(msg))
+			var message WebSocketMessage
+			if err := json.Unmarshal(msg, &message); err != nil {
+				logger.LogError("Failed to parse WebSocket message: %v", err)
+				continue
+			}
 
-				messageChan <- message
+			if message.Topic == "" && strings.Contains(string(msg), "pong") {
+				logger.LogDebug("Received pong message")
+				continue
 			}
+
+			messageChan <- message
 		}
 	}()
 
@@ -274,9 +442,54 @@ func (c *WebSocketClient) StartMessageHandler(ctx context.Context, handler func(
 	}()
 }
 
-// startPing отправляет пинг каждые 20 секунд
-func (c *WebSocketClient) startPing(ctx context.Context) {
-	ticker := time.NewTicker(20 * time.Second)
+// reconnect восстанавливает соединение и подписки; false — клиент закрыт или контекст отменен
+func (c *WebSocketClient) reconnect(ctx context.Context) bool {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		delay := reconnectDelay(attempt)
+		logger.LogWarn("WebSocket %s: попытка переподключения %d через %s", c.url, attempt, delay.Round(time.Millisecond))
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+		}
+		if c.isClosed() {
+			return false
+		}
+
+		err := c.Connect(ctx)
+		if err == nil {
+			if err = c.restoreSubscriptions(); err != nil {
+				c.mutex.Lock()
+				conn := c.conn
+				c.mutex.Unlock()
+				c.dropConn(conn)
+			}
+		}
+		if err != nil {
+			logger.LogError("Reconnect failed: %v", err)
+			continue
+		}
+
+		logger.LogInfo("WebSocket %s: соединение восстановлено после %d попыток", c.url, attempt)
+		c.emit(ConnectionEvent{State: ConnectionRestored, Attempts: attempt, Downtime: time.Since(start)})
+		return true
+	}
+}
+
+// reconnectDelay возвращает задержку перед попыткой переподключения: она удваивается до предела,
+// а случайный разброс не дает соединениям всех аккаунтов переподключаться одновременно
+func reconnectDelay(attempt int) time.Duration {
+	delay := wsReconnectMinDelay << min(attempt-1, wsReconnectMaxDoubles)
+	if delay > wsReconnectMaxDelay {
+		delay = wsReconnectMaxDelay
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// startPing отправляет пинг, пока соединение conn остается текущим
+func (c *WebSocketClient) startPing(ctx context.Context, conn *websocket.Conn) {
+	ticker := time.NewTicker(wsPingInterval)
 	defer ticker.Stop()
 
 	for {
@@ -285,23 +498,46 @@ func (c *WebSocketClient) startPing(ctx context.Context) {
 			return
 		case <-ticker.C:
 			c.mutex.Lock()
-			if c.conn != nil {
-				err := c.conn.WriteJSON(map[string]string{"op": "ping"})
-				if err != nil {
-					logger.LogError("Failed to send ping: %v", err)
-					c.Close() // Закрываем соединение при ошибке пинга
-				}
+			if c.conn != conn {
+				c.mutex.Unlock()
+				return
 			}
+			err := conn.WriteJSON(map[string]string{"op": "ping"})
 			c.mutex.Unlock()
+			if err != nil {
+				logger.LogError("Failed to send ping: %v", err)
+				// Чтение получит ошибку закрытого соединения и начнет переподключение
+				c.dropConn(conn)
+				return
+			}
 		}
 	}
 }
 
-// Close закрывает соединение
+// dropConn закрывает оборванное соединение, если оно все еще текущее
+func (c *WebSocketClient) dropConn(conn *websocket.Conn) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if conn != nil && c.conn == conn {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
+
+// isClosed сообщает, что клиент закрыт вызовом Close
+func (c *WebSocketClient) isClosed() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.closed
+}
+
+// Close закрывает соединение; после этого клиент не переподключается
 func (c *WebSocketClient) Close() {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
+	c.closed = true
 	if c.conn != nil {
 		c.conn.Close()
 		c.conn = nil
@@ -0,0 +1,383 @@
+package bybit
+
+import (
+	"CryptoLens_Shared/logger"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WebSocketPool распределяет публичные каналы по нескольким соединениям, чтобы не упираться
+// в ограничения Bybit на число аргументов подписки и поток данных одного соединения
+type WebSocketPool struct {
+	url           string
+	recvWindow    int
+	topicsPerConn int
+
+	mutex   sync.Mutex
+	ctx     context.Context
+	handler func(context.Context, WebSocketMessage)
+	onEvent func(event ConnectionEvent)
+	conns   []*poolConn
+	topics  map[string]*poolConn // Соединение, которое держит канал
+	down    map[*poolConn]bool   // Соединения, оборванные или еще не подключенные
+
+	events     []ConnectionEvent // События пула, которые unlock передаст обработчику
+	delivering bool              // Какая-то горутина уже передает события обработчику
+}
+
+// poolConn соединение пула и закрепленные за ним каналы
+type poolConn struct {
+	client *WebSocketClient
+	topics map[string]bool
+}
+
+// PoolConnectionStatus состояние одного соединения пула
+type PoolConnectionStatus struct {
+	Connected     bool
+	Channels      []string
+	LastMessageAt time.Time
+}
+
+// NewWebSocketPool создает пул публичных соединений; topicsPerConn — предел каналов на соединение
+func NewWebSocketPool(url string, recvWindow int, topicsPerConn int) *WebSocketPool {
+	return &WebSocketPool{
+		url:           url,
+		recvWindow:    recvWindow,
+		topicsPerConn: topicsPerConn,
+		topics:        make(map[string]*poolConn),
+		down:          make(map[*poolConn]bool),
+	}
+}
+
+// SetEventHandler задает обработчик событий соединения. Пул сообщает об обрыве, когда падает первое
+// из соединений, и о восстановлении, когда подключены все. Обработчик вызывается без блокировки пула.
+func (p *WebSocketPool) SetEventHandler(handler func(event ConnectionEvent)) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.onEvent = handler
+}
+
+// Start задает контекст и обработчик сообщений; соединения открываются по мере подписки
+func (p *WebSocketPool) Start(ctx context.Context, handler func(context.Context, WebSocketMessage)) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.ctx = ctx
+	p.handler = handler
+}
+
+// Subscribe закрепляет новые каналы за наименее загруженными соединениями и подписывается на них.
+// Если все соединения заполнены, открывается новое. Каналы закрепляются под блокировкой, а запись
+// в сокеты и подключение идут уже без нее, чтобы медленный Bybit не останавливал остальной пул.
+func (p *WebSocketPool) Subscribe(ctx context.Context, channels []string) error {
+	p.mutex.Lock()
+	if p.ctx == nil {
+		p.mutex.Unlock()
+		return fmt.Errorf("WebSocket pool not started")
+	}
+	poolCtx, handler := p.ctx, p.handler
+	added := make(map[*poolConn][]string)
+	var opened []*poolConn
+	for _, channel := range channels {
+		if p.topics[channel] != nil {
+			continue
+		}
+		pc := p.leastLoaded()
+		if pc == nil {
+			pc = p.newConn()
+			opened = append(opened, pc)
+		}
+		pc.topics[channel] = true
+		p.topics[channel] = pc
+		added[pc] = append(added[pc], channel)
+	}
+	p.unlock()
+
+	// Новые соединения только запоминают каналы и отправят их после подключения
+	err := subscribeAll(ctx, added)
+	for _, pc := range opened {
+		p.connect(poolCtx, pc, handler)
+	}
+	return err
+}
+
+// Unsubscribe отписывается от каналов и закрывает соединения, без которых оставшиеся каналы помещаются.
+// Пул перестает учитывать каналы сразу, а отписка идет без блокировки по всем соединениям: ошибка
+// одного соединения не оставляет остальные с каналами, о которых пул уже забыл.
+func (p *WebSocketPool) Unsubscribe(ctx context.Context, channels []string) error {
+	p.mutex.Lock()
+	removed := make(map[*poolConn][]string)
+	for _, channel := range channels {
+		pc := p.topics[channel]
+		if pc == nil {
+			continue
+		}
+		delete(pc.topics, channel)
+		delete(p.topics, channel)
+		removed[pc] = append(removed[pc], channel)
+	}
+	closed, added := p.rebalance()
+	p.unlock()
+
+	var errs []error
+	for _, pc := range closed {
+		// Закрытое соединение отписывать не нужно
+		delete(removed, pc)
+		pc.client.Close()
+	}
+	for pc, list := range removed {
+		if err := pc.client.Unsubscribe(ctx, list); err != nil {
+			errs = append(errs, fmt.Errorf("failed to unsubscribe from %v: %w", list, err))
+		}
+	}
+	// Каналы закрытых соединений переподписываются после закрытия: новая подписка пришлет
+	// снимок книги ордеров, и дельты старого соединения не перемешаются с ним
+	if err := subscribeAll(ctx, added); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// rebalance переносит каналы наименее загруженных соединений на остальные, пока соединений больше,
+// чем нужно для всех каналов. Меняет только учет пула: возвращает убранные из пула соединения,
+// которые вызывающий закроет, и перенесенные каналы, на которые он подпишется без блокировки.
+// Вызывающий должен удерживать p.mutex.
+func (p *WebSocketPool) rebalance() (closed []*poolConn, added map[*poolConn][]string) {
+	added = make(map[*poolConn][]string)
+	for len(p.conns) > (len(p.topics)+p.topicsPerConn-1)/p.topicsPerConn {
+		var src *poolConn
+		for _, pc := range p.conns {
+			if src == nil || len(pc.topics) < len(src.topics) {
+				src = pc
+			}
+		}
+		moved := make([]string, 0, len(src.topics))
+		for channel := range src.topics {
+			moved = append(moved, channel)
+		}
+		sort.Strings(moved)
+		p.removeConn(src)
+		closed = append(closed, src)
+		delete(added, src)
+
+		for _, channel := range moved {
+			pc := p.leastLoaded()
+			pc.topics[channel] = true
+			p.topics[channel] = pc
+			added[pc] = append(added[pc], channel)
+		}
+		if len(moved) > 0 {
+			logger.LogInfo("WebSocket %s: %d каналов перенесены, соединений: %d", p.url, len(moved), len(p.conns))
+		}
+	}
+	return closed, added
+}
+
+// subscribeAll подписывает соединения на закрепленные за ними каналы. Вызывается без p.mutex:
+// соединение, которое тем временем закрыли, только запомнит каналы. Ошибки соединений собираются вместе.
+func subscribeAll(ctx context.Context, added map[*poolConn][]string) error {
+	var errs []error
+	for pc, list := range added {
+		if err := pc.client.Subscribe(ctx, list); err != nil {
+			errs = append(errs, fmt.Errorf("failed to subscribe to %v: %w", list, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// leastLoaded возвращает соединение с наименьшим числом каналов, в котором есть место, или nil.
+// Вызывающий должен удерживать p.mutex.
+func (p *WebSocketPool) leastLoaded() *poolConn {
+	var best *poolConn
+	for _, pc := range p.conns {
+		if len(pc.topics) >= p.topicsPerConn {
+			continue
+		}
+		if best == nil || len(pc.topics) < len(best.topics) {
+			best = pc
+		}
+	}
+	return best
+}
+
+// newConn добавляет в пул соединение, которое подключит connect. Вызывающий должен удерживать p.mutex.
+func (p *WebSocketPool) newConn() *poolConn {
+	pc := &poolConn{
+		client: NewWebSocketClient(p.url, p.recvWindow, "", ""),
+		topics: make(map[string]bool),
+	}
+	p.conns = append(p.conns, pc)
+	pc.client.SetEventHandler(func(event ConnectionEvent) {
+		p.handleEvent(pc, event)
+	})
+	logger.LogInfo("WebSocket %s: открыто соединение пула, всего: %d", p.url, len(p.conns))
+	return pc
+}
+
+// connect подключает соединение пула и отправляет закрепленные за ним каналы. Вызывается без p.mutex.
+// Если подключиться сразу не удалось, клиент продолжает попытки сам и подпишется после подключения.
+func (p *WebSocketPool) connect(ctx context.Context, pc *poolConn, handler func(context.Context, WebSocketMessage)) {
+	err := pc.client.Connect(ctx)
+	if err == nil {
+		if err = pc.client.restoreSubscriptions(); err != nil {
+			pc.client.mutex.Lock()
+			conn := pc.client.conn
+			pc.client.mutex.Unlock()
+			pc.client.dropConn(conn)
+		}
+	}
+	if err != nil {
+		logger.LogError("Failed to open pooled WebSocket connection: %v", err)
+		// Соединение могли закрыть, пока оно подключалось: handleEvent проверит, что оно еще в пуле
+		p.handleEvent(pc, ConnectionEvent{State: ConnectionDisconnected, Err: err})
+	}
+	pc.client.StartMessageHandler(ctx, handler)
+}
+
+// removeConn убирает соединение из пула и забывает его каналы; закрывает его вызывающий.
+// Вызывающий должен удерживать p.mutex.
+func (p *WebSocketPool) removeConn(pc *poolConn) {
+	for channel := range pc.topics {
+		delete(p.topics, channel)
+	}
+	for i, conn := range p.conns {
+		if conn == pc {
+			p.conns = append(p.conns[:i], p.conns[i+1:]...)
+			break
+		}
+	}
+	// Закрытое соединение больше не держит пул в состоянии обрыва
+	if p.down[pc] {
+		delete(p.down, pc)
+		if len(p.down) == 0 {
+			p.dispatch(ConnectionEvent{State: ConnectionRestored})
+		}
+	}
+}
+
+// handleEvent сводит события отдельных соединений в состояние всего пула
+func (p *WebSocketPool) handleEvent(pc *poolConn, event ConnectionEvent) {
+	p.mutex.Lock()
+	defer p.unlock()
+
+	if !p.owns(pc) {
+		return
+	}
+	switch event.State {
+	case ConnectionDisconnected:
+		p.markDown(pc, event)
+	case ConnectionRestored:
+		if !p.down[pc] {
+			return
+		}
+		delete(p.down, pc)
+		if len(p.down) == 0 {
+			p.dispatch(event)
+		}
+	}
+}
+
+// markDown отмечает соединение оборванным; о первом обрыве сообщается обработчику.
+// Вызывающий должен удерживать p.mutex.
+func (p *WebSocketPool) markDown(pc *poolConn, event ConnectionEvent) {
+	if p.down[pc] {
+		return
+	}
+	p.down[pc] = true
+	if len(p.down) == 1 {
+		p.dispatch(event)
+	}
+}
+
+// dispatch ставит событие в очередь обработчика пула. Вызывающий должен удерживать p.mutex
+// и отпустить его через unlock, который и передаст событие.
+func (p *WebSocketPool) dispatch(event ConnectionEvent) {
+	p.events = append(p.events, event)
+}
+
+// unlock отпускает p.mutex и передает обработчику накопленные события. События передает одна горутина
+// за раз в порядке появления, а обработчик вызывается без блокировки и может обращаться к пулу:
+// события, которые появятся за это время, передаст та же горутина.
+func (p *WebSocketPool) unlock() {
+	if p.delivering {
+		p.mutex.Unlock()
+		return
+	}
+	p.delivering = true
+	for len(p.events) > 0 {
+		events, onEvent := p.events, p.onEvent
+		p.events = nil
+		p.mutex.Unlock()
+		if onEvent != nil {
+			for _, event := range events {
+				onEvent(event)
+			}
+		}
+		p.mutex.Lock()
+	}
+	p.delivering = false
+	p.mutex.Unlock()
+}
+
+// owns сообщает, что соединение все еще входит в пул. Вызывающий должен удерживать p.mutex.
+func (p *WebSocketPool) owns(pc *poolConn) bool {
+	for _, conn := range p.conns {
+		if conn == pc {
+			return true
+		}
+	}
+	return false
+}
+
+// IsConnected сообщает, что у пула есть соединения и все они подключены
+func (p *WebSocketPool) IsConnected() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if len(p.conns) == 0 {
+		return false
+	}
+	for _, pc := range p.conns {
+		if !pc.client.IsConnected() {
+			return false
+		}
+	}
+	return true
+}
+
+// Connections возвращает состояние соединений пула и закрепленные за ними каналы
+func (p *WebSocketPool) Connections() []PoolConnectionStatus {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	statuses := make([]PoolConnectionStatus, 0, len(p.conns))
+	for _, pc := range p.conns {
+		status := PoolConnectionStatus{
+			Connected:     pc.client.IsConnected(),
+			Channels:      make([]string, 0, len(pc.topics)),
+			LastMessageAt: pc.client.LastMessageAt(),
+		}
+		for channel := range pc.topics {
+			status.Channels = append(status.Channels, channel)
+		}
+		sort.Strings(status.Channels)
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Close закрывает все соединения пула
+func (p *WebSocketPool) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, pc := range p.conns {
+		pc.client.Close()
+	}
+	p.conns = nil
+	p.topics = make(map[string]*poolConn)
+	p.down = make(map[*poolConn]bool)
+}
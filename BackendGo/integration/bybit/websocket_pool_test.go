@@ -0,0 +1,141 @@
+package bybit
+
+import (
+	"errors"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPoolEventsDeliveredWithoutLock(t *testing.T) {
+	p := NewWebSocketPool("wss://stream.bybit.com/v5/public/spot", 5000, 10)
+	first := &poolConn{client: NewWebSocketClient(p.url, p.recvWindow, "", ""), topics: make(map[string]bool)}
+	second := &poolConn{client: NewWebSocketClient(p.url, p.recvWindow, "", ""), topics: make(map[string]bool)}
+	p.conns = []*poolConn{first, second}
+
+	var states []string
+	p.SetEventHandler(func(event ConnectionEvent) {
+		// Обработчик обращается к пулу: под блокировкой пула это была бы взаимоблокировка
+		p.IsConnected()
+		states = append(states, event.State)
+	})
+
+	p.handleEvent(first, ConnectionEvent{State: ConnectionDisconnected, Err: errors.New("read timeout")})
+	p.handleEvent(second, ConnectionEvent{State: ConnectionDisconnected, Err: errors.New("read timeout")})
+	p.handleEvent(first, ConnectionEvent{State: ConnectionRestored})
+	p.handleEvent(second, ConnectionEvent{State: ConnectionRestored})
+
+	want := []string{ConnectionDisconnected, ConnectionRestored}
+	if !reflect.DeepEqual(states, want) {
+		t.Fatalf("pool events = %v, want %v", states, want)
+	}
+}
+
+func TestPoolIgnoresEventsOfClosedConnection(t *testing.T) {
+	p := NewWebSocketPool("wss://stream.bybit.com/v5/public/spot", 5000, 10)
+	pc := &poolConn{client: NewWebSocketClient(p.url, p.recvWindow, "", ""), topics: make(map[string]bool)}
+
+	var states []string
+	p.SetEventHandler(func(event ConnectionEvent) {
+		states = append(states, event.State)
+	})
+	p.handleEvent(pc, ConnectionEvent{State: ConnectionDisconnected})
+	if len(states) != 0 {
+		t.Fatalf("events of a connection outside the pool were delivered: %v", states)
+	}
+}
+
+// poolRequest операция подписки, которую получил тестовый сервер
+type poolRequest struct {
+	Op   string   `json:"op"`
+	Args []string `json:"args"`
+}
+
+// dialTestConn подключается к тестовому серверу и возвращает соединение и канал полученных сервером операций
+func dialTestConn(t *testing.T) (*websocket.Conn, <-chan poolRequest) {
+	t.Helper()
+	requests := make(chan poolRequest, 10)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var req poolRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			requests <- req
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, requests
+}
+
+// testPoolConn создает соединение пула, уже подписанное на topics
+func testPoolConn(p *WebSocketPool, conn *websocket.Conn, topics ...string) *poolConn {
+	pc := &poolConn{client: NewWebSocketClient(p.url, p.recvWindow, "", ""), topics: make(map[string]bool)}
+	pc.client.conn = conn
+	for _, topic := range topics {
+		pc.topics[topic] = true
+		pc.client.subscriptions[topic] = true
+		p.topics[topic] = pc
+	}
+	p.conns = append(p.conns, pc)
+	return pc
+}
+
+func nextRequest(t *testing.T, requests <-chan poolRequest) poolRequest {
+	t.Helper()
+	select {
+	case req := <-requests:
+		return req
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server received nothing")
+		return poolRequest{}
+	}
+}
+
+func TestPoolUnsubscribeContinuesAfterError(t *testing.T) {
+	p := NewWebSocketPool("wss://stream.bybit.com/v5/public/spot", 5000, 3)
+	broken, _ := dialTestConn(t)
+	broken.Close() // Запись в это соединение вернет ошибку
+	movedConn, _ := dialTestConn(t)
+	liveConn, requests := dialTestConn(t)
+
+	a := testPoolConn(p, broken, "a1", "a2", "a3")
+	b := testPoolConn(p, movedConn, "b1")
+	c := testPoolConn(p, liveConn, "c1", "c2")
+
+	err := p.Unsubscribe(t.Context(), []string{"a1", "c1"})
+	if err == nil || !strings.Contains(err.Error(), "a1") {
+		t.Fatalf("error = %v, want the error of the broken connection", err)
+	}
+
+	// Отписка на исправном соединении выполнена, несмотря на ошибку другого
+	if req := nextRequest(t, requests); req.Op != "unsubscribe" || !reflect.DeepEqual(req.Args, []string{"c1"}) {
+		t.Errorf("first request = %+v, want unsubscribe c1", req)
+	}
+	// Лишнее соединение закрыто, а его канал перенесен
+	if req := nextRequest(t, requests); req.Op != "subscribe" || !reflect.DeepEqual(req.Args, []string{"b1"}) {
+		t.Errorf("second request = %+v, want subscribe b1", req)
+	}
+	if !b.client.closed {
+		t.Error("the emptied connection was not closed")
+	}
+	if len(p.conns) != 2 || p.topics["b1"] != c || p.topics["a2"] != a || p.topics["a1"] != nil {
+		t.Errorf("pool after unsubscribe: %d connections, topics %v", len(p.conns), p.topics)
+	}
+}
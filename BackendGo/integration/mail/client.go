@@ -0,0 +1,185 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Режимы шифрования соединения с SMTP-сервером
+const (
+	TLSModeStartTLS = "starttls"
+	TLSModeTLS      = "tls"
+	TLSModeNone     = "none"
+)
+
+// sendTimeout ограничивает отправку письма, если контекст не задает срок раньше
+const sendTimeout = 30 * time.Second
+
+// Message письмо с текстовым телом
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Client интерфейс для отправки писем
+type Client interface {
+	// Send отправляет письмо одному получателю
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config параметры подключения к SMTP-серверу
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	TLSMode  string
+	From     string
+}
+
+// client реализация клиента SMTP
+type client struct {
+	cfg  Config
+	from *mail.Address
+}
+
+// NewClient создает клиент SMTP.
+// Режим none позволяет направить письма на локальный тестовый сервер без TLS.
+func NewClient(cfg Config) (Client, error) {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", cfg.From, err)
+	}
+	switch cfg.TLSMode {
+	case TLSModeStartTLS, TLSModeTLS, TLSModeNone:
+	default:
+		return nil, fmt.Errorf("unknown SMTP TLS mode %q", cfg.TLSMode)
+	}
+	return &client{cfg: cfg, from: from}, nil
+}
+
+// Send отправляет письмо одному получателю
+func (c *client) Send(ctx context.Context, msg Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address %q: %w", msg.To, err)
+	}
+	data, err := c.compose(to, msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	// net/smtp не принимает контекст, поэтому срок переносится на соединение
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	smtpClient, err := smtp.NewClient(conn, c.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer smtpClient.Close()
+
+	if c.cfg.TLSMode == TLSModeStartTLS {
+		if err := smtpClient.StartTLS(&tls.Config{ServerName: c.cfg.Host}); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+	if c.cfg.Username != "" {
+		auth := smtp.PlainAuth("", c.cfg.Username, c.cfg.Password, c.cfg.Host)
+		if err := smtpClient.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := smtpClient.Mail(c.from.Address); err != nil {
+		return fmt.Errorf("MAIL FROM rejected: %w", err)
+	}
+	if err := smtpClient.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("RCPT TO rejected: %w", err)
+	}
+	writer, err := smtpClient.Data()
+	if err != nil {
+		return fmt.Errorf("DATA rejected: %w", err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+	return smtpClient.Quit()
+}
+
+// dial открывает соединение; в режиме tls шифрование начинается сразу, без STARTTLS
+func (c *client) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(c.cfg.Host, strconv.Itoa(c.cfg.Port))
+	if c.cfg.TLSMode == TLSModeTLS {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: c.cfg.Host}}
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+// compose собирает письмо в формате RFC 5322. Тема кодируется по RFC 2047, тело — quoted-printable
+// с переводами строк CRLF, поэтому кириллица доходит без искажений.
+func (c *client) compose(to *mail.Address, msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	headers := []struct{ name, value string }{
+		{"From", c.from.String()},
+		{"To", to.String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", msg.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", c.messageID()},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	}
+	for _, h := range headers {
+		fmt.Fprintf(&buf, "%s: %s\r\n", h.name, h.value)
+	}
+	buf.WriteString("\r\n")
+
+	body := quotedprintable.NewWriter(&buf)
+	if _, err := body.Write([]byte(msg.Body)); err != nil {
+		return nil, fmt.Errorf("failed to encode message body: %w", err)
+	}
+	if err := body.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode message body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// messageID создает уникальный Message-ID в домене отправителя
+func (c *client) messageID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	domain := "localhost"
+	if at := strings.LastIndex(c.from.Address, "@"); at >= 0 {
+		domain = c.from.Address[at+1:]
+	}
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(buf), domain)
+}
@@ -1,20 +1,22 @@
 package redis
 
 import (
-	"CryptoLens_Backend/env"
-	"CryptoLens_Backend/logger"
+	"CryptoLens_Backend/config"
+	"CryptoLens_Shared/logger"
 	"context"
+	"fmt"
 	"github.com/redis/go-redis/v9"
 )
 
 var Client *redis.Client
 
-func Init() error {
+func Init(cfg config.RedisConfig) error {
 	Client = redis.NewClient(&redis.Options{
-		Addr:     env.GetRedisHost() + ":" + env.GetRedisPortLocal(),
-		Password: env.GetRedisPassword(),
+		Addr:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Password: cfg.Password.Value(),
 		DB:       0,
 	})
+	Client.AddHook(metricsHook{})
 
 	_, err := Client.Ping(context.Background()).Result()
 	if err != nil {
@@ -0,0 +1,45 @@
+package redis
+
+import (
+	"CryptoLens_Backend/metrics"
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// metricsHook записывает задержку и ошибки команд Redis
+type metricsHook struct{}
+
+func (metricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (metricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		observeCommand(cmd.Name(), start, err)
+		return err
+	}
+}
+
+func (metricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		observeCommand("pipeline", start, err)
+		return err
+	}
+}
+
+func observeCommand(name string, start time.Time, err error) {
+	metrics.ObserveSince(metrics.RedisCommandDuration.WithLabelValues(name), start)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		metrics.RedisErrors.WithLabelValues(name).Inc()
+	}
+}
@@ -0,0 +1,135 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client интерфейс для работы с Telegram Bot API
+type Client interface {
+	// SendMessage отправляет текстовое сообщение в чат
+	SendMessage(ctx context.Context, chatID string, text string) error
+
+	// GetUpdates получает новые обновления методом long polling.
+	// timeout задает время ожидания на стороне Telegram в секундах.
+	GetUpdates(ctx context.Context, offset int64, timeout int) ([]Update, error)
+}
+
+// client реализация клиента Telegram Bot API
+type client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+	pollClient *http.Client
+}
+
+// apiResponse представляет базовый ответ Telegram Bot API
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	ErrorCode   int             `json:"error_code"`
+	Result      json.RawMessage `json:"result"`
+}
+
+// NewClient создает новый клиент Telegram Bot API.
+// baseURL задается конфигурацией, чтобы клиент можно было направить на локальную заглушку.
+func NewClient(baseURL, token string) Client {
+	return &client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		// Таймаут long polling задается через контекст запроса
+		pollClient: &http.Client{},
+	}
+}
+
+// SendMessage отправляет текстовое сообщение в чат
+func (c *client) SendMessage(ctx context.Context, chatID string, text string) error {
+	payload := map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	}
+	_, err := c.call(ctx, c.httpClient, "sendMessage", payload)
+	return err
+}
+
+// GetUpdates получает новые обновления методом long polling
+func (c *client) GetUpdates(ctx context.Context, offset int64, timeout int) ([]Update, error) {
+	payload := map[string]interface{}{
+		"offset":          offset,
+		"timeout":         timeout,
+		"allowed_updates": []string{"message"},
+	}
+
+	// Даем запросу запас времени сверх ожидания на стороне Telegram
+	pollCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second+10*time.Second)
+	defer cancel()
+
+	result, err := c.call(pollCtx, c.pollClient, "getUpdates", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []Update
+	if err := json.Unmarshal(result, &updates); err != nil {
+		return nil, fmt.Errorf("ошибка декодирования обновлений: %w", err)
+	}
+	return updates, nil
+}
+
+// call выполняет метод Bot API и возвращает поле result
+func (c *client) call(ctx context.Context, httpClient *http.Client, method string, payload interface{}) (json.RawMessage, error) {
+	if c.token == "" {
+		return nil, fmt.Errorf("telegram bot token is not configured")
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка маршалинга payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.token, method), bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// url.Error содержит полный адрес запроса вместе с токеном бота, поэтому не пробрасываем его
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("ошибка выполнения запроса %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения тела ответа: %w", err)
+	}
+
+	var apiResp apiResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return nil, fmt.Errorf("ошибка декодирования ответа (статус %d): %w", resp.StatusCode, err)
+	}
+	if !apiResp.OK {
+		return nil, fmt.Errorf("ошибка Telegram API %s: %d %s", method, apiResp.ErrorCode, apiResp.Description)
+	}
+
+	return apiResp.Result, nil
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"runtime"
+	"time"
+)
+
+// Метрики приложения
+var (
+	WSMessages = NewCounterVec(
+		"cryptolens_ws_messages_total",
+		"WebSocket messages received from Bybit by channel and topic.",
+		"channel", "topic",
+	)
+	WSMessagesDropped = NewCounterVec(
+		"cryptolens_ws_messages_dropped_total",
+		"Public WebSocket messages dropped because the processing queue was full.",
+		"topic",
+	)
+	WSConnectionEvents = NewCounterVec(
+		"cryptolens_ws_connection_events_total",
+		"Bybit WebSocket disconnects and restored connections by channel.",
+		"channel", "state",
+	)
+	RedisCommandDuration = NewHistogramVec(
+		"cryptolens_redis_command_duration_seconds",
+		"Redis command latency.",
+		nil,
+		"command",
+	)
+	RedisErrors = NewCounterVec(
+		"cryptolens_redis_errors_total",
+		"Redis commands that returned an error other than a missing key.",
+		"command",
+	)
+	DBQueryDuration = NewHistogramVec(
+		"cryptolens_db_query_duration_seconds",
+		"PostgreSQL statement latency by operation.",
+		nil,
+		"operation",
+	)
+	DBErrors = NewCounterVec(
+		"cryptolens_db_errors_total",
+		"PostgreSQL statements that returned an error.",
+		"operation",
+	)
+	BybitRequestDuration = NewHistogramVec(
+		"cryptolens_bybit_request_duration_seconds",
+		"Bybit REST API request latency by endpoint.",
+		nil,
+		"method", "endpoint",
+	)
+	BybitResponses = NewCounterVec(
+		"cryptolens_bybit_responses_total",
+		"Bybit REST API responses by endpoint, HTTP status and retCode.",
+		"endpoint", "status", "ret_code",
+	)
+	HTTPRequests = NewCounterVec(
+		"cryptolens_http_requests_total",
+		"HTTP requests by route pattern, method and status.",
+		"route", "method", "status",
+	)
+	HTTPRequestDuration = NewHistogramVec(
+		"cryptolens_http_request_duration_seconds",
+		"HTTP request latency by route pattern.",
+		nil,
+		"route", "method",
+	)
+	StreamMessages = NewCounterVec(
+		"cryptolens_stream_messages_total",
+		"Messages queued to client stream connections by type.",
+		"type",
+	)
+	StreamConflated = NewCounterVec(
+		"cryptolens_stream_conflated_total",
+		"Market data updates replaced by a newer one before a slow stream client received them.",
+		"type",
+	)
+	StreamDisconnects = NewCounterVec(
+		"cryptolens_stream_disconnects_total",
+		"Client stream connections closed by the server by reason.",
+		"reason",
+	)
+	Orders = NewCounterVec(
+		"cryptolens_orders_total",
+		"Order updates received on the private WebSocket by status.",
+		"status",
+	)
+)
+
+func init() {
+	NewGaugeFunc("cryptolens_goroutines", "Number of goroutines.", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+	startTime := time.Now()
+	NewGaugeFunc("cryptolens_uptime_seconds", "Time since the process started.", func() float64 {
+		return time.Since(startTime).Seconds()
+	})
+}
+
+// ObserveSince записывает в гистограмму время, прошедшее с start
+func ObserveSince(h *Histogram, start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
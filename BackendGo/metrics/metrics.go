@@ -0,0 +1,386 @@
+// Package metrics реализует минимальный реестр метрик с выдачей в текстовом формате Prometheus.
+// Поддерживаются счетчики, датчики и гистограммы с метками; внешние зависимости не требуются.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBuckets границы гистограмм задержек в секундах
+var DefaultBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// collector метрика, которую реестр умеет выводить
+type collector interface {
+	write(w io.Writer)
+}
+
+// Registry хранит зарегистрированные метрики
+type Registry struct {
+	mutex      sync.Mutex
+	names      map[string]struct{}
+	collectors []collector
+}
+
+// NewRegistry создает пустой реестр
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]struct{})}
+}
+
+// Default реестр, используемый функциями пакета
+var Default = NewRegistry()
+
+func (r *Registry) register(name string, c collector) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, exists := r.names[name]; exists {
+		panic("metrics: duplicate metric " + name)
+	}
+	r.names[name] = struct{}{}
+	r.collectors = append(r.collectors, c)
+}
+
+// Write выводит все метрики в текстовом формате Prometheus
+func (r *Registry) Write(w io.Writer) {
+	r.mutex.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mutex.Unlock()
+
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// Handler возвращает HTTP-обработчик для выдачи метрик
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	})
+}
+
+// Handler возвращает HTTP-обработчик реестра по умолчанию
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+// desc описание метрики
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d desc) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, escapeHelp(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, d.kind)
+}
+
+// seriesKey объединяет значения меток в ключ карты
+func seriesKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// CounterVec счетчик с метками
+type CounterVec struct {
+	desc
+	mutex  sync.RWMutex
+	series map[string]*Counter
+}
+
+// Counter монотонно растущий счетчик
+type Counter struct {
+	labelValues []string
+	bits        uint64
+}
+
+// NewCounterVec регистрирует счетчик с метками в реестре по умолчанию
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return Default.NewCounterVec(name, help, labels...)
+}
+
+// NewCounterVec регистрирует счетчик с метками
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		desc:   desc{name: name, help: help, kind: "counter", labels: labels},
+		series: make(map[string]*Counter),
+	}
+	r.register(name, c)
+	return c
+}
+
+// WithLabelValues возвращает счетчик для значений меток
+func (c *CounterVec) WithLabelValues(values ...string) *Counter {
+	if len(values) != len(c.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", c.name, len(c.labels), len(values)))
+	}
+	key := seriesKey(values)
+
+	c.mutex.RLock()
+	counter, ok := c.series[key]
+	c.mutex.RUnlock()
+	if ok {
+		return counter
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if counter, ok = c.series[key]; !ok {
+		counter = &Counter{labelValues: append([]string(nil), values...)}
+		c.series[key] = counter
+	}
+	return counter
+}
+
+// Inc увеличивает счетчик на 1
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add увеличивает счетчик на v
+func (c *Counter) Add(v float64) {
+	for {
+		old := atomic.LoadUint64(&c.bits)
+		updated := math.Float64bits(math.Float64frombits(old) + v)
+		if atomic.CompareAndSwapUint64(&c.bits, old, updated) {
+			return
+		}
+	}
+}
+
+func (c *Counter) value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&c.bits))
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.writeHeader(w)
+	c.mutex.RLock()
+	counters := make([]*Counter, 0, len(c.series))
+	for _, counter := range c.series {
+		counters = append(counters, counter)
+	}
+	c.mutex.RUnlock()
+
+	sortSeries(counters, func(s *Counter) []string { return s.labelValues })
+	for _, counter := range counters {
+		writeSample(w, c.name, c.labels, counter.labelValues, "", "", counter.value())
+	}
+}
+
+// GaugeFunc датчик, значения которого вычисляются в момент выдачи
+type GaugeFunc struct {
+	desc
+	collect func() map[string]float64
+}
+
+// NewGaugeFunc регистрирует датчик без меток в реестре по умолчанию
+func NewGaugeFunc(name, help string, value func() float64) *GaugeFunc {
+	return Default.NewGaugeVecFunc(name, help, nil, func() map[string]float64 {
+		return map[string]float64{"": value()}
+	})
+}
+
+// NewGaugeVecFunc регистрирует датчик с одной меткой в реестре по умолчанию.
+// collect возвращает значения по значению метки.
+func NewGaugeVecFunc(name, help, label string, collect func() map[string]float64) *GaugeFunc {
+	return Default.NewGaugeVecFunc(name, help, []string{label}, collect)
+}
+
+// NewGaugeVecFunc регистрирует датчик, значения которого вычисляются в момент выдачи
+func (r *Registry) NewGaugeVecFunc(name, help string, labels []string, collect func() map[string]float64) *GaugeFunc {
+	g := &GaugeFunc{
+		desc:    desc{name: name, help: help, kind: "gauge", labels: labels},
+		collect: collect,
+	}
+	r.register(name, g)
+	return g
+}
+
+func (g *GaugeFunc) write(w io.Writer) {
+	g.writeHeader(w)
+	values := g.collect()
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var labelValues []string
+		if len(g.labels) > 0 {
+			labelValues = []string{k}
+		}
+		writeSample(w, g.name, g.labels, labelValues, "", "", values[k])
+	}
+}
+
+// HistogramVec гистограмма с метками
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mutex   sync.RWMutex
+	series  map[string]*Histogram
+}
+
+// Histogram распределение наблюдаемых значений
+type Histogram struct {
+	labelValues []string
+	buckets     []float64
+	mutex       sync.Mutex
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+// NewHistogramVec регистрирует гистограмму с метками в реестре по умолчанию
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return Default.NewHistogramVec(name, help, buckets, labels...)
+}
+
+// NewHistogramVec регистрирует гистограмму с метками
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	h := &HistogramVec{
+		desc:    desc{name: name, help: help, kind: "histogram", labels: labels},
+		buckets: buckets,
+		series:  make(map[string]*Histogram),
+	}
+	r.register(name, h)
+	return h
+}
+
+// WithLabelValues возвращает гистограмму для значений меток
+func (h *HistogramVec) WithLabelValues(values ...string) *Histogram {
+	if len(values) != len(h.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", h.name, len(h.labels), len(values)))
+	}
+	key := seriesKey(values)
+
+	h.mutex.RLock()
+	histogram, ok := h.series[key]
+	h.mutex.RUnlock()
+	if ok {
+		return histogram
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if histogram, ok = h.series[key]; !ok {
+		histogram = &Histogram{
+			labelValues: append([]string(nil), values...),
+			buckets:     h.buckets,
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = histogram
+	}
+	return histogram
+}
+
+// Observe добавляет наблюдение
+func (h *Histogram) Observe(v float64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for i, upper := range h.buckets {
+		if v <= upper {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.writeHeader(w)
+	h.mutex.RLock()
+	histograms := make([]*Histogram, 0, len(h.series))
+	for _, histogram := range h.series {
+		histograms = append(histograms, histogram)
+	}
+	h.mutex.RUnlock()
+
+	sortSeries(histograms, func(s *Histogram) []string { return s.labelValues })
+	for _, histogram := range histograms {
+		histogram.mutex.Lock()
+		counts := append([]uint64(nil), histogram.counts...)
+		count, sum := histogram.count, histogram.sum
+		histogram.mutex.Unlock()
+
+		for i, upper := range h.buckets {
+			writeSample(w, h.name+"_bucket", h.labels, histogram.labelValues, "le", formatFloat(upper), float64(counts[i]))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, histogram.labelValues, "le", "+Inf", float64(count))
+		writeSample(w, h.name+"_sum", h.labels, histogram.labelValues, "", "", sum)
+		writeSample(w, h.name+"_count", h.labels, histogram.labelValues, "", "", float64(count))
+	}
+}
+
+// sortSeries упорядочивает серии по значениям меток, чтобы вывод был стабильным
+func sortSeries[T any](series []T, labelValues func(T) []string) {
+	sort.Slice(series, func(i, j int) bool {
+		return seriesKey(labelValues(series[i])) < seriesKey(labelValues(series[j]))
+	})
+}
+
+// writeSample выводит одну строку выборки. extraName/extraValue добавляют метку le для гистограмм.
+func writeSample(w io.Writer, name string, labels, values []string, extraName, extraValue string, v float64) {
+	var sb strings.Builder
+	sb.WriteString(name)
+	if len(labels) > 0 || extraName != "" {
+		sb.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(label)
+			sb.WriteString(`="`)
+			sb.WriteString(escapeLabelValue(values[i]))
+			sb.WriteByte('"')
+		}
+		if extraName != "" {
+			if len(labels) > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(extraName)
+			sb.WriteString(`="`)
+			sb.WriteString(extraValue)
+			sb.WriteByte('"')
+		}
+		sb.WriteByte('}')
+	}
+	sb.WriteByte(' ')
+	sb.WriteString(formatFloat(v))
+	sb.WriteByte('\n')
+	io.WriteString(w, sb.String())
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
+
+func escapeHelp(v string) string {
+	return helpEscaper.Replace(v)
+}
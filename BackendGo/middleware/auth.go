@@ -1,40 +1,126 @@
 package middleware
 
 import (
+	"CryptoLens_Backend/httpapi"
+	"CryptoLens_Backend/models"
 	"CryptoLens_Backend/services"
+	"CryptoLens_Backend/storages"
+	"CryptoLens_Backend/types"
+	"CryptoLens_Shared/logger"
 	"context"
 	"net/http"
 	"strings"
+	"time"
 )
 
-// AuthMiddleware проверяет JWT токен в заголовке Authorization
+// apiKeys проверяет персональные API-ключи; задается при сборке контейнера
+var apiKeys types.UserAPIKeyServiceInterface
+
+// SetAPIKeyService подключает проверку API-ключей в AuthMiddleware
+func SetAPIKeyService(service types.UserAPIKeyServiceInterface) {
+	apiKeys = service
+}
+
+// AuthMiddleware проверяет JWT токен или персональный API-ключ в заголовке Authorization
 func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, "Authorization header is required", http.StatusUnauthorized)
+			httpapi.Error(w, "Authorization header is required", http.StatusUnauthorized)
 			return
 		}
 
 		// Проверяем формат заголовка
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+			httpapi.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
 			return
 		}
 
 		// Получаем токен
 		token := parts[1]
 
+		// API-ключ вместо JWT: права ограничены областями ключа, см. RequireScope
+		if strings.HasPrefix(token, models.APIKeyPrefix) {
+			authenticateAPIKey(w, r, token, next)
+			return
+		}
+
 		// Валидируем токен и получаем userID
-		userID, err := services.ValidateToken(token)
+		claims, err := services.ParseToken(token)
 		if err != nil {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			httpapi.ErrorCode(w, httpapi.CodeInvalidToken, "Invalid token", http.StatusUnauthorized)
 			return
 		}
+		userID := claims.UserID
 
-		// Добавляем userID в контекст
+		// Токен отозванной сессии (logout или отзыв из списка сессий) не принимается до истечения срока
+		if claims.SessionID != "" {
+			revoked, err := storages.IsSessionRevoked(r.Context(), claims.SessionID)
+			if err != nil {
+				logger.ErrorCtx(r.Context(), "Ошибка проверки отзыва сессии: %v", err)
+				httpapi.Error(w, "Session check unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			if revoked {
+				httpapi.ErrorCode(w, httpapi.CodeSessionRevoked, "Session revoked", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		// Токены, выпущенные до появления ролей, получают права обычного пользователя
+		role := claims.Role
+		if role == "" {
+			role = models.RoleUser
+		}
+
+		// Срок токена нужен долгим подключениям: поток закрывается, когда токен истекает
+		var expiresAt time.Time
+		if claims.ExpiresAt != nil {
+			expiresAt = claims.ExpiresAt.Time
+		}
+
+		// Добавляем userID, сессию, роль и срок токена в контекст и в поля логов запроса
 		ctx := context.WithValue(r.Context(), "userID", userID)
+		ctx = context.WithValue(ctx, "sessionID", claims.SessionID)
+		ctx = context.WithValue(ctx, "role", role)
+		ctx = context.WithValue(ctx, "tokenExpiresAt", expiresAt)
+		ctx = logger.WithFields(ctx, logger.FieldUserID, userID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
-} 
\ No newline at end of file
+}
+
+// StreamAuthMiddleware проверяет токен как AuthMiddleware. Браузер не может передать заголовок
+// при открытии WebSocket, поэтому токен принимается и из параметра access_token.
+func StreamAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	auth := AuthMiddleware(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+			r = r.Clone(r.Context())
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		auth(w, r)
+	}
+}
+
+// authenticateAPIKey проверяет API-ключ и передает запрос дальше от имени владельца ключа
+func authenticateAPIKey(w http.ResponseWriter, r *http.Request, key string, next http.HandlerFunc) {
+	if apiKeys == nil {
+		httpapi.ErrorCode(w, httpapi.CodeInvalidToken, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+	identity, err := apiKeys.Authenticate(r.Context(), key)
+	if err != nil {
+		httpapi.ErrorCode(w, httpapi.CodeInvalidToken, "Invalid API key", http.StatusUnauthorized)
+		return
+	}
+
+	// Сессии у ключа нет, роль берется из БД на момент запроса
+	ctx := context.WithValue(r.Context(), "userID", identity.UserID)
+	ctx = context.WithValue(ctx, "sessionID", "")
+	ctx = context.WithValue(ctx, "role", identity.Role)
+	ctx = context.WithValue(ctx, "apiKeyID", identity.KeyID)
+	ctx = context.WithValue(ctx, "scopes", identity.Scopes)
+	ctx = logger.WithFields(ctx, logger.FieldUserID, identity.UserID, logger.FieldAPIKeyID, identity.KeyID)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"CryptoLens_Backend/httpapi"
+	"CryptoLens_Backend/models"
+	"CryptoLens_Shared/logger"
+	"net/http"
+)
+
+// Permission право на группу маршрутов
+type Permission string
+
+const (
+	PermissionManageSystem     Permission = "system:manage"     // Настройки сервиса, например уровень логирования
+	PermissionManageUsers      Permission = "users:manage"      // Список пользователей и их отключение
+	PermissionManageStrategies Permission = "strategies:manage" // Просмотр и остановка стратегий любого пользователя
+)
+
+// rolePermissions права ролей. Обычному пользователю доступны только собственные данные:
+// обработчики передают userID из токена, а репозитории ищут записи только с этим user_id.
+var rolePermissions = map[string][]Permission{
+	models.RoleAdmin: {PermissionManageSystem, PermissionManageUsers, PermissionManageStrategies},
+	models.RoleUser:  {},
+}
+
+// HasPermission проверяет, есть ли право у роли
+func HasPermission(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission проверяет токен и пропускает запрос, только если у роли из токена есть право.
+// API-ключу для этого дополнительно нужна область admin.
+func RequirePermission(permission Permission, next http.HandlerFunc) http.HandlerFunc {
+	return AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		role, _ := r.Context().Value("role").(string)
+		if !HasPermission(role, permission) || !hasScope(r, models.APIKeyScopeAdmin) {
+			logger.WarnCtx(r.Context(), "Отказано в доступе к %s: роль %s без права %s", r.URL.Path, role, permission)
+			httpapi.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireScope проверяет аутентификацию и требует у API-ключа область scope.
+// Запросы с токеном сессии проходят без ограничений: области есть только у ключей.
+func RequireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if !hasScope(r, scope) {
+			httpapi.ErrorCode(w, httpapi.CodeInsufficientScope, "API key scope "+scope+" required", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireSession пропускает только запросы с токеном сессии. Так защищены выдача ключей, смена
+// учетных данных и настройка каналов доставки: утекший API-ключ не должен давать закрепиться в учетной записи.
+func RequireSession(next http.HandlerFunc) http.HandlerFunc {
+	return AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if _, isAPIKey := r.Context().Value("apiKeyID").(string); isAPIKey {
+			httpapi.ErrorCode(w, httpapi.CodeSessionRequired, "This endpoint requires a session token", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hasScope сообщает, разрешает ли запрос область. Любой ключ дает чтение.
+func hasScope(r *http.Request, scope string) bool {
+	scopes, isAPIKey := r.Context().Value("scopes").([]string)
+	if !isAPIKey || scope == models.APIKeyScopeRead {
+		return true
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
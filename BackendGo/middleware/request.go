@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"CryptoLens_Backend/httpapi"
+	"CryptoLens_Backend/metrics"
+	"CryptoLens_Shared/logger"
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"time"
+)
+
+// Middleware оборачивает обработчик
+type Middleware func(http.Handler) http.Handler
+
+// Chain применяет middleware так, что первый в списке выполняется первым
+func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// maxRequestIDLength ограничивает ID запроса от клиента, чтобы он не раздувал логи
+const maxRequestIDLength = 64
+
+// RequestID присваивает запросу ID: берет X-Request-ID клиента или создает новый.
+// ID возвращается в заголовке ответа и добавляется ко всем записям логов запроса.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(httpapi.HeaderRequestID)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(httpapi.HeaderRequestID, id)
+		ctx := logger.WithFields(r.Context(), logger.FieldRequestID, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID допускает только печатные символы без пробелов
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// AccessLog пишет в лог и метрики метод, шаблон маршрута, статус и длительность запроса.
+// Проверки живости и сбор метрик опрашиваются постоянно, их записи уходят на уровень debug.
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		status := rec.Status()
+		duration := time.Since(start)
+		// Шаблон маршрута выставляет ServeMux; для несуществующих путей он пуст,
+		// и в метрики попадает общая метка, а не произвольный путь из запроса
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequests.WithLabelValues(route, r.Method, strconv.Itoa(status)).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(route, r.Method).Observe(duration.Seconds())
+
+		log := logger.InfoCtx
+		switch {
+		case status >= http.StatusInternalServerError:
+			log = logger.ErrorCtx
+		case r.URL.Path == "/healthz" || r.URL.Path == "/readyz" || r.URL.Path == "/metrics":
+			log = logger.DebugCtx
+		}
+		log(r.Context(), "%s %s -> %d за %s (маршрут %s)", r.Method, r.URL.Path, status, duration.Round(time.Microsecond), route)
+	})
+}
+
+// Recover перехватывает панику в обработчике и отвечает 500 вместо обрыва соединения
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			// Этой паникой сервер намеренно прерывает ответ, ее нужно пропустить дальше
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+			logger.ErrorCtx(r.Context(), "Паника при обработке %s %s: %v\n%s", r.Method, r.URL.Path, err, debug.Stack())
+			if rec, ok := w.(*statusRecorder); ok && rec.status != 0 {
+				// Заголовки уже отправлены, корректный ответ с ошибкой не получится
+				return
+			}
+			httpapi.Error(w, "Internal server error", http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// statusRecorder запоминает статус ответа. Hijack и Flush пробрасываются,
+// иначе через него не работали бы WebSocket и потоковые ответы.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+// Status возвращает отправленный статус; 200, если обработчик ничего не записал
+func (rec *statusRecorder) Status() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
+
+func (rec *statusRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rec *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rec.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil && rec.status == 0 {
+		rec.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap дает http.ResponseController доступ к исходному ResponseWriter
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
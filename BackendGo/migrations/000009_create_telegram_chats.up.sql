@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS telegram_chats (
+    chat_id BIGINT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    scope VARCHAR(10) NOT NULL DEFAULT 'read',
+    username VARCHAR(64),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_telegram_chats_user_id ON telegram_chats (user_id);
@@ -0,0 +1,10 @@
+-- Зашифрованные секреты не восстанавливаются: такие аккаунты нужно добавить заново
+UPDATE bybit_accounts SET api_secret = '', is_active = false WHERE api_secret IS NULL;
+
+DROP INDEX IF EXISTS idx_bybit_accounts_key_version;
+
+ALTER TABLE bybit_accounts
+    DROP COLUMN IF EXISTS key_version,
+    DROP COLUMN IF EXISTS api_secret_dek,
+    DROP COLUMN IF EXISTS api_secret_ciphertext,
+    ALTER COLUMN api_secret SET NOT NULL;
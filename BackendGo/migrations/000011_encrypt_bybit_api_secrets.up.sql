@@ -0,0 +1,10 @@
+-- Секрет хранится в зашифрованном виде: api_secret_ciphertext шифруется ключом данных,
+-- api_secret_dek содержит ключ данных, зашифрованный мастер-ключом версии key_version.
+-- Незашифрованные записи (key_version IS NULL) переводятся командой ./app reencrypt-secrets.
+ALTER TABLE bybit_accounts
+    ALTER COLUMN api_secret DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS api_secret_ciphertext BYTEA,
+    ADD COLUMN IF NOT EXISTS api_secret_dek BYTEA,
+    ADD COLUMN IF NOT EXISTS key_version INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_bybit_accounts_key_version ON bybit_accounts (key_version);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_trade_logs_bybit_account_id;
+DROP INDEX IF EXISTS idx_user_strategies_bybit_account_id;
+DROP INDEX IF EXISTS idx_bybit_accounts_user_id;
+
+ALTER TABLE trade_logs DROP COLUMN IF EXISTS bybit_account_id;
+ALTER TABLE user_strategies DROP COLUMN IF EXISTS bybit_account_id;
+ALTER TABLE bybit_accounts DROP COLUMN IF EXISTS label;
@@ -0,0 +1,33 @@
+-- У пользователя может быть несколько аккаунтов и субаккаунтов Bybit.
+-- Стратегии и сделки привязываются к конкретному аккаунту; существующие записи
+-- переносятся на аккаунт, которым пользователь пользовался до этой миграции.
+ALTER TABLE bybit_accounts
+    ADD COLUMN IF NOT EXISTS label VARCHAR(100) NOT NULL DEFAULT '';
+
+ALTER TABLE user_strategies
+    ADD COLUMN IF NOT EXISTS bybit_account_id BIGINT REFERENCES bybit_accounts(id);
+
+ALTER TABLE trade_logs
+    ADD COLUMN IF NOT EXISTS bybit_account_id BIGINT REFERENCES bybit_accounts(id);
+
+UPDATE user_strategies us
+SET bybit_account_id = (
+    SELECT ba.id FROM bybit_accounts ba
+    WHERE ba.user_id = us.user_id AND ba.deleted_at IS NULL
+    ORDER BY ba.is_active DESC, ba.id
+    LIMIT 1
+)
+WHERE us.bybit_account_id IS NULL;
+
+UPDATE trade_logs tl
+SET bybit_account_id = (
+    SELECT ba.id FROM bybit_accounts ba
+    WHERE ba.user_id = tl.user_id
+    ORDER BY ba.deleted_at IS NULL DESC, ba.is_active DESC, ba.id
+    LIMIT 1
+)
+WHERE tl.bybit_account_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_bybit_accounts_user_id ON bybit_accounts (user_id);
+CREATE INDEX IF NOT EXISTS idx_user_strategies_bybit_account_id ON user_strategies (bybit_account_id);
+CREATE INDEX IF NOT EXISTS idx_trade_logs_bybit_account_id ON trade_logs (bybit_account_id);
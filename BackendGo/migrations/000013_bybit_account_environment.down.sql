@@ -0,0 +1 @@
+ALTER TABLE bybit_accounts DROP COLUMN IF EXISTS environment;
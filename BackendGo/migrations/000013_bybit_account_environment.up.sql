@@ -0,0 +1,5 @@
+-- Окружение Bybit, в котором работает аккаунт. NULL — окружение из BYBIT_API_MODE,
+-- так существующие аккаунты продолжают работать там же, где работали до миграции.
+ALTER TABLE bybit_accounts
+    ADD COLUMN IF NOT EXISTS environment VARCHAR(16)
+        CHECK (environment IN ('mainnet', 'testnet', 'demo'));
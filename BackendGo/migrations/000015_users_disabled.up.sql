@@ -0,0 +1,2 @@
+-- Отключенный администратором пользователь не может войти и обновить токены
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP(0);
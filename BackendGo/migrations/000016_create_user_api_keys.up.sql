@@ -0,0 +1,16 @@
+-- Персональные API-ключи для скриптов. Сам ключ показывается один раз и хранится только в виде SHA-256,
+-- key_prefix позволяет узнать ключ в списке.
+CREATE TABLE IF NOT EXISTS user_api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    scopes VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_user_api_keys_key_hash ON user_api_keys (key_hash);
+CREATE INDEX idx_user_api_keys_user_id ON user_api_keys (user_id);
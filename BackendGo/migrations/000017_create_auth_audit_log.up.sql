@@ -0,0 +1,14 @@
+-- Журнал событий аутентификации: неудачные и заблокированные попытки входа, регистрации
+-- и успешные входы, по которым определяются новые для пользователя IP-адреса.
+CREATE TABLE IF NOT EXISTS auth_audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    event VARCHAR(32) NOT NULL,
+    ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_auth_audit_log_user_id ON auth_audit_log (user_id, event, ip_address);
+CREATE INDEX idx_auth_audit_log_created_at ON auth_audit_log (created_at DESC);
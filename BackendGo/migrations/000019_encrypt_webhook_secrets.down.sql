@@ -0,0 +1,10 @@
+-- Зашифрованные секреты не восстанавливаются: такие эндпоинты нужно создать заново
+UPDATE webhook_endpoints SET secret = '', is_active = false WHERE secret IS NULL;
+
+DROP INDEX IF EXISTS idx_webhook_endpoints_key_version;
+
+ALTER TABLE webhook_endpoints
+    DROP COLUMN IF EXISTS key_version,
+    DROP COLUMN IF EXISTS secret_dek,
+    DROP COLUMN IF EXISTS secret_ciphertext,
+    ALTER COLUMN secret SET NOT NULL;
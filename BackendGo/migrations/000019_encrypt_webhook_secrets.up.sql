@@ -0,0 +1,11 @@
+-- Секрет подписи вебхука шифруется мастер-ключом так же, как API-секреты Bybit:
+-- secret_ciphertext шифруется ключом данных, secret_dek содержит ключ данных, зашифрованный
+-- мастер-ключом версии key_version. Открытые секреты (key_version IS NULL) переводятся
+-- командой ./app reencrypt-secrets.
+ALTER TABLE webhook_endpoints
+    ALTER COLUMN secret DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS secret_ciphertext BYTEA,
+    ADD COLUMN IF NOT EXISTS secret_dek BYTEA,
+    ADD COLUMN IF NOT EXISTS key_version INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_key_version ON webhook_endpoints (key_version);
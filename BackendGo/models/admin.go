@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// LogLevelRequest представляет запрос на изменение уровня логирования
+type LogLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// LogLevelResponse представляет текущий уровень логирования
+type LogLevelResponse struct {
+	Level string `json:"level"`
+}
+
+// AdminUserResponse представляет пользователя в административном списке
+type AdminUserResponse struct {
+	ID              string     `json:"id"`
+	Nickname        string     `json:"nickname"`
+	Email           string     `json:"email"`
+	Role            string     `json:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	DisabledAt      *time.Time `json:"disabled_at"`
+	CreatedAt       *time.Time `json:"created_at"`
+}
+
+// SetUserDisabledRequest представляет запрос на отключение или включение пользователя
+type SetUserDisabledRequest struct {
+	UserID   string `json:"-"` // Из пути запроса
+	Disabled bool   `json:"disabled"`
+}
+
+// AdminStopStrategyRequest представляет запрос на принудительную остановку стратегии
+type AdminStopStrategyRequest struct {
+	ID     string `json:"-"`      // Из пути запроса
+	Reason string `json:"reason"` // Попадает в уведомление владельцу стратегии
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// APIKeyPrefix отличает API-ключ от JWT в заголовке Authorization
+const APIKeyPrefix = "clk_"
+
+// Области действия API-ключей
+const (
+	APIKeyScopeRead  = "read"  // чтение данных пользователя
+	APIKeyScopeTrade = "trade" // управление стратегиями и инструментами
+	APIKeyScopeAdmin = "admin" // административные маршруты, только для администраторов
+)
+
+// APIKeyScopes перечисляет области, которые можно выдать ключу
+var APIKeyScopes = []string{
+	APIKeyScopeRead,
+	APIKeyScopeTrade,
+	APIKeyScopeAdmin,
+}
+
+// APIKey представляет персональный API-ключ пользователя
+type APIKey struct {
+	ID         string     `json:"id" db:"id"`
+	UserID     string     `json:"user_id" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"key_prefix"` // начало ключа, по которому его можно узнать
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at" db:"expires_at"` // nil — бессрочный
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// APIKeyIdentity представляет владельца ключа, прошедшего проверку
+type APIKeyIdentity struct {
+	KeyID  string
+	UserID string
+	Role   string // текущая роль владельца, а не роль на момент создания ключа
+	Scopes []string
+}
+
+// CreateAPIKeyRequest представляет запрос на создание API-ключа
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required"`
+	ExpiresAt *time.Time `json:"expires_at"` // можно не указывать для бессрочного ключа
+}
+
+// CreateAPIKeyResponse представляет ответ с ключом, который показывается только один раз
+type CreateAPIKeyResponse struct {
+	APIKey *APIKey `json:"api_key"`
+	Key    string  `json:"key"`
+}
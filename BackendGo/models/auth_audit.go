@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// События журнала аутентификации
+const (
+	AuthEventRegistered        = "registered"
+	AuthEventRegisterLimited   = "register_rate_limited"
+	AuthEventLoginSucceeded    = "login_succeeded"
+	AuthEventLoginFailed       = "login_failed"
+	AuthEventLoginLimited      = "login_rate_limited" // превышен лимит попыток с IP
+	AuthEventLoginLocked       = "login_locked"       // попытка входа в заблокированный аккаунт
+	AuthEventAccountLocked     = "account_locked"     // аккаунт заблокирован после неудачных входов
+	AuthEventRefreshTokenReuse = "refresh_token_reuse"
+	AuthEventEmailVerified     = "email_verified"
+	AuthEventResetRequested    = "password_reset_requested"
+	AuthEventPasswordReset     = "password_reset"
+	AuthEventTwoFactorEnabled  = "two_factor_enabled"
+	AuthEventTwoFactorDisabled = "two_factor_disabled"
+	AuthEventTwoFactorFailed   = "two_factor_failed" // неверный код для защищенной операции
+	AuthEventRecoveryCodeUsed  = "recovery_code_used"
+)
+
+// AuthAuditEntry представляет запись журнала аутентификации
+type AuthAuditEntry struct {
+	ID        int64     `json:"id" db:"id"`
+	UserID    *string   `json:"user_id" db:"user_id"` // nil, если аккаунт с таким email не найден
+	Email     string    `json:"email" db:"email"`
+	Event     string    `json:"event" db:"event"`
+	IPAddress string    `json:"ip_address" db:"ip_address"`
+	UserAgent string    `json:"user_agent" db:"user_agent"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"CryptoLens_Backend/integration/bybit"
+	"github.com/shopspring/decimal"
 	"time"
 )
 
@@ -8,8 +10,10 @@ type BybitAccount struct {
 	ID          int64      `json:"id"`
 	UserID      string     `json:"user_id"`
 	APIKey      string     `json:"api_key"`
-	APISecret   string     `json:"api_secret"`
+	APISecret   string     `json:"-"` // Никогда не сериализуется в ответы и логи
 	AccountType string     `json:"account_type"`
+	Label       string     `json:"label"`
+	Environment string     `json:"environment"` // mainnet, testnet или demo; пусто — окружение по умолчанию
 	IsActive    bool       `json:"is_active"`
 	CreatedAt   *time.Time `json:"-"`
 	UpdatedAt   *time.Time `json:"-"`
@@ -20,19 +24,78 @@ type CreateBybitAccountRequest struct {
 	APIKey      string `json:"api_key" validate:"required"`
 	APISecret   string `json:"api_secret" validate:"required"`
 	AccountType string `json:"account_type" validate:"required,oneof=UNIFIED SPOT FUTURES"`
+	Label       string `json:"label"`       // Название для различения аккаунтов и субаккаунтов пользователя
+	Environment string `json:"environment"` // mainnet, testnet или demo; по умолчанию — окружение из BYBIT_API_MODE
+	OTPCode     string `json:"otp_code"`    // Обязателен, если включена двухфакторная аутентификация
 }
 
-type UpdateBybitAccountRequest struct {
-	APIKey      string `json:"api_key" validate:"omitempty"`
-	APISecret   string `json:"api_secret" validate:"omitempty"`
-	AccountType string `json:"account_type" validate:"omitempty,oneof=UNIFIED SPOT FUTURES"`
-	IsActive    *bool  `json:"is_active" validate:"omitempty"`
+// RotateBybitAccountRequest заменяет ключ и секрет существующего аккаунта.
+// Ключи Bybit действуют только в своем окружении, поэтому вместе с ними можно сменить и окружение.
+type RotateBybitAccountRequest struct {
+	APIKey      string `json:"api_key" validate:"required"`
+	APISecret   string `json:"api_secret" validate:"required"`
+	Environment string `json:"environment"` // Пусто — окружение не меняется
+	OTPCode     string `json:"otp_code"`    // Обязателен, если включена двухфакторная аутентификация
+}
+
+// SetBybitAccountStatusRequest включает или отключает аккаунт
+type SetBybitAccountStatusRequest struct {
+	IsActive bool   `json:"is_active"`
+	OTPCode  string `json:"otp_code"` // Обязателен, если включена двухфакторная аутентификация
+}
+
+// RemoveBybitAccountRequest необязательное тело запроса на удаление аккаунта
+type RemoveBybitAccountRequest struct {
+	OTPCode string `json:"otp_code"` // Обязателен, если включена двухфакторная аутентификация
 }
 
+// BybitAccountResponse аккаунт Bybit в ответах API. Секрет не возвращается, ключ маскируется.
 type BybitAccountResponse struct {
-	ID          int64  `json:"id"`
-	UserID      string `json:"user_id"`
-	APIKey      string `json:"api_key"`
-	AccountType string `json:"account_type"`
-	IsActive    bool   `json:"is_active"`
-} 
\ No newline at end of file
+	ID          int64      `json:"id"`
+	APIKey      string     `json:"api_key"`
+	AccountType string     `json:"account_type"`
+	Label       string     `json:"label"`
+	Environment string     `json:"environment"`
+	IsActive    bool       `json:"is_active"`
+	CreatedAt   *time.Time `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+}
+
+// AccountWalletBalance баланс одного аккаунта пользователя. Если баланс получить не удалось,
+// заполняется Error, а остальные аккаунты возвращаются как обычно.
+type AccountWalletBalance struct {
+	AccountID int64                     `json:"account_id"`
+	Label     string                    `json:"label"`
+	Balance   *bybit.BybitWalletBalance `json:"balance,omitempty"`
+	Error     string                    `json:"error,omitempty"`
+}
+
+// CoinBalance суммарный баланс монеты по всем аккаунтам пользователя
+type CoinBalance struct {
+	Coin          string          `json:"coin"`
+	WalletBalance decimal.Decimal `json:"wallet_balance"`
+	Locked        decimal.Decimal `json:"locked"`
+	USDValue      decimal.Decimal `json:"usd_value"`
+}
+
+// WalletBalancesResponse балансы пользователя по аккаунтам и в сумме
+type WalletBalancesResponse struct {
+	Accounts []AccountWalletBalance `json:"accounts"`
+	Totals   []CoinBalance          `json:"totals"`
+}
+
+// AccountOrder открытый ордер с указанием аккаунта, на котором он выставлен
+type AccountOrder struct {
+	AccountID int64  `json:"account_id"`
+	Label     string `json:"label"`
+	bybit.BybitOrder
+}
+
+// SecretsReencryptResult итог перешифрования API-секретов
+type SecretsReencryptResult struct {
+	ActiveKeyVersion int `json:"active_key_version"`
+	Encrypted        int `json:"encrypted"` // Открытые секреты, которые были зашифрованы
+	Rewrapped        int `json:"rewrapped"` // Секреты, перешифрованные с прежнего ключа
+	Skipped          int `json:"skipped"`   // Записи, измененные параллельно
+	Failed           int `json:"failed"`
+}
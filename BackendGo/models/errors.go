@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Виды ошибок, текст которых можно показать клиенту. Обработчики отвечают на них 404, 400, 409 и 401,
+// а на остальные ошибки — 500 без подробностей.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrInvalid      = errors.New("invalid request")
+	ErrConflict     = errors.New("conflict")
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// Error ошибка с понятным клиенту сообщением; Kind — один из видов выше
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// NotFound сообщает, что запрошенный объект не найден или принадлежит другому пользователю
+func NotFound(format string, args ...interface{}) error {
+	return &Error{Kind: ErrNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+// Invalid сообщает, что запрос не прошел проверку
+func Invalid(format string, args ...interface{}) error {
+	return &Error{Kind: ErrInvalid, Message: fmt.Sprintf(format, args...)}
+}
+
+// Conflict сообщает, что запрос противоречит текущему состоянию
+func Conflict(format string, args ...interface{}) error {
+	return &Error{Kind: ErrConflict, Message: fmt.Sprintf(format, args...)}
+}
+
+// Unauthorized сообщает, что учетные данные или токен не подошли
+func Unauthorized(format string, args ...interface{}) error {
+	return &Error{Kind: ErrUnauthorized, Message: fmt.Sprintf(format, args...)}
+}
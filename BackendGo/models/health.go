@@ -0,0 +1,95 @@
+package models
+
+import "time"
+
+// Состояния компонентов
+const (
+	HealthStatusOK       = "ok"
+	HealthStatusDegraded = "degraded"
+	HealthStatusDown     = "down"
+)
+
+// ComponentStatus представляет результат проверки внешней зависимости
+type ComponentStatus struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ReadinessStatus представляет ответ /readyz
+type ReadinessStatus struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+// SymbolStreamStatus представляет поток публичных данных по символу
+type SymbolStreamStatus struct {
+	Symbol        string     `json:"symbol"`
+	Status        string     `json:"status"`
+	Subscribed    bool       `json:"subscribed"`
+	LastMessageAt *time.Time `json:"last_message_at"`
+	AgeSeconds    *float64   `json:"age_seconds"`
+}
+
+// PublicSubscriptionsStatus представляет подписки публичного WebSocket-соединения
+type PublicSubscriptionsStatus struct {
+	Symbols  []string   `json:"symbols"`
+	Channels []string   `json:"channels"`
+	SyncedAt *time.Time `json:"synced_at"`       // Последняя успешная сверка с активными инструментами
+	Error    string     `json:"error,omitempty"` // Ошибка последней сверки
+	// Соединения пула и закрепленные за ними каналы
+	Connections []PublicConnectionStatus `json:"connections"`
+}
+
+// PublicConnectionStatus представляет одно соединение пула публичных подписок
+type PublicConnectionStatus struct {
+	Connected     bool       `json:"connected"`
+	Channels      []string   `json:"channels"`
+	LastMessageAt *time.Time `json:"last_message_at"`
+}
+
+// PublicWebSocketStatus представляет состояние публичного WebSocket-соединения
+type PublicWebSocketStatus struct {
+	Status        string                    `json:"status"`
+	Connected     bool                      `json:"connected"`
+	Symbols       []SymbolStreamStatus      `json:"symbols"`
+	Subscriptions PublicSubscriptionsStatus `json:"subscriptions"`
+}
+
+// PrivateWebSocketStatus представляет состояние приватного WebSocket-соединения аккаунта
+type PrivateWebSocketStatus struct {
+	AccountID     int64      `json:"account_id"`
+	UserID        string     `json:"user_id"`
+	Environment   string     `json:"environment"`
+	Connected     bool       `json:"connected"`
+	LastMessageAt *time.Time `json:"last_message_at"`
+	AgeSeconds    *float64   `json:"age_seconds"`
+}
+
+// InstrumentsStatus представляет состояние справочника инструментов
+type InstrumentsStatus struct {
+	Status        string     `json:"status"`
+	LastRefreshAt *time.Time `json:"last_refresh_at"`
+	AgeSeconds    *float64   `json:"age_seconds"`
+	Error         string     `json:"error,omitempty"`
+}
+
+// StrategiesStatus представляет число стратегий, запущенных в менеджере
+type StrategiesStatus struct {
+	Running int            `json:"running"`
+	Users   int            `json:"users"`
+	ByType  map[string]int `json:"by_type"`
+}
+
+// SystemStatus представляет подробное состояние сервиса
+type SystemStatus struct {
+	Status            string                   `json:"status"`
+	CheckedAt         time.Time                `json:"checked_at"`
+	UptimeSeconds     float64                  `json:"uptime_seconds"`
+	Database          ComponentStatus          `json:"database"`
+	Redis             ComponentStatus          `json:"redis"`
+	PublicWebSocket   PublicWebSocketStatus    `json:"public_websocket"`
+	PrivateWebSockets []PrivateWebSocketStatus `json:"private_websockets"`
+	Instruments       InstrumentsStatus        `json:"instruments"`
+	Strategies        StrategiesStatus         `json:"strategies"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+// Типы событий, о которых пользователь может получать уведомления
+const (
+	EventOrderFilled     = "order_filled"
+	EventStrategyStopped = "strategy_stopped"
+	EventWSDisconnected  = "ws_disconnected"
+	EventRiskLimitHit    = "risk_limit_hit"
+	EventDailySummary    = "daily_summary"
+	EventSecurityAlert   = "security_alert" // вход с нового IP, блокировка входа, повторное использование токена
+)
+
+// Каналы доставки уведомлений
+const (
+	ChannelTelegram = "telegram"
+	ChannelWebhook  = "webhook"
+)
+
+// NotificationEventTypes перечисляет все поддерживаемые типы событий
+var NotificationEventTypes = []string{
+	EventOrderFilled,
+	EventStrategyStopped,
+	EventWSDisconnected,
+	EventRiskLimitHit,
+	EventDailySummary,
+	EventSecurityAlert,
+}
+
+// NotificationEvent представляет событие, которое нужно доставить пользователю
+type NotificationEvent struct {
+	Type    string            `json:"type"`
+	UserID  string            `json:"user_id"`
+	Title   string            `json:"title"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+	Time    time.Time         `json:"time"`
+}
+
+// NotificationChannel представляет настроенный канал доставки пользователя
+type NotificationChannel struct {
+	ID        string     `json:"id" db:"id"`
+	UserID    string     `json:"user_id" db:"user_id"`
+	Channel   string     `json:"channel" db:"channel"`
+	Target    string     `json:"target" db:"target"`
+	IsActive  bool       `json:"is_active" db:"is_active"`
+	CreatedAt *time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// NotificationPreference определяет, включен ли тип события для канала
+type NotificationPreference struct {
+	EventType string `json:"event_type" db:"event_type"`
+	Channel   string `json:"channel" db:"channel"`
+	IsEnabled bool   `json:"is_enabled" db:"is_enabled"`
+}
+
+// NotificationSettingsResponse представляет ответ с настройками уведомлений
+type NotificationSettingsResponse struct {
+	Channels    []NotificationChannel    `json:"channels"`
+	Preferences []NotificationPreference `json:"preferences"`
+	EventTypes  []string                 `json:"event_types"`
+}
+
+// UpsertNotificationChannelRequest представляет запрос на настройку канала
+type UpsertNotificationChannelRequest struct {
+	Channel  string `json:"channel" validate:"required,oneof=telegram webhook"`
+	Target   string `json:"target" validate:"required"`
+	IsActive bool   `json:"is_active"`
+}
+
+// UpdateNotificationPreferenceRequest представляет запрос на изменение подписки
+type UpdateNotificationPreferenceRequest struct {
+	EventType string `json:"event_type" validate:"required"`
+	Channel   string `json:"channel" validate:"required"`
+	IsEnabled bool   `json:"is_enabled"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// Типы сообщений потока /api/v1/stream
+const (
+	StreamEventOrder     = "order"     // изменение статуса ордера
+	StreamEventExecution = "execution" // исполнение ордера
+	StreamEventWallet    = "wallet"    // изменение баланса аккаунта
+	StreamEventStrategy  = "strategy"  // изменение жизненного цикла стратегии
+	StreamEventPnL       = "pnl"       // результат торговли по инструменту после исполнения
+	StreamEventTicker    = "ticker"    // тикер инструмента, по подписке
+	StreamEventOrderBook = "orderbook" // книга ордеров инструмента целиком, по подписке
+
+	StreamReplySubscribed   = "subscribed"
+	StreamReplyUnsubscribed = "unsubscribed"
+	StreamReplyPong         = "pong"
+	StreamReplyError        = "error"
+)
+
+// Операции, которые клиент отправляет в поток
+const (
+	StreamOpSubscribe   = "subscribe"
+	StreamOpUnsubscribe = "unsubscribe"
+	StreamOpPing        = "ping"
+)
+
+// Префиксы публичных топиков; за префиксом следует символ, например tickers.BTCUSDT
+const (
+	StreamTopicTickers   = "tickers."
+	StreamTopicOrderBook = "orderbook."
+)
+
+// StreamMessage событие, которое сервер отправляет клиенту потока
+type StreamMessage struct {
+	Type      string      `json:"type"`
+	Topic     string      `json:"topic,omitempty"`      // Для публичных данных
+	AccountID int64       `json:"account_id,omitempty"` // Для событий аккаунта Bybit
+	Time      time.Time   `json:"ts"`
+	Data      interface{} `json:"data"`
+}
+
+// StreamRequest команда клиента потока
+type StreamRequest struct {
+	ID     string   `json:"id,omitempty"` // Возвращается в ответе, чтобы клиент сопоставил его с командой
+	Op     string   `json:"op"`
+	Topics []string `json:"topics,omitempty"`
+}
+
+// StreamReply ответ на команду клиента
+type StreamReply struct {
+	Type    string   `json:"type"`
+	ID      string   `json:"id,omitempty"`
+	Topics  []string `json:"topics,omitempty"` // Действующие подписки после команды
+	Code    string   `json:"code,omitempty"`
+	Message string   `json:"message,omitempty"`
+}
+
+// StreamPnLUpdate результат торговли по инструменту за скользящее окно, отправляется после каждого исполнения
+type StreamPnLUpdate struct {
+	SymbolPnL
+	RealizedPnL decimal.Decimal `json:"realized_pnl"`
+	From        time.Time       `json:"from"`
+	To          time.Time       `json:"to"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Права доступа привязанного чата Telegram
+const (
+	TelegramScopeRead  = "read"  // просмотр состояния
+	TelegramScopeTrade = "trade" // просмотр и управление стратегиями и ордерами
+)
+
+// TelegramChat представляет чат Telegram, привязанный к пользователю
+type TelegramChat struct {
+	ChatID    int64      `json:"chat_id" db:"chat_id"`
+	UserID    string     `json:"user_id" db:"user_id"`
+	Scope     string     `json:"scope" db:"scope"`
+	Username  string     `json:"username" db:"username"`
+	CreatedAt *time.Time `json:"created_at" db:"created_at"`
+}
+
+// TelegramLinkCode представляет одноразовый код привязки, хранящийся в Redis
+type TelegramLinkCode struct {
+	UserID string `json:"user_id"`
+	Scope  string `json:"scope"`
+}
+
+// CreateTelegramLinkRequest представляет запрос на получение кода привязки
+type CreateTelegramLinkRequest struct {
+	Scope string `json:"scope" validate:"oneof=read trade"`
+}
+
+// TelegramLinkResponse представляет ответ с кодом привязки
+type TelegramLinkResponse struct {
+	Code      string `json:"code"`
+	Command   string `json:"command"`
+	Scope     string `json:"scope"`
+	ExpiresIn int    `json:"expires_in"` // секунды
+}
@@ -0,0 +1,40 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// TradeSummary представляет агрегированную статистику сделок за период
+type TradeSummary struct {
+	Trades     int64           `json:"trades"`
+	BuyVolume  decimal.Decimal `json:"buy_volume"`
+	SellVolume decimal.Decimal `json:"sell_volume"`
+	Fees       decimal.Decimal `json:"fees"`
+}
+
+// AccountTradeSummary статистика сделок одного аккаунта Bybit за период
+type AccountTradeSummary struct {
+	AccountID int64 `json:"account_id"`
+	TradeSummary
+}
+
+// SymbolPnL представляет результат торговли по инструменту за период
+type SymbolPnL struct {
+	Symbol    string          `json:"symbol"`
+	Trades    int64           `json:"trades"`
+	BuyQty    decimal.Decimal `json:"buy_qty"`
+	BuyValue  decimal.Decimal `json:"buy_value"`
+	SellQty   decimal.Decimal `json:"sell_qty"`
+	SellValue decimal.Decimal `json:"sell_value"`
+	Fees      decimal.Decimal `json:"fees"` // в валюте котировки
+}
+
+// RealizedPnL рассчитывает реализованный результат по закрытому объему:
+// совпавшее количество покупок и продаж по средним ценам за вычетом комиссий
+func (p SymbolPnL) RealizedPnL() decimal.Decimal {
+	if p.BuyQty.IsZero() || p.SellQty.IsZero() {
+		return p.Fees.Neg()
+	}
+	matched := decimal.Min(p.BuyQty, p.SellQty)
+	avgBuy := p.BuyValue.Div(p.BuyQty)
+	avgSell := p.SellValue.Div(p.SellQty)
+	return matched.Mul(avgSell.Sub(avgBuy)).Sub(p.Fees)
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// UserTOTP настройки второго фактора пользователя с расшифрованным секретом
+type UserTOTP struct {
+	UserID       string     `json:"user_id" db:"user_id"`
+	Secret       string     `json:"-" db:"-"` // base32, как его показывают приложению-аутентификатору
+	LastUsedStep int64      `json:"-" db:"last_used_step"`
+	ConfirmedAt  *time.Time `json:"confirmed_at" db:"confirmed_at"` // nil — настройка не завершена
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
+
+// TwoFactorStatus состояние двухфакторной аутентификации пользователя
+type TwoFactorStatus struct {
+	Enabled           bool       `json:"enabled"`
+	EnabledAt         *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left"`
+}
+
+// TwoFactorSetupResponse секрет для приложения-аутентификатора.
+// ProvisioningURI отображается клиентом в виде QR-кода.
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// TwoFactorCodeRequest код из приложения-аутентификатора или код восстановления
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// DisableTwoFactorRequest отключение второго фактора требует пароль и действующий код
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// RecoveryCodesResponse коды восстановления показываются один раз
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -4,6 +4,12 @@ import (
 	"time"
 )
 
+// Роли пользователей совпадают с именами в user_types
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
 type UserType struct {
 	ID          string     `json:"id"`
 	Name        string     `json:"name"`
@@ -16,10 +22,12 @@ type UserType struct {
 type User struct {
 	ID              string     `json:"id"`
 	UserTypeID      string     `json:"user_type_id"`
+	Role            string     `json:"role"`
 	Nickname        string     `json:"nickname"`
 	Email           string     `json:"email"`
 	Password        string     `json:"-"`
 	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	DisabledAt      *time.Time `json:"disabled_at"`
 	CreatedAt       *time.Time `json:"-"`
 	UpdatedAt       *time.Time `json:"-"`
 	DeletedAt       *time.Time `json:"-"`
@@ -35,20 +43,37 @@ type RegisterRequest struct {
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	OTPCode  string `json:"otp_code,omitempty"` // Код TOTP или код восстановления, если включена двухфакторная аутентификация
 }
 
 type RegisterResponse struct {
-	User  User   `json:"user"`
-	Token string `json:"token"`
+	User         User   `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
 }
 
 type LoginResponse struct {
-	AccessToken string `json:"access_token"`
-	TokenType   string `json:"token_type"`
-	ExpiresIn   int    `json:"expires_in"`
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"` // Секунды до окончания сессии
 }
 
 type LogoutResponse struct {
 	Status  string `json:"status"`
 	Message string `json:"message"`
-} 
\ No newline at end of file
+} 
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ConfirmPasswordResetRequest struct {
+	Token                string `json:"token" validate:"required"`
+	Password             string `json:"password" validate:"required,min=8"`
+	PasswordConfirmation string `json:"password_confirmation" validate:"required,eqfield=Password"`
+}
@@ -21,7 +21,7 @@ type CreateUserInstrumentRequest struct {
 
 // UpdateUserInstrumentRequest представляет запрос на обновление связи
 type UpdateUserInstrumentRequest struct {
-	ID       string `json:"id" validate:"required"`
+	ID       string `json:"-"` // Из пути запроса
 	IsActive bool   `json:"is_active" validate:"required"`
 }
 
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// UserSession сессия пользователя, которая продлевается refresh-токеном до ExpiresAt
+type UserSession struct {
+	ID                string     `json:"id"`
+	UserID            string     `json:"-"`
+	RefreshTokenHash  string     `json:"-"`
+	PreviousTokenHash string     `json:"-"`
+	UserAgent         string     `json:"user_agent"`
+	IPAddress         string     `json:"ip_address"`
+	CreatedAt         time.Time  `json:"created_at"`
+	LastUsedAt        time.Time  `json:"last_used_at"`
+	ExpiresAt         time.Time  `json:"expires_at"`
+	RevokedAt         *time.Time `json:"-"`
+	Current           bool       `json:"current"` // Сессия, которой принадлежит токен запроса
+}
+
+// SessionMeta сведения о клиенте, открывшем или обновившем сессию
+type SessionMeta struct {
+	UserAgent string
+	IPAddress string
+}
+
+// RefreshTokenRequest запрос на обмен refresh-токена на новую пару токенов
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
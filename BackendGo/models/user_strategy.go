@@ -3,29 +3,32 @@ package models
 import "time"
 
 type UserStrategy struct {
-	ID           string     `json:"id" db:"id"`
-	UserID       string     `json:"user_id" db:"user_id"`
-	StrategyName string     `json:"strategy_name" db:"strategy_name"`
-	IsActive     bool       `json:"is_active" db:"is_active"`
-	CreatedAt    *time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    *time.Time `json:"updated_at" db:"updated_at"`
-	DeletedAt    *time.Time `json:"deleted_at" db:"deleted_at"`
+	ID             string     `json:"id" db:"id"`
+	UserID         string     `json:"user_id" db:"user_id"`
+	BybitAccountID *int64     `json:"bybit_account_id" db:"bybit_account_id"` // Аккаунт, на котором торгует стратегия
+	StrategyName   string     `json:"strategy_name" db:"strategy_name"`
+	IsActive       bool       `json:"is_active" db:"is_active"`
+	CreatedAt      *time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      *time.Time `json:"updated_at" db:"updated_at"`
+	DeletedAt      *time.Time `json:"deleted_at" db:"deleted_at"`
 }
 
 type CreateUserStrategyRequest struct {
-	StrategyName string `json:"strategy_name" validate:"required"`
+	StrategyName   string `json:"strategy_name" validate:"required"`
+	BybitAccountID int64  `json:"bybit_account_id"` // Можно не указывать, если у пользователя один активный аккаунт
 }
 
 type UpdateUserStrategyRequest struct {
-	ID       string `json:"id" validate:"required"`
+	ID       string `json:"-"` // Из пути запроса
 	IsActive bool   `json:"is_active" validate:"required"`
 }
 
 type UserStrategyResponse struct {
-	ID           string     `json:"id"`
-	UserID       string     `json:"user_id"`
-	StrategyName string     `json:"strategy_name"`
-	IsActive     bool       `json:"is_active"`
-	CreatedAt    *time.Time `json:"created_at"`
-	UpdatedAt    *time.Time `json:"updated_at"`
-} 
\ No newline at end of file
+	ID             string     `json:"id"`
+	UserID         string     `json:"user_id"`
+	BybitAccountID *int64     `json:"bybit_account_id"`
+	StrategyName   string     `json:"strategy_name"`
+	IsActive       bool       `json:"is_active"`
+	CreatedAt      *time.Time `json:"created_at"`
+	UpdatedAt      *time.Time `json:"updated_at"`
+}
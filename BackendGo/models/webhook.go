@@ -0,0 +1,88 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Типы событий исходящих вебхуков
+const (
+	WebhookEventExecution = "execution"    // исполнение ордера
+	WebhookEventOrder     = "order"        // изменение статуса ордера
+	WebhookEventStrategy  = "strategy"     // изменение жизненного цикла стратегии
+	WebhookEventTest      = "webhook.test" // проверка эндпоинта
+)
+
+// WebhookEventTypes перечисляет типы событий, на которые можно подписать эндпоинт
+var WebhookEventTypes = []string{
+	WebhookEventExecution,
+	WebhookEventOrder,
+	WebhookEventStrategy,
+}
+
+// Статусы доставки вебхука
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookEndpoint представляет эндпоинт пользователя для исходящих вебхуков
+type WebhookEndpoint struct {
+	ID         string     `json:"id" db:"id"`
+	UserID     string     `json:"user_id" db:"user_id"`
+	URL        string     `json:"url" db:"url"`
+	EventTypes []string   `json:"event_types" db:"event_types"` // пустой список — все события
+	IsActive   bool       `json:"is_active" db:"is_active"`
+	CreatedAt  *time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WebhookDelivery представляет попытку доставки события на эндпоинт
+type WebhookDelivery struct {
+	ID             string          `json:"id" db:"id"`
+	EndpointID     string          `json:"endpoint_id" db:"endpoint_id"`
+	UserID         string          `json:"user_id" db:"user_id"`
+	EventType      string          `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Status         string          `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at" db:"next_attempt_at"`
+	LastStatusCode *int            `json:"last_status_code,omitempty" db:"last_status_code"`
+	LastError      *string         `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      *time.Time      `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty" db:"delivered_at"`
+
+	// Заполняются при выборке из очереди
+	URL    string `json:"-" db:"url"`
+	Secret string `json:"-" db:"secret"`
+}
+
+// WebhookPayload представляет тело запроса, отправляемого на эндпоинт
+type WebhookPayload struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	UserID    string      `json:"user_id"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// StrategyLifecycleEvent представляет данные события жизненного цикла стратегии
+type StrategyLifecycleEvent struct {
+	StrategyID   string `json:"strategy_id"`
+	StrategyName string `json:"strategy_name"`
+	Action       string `json:"action"` // added, started, stopped, removed
+}
+
+// CreateWebhookEndpointRequest представляет запрос на регистрацию эндпоинта
+type CreateWebhookEndpointRequest struct {
+	URL        string   `json:"url" validate:"required"`
+	Secret     string   `json:"secret"` // если не задан, генерируется сервером
+	EventTypes []string `json:"event_types"`
+}
+
+// CreateWebhookEndpointResponse представляет ответ с секретом, который показывается только один раз
+type CreateWebhookEndpointResponse struct {
+	Endpoint *WebhookEndpoint `json:"endpoint"`
+	Secret   string           `json:"secret"`
+}
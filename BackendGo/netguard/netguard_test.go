@@ -0,0 +1,80 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := IsPublicIP(net.ParseIP(tt.ip)); got != tt.public {
+			t.Errorf("IsPublicIP(%s) = %t, want %t", tt.ip, got, tt.public)
+		}
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url       string
+		ok        bool
+		forbidden bool
+	}{
+		{"https://8.8.8.8/hook", true, false},
+		{"http://127.0.0.1:8080/hook", false, true},
+		{"http://[::1]/hook", false, true},
+		{"http://169.254.169.254/latest/meta-data/", false, true},
+		{"http://localhost/hook", false, true},
+		{"ftp://8.8.8.8/hook", false, false},
+		{"/relative", false, false},
+	}
+	for _, tt := range tests {
+		err := ValidateURL(context.Background(), tt.url)
+		if (err == nil) != tt.ok {
+			t.Errorf("ValidateURL(%s) error = %v, want ok=%t", tt.url, err, tt.ok)
+		}
+		if errors.Is(err, ErrForbiddenAddress) != tt.forbidden {
+			t.Errorf("ValidateURL(%s) error = %v, want forbidden=%t", tt.url, err, tt.forbidden)
+		}
+	}
+}
+
+func TestNewClientRefusesLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached a loopback server")
+	}))
+	defer server.Close()
+
+	resp, err := NewClient(5 * time.Second).Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected the request to be refused")
+	}
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("expected ErrForbiddenAddress, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package notifications
+
+import (
+	"CryptoLens_Backend/integration/telegram"
+	"CryptoLens_Backend/models"
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TelegramChannel доставляет уведомления через Telegram Bot API
+type TelegramChannel struct {
+	client telegram.Client
+}
+
+// NewTelegramChannel создает канал доставки через Telegram
+func NewTelegramChannel(client telegram.Client) *TelegramChannel {
+	return &TelegramChannel{client: client}
+}
+
+// Name возвращает имя канала
+func (c *TelegramChannel) Name() string {
+	return models.ChannelTelegram
+}
+
+// Send отправляет событие в чат, target - chat_id получателя
+func (c *TelegramChannel) Send(ctx context.Context, target string, event models.NotificationEvent) error {
+	if err := c.client.SendMessage(ctx, target, FormatText(event)); err != nil {
+		return fmt.Errorf("failed to send telegram message: %w", err)
+	}
+	return nil
+}
+
+// FormatText форматирует событие в HTML-текст для Telegram
+func FormatText(event models.NotificationEvent) string {
+	var b strings.Builder
+	b.WriteString("<b>")
+	b.WriteString(html.EscapeString(event.Title))
+	b.WriteString("</b>")
+	if event.Message != "" {
+		b.WriteString("\n")
+		b.WriteString(html.EscapeString(event.Message))
+	}
+
+	// Сортируем поля, чтобы порядок в сообщении был стабильным
+	keys := make([]string, 0, len(event.Fields))
+	for k := range event.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteString(fmt.Sprintf("\n%s: <code>%s</code>", html.EscapeString(k), html.EscapeString(event.Fields[k])))
+	}
+
+	b.WriteString("\n<i>")
+	b.WriteString(event.Time.UTC().Format(time.RFC3339))
+	b.WriteString("</i>")
+	return b.String()
+}
@@ -0,0 +1,57 @@
+package notifications
+
+import (
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/netguard"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WebhookChannel доставляет уведомления POST-запросом с JSON на URL пользователя
+type WebhookChannel struct {
+	httpClient *http.Client
+}
+
+// NewWebhookChannel создает канал доставки через вебхук. Запросы уходят только на публичные адреса.
+func NewWebhookChannel(timeout time.Duration) *WebhookChannel {
+	return &WebhookChannel{
+		httpClient: netguard.NewClient(timeout),
+	}
+}
+
+// Name возвращает имя канала
+func (c *WebhookChannel) Name() string {
+	return models.ChannelWebhook
+}
+
+// Send отправляет событие на URL получателя
+func (c *WebhookChannel) Send(ctx context.Context, target string, event models.NotificationEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CryptoLens-Notifications/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
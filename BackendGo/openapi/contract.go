@@ -0,0 +1,353 @@
+package openapi
+
+import (
+	"CryptoLens_Backend/httpapi"
+	"CryptoLens_Backend/integration/bybit"
+	"CryptoLens_Backend/models"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// schemaTypes сопоставляет схемы спецификации с типами, которые кодируют обработчики
+var schemaTypes = map[string]reflect.Type{
+	"Error":                        reflect.TypeOf(httpapi.ErrorResponse{}),
+	"ErrorBody":                    reflect.TypeOf(httpapi.ErrorBody{}),
+	"User":                         reflect.TypeOf(models.User{}),
+	"RegisterRequest":              reflect.TypeOf(models.RegisterRequest{}),
+	"RegisterResponse":             reflect.TypeOf(models.RegisterResponse{}),
+	"LoginRequest":                 reflect.TypeOf(models.LoginRequest{}),
+	"LoginResponse":                reflect.TypeOf(models.LoginResponse{}),
+	"LogoutResponse":               reflect.TypeOf(models.LogoutResponse{}),
+	"RefreshTokenRequest":          reflect.TypeOf(models.RefreshTokenRequest{}),
+	"UserSession":                  reflect.TypeOf(models.UserSession{}),
+	"VerifyEmailRequest":           reflect.TypeOf(models.VerifyEmailRequest{}),
+	"PasswordResetRequest":         reflect.TypeOf(models.PasswordResetRequest{}),
+	"ConfirmPasswordResetRequest":  reflect.TypeOf(models.ConfirmPasswordResetRequest{}),
+	"TwoFactorStatus":              reflect.TypeOf(models.TwoFactorStatus{}),
+	"TwoFactorSetupResponse":       reflect.TypeOf(models.TwoFactorSetupResponse{}),
+	"TwoFactorCodeRequest":         reflect.TypeOf(models.TwoFactorCodeRequest{}),
+	"DisableTwoFactorRequest":      reflect.TypeOf(models.DisableTwoFactorRequest{}),
+	"RecoveryCodesResponse":        reflect.TypeOf(models.RecoveryCodesResponse{}),
+	"APIKey":                       reflect.TypeOf(models.APIKey{}),
+	"CreateAPIKeyRequest":          reflect.TypeOf(models.CreateAPIKeyRequest{}),
+	"CreateAPIKeyResponse":         reflect.TypeOf(models.CreateAPIKeyResponse{}),
+	"CreateUserInstrumentRequest":  reflect.TypeOf(models.CreateUserInstrumentRequest{}),
+	"UpdateUserInstrumentRequest":  reflect.TypeOf(models.UpdateUserInstrumentRequest{}),
+	"UserInstrumentResponse":       reflect.TypeOf(models.UserInstrumentResponse{}),
+	"BybitInstrument":              reflect.TypeOf(models.BybitInstrument{}),
+	"BybitInstrumentResponse":      reflect.TypeOf(models.BybitInstrumentResponse{}),
+	"CreateUserStrategyRequest":    reflect.TypeOf(models.CreateUserStrategyRequest{}),
+	"UpdateUserStrategyRequest":    reflect.TypeOf(models.UpdateUserStrategyRequest{}),
+	"UserStrategyResponse":         reflect.TypeOf(models.UserStrategyResponse{}),
+	"BybitAccountResponse":         reflect.TypeOf(models.BybitAccountResponse{}),
+	"CreateBybitAccountRequest":    reflect.TypeOf(models.CreateBybitAccountRequest{}),
+	"RotateBybitAccountRequest":    reflect.TypeOf(models.RotateBybitAccountRequest{}),
+	"SetBybitAccountStatusRequest": reflect.TypeOf(models.SetBybitAccountStatusRequest{}),
+	"RemoveBybitAccountRequest":    reflect.TypeOf(models.RemoveBybitAccountRequest{}),
+	"WalletBalance":                reflect.TypeOf(bybit.BybitWalletBalance{}),
+	"AccountBalance":               reflect.TypeOf(bybit.BybitAccountBalance{}),
+	"CoinBalanceDetail":            reflect.TypeOf(bybit.BybitCoinBalance{}),
+	"AccountWalletBalance":         reflect.TypeOf(models.AccountWalletBalance{}),
+	"CoinBalance":                  reflect.TypeOf(models.CoinBalance{}),
+	"WalletBalancesResponse":       reflect.TypeOf(models.WalletBalancesResponse{}),
+	"FeeRateResponse":              reflect.TypeOf(bybit.BybitFeeRateResponse{}),
+	"FeeRate":                      reflect.TypeOf(bybit.BybitFeeRate{}),
+	"StreamMessage":                reflect.TypeOf(models.StreamMessage{}),
+	"StreamRequest":                reflect.TypeOf(models.StreamRequest{}),
+	"StreamReply":                  reflect.TypeOf(models.StreamReply{}),
+	"StreamPnLUpdate":              reflect.TypeOf(models.StreamPnLUpdate{}),
+
+	"NotificationChannel":                 reflect.TypeOf(models.NotificationChannel{}),
+	"NotificationPreference":              reflect.TypeOf(models.NotificationPreference{}),
+	"NotificationSettingsResponse":        reflect.TypeOf(models.NotificationSettingsResponse{}),
+	"UpsertNotificationChannelRequest":    reflect.TypeOf(models.UpsertNotificationChannelRequest{}),
+	"UpdateNotificationPreferenceRequest": reflect.TypeOf(models.UpdateNotificationPreferenceRequest{}),
+	"TelegramChat":                        reflect.TypeOf(models.TelegramChat{}),
+	"CreateTelegramLinkRequest":           reflect.TypeOf(models.CreateTelegramLinkRequest{}),
+	"TelegramLinkResponse":                reflect.TypeOf(models.TelegramLinkResponse{}),
+	"WebhookEndpoint":                     reflect.TypeOf(models.WebhookEndpoint{}),
+	"WebhookDelivery":                     reflect.TypeOf(models.WebhookDelivery{}),
+	"CreateWebhookEndpointRequest":        reflect.TypeOf(models.CreateWebhookEndpointRequest{}),
+	"CreateWebhookEndpointResponse":       reflect.TypeOf(models.CreateWebhookEndpointResponse{}),
+	"LogLevelRequest":                     reflect.TypeOf(models.LogLevelRequest{}),
+	"LogLevelResponse":                    reflect.TypeOf(models.LogLevelResponse{}),
+	"AdminUserResponse":                   reflect.TypeOf(models.AdminUserResponse{}),
+	"SetUserDisabledRequest":              reflect.TypeOf(models.SetUserDisabledRequest{}),
+	"AdminStopStrategyRequest":            reflect.TypeOf(models.AdminStopStrategyRequest{}),
+	"AuthAuditEntry":                      reflect.TypeOf(models.AuthAuditEntry{}),
+	"SystemStatus":                        reflect.TypeOf(models.SystemStatus{}),
+	"ComponentStatus":                     reflect.TypeOf(models.ComponentStatus{}),
+	"PublicWebSocketStatus":               reflect.TypeOf(models.PublicWebSocketStatus{}),
+	"SymbolStreamStatus":                  reflect.TypeOf(models.SymbolStreamStatus{}),
+	"PublicSubscriptionsStatus":           reflect.TypeOf(models.PublicSubscriptionsStatus{}),
+	"PublicConnectionStatus":              reflect.TypeOf(models.PublicConnectionStatus{}),
+	"PrivateWebSocketStatus":              reflect.TypeOf(models.PrivateWebSocketStatus{}),
+	"InstrumentsStatus":                   reflect.TypeOf(models.InstrumentsStatus{}),
+	"StrategiesStatus":                    reflect.TypeOf(models.StrategiesStatus{}),
+}
+
+// document часть документа OpenAPI, которую проверяет сверка
+type document struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]*schema `json:"schemas"`
+	} `json:"components"`
+}
+
+type schema struct {
+	Ref        string             `json:"$ref"`
+	Type       string             `json:"type"`
+	Items      *schema            `json:"items"`
+	Properties map[string]*schema `json:"properties"`
+}
+
+const schemaRefPrefix = "#/components/schemas/"
+
+var pathMethods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true, "patch": true, "head": true, "options": true,
+}
+
+func parse() (*document, error) {
+	var doc document
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	return &doc, nil
+}
+
+// Operations возвращает операции спецификации в виде шаблонов роутера: "GET /api/v1/user/strategies/{id}"
+func Operations() ([]string, error) {
+	doc, err := parse()
+	if err != nil {
+		return nil, err
+	}
+	var operations []string
+	for path, item := range doc.Paths {
+		for method := range item {
+			if pathMethods[method] {
+				operations = append(operations, strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+	sort.Strings(operations)
+	return operations, nil
+}
+
+// CheckRoutes сверяет шаблоны роутера со спецификацией и возвращает найденные расхождения.
+// Маршруты вне /api/ (метрики, проверка состояния) не проверяются.
+func CheckRoutes(patterns []string) ([]string, error) {
+	operations, err := Operations()
+	if err != nil {
+		return nil, err
+	}
+	var documented []string
+	for _, pattern := range patterns {
+		_, path, _ := strings.Cut(pattern, " ")
+		if isDocumented(path) {
+			documented = append(documented, pattern)
+		}
+	}
+	return diff(operations, documented, "registered route", "route"), nil
+}
+
+// CheckClient сверяет операции клиента API со спецификацией
+func CheckClient(clientOperations []string) ([]string, error) {
+	operations, err := Operations()
+	if err != nil {
+		return nil, err
+	}
+	return diff(operations, clientOperations, "client method", "client operation"), nil
+}
+
+func isDocumented(path string) bool {
+	return strings.HasPrefix(path, "/api/")
+}
+
+// diff сообщает об операциях спецификации, которых нет в actual, и наоборот
+func diff(operations, actual []string, missingWhat, extraWhat string) []string {
+	inSpec := make(map[string]bool, len(operations))
+	for _, op := range operations {
+		inSpec[op] = true
+	}
+	present := make(map[string]bool, len(actual))
+	var problems []string
+	for _, op := range actual {
+		present[op] = true
+		if !inSpec[op] {
+			problems = append(problems, fmt.Sprintf("%s %q is not described in the spec", extraWhat, op))
+		}
+	}
+	for _, op := range operations {
+		if !present[op] {
+			problems = append(problems, fmt.Sprintf("spec operation %q has no %s", op, missingWhat))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// CheckSchemas сверяет схемы спецификации с JSON-представлением моделей:
+// имена полей, их типы и ссылки на вложенные схемы
+func CheckSchemas() ([]string, error) {
+	doc, err := parse()
+	if err != nil {
+		return nil, err
+	}
+	var problems []string
+	for name, s := range doc.Components.Schemas {
+		typ, ok := schemaTypes[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("schema %s is not mapped to a Go type", name))
+			continue
+		}
+		problems = append(problems, compareObject(name, s, typ)...)
+	}
+	for name := range schemaTypes {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			problems = append(problems, fmt.Sprintf("schema %s is mapped to a Go type but missing in the spec", name))
+		}
+	}
+	problems = append(problems, checkRefs(doc)...)
+	sort.Strings(problems)
+	return problems, nil
+}
+
+// compareObject сравнивает свойства схемы с полями структуры, которые попадают в JSON
+func compareObject(where string, s *schema, typ reflect.Type) []string {
+	var problems []string
+	fields := jsonFields(typ)
+	for name, field := range fields {
+		prop, ok := s.Properties[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: field %q of %v is missing in the spec", where, name, typ))
+			continue
+		}
+		problems = append(problems, compareType(where+"."+name, prop, field)...)
+	}
+	for name := range s.Properties {
+		if _, ok := fields[name]; !ok {
+			problems = append(problems, fmt.Sprintf("%s: property %q is not encoded by %v", where, name, typ))
+		}
+	}
+	return problems
+}
+
+func compareType(where string, s *schema, typ reflect.Type) []string {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, schemaRefPrefix)
+		if want := schemaTypes[name]; want != typ {
+			return []string{fmt.Sprintf("%s: refers to %s (%v), but the field is %v", where, name, want, typ)}
+		}
+		return nil
+	}
+	kind := jsonKind(typ)
+	if kind == "" {
+		return nil
+	}
+	if s.Type != kind {
+		return []string{fmt.Sprintf("%s: type %q in the spec, %q in %v", where, s.Type, kind, typ)}
+	}
+	if kind == "array" && s.Items != nil {
+		return compareType(where+"[]", s.Items, typ.Elem())
+	}
+	return nil
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// jsonKind возвращает тип JSON Schema для значения Go. Типы со своим MarshalJSON (время, decimal)
+// кодируются строкой. Пустая строка — тип произвольный и не проверяется, как у json.RawMessage.
+func jsonKind(typ reflect.Type) string {
+	if typ == rawMessageType {
+		return ""
+	}
+	if typ == timeType || typ.Implements(marshalerType) || reflect.PointerTo(typ).Implements(marshalerType) {
+		return "string"
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return "string"
+		}
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return ""
+}
+
+// jsonFields возвращает поля структуры по именам в JSON, включая поля встроенных структур
+func jsonFields(typ reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for embeddedName, embeddedType := range jsonFields(field.Type) {
+				fields[embeddedName] = embeddedType
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// checkRefs находит ссылки на несуществующие схемы во всем документе
+func checkRefs(doc *document) []string {
+	var raw interface{}
+	if err := json.Unmarshal(spec, &raw); err != nil {
+		return []string{err.Error()}
+	}
+	var problems []string
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok && strings.HasPrefix(ref, schemaRefPrefix) {
+				if _, ok := doc.Components.Schemas[strings.TrimPrefix(ref, schemaRefPrefix)]; !ok {
+					problems = append(problems, fmt.Sprintf("dangling reference %s", ref))
+				}
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(raw)
+	return problems
+}
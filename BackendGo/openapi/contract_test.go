@@ -0,0 +1,38 @@
+package openapi_test
+
+import (
+	"CryptoLens_Backend/client"
+	"CryptoLens_Backend/openapi"
+	"CryptoLens_Backend/routes"
+	"testing"
+)
+
+func TestRoutesMatchSpec(t *testing.T) {
+	problems, err := openapi.CheckRoutes(routes.NewContractRouter().Patterns())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, problem := range problems {
+		t.Error(problem)
+	}
+}
+
+func TestClientMatchesSpec(t *testing.T) {
+	problems, err := openapi.CheckClient(client.Operations())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, problem := range problems {
+		t.Error(problem)
+	}
+}
+
+func TestSchemasMatchModels(t *testing.T) {
+	problems, err := openapi.CheckSchemas()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, problem := range problems {
+		t.Error(problem)
+	}
+}
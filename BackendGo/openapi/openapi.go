@@ -0,0 +1,26 @@
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// spec описание REST API в формате OpenAPI 3. Файл правится вручную вместе с обработчиками,
+// расхождения находит команда check-api-contract.
+//
+//go:embed openapi.json
+var spec []byte
+
+// Spec возвращает документ OpenAPI
+func Spec() []byte {
+	return spec
+}
+
+// Handler отдает документ OpenAPI
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write(spec)
+	})
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/types"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// AuthAuditRepository реализует интерфейс AuthAuditRepositoryInterface
+type AuthAuditRepository struct {
+	db *sql.DB
+}
+
+// NewAuthAuditRepository создает новый репозиторий журнала аутентификации
+func NewAuthAuditRepository(db *sql.DB) types.AuthAuditRepositoryInterface {
+	return &AuthAuditRepository{db: db}
+}
+
+// Record добавляет событие в журнал
+func (r *AuthAuditRepository) Record(ctx context.Context, entry models.AuthAuditEntry) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO auth_audit_log (user_id, email, event, ip_address, user_agent)
+		VALUES ($1, $2, $3, $4, $5)`,
+		entry.UserID, truncate(entry.Email, 255), entry.Event, truncate(entry.IPAddress, 64), truncate(entry.UserAgent, 255),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record auth event: %w", err)
+	}
+	return nil
+}
+
+// GetLoginHistory сообщает, входил ли пользователь раньше и встречался ли среди его входов этот IP.
+// Регистрация считается первым входом.
+func (r *AuthAuditRepository) GetLoginHistory(ctx context.Context, userID, ipAddress string) (hasHistory bool, knownIP bool, err error) {
+	err = r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) > 0, COALESCE(bool_or(ip_address = $2), false)
+		FROM auth_audit_log
+		WHERE user_id = $1 AND event IN ($3, $4)`,
+		userID, ipAddress, models.AuthEventRegistered, models.AuthEventLoginSucceeded,
+	).Scan(&hasHistory, &knownIP)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to get login history: %w", err)
+	}
+	return hasHistory, knownIP, nil
+}
+
+// List возвращает последние события журнала. Если userID пуст, возвращаются события всех пользователей.
+func (r *AuthAuditRepository) List(ctx context.Context, userID string, limit int) ([]models.AuthAuditEntry, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, user_id, email, event, ip_address, user_agent, created_at
+		FROM auth_audit_log
+		WHERE $1 = '' OR user_id::text = $1
+		ORDER BY created_at DESC
+		LIMIT $2`,
+		userID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query auth events: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuthAuditEntry{}
+	for rows.Next() {
+		var entry models.AuthAuditEntry
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.UserID,
+			&entry.Email,
+			&entry.Event,
+			&entry.IPAddress,
+			&entry.UserAgent,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan auth event: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -1,112 +1,258 @@
 package repositories
 
 import (
+	"CryptoLens_Backend/encryption"
 	"CryptoLens_Backend/integration/bybit"
+	"CryptoLens_Backend/models"
 	"CryptoLens_Backend/types"
+	"CryptoLens_Shared/logger"
 	"context"
 	"database/sql"
 	"fmt"
 	"time"
 )
 
-// BybitAccountRepository реализует интерфейс BybitAccountRepositoryInterface
+// accountColumns колонки аккаунта, которые читаются вместе с зашифрованным секретом
+const accountColumns = `id, user_id, api_key, api_secret, api_secret_ciphertext, api_secret_dek, key_version, account_type, label, environment, is_active, created_at, updated_at`
+
+// BybitAccountRepository реализует интерфейс BybitAccountRepositoryInterface.
+// API-секрет хранится зашифрованным и расшифровывается только при чтении аккаунта.
 type BybitAccountRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	keyring *encryption.Keyring
 }
 
 // NewBybitAccountRepository создает новый репозиторий для работы с аккаунтами Bybit
-func NewBybitAccountRepository(db *sql.DB) types.BybitAccountRepositoryInterface {
-	return &BybitAccountRepository{db: db}
+func NewBybitAccountRepository(db *sql.DB, keyring *encryption.Keyring) types.BybitAccountRepositoryInterface {
+	return &BybitAccountRepository{db: db, keyring: keyring}
 }
 
-// GetActiveAccountByUserID получает активный аккаунт Bybit для пользователя
-func (r *BybitAccountRepository) GetActiveAccountByUserID(ctx context.Context, userID string) (*bybit.BybitAccount, error) {
+// scanAccount читает аккаунт и расшифровывает секрет.
+// Записи без key_version созданы до включения шифрования и хранят секрет открытым текстом.
+func (r *BybitAccountRepository) scanAccount(row rowScanner) (*bybit.BybitAccount, error) {
 	var account bybit.BybitAccount
-	err := r.db.QueryRowContext(ctx,
-		`SELECT id, user_id, api_key, api_secret, account_type, is_active 
-		FROM bybit_accounts 
-		WHERE user_id = $1 AND is_active = true AND deleted_at IS NULL`,
-		userID,
-	).Scan(
+	var plainSecret, environment sql.NullString
+	var ciphertext, wrappedKey []byte
+	var keyVersion sql.NullInt64
+	if err := row.Scan(
 		&account.ID,
 		&account.UserID,
 		&account.APIKey,
-		&account.APISecret,
+		&plainSecret,
+		&ciphertext,
+		&wrappedKey,
+		&keyVersion,
 		&account.AccountType,
+		&account.Label,
+		&environment,
 		&account.IsActive,
-	)
+		&account.CreatedAt,
+		&account.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	account.Environment = environment.String
+
+	if !keyVersion.Valid {
+		account.APISecret = plainSecret.String
+		return &account, nil
+	}
+	secret, err := r.keyring.Open(&encryption.Envelope{
+		KeyVersion: int(keyVersion.Int64),
+		WrappedKey: wrappedKey,
+		Ciphertext: ciphertext,
+	}, secretAAD(account.UserID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret of Bybit account %d: %w", account.ID, err)
+	}
+	account.APISecret = string(secret)
+	return &account, nil
+}
+
+// sealSecret шифрует секрет активным мастер-ключом
+func (r *BybitAccountRepository) sealSecret(userID, apiSecret string) (*encryption.Envelope, error) {
+	envelope, err := r.keyring.Seal([]byte(apiSecret), secretAAD(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt API secret: %w", err)
+	}
+	return envelope, nil
+}
+
+// secretAAD привязывает шифротекст к владельцу, чтобы секрет нельзя было перенести в чужую запись
+func secretAAD(userID string) []byte {
+	return []byte("bybit_accounts.api_secret:" + userID)
+}
+
+// GetActiveAccount получает активный аккаунт пользователя по ID.
+// Если id равен 0, возвращается единственный активный аккаунт; при нескольких аккаунтах ID обязателен.
+func (r *BybitAccountRepository) GetActiveAccount(ctx context.Context, userID string, id int64) (*bybit.BybitAccount, error) {
+	if id == 0 {
+		accounts, err := r.GetActiveAccountsByUserID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		switch len(accounts) {
+		case 0:
+			return nil, models.NotFound("Bybit account not found")
+		case 1:
+			return &accounts[0], nil
+		default:
+			return nil, models.Invalid("user has several active Bybit accounts, account_id is required")
+		}
+	}
+
+	account, err := r.scanAccount(r.db.QueryRowContext(ctx,
+		`SELECT `+accountColumns+`
+		FROM bybit_accounts 
+		WHERE id = $1 AND user_id = $2 AND is_active = true AND deleted_at IS NULL`,
+		id, userID,
+	))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("Bybit account not found for user %s", userID)
+			return nil, models.NotFound("active Bybit account %d not found", id)
 		}
 		return nil, fmt.Errorf("failed to get Bybit account: %w", err)
 	}
-	return &account, nil
+	return account, nil
 }
 
-// CreateAccount создает новый аккаунт Bybit для пользователя
-func (r *BybitAccountRepository) CreateAccount(ctx context.Context, userID string, apiKey, apiSecret, accountType string) (*bybit.BybitAccount, error) {
-	var account bybit.BybitAccount
-	now := time.Now()
-	err := r.db.QueryRowContext(ctx,
-		`INSERT INTO bybit_accounts (user_id, api_key, api_secret, account_type, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, true, $5, $6)
-		RETURNING id, user_id, api_key, api_secret, account_type, is_active`,
-		userID, apiKey, apiSecret, accountType, now, now,
-	).Scan(
-		&account.ID,
-		&account.UserID,
-		&account.APIKey,
-		&account.APISecret,
-		&account.AccountType,
-		&account.IsActive,
+// GetActiveAccountsByUserID получает все активные аккаунты пользователя
+func (r *BybitAccountRepository) GetActiveAccountsByUserID(ctx context.Context, userID string) ([]bybit.BybitAccount, error) {
+	return r.queryAccounts(ctx,
+		`SELECT `+accountColumns+`
+		FROM bybit_accounts
+		WHERE user_id = $1 AND is_active = true AND deleted_at IS NULL
+		ORDER BY id`,
+		userID,
 	)
+}
+
+// CreateAccount создает новый аккаунт Bybit для пользователя.
+// Пустое окружение сохраняется как NULL: аккаунт работает в окружении по умолчанию.
+func (r *BybitAccountRepository) CreateAccount(ctx context.Context, userID string, apiKey, apiSecret, accountType, label, environment string) (*bybit.BybitAccount, error) {
+	envelope, err := r.sealSecret(userID, apiSecret)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	account, err := r.scanAccount(r.db.QueryRowContext(ctx,
+		`INSERT INTO bybit_accounts (user_id, api_key, api_secret_ciphertext, api_secret_dek, key_version, account_type, label, environment, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), true, $9, $10)
+		RETURNING `+accountColumns,
+		userID, apiKey, envelope.Ciphertext, envelope.WrappedKey, envelope.KeyVersion, accountType, label, environment, now, now,
+	))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Bybit account: %w", err)
 	}
-	return &account, nil
+	return account, nil
 }
 
-// UpdateAccount обновляет аккаунт Bybit для пользователя
-func (r *BybitAccountRepository) UpdateAccount(ctx context.Context, userID string, apiKey, apiSecret, accountType string, isActive bool) error {
-	now := time.Now()
+// GetAccountsByUserID получает все неудаленные аккаунты пользователя
+func (r *BybitAccountRepository) GetAccountsByUserID(ctx context.Context, userID string) ([]bybit.BybitAccount, error) {
+	return r.queryAccounts(ctx,
+		`SELECT `+accountColumns+`
+		FROM bybit_accounts
+		WHERE user_id = $1 AND deleted_at IS NULL
+		ORDER BY id`,
+		userID,
+	)
+}
+
+// queryAccounts читает список аккаунтов пользователя; пустой результат возвращается пустым срезом
+func (r *BybitAccountRepository) queryAccounts(ctx context.Context, query string, args ...interface{}) ([]bybit.BybitAccount, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Bybit accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := []bybit.BybitAccount{}
+	for rows.Next() {
+		account, err := r.scanAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		accounts = append(accounts, *account)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate accounts: %w", err)
+	}
+	return accounts, nil
+}
+
+// GetAccount получает неудаленный аккаунт пользователя по ID
+func (r *BybitAccountRepository) GetAccount(ctx context.Context, userID string, id int64) (*bybit.BybitAccount, error) {
+	account, err := r.scanAccount(r.db.QueryRowContext(ctx,
+		`SELECT `+accountColumns+`
+		FROM bybit_accounts
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`,
+		id, userID,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.NotFound("Bybit account not found")
+		}
+		return nil, fmt.Errorf("failed to get Bybit account: %w", err)
+	}
+	return account, nil
+}
+
+// UpdateCredentials заменяет ключ и секрет аккаунта. Пустое окружение оставляет текущее.
+func (r *BybitAccountRepository) UpdateCredentials(ctx context.Context, userID string, id int64, apiKey, apiSecret, environment string) error {
+	envelope, err := r.sealSecret(userID, apiSecret)
+	if err != nil {
+		return err
+	}
 	result, err := r.db.ExecContext(ctx,
 		`UPDATE bybit_accounts 
-		SET api_key = $1, api_secret = $2, account_type = $3, is_active = $4, updated_at = $5
-		WHERE user_id = $6 AND deleted_at IS NULL`,
-		apiKey, apiSecret, accountType, isActive, now, userID,
+		SET api_key = $1, api_secret = NULL, api_secret_ciphertext = $2, api_secret_dek = $3, key_version = $4,
+			environment = COALESCE(NULLIF($5, ''), environment), updated_at = $6
+		WHERE id = $7 AND user_id = $8 AND deleted_at IS NULL`,
+		apiKey, envelope.Ciphertext, envelope.WrappedKey, envelope.KeyVersion, environment, time.Now(), id, userID,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update Bybit account: %w", err)
 	}
-	rowsAffected, err := result.RowsAffected()
+	return requireAccountAffected(result)
+}
+
+// SetActive включает или отключает аккаунт
+func (r *BybitAccountRepository) SetActive(ctx context.Context, userID string, id int64, isActive bool) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE bybit_accounts 
+		SET is_active = $1, updated_at = $2
+		WHERE id = $3 AND user_id = $4 AND deleted_at IS NULL`,
+		isActive, time.Now(), id, userID,
+	)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("Bybit account not found for user %s", userID)
+		return fmt.Errorf("failed to update Bybit account: %w", err)
 	}
-	return nil
+	return requireAccountAffected(result)
 }
 
-// DeleteAccount удаляет аккаунт Bybit для пользователя (soft delete)
-func (r *BybitAccountRepository) DeleteAccount(ctx context.Context, userID string) error {
+// DeleteAccount удаляет аккаунт (soft delete). Секрет стирается сразу: восстановить удаленный аккаунт нельзя.
+func (r *BybitAccountRepository) DeleteAccount(ctx context.Context, userID string, id int64) error {
 	now := time.Now()
 	result, err := r.db.ExecContext(ctx,
 		`UPDATE bybit_accounts 
-		SET deleted_at = $1, updated_at = $1
-		WHERE user_id = $2 AND deleted_at IS NULL`,
-		now, userID,
+		SET deleted_at = $1, updated_at = $1, is_active = false,
+			api_secret = NULL, api_secret_ciphertext = NULL, api_secret_dek = NULL, key_version = NULL
+		WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL`,
+		now, id, userID,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to delete Bybit account: %w", err)
 	}
+	return requireAccountAffected(result)
+}
+
+func requireAccountAffected(result sql.Result) error {
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 	if rowsAffected == 0 {
-		return fmt.Errorf("Bybit account not found for user %s", userID)
+		return models.NotFound("Bybit account not found")
 	}
 	return nil
 }
@@ -114,9 +260,10 @@ func (r *BybitAccountRepository) DeleteAccount(ctx context.Context, userID strin
 // GetActiveAccounts получает все активные аккаунты Bybit
 func (r *BybitAccountRepository) GetActiveAccounts(ctx context.Context) ([]bybit.BybitAccount, error) {
 	rows, err := r.db.QueryContext(ctx,
-		`SELECT id, user_id, api_key, api_secret, account_type, is_active 
+		`SELECT `+accountColumns+`
 		FROM bybit_accounts 
-		WHERE is_active = true AND deleted_at IS NULL`,
+		WHERE is_active = true AND deleted_at IS NULL
+		ORDER BY id`,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query active accounts: %w", err)
@@ -125,19 +272,110 @@ func (r *BybitAccountRepository) GetActiveAccounts(ctx context.Context) ([]bybit
 
 	var accounts []bybit.BybitAccount
 	for rows.Next() {
-		var account bybit.BybitAccount
-		if err := rows.Scan(
-			&account.ID,
-			&account.UserID,
-			&account.APIKey,
-			&account.APISecret,
-			&account.AccountType,
-			&account.IsActive,
-		); err != nil {
-			return nil, fmt.Errorf("failed to scan account: %w", err)
+		account, err := r.scanAccount(rows)
+		if err != nil {
+			// Аккаунт, секрет которого не удалось расшифровать, пропускаем, чтобы не останавливать остальных
+			logger.LogError("Не удалось прочитать аккаунт Bybit: %v", err)
+			continue
 		}
-		accounts = append(accounts, account)
+		accounts = append(accounts, *account)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate accounts: %w", err)
 	}
 
 	return accounts, nil
-} 
\ No newline at end of file
+}
+
+// CountSecretsToReencrypt возвращает число записей, секрет которых открыт или зашифрован неактивным ключом
+func (r *BybitAccountRepository) CountSecretsToReencrypt(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM bybit_accounts WHERE (key_version IS NULL AND api_secret IS NOT NULL) OR key_version <> $1`,
+		r.keyring.ActiveVersion(),
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count secrets to re-encrypt: %w", err)
+	}
+	return count, nil
+}
+
+// ReencryptSecrets шифрует открытые секреты и перешифровывает ключи данных активным мастер-ключом.
+// Обрабатываются и удаленные аккаунты: их секреты тоже не должны оставаться под старым ключом.
+// Запись обновляется, только если ее версия ключа не изменилась с момента чтения.
+func (r *BybitAccountRepository) ReencryptSecrets(ctx context.Context) (*models.SecretsReencryptResult, error) {
+	type pendingRow struct {
+		id          int64
+		userID      string
+		plainSecret sql.NullString
+		ciphertext  []byte
+		wrappedKey  []byte
+		keyVersion  sql.NullInt64
+	}
+
+	active := r.keyring.ActiveVersion()
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, user_id, api_secret, api_secret_ciphertext, api_secret_dek, key_version
+		FROM bybit_accounts
+		WHERE (key_version IS NULL AND api_secret IS NOT NULL) OR key_version <> $1
+		ORDER BY id`,
+		active,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query secrets to re-encrypt: %w", err)
+	}
+	var pending []pendingRow
+	for rows.Next() {
+		var row pendingRow
+		if err := rows.Scan(&row.id, &row.userID, &row.plainSecret, &row.ciphertext, &row.wrappedKey, &row.keyVersion); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		pending = append(pending, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate accounts: %w", err)
+	}
+
+	result := &models.SecretsReencryptResult{ActiveKeyVersion: active}
+	for _, row := range pending {
+		var envelope *encryption.Envelope
+		var err error
+		if row.keyVersion.Valid {
+			envelope, err = r.keyring.Rewrap(&encryption.Envelope{
+				KeyVersion: int(row.keyVersion.Int64),
+				WrappedKey: row.wrappedKey,
+				Ciphertext: row.ciphertext,
+			})
+		} else {
+			envelope, err = r.sealSecret(row.userID, row.plainSecret.String)
+		}
+		if err != nil {
+			logger.LogError("Не удалось перешифровать секрет аккаунта Bybit %d: %v", row.id, err)
+			result.Failed++
+			continue
+		}
+
+		res, err := r.db.ExecContext(ctx,
+			`UPDATE bybit_accounts
+			SET api_secret = NULL, api_secret_ciphertext = $1, api_secret_dek = $2, key_version = $3
+			WHERE id = $4 AND key_version IS NOT DISTINCT FROM $5`,
+			envelope.Ciphertext, envelope.WrappedKey, envelope.KeyVersion, row.id, row.keyVersion,
+		)
+		if err != nil {
+			return result, fmt.Errorf("failed to update account %d: %w", row.id, err)
+		}
+		if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+			// Запись изменили параллельно, она уже сохранена с актуальным ключом
+			result.Skipped++
+			continue
+		}
+		if row.keyVersion.Valid {
+			result.Rewrapped++
+		} else {
+			result.Encrypted++
+		}
+	}
+	return result, nil
+}
@@ -1,10 +1,12 @@
 package repositories
 
 import (
-	"CryptoLens_Backend/logger"
 	"CryptoLens_Backend/models"
+	"CryptoLens_Shared/logger"
 	"context"
 	"database/sql"
+	"fmt"
+	"time"
 )
 
 type BybitInstrumentRepository struct {
@@ -150,6 +152,19 @@ func (r *BybitInstrumentRepository) GetInstruments(ctx context.Context, category
 	return instruments, nil
 }
 
+// GetLastUpdatedAt возвращает время последнего обновления справочника инструментов.
+// Возвращает nil, если справочник пуст.
+func (r *BybitInstrumentRepository) GetLastUpdatedAt(ctx context.Context) (*time.Time, error) {
+	var updatedAt sql.NullTime
+	if err := r.db.QueryRowContext(ctx, `SELECT MAX(updated_at) FROM bybit_instruments`).Scan(&updatedAt); err != nil {
+		return nil, fmt.Errorf("failed to get instruments update time: %w", err)
+	}
+	if !updatedAt.Valid {
+		return nil, nil
+	}
+	return &updatedAt.Time, nil
+}
+
 // Exists проверяет существование инструмента по символу
 func (r *BybitInstrumentRepository) Exists(ctx context.Context, symbol string) (bool, error) {
 	query := `
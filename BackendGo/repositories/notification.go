@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/types"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// NotificationRepository реализует интерфейс NotificationRepositoryInterface
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+// NewNotificationRepository создает новый репозиторий настроек уведомлений
+func NewNotificationRepository(db *sql.DB) types.NotificationRepositoryInterface {
+	return &NotificationRepository{db: db}
+}
+
+// UpsertChannel создает или обновляет канал доставки пользователя
+func (r *NotificationRepository) UpsertChannel(ctx context.Context, userID, channel, target string, isActive bool) (*models.NotificationChannel, error) {
+	query := `
+		INSERT INTO notification_channels (user_id, channel, target, is_active)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, channel) DO UPDATE SET
+			target = EXCLUDED.target,
+			is_active = EXCLUDED.is_active,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING id, user_id, channel, target, is_active, created_at, updated_at`
+
+	var ch models.NotificationChannel
+	err := r.db.QueryRowContext(ctx, query, userID, channel, target, isActive).Scan(
+		&ch.ID,
+		&ch.UserID,
+		&ch.Channel,
+		&ch.Target,
+		&ch.IsActive,
+		&ch.CreatedAt,
+		&ch.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert notification channel: %w", err)
+	}
+	return &ch, nil
+}
+
+// GetChannelsByUserID получает все каналы пользователя
+func (r *NotificationRepository) GetChannelsByUserID(ctx context.Context, userID string) ([]models.NotificationChannel, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, user_id, channel, target, is_active, created_at, updated_at
+		FROM notification_channels
+		WHERE user_id = $1
+		ORDER BY channel`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification channels: %w", err)
+	}
+	defer rows.Close()
+
+	return scanNotificationChannels(rows)
+}
+
+// DeleteChannel удаляет канал пользователя
+func (r *NotificationRepository) DeleteChannel(ctx context.Context, userID, channel string) error {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM notification_channels WHERE user_id = $1 AND channel = $2`,
+		userID, channel,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete notification channel: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return models.NotFound("notification channel not found")
+	}
+	return nil
+}
+
+// SetPreference включает или выключает тип события для канала
+func (r *NotificationRepository) SetPreference(ctx context.Context, userID, eventType, channel string, isEnabled bool) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO notification_preferences (user_id, event_type, channel, is_enabled)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, event_type, channel) DO UPDATE SET
+			is_enabled = EXCLUDED.is_enabled,
+			updated_at = CURRENT_TIMESTAMP`,
+		userID, eventType, channel, isEnabled,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save notification preference: %w", err)
+	}
+	return nil
+}
+
+// GetPreferencesByUserID получает явно заданные подписки пользователя
+func (r *NotificationRepository) GetPreferencesByUserID(ctx context.Context, userID string) ([]models.NotificationPreference, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT event_type, channel, is_enabled
+		FROM notification_preferences
+		WHERE user_id = $1
+		ORDER BY event_type, channel`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification preferences: %w", err)
+	}
+	defer rows.Close()
+
+	var preferences []models.NotificationPreference
+	for rows.Next() {
+		var p models.NotificationPreference
+		if err := rows.Scan(&p.EventType, &p.Channel, &p.IsEnabled); err != nil {
+			return nil, fmt.Errorf("failed to scan notification preference: %w", err)
+		}
+		preferences = append(preferences, p)
+	}
+	return preferences, rows.Err()
+}
+
+// GetSubscribedChannels получает активные каналы пользователя, подписанные на тип события.
+// Если подписка для пары событие/канал не задана явно, событие считается включенным.
+func (r *NotificationRepository) GetSubscribedChannels(ctx context.Context, userID, eventType string) ([]models.NotificationChannel, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT c.id, c.user_id, c.channel, c.target, c.is_active, c.created_at, c.updated_at
+		FROM notification_channels c
+		LEFT JOIN notification_preferences p
+			ON p.user_id = c.user_id AND p.channel = c.channel AND p.event_type = $2
+		WHERE c.user_id = $1 AND c.is_active = true AND COALESCE(p.is_enabled, true)`,
+		userID, eventType,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subscribed channels: %w", err)
+	}
+	defer rows.Close()
+
+	return scanNotificationChannels(rows)
+}
+
+// GetUserIDsWithActiveChannels получает пользователей, у которых есть хотя бы один активный канал
+func (r *NotificationRepository) GetUserIDsWithActiveChannels(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT DISTINCT user_id FROM notification_channels WHERE is_active = true`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users with channels: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan user id: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+func scanNotificationChannels(rows *sql.Rows) ([]models.NotificationChannel, error) {
+	var channels []models.NotificationChannel
+	for rows.Next() {
+		var ch models.NotificationChannel
+		if err := rows.Scan(
+			&ch.ID,
+			&ch.UserID,
+			&ch.Channel,
+			&ch.Target,
+			&ch.IsActive,
+			&ch.CreatedAt,
+			&ch.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan notification channel: %w", err)
+		}
+		channels = append(channels, ch)
+	}
+	return channels, rows.Err()
+}
@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/types"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// TelegramChatRepository реализует интерфейс TelegramChatRepositoryInterface
+type TelegramChatRepository struct {
+	db *sql.DB
+}
+
+// NewTelegramChatRepository создает новый репозиторий привязанных чатов Telegram
+func NewTelegramChatRepository(db *sql.DB) types.TelegramChatRepositoryInterface {
+	return &TelegramChatRepository{db: db}
+}
+
+// Link привязывает чат к пользователю. Повторная привязка чата перезаписывает владельца и права.
+func (r *TelegramChatRepository) Link(ctx context.Context, chatID int64, userID, scope, username string) (*models.TelegramChat, error) {
+	var chat models.TelegramChat
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO telegram_chats (chat_id, user_id, scope, username)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (chat_id) DO UPDATE SET
+			user_id = EXCLUDED.user_id,
+			scope = EXCLUDED.scope,
+			username = EXCLUDED.username
+		RETURNING chat_id, user_id, scope, COALESCE(username, ''), created_at`,
+		chatID, userID, scope, username,
+	).Scan(&chat.ChatID, &chat.UserID, &chat.Scope, &chat.Username, &chat.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to link telegram chat: %w", err)
+	}
+	return &chat, nil
+}
+
+// GetByChatID получает привязку чата. Возвращает nil, если чат не привязан.
+func (r *TelegramChatRepository) GetByChatID(ctx context.Context, chatID int64) (*models.TelegramChat, error) {
+	var chat models.TelegramChat
+	err := r.db.QueryRowContext(ctx,
+		`SELECT chat_id, user_id, scope, COALESCE(username, ''), created_at
+		FROM telegram_chats
+		WHERE chat_id = $1`,
+		chatID,
+	).Scan(&chat.ChatID, &chat.UserID, &chat.Scope, &chat.Username, &chat.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get telegram chat: %w", err)
+	}
+	return &chat, nil
+}
+
+// GetByUserID получает все чаты пользователя
+func (r *TelegramChatRepository) GetByUserID(ctx context.Context, userID string) ([]models.TelegramChat, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT chat_id, user_id, scope, COALESCE(username, ''), created_at
+		FROM telegram_chats
+		WHERE user_id = $1
+		ORDER BY created_at`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query telegram chats: %w", err)
+	}
+	defer rows.Close()
+
+	var chats []models.TelegramChat
+	for rows.Next() {
+		var chat models.TelegramChat
+		if err := rows.Scan(&chat.ChatID, &chat.UserID, &chat.Scope, &chat.Username, &chat.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan telegram chat: %w", err)
+		}
+		chats = append(chats, chat)
+	}
+	return chats, rows.Err()
+}
+
+// Unlink удаляет привязку чата пользователя
+func (r *TelegramChatRepository) Unlink(ctx context.Context, userID string, chatID int64) error {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM telegram_chats WHERE user_id = $1 AND chat_id = $2`,
+		userID, chatID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to unlink telegram chat: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return models.NotFound("telegram chat not found")
+	}
+	return nil
+}
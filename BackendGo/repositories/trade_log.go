@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"CryptoLens_Backend/integration/bybit"
+	"CryptoLens_Backend/models"
 	"CryptoLens_Backend/types"
 	"context"
 	"database/sql"
@@ -21,8 +22,8 @@ func NewTradeLogRepository(db *sql.DB) types.TradeLogRepositoryInterface {
 	return &TradeLogRepository{db: db}
 }
 
-// SaveExecution сохраняет информацию об исполнении ордера
-func (r *TradeLogRepository) SaveExecution(ctx context.Context, userID string, exec bybit.ExecutionMessage) error {
+// SaveExecution сохраняет информацию об исполнении ордера на аккаунте Bybit
+func (r *TradeLogRepository) SaveExecution(ctx context.Context, userID string, accountID int64, exec bybit.ExecutionMessage) error {
 	execPrice, err := decimal.NewFromString(exec.ExecPrice)
 	if err != nil {
 		return fmt.Errorf("invalid exec_price: %w", err)
@@ -46,11 +47,11 @@ func (r *TradeLogRepository) SaveExecution(ctx context.Context, userID string, e
 
 	_, err = r.db.ExecContext(ctx,
 		`INSERT INTO trade_logs (
-			user_id, symbol, exec_id, order_id, order_link_id, side, 
+			user_id, bybit_account_id, symbol, exec_id, order_id, order_link_id, side, 
 			exec_price, exec_qty, exec_fee, fee_rate, is_maker, 
 			order_type, exec_time
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
-		userID, exec.Symbol, exec.ExecID, exec.OrderID, exec.OrderLinkID, exec.Side,
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
+		userID, accountID, exec.Symbol, exec.ExecID, exec.OrderID, exec.OrderLinkID, exec.Side,
 		execPrice, execQty, execFee, feeRate, exec.IsMaker,
 		exec.OrderType, execTime,
 	)
@@ -59,3 +60,89 @@ func (r *TradeLogRepository) SaveExecution(ctx context.Context, userID string, e
 	}
 	return nil
 }
+
+// GetSummary возвращает агрегированную статистику сделок пользователя за период по всем аккаунтам
+func (r *TradeLogRepository) GetSummary(ctx context.Context, userID string, from, to time.Time) (*models.TradeSummary, error) {
+	var summary models.TradeSummary
+	err := r.db.QueryRowContext(ctx,
+		`SELECT
+			COUNT(*),
+			COALESCE(SUM(CASE WHEN side = 'Buy' THEN exec_price * exec_qty ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN side = 'Sell' THEN exec_price * exec_qty ELSE 0 END), 0),
+			COALESCE(SUM(exec_fee), 0)
+		FROM trade_logs
+		WHERE user_id = $1 AND exec_time >= $2 AND exec_time < $3`,
+		userID, from, to,
+	).Scan(&summary.Trades, &summary.BuyVolume, &summary.SellVolume, &summary.Fees)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trade summary: %w", err)
+	}
+	return &summary, nil
+}
+
+// GetAccountSummaries возвращает статистику сделок пользователя за период отдельно по каждому аккаунту
+func (r *TradeLogRepository) GetAccountSummaries(ctx context.Context, userID string, from, to time.Time) ([]models.AccountTradeSummary, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT
+			COALESCE(bybit_account_id, 0),
+			COUNT(*),
+			COALESCE(SUM(CASE WHEN side = 'Buy' THEN exec_price * exec_qty ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN side = 'Sell' THEN exec_price * exec_qty ELSE 0 END), 0),
+			COALESCE(SUM(exec_fee), 0)
+		FROM trade_logs
+		WHERE user_id = $1 AND exec_time >= $2 AND exec_time < $3
+		GROUP BY bybit_account_id
+		ORDER BY bybit_account_id`,
+		userID, from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query account summaries: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.AccountTradeSummary
+	for rows.Next() {
+		var s models.AccountTradeSummary
+		if err := rows.Scan(&s.AccountID, &s.Trades, &s.BuyVolume, &s.SellVolume, &s.Fees); err != nil {
+			return nil, fmt.Errorf("failed to scan account summary: %w", err)
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
+
+// GetPnL возвращает объемы покупок и продаж пользователя по инструментам за период.
+// Если accountID равен 0, сделки суммируются по всем аккаунтам пользователя.
+// Комиссия по покупкам на споте списывается в базовой монете, поэтому пересчитывается по цене исполнения.
+func (r *TradeLogRepository) GetPnL(ctx context.Context, userID string, accountID int64, from, to time.Time) ([]models.SymbolPnL, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT
+			symbol,
+			COUNT(*),
+			COALESCE(SUM(CASE WHEN side = 'Buy' THEN exec_qty ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN side = 'Buy' THEN exec_price * exec_qty ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN side = 'Sell' THEN exec_qty ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN side = 'Sell' THEN exec_price * exec_qty ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN side = 'Buy' THEN exec_fee * exec_price ELSE exec_fee END), 0)
+		FROM trade_logs
+		WHERE user_id = $1 AND exec_time >= $2 AND exec_time < $3
+			AND ($4::BIGINT = 0 OR bybit_account_id = $4)
+		GROUP BY symbol
+		ORDER BY symbol`,
+		userID, from, to, accountID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pnl: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.SymbolPnL
+	for rows.Next() {
+		var p models.SymbolPnL
+		if err := rows.Scan(&p.Symbol, &p.Trades, &p.BuyQty, &p.BuyValue, &p.SellQty, &p.SellValue, &p.Fees); err != nil {
+			return nil, fmt.Errorf("failed to scan pnl: %w", err)
+		}
+		result = append(result, p)
+	}
+	return result, rows.Err()
+}
@@ -4,9 +4,13 @@ import (
 	"CryptoLens_Backend/models"
 	"context"
 	"database/sql"
-	"errors"
+	"fmt"
 )
 
+// userColumns включает имя роли из user_types: роль попадает в JWT и проверяется middleware
+const userColumns = `u.id, u.user_type_id, ut.name, u.nickname, u.email, u.password, u.email_verified_at,
+		u.disabled_at, u.created_at, u.updated_at, u.deleted_at`
+
 type UserRepository struct {
 	db *sql.DB
 }
@@ -17,6 +21,27 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 	}
 }
 
+func scanUser(row rowScanner) (*models.User, error) {
+	user := &models.User{}
+	err := row.Scan(
+		&user.ID,
+		&user.UserTypeID,
+		&user.Role,
+		&user.Nickname,
+		&user.Email,
+		&user.Password,
+		&user.EmailVerifiedAt,
+		&user.DisabledAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 	query := `
 		INSERT INTO users (user_type_id, nickname, email, password, created_at)
@@ -39,26 +64,16 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
-		SELECT id, user_type_id, nickname, email, password, email_verified_at, created_at, updated_at, deleted_at
-		FROM users
-		WHERE email = $1 AND deleted_at IS NULL`
+		SELECT ` + userColumns + `
+		FROM users u
+		JOIN user_types ut ON ut.id = u.user_type_id
+		WHERE u.email = $1 AND u.deleted_at IS NULL`
 
-	user := &models.User{}
-	err := r.db.QueryRowContext(ctx, query, email).Scan(
-		&user.ID,
-		&user.UserTypeID,
-		&user.Nickname,
-		&user.Email,
-		&user.Password,
-		&user.EmailVerifiedAt,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-		&user.DeletedAt,
-	)
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, email))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("user not found")
+			return nil, models.NotFound("user not found")
 		}
 		return nil, err
 	}
@@ -68,26 +83,16 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.
 
 func (r *UserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
 	query := `
-		SELECT id, user_type_id, nickname, email, password, email_verified_at, created_at, updated_at, deleted_at
-		FROM users
-		WHERE id = $1 AND deleted_at IS NULL`
+		SELECT ` + userColumns + `
+		FROM users u
+		JOIN user_types ut ON ut.id = u.user_type_id
+		WHERE u.id = $1 AND u.deleted_at IS NULL`
 
-	user := &models.User{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&user.ID,
-		&user.UserTypeID,
-		&user.Nickname,
-		&user.Email,
-		&user.Password,
-		&user.EmailVerifiedAt,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-		&user.DeletedAt,
-	)
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("user not found")
+			return nil, models.NotFound("user not found")
 		}
 		return nil, err
 	}
@@ -108,4 +113,114 @@ func (r *UserRepository) ExistsByEmail(ctx context.Context, email string) (bool,
 	}
 
 	return exists, nil
-} 
\ No newline at end of file
+}
+
+// List возвращает пользователей, начиная с новых
+func (r *UserRepository) List(ctx context.Context, limit, offset int) ([]models.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users u
+		JOIN user_types ut ON ut.id = u.user_type_id
+		WHERE u.deleted_at IS NULL
+		ORDER BY u.created_at DESC
+		LIMIT $1 OFFSET $2`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, *user)
+	}
+	return users, rows.Err()
+}
+
+// SetDisabled отключает пользователя или снимает отключение
+func (r *UserRepository) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE users
+		SET disabled_at = CASE WHEN $2 THEN COALESCE(disabled_at, NOW()) END, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL`,
+		id, disabled,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.NotFound("user not found")
+	}
+	return nil
+}
+
+// SetRole назначает пользователю роль по имени типа пользователя
+func (r *UserRepository) SetRole(ctx context.Context, email, role string) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE users
+		SET user_type_id = (SELECT id FROM user_types WHERE name = $2 AND deleted_at IS NULL LIMIT 1), updated_at = NOW()
+		WHERE email = $1 AND deleted_at IS NULL
+			AND EXISTS (SELECT 1 FROM user_types WHERE name = $2 AND deleted_at IS NULL)`,
+		email, role,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update user role: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.NotFound("user or role not found")
+	}
+	return nil
+}
+
+// MarkEmailVerified отмечает email пользователя подтвержденным; повторное подтверждение не меняет дату
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE users
+		SET email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.NotFound("user not found")
+	}
+	return nil
+}
+
+// UpdatePassword заменяет хеш пароля, если он не изменился с момента проверки.
+// Так одна ссылка сброса не сработает дважды даже при параллельных запросах.
+func (r *UserRepository) UpdatePassword(ctx context.Context, id, oldHash, newHash string) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE users
+		SET password = $3, updated_at = NOW()
+		WHERE id = $1 AND password = $2 AND deleted_at IS NULL`,
+		id, oldHash, newHash,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update password: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return affected > 0, nil
+}
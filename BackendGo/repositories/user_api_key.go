@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/types"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const apiKeyColumns = `id, user_id, name, key_prefix, scopes, expires_at, last_used_at, created_at`
+
+// UserAPIKeyRepository реализует интерфейс UserAPIKeyRepositoryInterface
+type UserAPIKeyRepository struct {
+	db *sql.DB
+}
+
+// NewUserAPIKeyRepository создает новый репозиторий API-ключей
+func NewUserAPIKeyRepository(db *sql.DB) types.UserAPIKeyRepositoryInterface {
+	return &UserAPIKeyRepository{db: db}
+}
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var key models.APIKey
+	var scopes string
+	if err := row.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&scopes,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	key.Scopes = strings.Split(scopes, ",")
+	return &key, nil
+}
+
+// Create сохраняет хеш нового ключа
+func (r *UserAPIKeyRepository) Create(ctx context.Context, userID, name, prefix, keyHash string, scopes []string, expiresAt *time.Time) (*models.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx,
+		`INSERT INTO user_api_keys (user_id, name, key_prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+apiKeyColumns,
+		userID, name, prefix, keyHash, strings.Join(scopes, ","), expiresAt,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+	return key, nil
+}
+
+// GetByUserID получает ключи пользователя, включая истекшие
+func (r *UserAPIKeyRepository) GetByUserID(ctx context.Context, userID string) ([]models.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+apiKeyColumns+`
+		FROM user_api_keys
+		WHERE user_id = $1
+		ORDER BY created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// Delete удаляет ключ пользователя
+func (r *UserAPIKeyRepository) Delete(ctx context.Context, userID, id string) error {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM user_api_keys WHERE id = $1 AND user_id = $2`,
+		id, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete api key: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.NotFound("api key not found")
+	}
+	return nil
+}
+
+// Authenticate находит действующий ключ по хешу и отмечает его использование.
+// Ключ отключенного или удаленного пользователя не принимается.
+func (r *UserAPIKeyRepository) Authenticate(ctx context.Context, keyHash string) (*models.APIKeyIdentity, error) {
+	var identity models.APIKeyIdentity
+	var scopes string
+	err := r.db.QueryRowContext(ctx,
+		`UPDATE user_api_keys k SET last_used_at = CURRENT_TIMESTAMP
+		FROM users u
+		JOIN user_types ut ON ut.id = u.user_type_id
+		WHERE k.key_hash = $1 AND u.id = k.user_id
+			AND u.deleted_at IS NULL AND u.disabled_at IS NULL
+			AND (k.expires_at IS NULL OR k.expires_at > CURRENT_TIMESTAMP)
+		RETURNING k.id, k.user_id, ut.name, k.scopes`,
+		keyHash,
+	).Scan(&identity.KeyID, &identity.UserID, &identity.Role, &scopes)
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("api key not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate api key: %w", err)
+	}
+	identity.Scopes = strings.Split(scopes, ",")
+	return &identity, nil
+}
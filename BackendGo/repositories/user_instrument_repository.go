@@ -1,11 +1,10 @@
 package repositories
 
 import (
-	"CryptoLens_Backend/logger"
 	"CryptoLens_Backend/models"
+	"CryptoLens_Shared/logger"
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
 )
 
@@ -78,14 +77,14 @@ func (r *UserInstrumentRepository) GetByUserID(ctx context.Context, userID strin
 	return instruments, nil
 }
 
-// Update обновляет статус инструмента пользователя
-func (r *UserInstrumentRepository) Update(ctx context.Context, id string, isActive bool) error {
+// Update обновляет статус инструмента пользователя. Чужой инструмент не найдется, как и несуществующий.
+func (r *UserInstrumentRepository) Update(ctx context.Context, userID, id string, isActive bool) error {
 	query := `
 		UPDATE user_instruments
 		SET is_active = $1, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $2 AND deleted_at IS NULL`
+		WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL`
 
-	result, err := r.db.ExecContext(ctx, query, isActive, id)
+	result, err := r.db.ExecContext(ctx, query, isActive, id, userID)
 	if err != nil {
 		logger.LogError("Failed to update user instrument: %v", err)
 		return err
@@ -97,20 +96,20 @@ func (r *UserInstrumentRepository) Update(ctx context.Context, id string, isActi
 	}
 
 	if rows == 0 {
-		return errors.New("user instrument not found")
+		return models.NotFound("user instrument not found")
 	}
 
 	return nil
 }
 
 // Delete мягко удаляет связь пользователя с инструментом
-func (r *UserInstrumentRepository) Delete(ctx context.Context, id string) error {
+func (r *UserInstrumentRepository) Delete(ctx context.Context, userID, id string) error {
 	query := `
 		UPDATE user_instruments
 		SET deleted_at = CURRENT_TIMESTAMP
-		WHERE id = $1 AND deleted_at IS NULL`
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := r.db.ExecContext(ctx, query, id, userID)
 	if err != nil {
 		logger.LogError("Failed to delete user instrument: %v", err)
 		return err
@@ -122,7 +121,7 @@ func (r *UserInstrumentRepository) Delete(ctx context.Context, id string) error
 	}
 
 	if rows == 0 {
-		return errors.New("user instrument not found")
+		return models.NotFound("user instrument not found")
 	}
 
 	return nil
@@ -202,30 +201,3 @@ func (r *UserInstrumentRepository) GetActiveInstrumentsByUserID(ctx context.Cont
 
 	return symbols, nil
 }
-
-// GetByID получает инструмент пользователя по ID
-func (r *UserInstrumentRepository) GetByID(ctx context.Context, id string) (*models.UserInstrument, error) {
-	query := `
-		SELECT id, user_id, symbol, is_active, created_at, updated_at
-		FROM user_instruments
-		WHERE id = $1 AND deleted_at IS NULL`
-
-	instrument := &models.UserInstrument{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&instrument.ID,
-		&instrument.UserID,
-		&instrument.Symbol,
-		&instrument.IsActive,
-		&instrument.CreatedAt,
-		&instrument.UpdatedAt,
-	)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.New("user instrument not found")
-		}
-		logger.LogError("Failed to get user instrument: %v", err)
-		return nil, err
-	}
-
-	return instrument, nil
-}
@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/types"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const sessionColumns = `id, user_id, refresh_token_hash, COALESCE(previous_token_hash, ''), user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at`
+
+// UserSessionRepository реализует интерфейс UserSessionRepositoryInterface
+type UserSessionRepository struct {
+	db *sql.DB
+}
+
+// NewUserSessionRepository создает новый репозиторий сессий пользователей
+func NewUserSessionRepository(db *sql.DB) types.UserSessionRepositoryInterface {
+	return &UserSessionRepository{db: db}
+}
+
+func scanUserSession(row rowScanner) (*models.UserSession, error) {
+	var session models.UserSession
+	if err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.RefreshTokenHash,
+		&session.PreviousTokenHash,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Create открывает сессию и удаляет истекшие и отозванные сессии пользователя
+func (r *UserSessionRepository) Create(ctx context.Context, userID, tokenHash string, meta models.SessionMeta, expiresAt time.Time) (*models.UserSession, error) {
+	if _, err := r.db.ExecContext(ctx,
+		`DELETE FROM user_sessions WHERE user_id = $1 AND (expires_at < CURRENT_TIMESTAMP OR revoked_at IS NOT NULL)`,
+		userID,
+	); err != nil {
+		return nil, fmt.Errorf("failed to delete stale sessions: %w", err)
+	}
+
+	session, err := scanUserSession(r.db.QueryRowContext(ctx,
+		`INSERT INTO user_sessions (user_id, refresh_token_hash, user_agent, ip_address, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+sessionColumns,
+		userID, tokenHash, truncate(meta.UserAgent, 255), truncate(meta.IPAddress, 64), expiresAt,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	return session, nil
+}
+
+// GetByTokenHash ищет сессию по текущему или предыдущему хешу refresh-токена
+func (r *UserSessionRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.UserSession, error) {
+	session, err := scanUserSession(r.db.QueryRowContext(ctx,
+		`SELECT `+sessionColumns+`
+		FROM user_sessions
+		WHERE refresh_token_hash = $1 OR previous_token_hash = $1`,
+		tokenHash,
+	))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("session not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return session, nil
+}
+
+// Rotate заменяет refresh-токен сессии. Возвращает false, если токен уже был заменен параллельным запросом
+// или сессия отозвана.
+func (r *UserSessionRepository) Rotate(ctx context.Context, id, oldHash, newHash string, meta models.SessionMeta) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE user_sessions
+		SET refresh_token_hash = $1, previous_token_hash = $2, user_agent = $3, ip_address = $4, last_used_at = CURRENT_TIMESTAMP
+		WHERE id = $5 AND refresh_token_hash = $2 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP`,
+		newHash, oldHash, truncate(meta.UserAgent, 255), truncate(meta.IPAddress, 64), id,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate session: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// GetActiveByUserID получает неотозванные и неистекшие сессии пользователя
+func (r *UserSessionRepository) GetActiveByUserID(ctx context.Context, userID string) ([]models.UserSession, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+sessionColumns+`
+		FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		ORDER BY last_used_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.UserSession{}
+	for rows.Next() {
+		session, err := scanUserSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, rows.Err()
+}
+
+// Revoke отзывает сессию пользователя
+func (r *UserSessionRepository) Revoke(ctx context.Context, userID, id string) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE user_sessions SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`,
+		id, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.NotFound("session not found")
+	}
+	return nil
+}
+
+// RevokeAll отзывает все активные сессии пользователя и возвращает их ID
+func (r *UserSessionRepository) RevokeAll(ctx context.Context, userID string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`UPDATE user_sessions SET revoked_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		RETURNING id`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan session id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// truncate обрезает строку до ограничения колонки в символах
+func truncate(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit])
+}
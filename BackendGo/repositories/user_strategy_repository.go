@@ -4,7 +4,6 @@ import (
 	"CryptoLens_Backend/models"
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
 	"time"
 )
@@ -17,16 +16,17 @@ func NewUserStrategyRepository(db *sql.DB) *UserStrategyRepository {
 	return &UserStrategyRepository{db: db}
 }
 
-func (r *UserStrategyRepository) Create(ctx context.Context, userID string, strategyName string) (*models.UserStrategy, error) {
+func (r *UserStrategyRepository) Create(ctx context.Context, userID string, accountID int64, strategyName string) (*models.UserStrategy, error) {
 	query := `
-		INSERT INTO user_strategies (user_id, strategy_name, is_active)
-		VALUES ($1, $2, false)
-		RETURNING id, user_id, strategy_name, is_active, created_at, updated_at`
+		INSERT INTO user_strategies (user_id, bybit_account_id, strategy_name, is_active)
+		VALUES ($1, $2, $3, false)
+		RETURNING id, user_id, bybit_account_id, strategy_name, is_active, created_at, updated_at`
 
 	var strategy models.UserStrategy
-	err := r.db.QueryRowContext(ctx, query, userID, strategyName).Scan(
+	err := r.db.QueryRowContext(ctx, query, userID, accountID, strategyName).Scan(
 		&strategy.ID,
 		&strategy.UserID,
+		&strategy.BybitAccountID,
 		&strategy.StrategyName,
 		&strategy.IsActive,
 		&strategy.CreatedAt,
@@ -41,7 +41,7 @@ func (r *UserStrategyRepository) Create(ctx context.Context, userID string, stra
 
 func (r *UserStrategyRepository) GetByUserID(ctx context.Context, userID string) ([]models.UserStrategy, error) {
 	query := `
-		SELECT id, user_id, strategy_name, is_active, created_at, updated_at
+		SELECT id, user_id, bybit_account_id, strategy_name, is_active, created_at, updated_at
 		FROM user_strategies
 		WHERE user_id = $1 AND deleted_at IS NULL`
 
@@ -57,6 +57,7 @@ func (r *UserStrategyRepository) GetByUserID(ctx context.Context, userID string)
 		err := rows.Scan(
 			&strategy.ID,
 			&strategy.UserID,
+			&strategy.BybitAccountID,
 			&strategy.StrategyName,
 			&strategy.IsActive,
 			&strategy.CreatedAt,
@@ -71,41 +72,61 @@ func (r *UserStrategyRepository) GetByUserID(ctx context.Context, userID string)
 	return strategies, nil
 }
 
-func (r *UserStrategyRepository) Update(ctx context.Context, id string, isActive bool) error {
+// Update меняет статус стратегии пользователя. Чужая стратегия не найдется, как и несуществующая.
+func (r *UserStrategyRepository) Update(ctx context.Context, userID, id string, isActive bool) error {
 	query := `
 		UPDATE user_strategies
 		SET is_active = $1, updated_at = $2
-		WHERE id = $3 AND deleted_at IS NULL`
+		WHERE id = $3 AND user_id = $4 AND deleted_at IS NULL`
 
-	_, err := r.db.ExecContext(ctx, query, isActive, time.Now(), id)
-	return err
+	result, err := r.db.ExecContext(ctx, query, isActive, time.Now(), id, userID)
+	if err != nil {
+		return err
+	}
+	return requireStrategyAffected(result)
 }
 
-func (r *UserStrategyRepository) Delete(ctx context.Context, id string) error {
+// Delete мягко удаляет стратегию пользователя
+func (r *UserStrategyRepository) Delete(ctx context.Context, userID, id string) error {
 	query := `
 		UPDATE user_strategies
 		SET deleted_at = $1
-		WHERE id = $2 AND deleted_at IS NULL`
+		WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL`
 
-	_, err := r.db.ExecContext(ctx, query, time.Now(), id)
-	return err
+	result, err := r.db.ExecContext(ctx, query, time.Now(), id, userID)
+	if err != nil {
+		return err
+	}
+	return requireStrategyAffected(result)
 }
 
-func (r *UserStrategyRepository) Exists(ctx context.Context, userID string, strategyName string) (bool, error) {
+func requireStrategyAffected(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return models.NotFound("user strategy not found")
+	}
+	return nil
+}
+
+// Exists проверяет, добавлена ли стратегия на аккаунт пользователя
+func (r *UserStrategyRepository) Exists(ctx context.Context, userID string, accountID int64, strategyName string) (bool, error) {
 	query := `
 		SELECT EXISTS(
 			SELECT 1 FROM user_strategies
-			WHERE user_id = $1 AND strategy_name = $2 AND deleted_at IS NULL
+			WHERE user_id = $1 AND bybit_account_id = $2 AND strategy_name = $3 AND deleted_at IS NULL
 		)`
 
 	var exists bool
-	err := r.db.QueryRowContext(ctx, query, userID, strategyName).Scan(&exists)
+	err := r.db.QueryRowContext(ctx, query, userID, accountID, strategyName).Scan(&exists)
 	return exists, err
 }
 
 func (r *UserStrategyRepository) GetActiveStrategies(ctx context.Context) ([]models.UserStrategy, error) {
 	query := `
-		SELECT id, user_id, strategy_name, is_active, created_at, updated_at
+		SELECT id, user_id, bybit_account_id, strategy_name, is_active, created_at, updated_at
 		FROM user_strategies
 		WHERE is_active = true AND deleted_at IS NULL`
 
@@ -121,6 +142,7 @@ func (r *UserStrategyRepository) GetActiveStrategies(ctx context.Context) ([]mod
 		err := rows.Scan(
 			&strategy.ID,
 			&strategy.UserID,
+			&strategy.BybitAccountID,
 			&strategy.StrategyName,
 			&strategy.IsActive,
 			&strategy.CreatedAt,
@@ -135,17 +157,32 @@ func (r *UserStrategyRepository) GetActiveStrategies(ctx context.Context) ([]mod
 	return strategies, nil
 }
 
-// GetByID получает стратегию по ID
+// GetByID получает стратегию любого пользователя по ID. Для запросов пользователя нужен GetUserStrategy.
 func (r *UserStrategyRepository) GetByID(ctx context.Context, id string) (*models.UserStrategy, error) {
-	var strategy models.UserStrategy
-	err := r.db.QueryRowContext(ctx,
-		`SELECT id, user_id, strategy_name, is_active, created_at, updated_at 
+	return r.scanStrategy(r.db.QueryRowContext(ctx,
+		`SELECT id, user_id, bybit_account_id, strategy_name, is_active, created_at, updated_at 
 		FROM user_strategies 
 		WHERE id = $1 AND deleted_at IS NULL`,
 		id,
-	).Scan(
+	))
+}
+
+// GetUserStrategy получает стратегию по ID, только если она принадлежит пользователю
+func (r *UserStrategyRepository) GetUserStrategy(ctx context.Context, userID, id string) (*models.UserStrategy, error) {
+	return r.scanStrategy(r.db.QueryRowContext(ctx,
+		`SELECT id, user_id, bybit_account_id, strategy_name, is_active, created_at, updated_at
+		FROM user_strategies
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`,
+		id, userID,
+	))
+}
+
+func (r *UserStrategyRepository) scanStrategy(row *sql.Row) (*models.UserStrategy, error) {
+	var strategy models.UserStrategy
+	err := row.Scan(
 		&strategy.ID,
 		&strategy.UserID,
+		&strategy.BybitAccountID,
 		&strategy.StrategyName,
 		&strategy.IsActive,
 		&strategy.CreatedAt,
@@ -154,7 +191,7 @@ func (r *UserStrategyRepository) GetByID(ctx context.Context, id string) (*model
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("стратегия не найдена")
+			return nil, models.NotFound("user strategy not found")
 		}
 		return nil, err
 	}
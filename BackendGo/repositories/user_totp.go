@@ -0,0 +1,301 @@
+package repositories
+
+import (
+	"CryptoLens_Backend/encryption"
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/types"
+	"CryptoLens_Shared/logger"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// UserTOTPRepository реализует интерфейс UserTOTPRepositoryInterface.
+// Секрет TOTP дает право подтверждать вход, поэтому хранится зашифрованным, как API-секреты Bybit.
+type UserTOTPRepository struct {
+	db      *sql.DB
+	keyring *encryption.Keyring
+}
+
+// NewUserTOTPRepository создает новый репозиторий второго фактора
+func NewUserTOTPRepository(db *sql.DB, keyring *encryption.Keyring) types.UserTOTPRepositoryInterface {
+	return &UserTOTPRepository{db: db, keyring: keyring}
+}
+
+// totpAAD привязывает шифротекст к владельцу, чтобы секрет нельзя было перенести в чужую запись
+func totpAAD(userID string) []byte {
+	return []byte("user_totp.secret:" + userID)
+}
+
+// Get получает настройки второго фактора. Возвращает nil, если пользователь их не начинал.
+func (r *UserTOTPRepository) Get(ctx context.Context, userID string) (*models.UserTOTP, error) {
+	totp := models.UserTOTP{UserID: userID}
+	var envelope encryption.Envelope
+	err := r.db.QueryRowContext(ctx,
+		`SELECT secret_ciphertext, secret_dek, key_version, last_used_step, confirmed_at, created_at
+		FROM user_totp
+		WHERE user_id = $1`,
+		userID,
+	).Scan(&envelope.Ciphertext, &envelope.WrappedKey, &envelope.KeyVersion, &totp.LastUsedStep, &totp.ConfirmedAt, &totp.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor settings: %w", err)
+	}
+
+	secret, err := r.keyring.Open(&envelope, totpAAD(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+	totp.Secret = string(secret)
+	return &totp, nil
+}
+
+// SaveSecret начинает настройку с новым секретом. Незавершенная настройка перезаписывается,
+// а включенный второй фактор сначала нужно отключить.
+func (r *UserTOTPRepository) SaveSecret(ctx context.Context, userID, secret string) error {
+	envelope, err := r.keyring.Seal([]byte(secret), totpAAD(userID))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt TOTP secret: %w", err)
+	}
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO user_totp (user_id, secret_ciphertext, secret_dek, key_version)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret_ciphertext = EXCLUDED.secret_ciphertext, secret_dek = EXCLUDED.secret_dek,
+			key_version = EXCLUDED.key_version, last_used_step = 0, created_at = CURRENT_TIMESTAMP
+		WHERE user_totp.confirmed_at IS NULL`,
+		userID, envelope.Ciphertext, envelope.WrappedKey, envelope.KeyVersion,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save TOTP secret: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.Conflict("two-factor authentication is already enabled")
+	}
+	return nil
+}
+
+// Confirm включает второй фактор и сохраняет коды восстановления.
+// Возвращает false, если настройку успели подтвердить или код этого шага уже использован.
+func (r *UserTOTPRepository) Confirm(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE user_totp
+		SET confirmed_at = CURRENT_TIMESTAMP, last_used_step = $2
+		WHERE user_id = $1 AND confirmed_at IS NULL AND last_used_step < $2`,
+		userID, step,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to confirm two-factor authentication: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return false, nil
+	}
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// UseStep отмечает шаг времени, код которого принят. Возвращает false, если код этого
+// или более позднего шага уже использован: один код нельзя предъявить дважды.
+func (r *UserTOTPRepository) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE user_totp
+		SET last_used_step = $2
+		WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_used_step < $2`,
+		userID, step,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update TOTP step: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// UseRecoveryCode погашает код восстановления. Возвращает false, если кода нет или он уже использован.
+func (r *UserTOTPRepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE user_recovery_codes
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`,
+		userID, codeHash,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// CountRecoveryCodes возвращает число неиспользованных кодов восстановления
+func (r *UserTOTPRepository) CountRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL`,
+		userID,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+// ReplaceRecoveryCodes заменяет все коды восстановления новыми
+func (r *UserTOTPRepository) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Delete отключает второй фактор и удаляет коды восстановления
+func (r *UserTOTPRepository) Delete(ctx context.Context, userID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete two-factor settings: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return models.Conflict("two-factor authentication is not enabled")
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// CountSecretsToReencrypt возвращает число секретов TOTP, зашифрованных неактивным ключом
+func (r *UserTOTPRepository) CountSecretsToReencrypt(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM user_totp WHERE key_version <> $1`,
+		r.keyring.ActiveVersion(),
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count TOTP secrets to re-encrypt: %w", err)
+	}
+	return count, nil
+}
+
+// ReencryptSecrets перешифровывает ключи данных секретов TOTP активным мастер-ключом.
+// Запись обновляется, только если ее версия ключа не изменилась с момента чтения.
+func (r *UserTOTPRepository) ReencryptSecrets(ctx context.Context) (*models.SecretsReencryptResult, error) {
+	type pendingRow struct {
+		userID   string
+		envelope encryption.Envelope
+	}
+
+	active := r.keyring.ActiveVersion()
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT user_id, secret_ciphertext, secret_dek, key_version
+		FROM user_totp
+		WHERE key_version <> $1
+		ORDER BY user_id`,
+		active,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query TOTP secrets to re-encrypt: %w", err)
+	}
+	var pending []pendingRow
+	for rows.Next() {
+		var row pendingRow
+		if err := rows.Scan(&row.userID, &row.envelope.Ciphertext, &row.envelope.WrappedKey, &row.envelope.KeyVersion); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan TOTP secret: %w", err)
+		}
+		pending = append(pending, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate TOTP secrets: %w", err)
+	}
+
+	result := &models.SecretsReencryptResult{ActiveKeyVersion: active}
+	for _, row := range pending {
+		envelope, err := r.keyring.Rewrap(&row.envelope)
+		if err != nil {
+			logger.LogError("Не удалось перешифровать секрет TOTP пользователя %s: %v", row.userID, err)
+			result.Failed++
+			continue
+		}
+
+		res, err := r.db.ExecContext(ctx,
+			`UPDATE user_totp
+			SET secret_ciphertext = $1, secret_dek = $2, key_version = $3
+			WHERE user_id = $4 AND key_version = $5`,
+			envelope.Ciphertext, envelope.WrappedKey, envelope.KeyVersion, row.userID, row.envelope.KeyVersion,
+		)
+		if err != nil {
+			return result, fmt.Errorf("failed to update TOTP secret of user %s: %w", row.userID, err)
+		}
+		if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+			// Секрет заменили параллельно, он уже сохранен с актуальным ключом
+			result.Skipped++
+			continue
+		}
+		result.Rewrapped++
+	}
+	return result, nil
+}
+
+// replaceRecoveryCodes удаляет прежние коды восстановления и сохраняет новые в транзакции tx
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID string, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`,
+			userID, hash,
+		); err != nil {
+			return fmt.Errorf("failed to save recovery code: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,438 @@
+package repositories
+
+import (
+	"CryptoLens_Backend/encryption"
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/types"
+	"CryptoLens_Shared/logger"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// webhookEndpointColumns колонки эндпоинта без секрета: секрет расшифровывается только для отправки
+const webhookEndpointColumns = `id, user_id, url, event_types, is_active, created_at, updated_at`
+
+// WebhookRepository реализует интерфейс WebhookRepositoryInterface.
+// Секрет подписи хранится зашифрованным, как API-секреты Bybit, и расшифровывается только при выборке доставок.
+type WebhookRepository struct {
+	db      *sql.DB
+	keyring *encryption.Keyring
+}
+
+// NewWebhookRepository создает новый репозиторий исходящих вебхуков
+func NewWebhookRepository(db *sql.DB, keyring *encryption.Keyring) types.WebhookRepositoryInterface {
+	return &WebhookRepository{db: db, keyring: keyring}
+}
+
+// webhookSecretAAD привязывает шифротекст к владельцу, чтобы секрет нельзя было перенести в чужую запись
+func webhookSecretAAD(userID string) []byte {
+	return []byte("webhook_endpoints.secret:" + userID)
+}
+
+// sealSecret шифрует секрет активным мастер-ключом
+func (r *WebhookRepository) sealSecret(userID, secret string) (*encryption.Envelope, error) {
+	envelope, err := r.keyring.Seal([]byte(secret), webhookSecretAAD(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+	return envelope, nil
+}
+
+// openSecret расшифровывает секрет. Записи без key_version созданы до включения шифрования
+// и хранят секрет открытым текстом.
+func (r *WebhookRepository) openSecret(userID string, plain sql.NullString, ciphertext, wrappedKey []byte, keyVersion sql.NullInt64) (string, error) {
+	if !keyVersion.Valid {
+		return plain.String, nil
+	}
+	secret, err := r.keyring.Open(&encryption.Envelope{
+		KeyVersion: int(keyVersion.Int64),
+		WrappedKey: wrappedKey,
+		Ciphertext: ciphertext,
+	}, webhookSecretAAD(userID))
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+// CreateEndpoint регистрирует эндпоинт пользователя
+func (r *WebhookRepository) CreateEndpoint(ctx context.Context, userID, url, secret string, eventTypes []string) (*models.WebhookEndpoint, error) {
+	envelope, err := r.sealSecret(userID, secret)
+	if err != nil {
+		return nil, err
+	}
+	row := r.db.QueryRowContext(ctx,
+		`INSERT INTO webhook_endpoints (user_id, url, secret_ciphertext, secret_dek, key_version, event_types)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+webhookEndpointColumns,
+		userID, url, envelope.Ciphertext, envelope.WrappedKey, envelope.KeyVersion, strings.Join(eventTypes, ","),
+	)
+	endpoint, err := scanWebhookEndpoint(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+	return endpoint, nil
+}
+
+// GetEndpointByID получает эндпоинт пользователя по ID
+func (r *WebhookRepository) GetEndpointByID(ctx context.Context, userID, id string) (*models.WebhookEndpoint, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+webhookEndpointColumns+`
+		FROM webhook_endpoints
+		WHERE user_id = $1 AND id = $2`,
+		userID, id,
+	)
+	endpoint, err := scanWebhookEndpoint(row)
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("webhook endpoint not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+	return endpoint, nil
+}
+
+// GetEndpointsByUserID получает все эндпоинты пользователя
+func (r *WebhookRepository) GetEndpointsByUserID(ctx context.Context, userID string) ([]models.WebhookEndpoint, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+webhookEndpointColumns+`
+		FROM webhook_endpoints
+		WHERE user_id = $1
+		ORDER BY created_at`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	return scanWebhookEndpoints(rows)
+}
+
+// GetSubscribedEndpoints получает активные эндпоинты пользователя, подписанные на тип события.
+// Эндпоинт без списка событий получает все события.
+func (r *WebhookRepository) GetSubscribedEndpoints(ctx context.Context, userID, eventType string) ([]models.WebhookEndpoint, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+webhookEndpointColumns+`
+		FROM webhook_endpoints
+		WHERE user_id = $1 AND is_active = true
+			AND (event_types = '' OR $2 = ANY(string_to_array(event_types, ',')))`,
+		userID, eventType,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subscribed webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	return scanWebhookEndpoints(rows)
+}
+
+// DeleteEndpoint удаляет эндпоинт пользователя вместе с журналом доставки
+func (r *WebhookRepository) DeleteEndpoint(ctx context.Context, userID, id string) error {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM webhook_endpoints WHERE user_id = $1 AND id = $2`,
+		userID, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return models.NotFound("webhook endpoint not found")
+	}
+	return nil
+}
+
+// EnqueueDelivery ставит событие в очередь доставки
+func (r *WebhookRepository) EnqueueDelivery(ctx context.Context, endpointID, userID, eventType string, payload []byte) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO webhook_deliveries (endpoint_id, user_id, event_type, payload)
+		VALUES ($1, $2, $3, $4)`,
+		endpointID, userID, eventType, payload,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ClaimDueDeliveries забирает из очереди доставки, время которых наступило.
+// Выбранные записи откладываются на время lease, чтобы их не взял другой обработчик;
+// если процесс упадет во время отправки, доставка будет повторена после истечения lease.
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`WITH due AS (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + make_interval(secs => $2)
+		FROM due, webhook_endpoints e
+		WHERE d.id = due.id AND e.id = d.endpoint_id
+		RETURNING d.id, d.endpoint_id, d.user_id, d.event_type, d.payload, d.attempts, e.url,
+			e.secret, e.secret_ciphertext, e.secret_dek, e.key_version`,
+		limit, lease.Seconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var payload, ciphertext, wrappedKey []byte
+		var plainSecret sql.NullString
+		var keyVersion sql.NullInt64
+		if err := rows.Scan(&d.ID, &d.EndpointID, &d.UserID, &d.EventType, &payload, &d.Attempts, &d.URL,
+			&plainSecret, &ciphertext, &wrappedKey, &keyVersion); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		secret, err := r.openSecret(d.UserID, plainSecret, ciphertext, wrappedKey, keyVersion)
+		if err != nil {
+			// Без секрета запрос нельзя подписать; доставка вернется в очередь после lease
+			logger.LogError("Не удалось расшифровать секрет вебхука %s: %v", d.EndpointID, err)
+			continue
+		}
+		d.Secret = secret
+		d.Payload = payload
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// MarkDelivered отмечает доставку успешной
+func (r *WebhookRepository) MarkDelivered(ctx context.Context, id string, statusCode int) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE webhook_deliveries
+		SET status = 'delivered', attempts = attempts + 1, last_status_code = $2,
+			last_error = NULL, delivered_at = NOW()
+		WHERE id = $1`,
+		id, statusCode,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook delivered: %w", err)
+	}
+	return nil
+}
+
+// MarkAttemptFailed фиксирует неудачную попытку. Если nextAttemptAt не задан, доставка считается окончательно проваленной.
+func (r *WebhookRepository) MarkAttemptFailed(ctx context.Context, id string, statusCode *int, errMsg string, nextAttemptAt *time.Time) error {
+	status := models.WebhookDeliveryPending
+	if nextAttemptAt == nil {
+		status = models.WebhookDeliveryFailed
+	}
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE webhook_deliveries
+		SET status = $2, attempts = attempts + 1, last_status_code = $3, last_error = $4,
+			next_attempt_at = COALESCE($5, next_attempt_at)
+		WHERE id = $1`,
+		id, status, statusCode, errMsg, nextAttemptAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook attempt: %w", err)
+	}
+	return nil
+}
+
+// GetDeliveries получает журнал доставки пользователя, при необходимости по одному эндпоинту
+func (r *WebhookRepository) GetDeliveries(ctx context.Context, userID, endpointID string, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, endpoint_id, user_id, event_type, payload, status, attempts, next_attempt_at,
+			last_status_code, last_error, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE user_id = $1 AND ($2 = '' OR endpoint_id::text = $2)
+		ORDER BY created_at DESC
+		LIMIT $3`,
+		userID, endpointID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var payload []byte
+		if err := rows.Scan(
+			&d.ID,
+			&d.EndpointID,
+			&d.UserID,
+			&d.EventType,
+			&payload,
+			&d.Status,
+			&d.Attempts,
+			&d.NextAttemptAt,
+			&d.LastStatusCode,
+			&d.LastError,
+			&d.CreatedAt,
+			&d.DeliveredAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		d.Payload = payload
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// RetryDelivery возвращает проваленную доставку в очередь
+func (r *WebhookRepository) RetryDelivery(ctx context.Context, userID, id string) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = NOW()
+		WHERE user_id = $1 AND id = $2 AND status = 'failed'`,
+		userID, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to retry webhook delivery: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return models.NotFound("failed webhook delivery not found")
+	}
+	return nil
+}
+
+// rowScanner общий интерфейс для sql.Row и sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWebhookEndpoint(row rowScanner) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	var eventTypes string
+	if err := row.Scan(
+		&endpoint.ID,
+		&endpoint.UserID,
+		&endpoint.URL,
+		&eventTypes,
+		&endpoint.IsActive,
+		&endpoint.CreatedAt,
+		&endpoint.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	endpoint.EventTypes = []string{}
+	if eventTypes != "" {
+		endpoint.EventTypes = strings.Split(eventTypes, ",")
+	}
+	return &endpoint, nil
+}
+
+func scanWebhookEndpoints(rows *sql.Rows) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	for rows.Next() {
+		endpoint, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook endpoint: %w", err)
+		}
+		endpoints = append(endpoints, *endpoint)
+	}
+	return endpoints, rows.Err()
+}
+
+// CountSecretsToReencrypt возвращает число секретов вебхуков, которые хранятся открыто или зашифрованы неактивным ключом
+func (r *WebhookRepository) CountSecretsToReencrypt(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM webhook_endpoints WHERE (key_version IS NULL AND secret IS NOT NULL) OR key_version <> $1`,
+		r.keyring.ActiveVersion(),
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count webhook secrets to re-encrypt: %w", err)
+	}
+	return count, nil
+}
+
+// ReencryptSecrets шифрует открытые секреты вебхуков и перешифровывает ключи данных активным мастер-ключом.
+// Запись обновляется, только если ее версия ключа не изменилась с момента чтения.
+func (r *WebhookRepository) ReencryptSecrets(ctx context.Context) (*models.SecretsReencryptResult, error) {
+	type pendingRow struct {
+		id          string
+		userID      string
+		plainSecret sql.NullString
+		ciphertext  []byte
+		wrappedKey  []byte
+		keyVersion  sql.NullInt64
+	}
+
+	active := r.keyring.ActiveVersion()
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, user_id, secret, secret_ciphertext, secret_dek, key_version
+		FROM webhook_endpoints
+		WHERE (key_version IS NULL AND secret IS NOT NULL) OR key_version <> $1
+		ORDER BY id`,
+		active,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook secrets to re-encrypt: %w", err)
+	}
+	var pending []pendingRow
+	for rows.Next() {
+		var row pendingRow
+		if err := rows.Scan(&row.id, &row.userID, &row.plainSecret, &row.ciphertext, &row.wrappedKey, &row.keyVersion); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan webhook endpoint: %w", err)
+		}
+		pending = append(pending, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate webhook endpoints: %w", err)
+	}
+
+	result := &models.SecretsReencryptResult{ActiveKeyVersion: active}
+	for _, row := range pending {
+		var envelope *encryption.Envelope
+		var err error
+		if row.keyVersion.Valid {
+			envelope, err = r.keyring.Rewrap(&encryption.Envelope{
+				KeyVersion: int(row.keyVersion.Int64),
+				WrappedKey: row.wrappedKey,
+				Ciphertext: row.ciphertext,
+			})
+		} else {
+			envelope, err = r.sealSecret(row.userID, row.plainSecret.String)
+		}
+		if err != nil {
+			logger.LogError("Не удалось перешифровать секрет вебхука %s: %v", row.id, err)
+			result.Failed++
+			continue
+		}
+
+		res, err := r.db.ExecContext(ctx,
+			`UPDATE webhook_endpoints
+			SET secret = NULL, secret_ciphertext = $1, secret_dek = $2, key_version = $3
+			WHERE id = $4 AND key_version IS NOT DISTINCT FROM $5`,
+			envelope.Ciphertext, envelope.WrappedKey, envelope.KeyVersion, row.id, row.keyVersion,
+		)
+		if err != nil {
+			return result, fmt.Errorf("failed to update webhook endpoint %s: %w", row.id, err)
+		}
+		if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+			// Запись изменили параллельно, она уже сохранена с актуальным ключом
+			result.Skipped++
+			continue
+		}
+		if row.keyVersion.Valid {
+			result.Rewrapped++
+		} else {
+			result.Encrypted++
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"CryptoLens_Backend/handlers"
+	"CryptoLens_Backend/middleware"
+)
+
+type AdminRoutes struct {
+	handler *handlers.AdminHandler
+}
+
+func NewAdminRoutes(handler *handlers.AdminHandler) *AdminRoutes {
+	return &AdminRoutes{
+		handler: handler,
+	}
+}
+
+func (r *AdminRoutes) Register(router *Router) {
+	router.HandleFunc("GET /api/v1/admin/log-level", middleware.RequirePermission(middleware.PermissionManageSystem, r.handler.GetLogLevel))
+	router.HandleFunc("PUT /api/v1/admin/log-level", middleware.RequirePermission(middleware.PermissionManageSystem, r.handler.SetLogLevel))
+
+	router.HandleFunc("GET /api/v1/admin/users", middleware.RequirePermission(middleware.PermissionManageUsers, r.handler.ListUsers))
+	router.HandleFunc("PATCH /api/v1/admin/users/{id}", middleware.RequirePermission(middleware.PermissionManageUsers, r.handler.SetUserDisabled))
+	router.HandleFunc("GET /api/v1/admin/auth-events", middleware.RequirePermission(middleware.PermissionManageUsers, r.handler.GetAuthEvents))
+
+	router.HandleFunc("GET /api/v1/admin/users/{id}/strategies", middleware.RequirePermission(middleware.PermissionManageStrategies, r.handler.GetUserStrategies))
+	router.HandleFunc("POST /api/v1/admin/strategies/{id}/stop", middleware.RequirePermission(middleware.PermissionManageStrategies, r.handler.StopStrategy))
+}
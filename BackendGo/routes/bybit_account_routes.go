@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"CryptoLens_Backend/handlers"
+	"CryptoLens_Backend/middleware"
+)
+
+type BybitAccountRoutes struct {
+	handler *handlers.BybitAccountHandler
+}
+
+func NewBybitAccountRoutes(handler *handlers.BybitAccountHandler) *BybitAccountRoutes {
+	return &BybitAccountRoutes{
+		handler: handler,
+	}
+}
+
+func (r *BybitAccountRoutes) Register(router *Router) {
+	router.HandleFunc("GET /api/v1/user/bybit/accounts", middleware.AuthMiddleware(r.handler.GetAccounts))
+	router.HandleFunc("POST /api/v1/user/bybit/accounts", middleware.RequireSession(r.handler.AddAccount))
+	router.HandleFunc("PUT /api/v1/user/bybit/accounts/{id}/credentials", middleware.RequireSession(r.handler.RotateCredentials))
+	router.HandleFunc("PATCH /api/v1/user/bybit/accounts/{id}", middleware.RequireSession(r.handler.SetStatus))
+	router.HandleFunc("DELETE /api/v1/user/bybit/accounts/{id}", middleware.RequireSession(r.handler.RemoveAccount))
+}
@@ -3,7 +3,6 @@ package routes
 import (
 	"CryptoLens_Backend/handlers"
 	"CryptoLens_Backend/middleware"
-	"net/http"
 )
 
 type BybitRoutes struct {
@@ -16,9 +15,10 @@ func NewBybitRoutes(bybitHandler *handlers.BybitHandler) *BybitRoutes {
 	}
 }
 
-func (r *BybitRoutes) Register() {
+func (r *BybitRoutes) Register(router *Router) {
 	// Все маршруты Bybit требуют аутентификации
-	http.HandleFunc("/api/v1/bybit/wallet/balance", middleware.AuthMiddleware(r.bybitHandler.GetWalletBalance))
-	http.HandleFunc("/api/v1/bybit/wallet/fee-rate", middleware.AuthMiddleware(r.bybitHandler.GetFeeRate))
-	http.HandleFunc("/api/v1/bybit/instruments", middleware.AuthMiddleware(r.bybitHandler.GetInstruments))
+	router.HandleFunc("GET /api/v1/bybit/wallet/balance", middleware.AuthMiddleware(r.bybitHandler.GetWalletBalance))
+	router.HandleFunc("GET /api/v1/bybit/wallet/balances", middleware.AuthMiddleware(r.bybitHandler.GetWalletBalances))
+	router.HandleFunc("GET /api/v1/bybit/wallet/fee-rate", middleware.AuthMiddleware(r.bybitHandler.GetFeeRate))
+	router.HandleFunc("GET /api/v1/bybit/instruments", middleware.AuthMiddleware(r.bybitHandler.GetInstruments))
 }
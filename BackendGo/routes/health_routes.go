@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"CryptoLens_Backend/handlers"
+	"CryptoLens_Backend/middleware"
+)
+
+type HealthRoutes struct {
+	handler *handlers.HealthHandler
+}
+
+func NewHealthRoutes(handler *handlers.HealthHandler) *HealthRoutes {
+	return &HealthRoutes{
+		handler: handler,
+	}
+}
+
+func (r *HealthRoutes) Register(router *Router) {
+	router.HandleFunc("GET /healthz", r.handler.Healthz)
+	router.HandleFunc("GET /readyz", r.handler.Readyz)
+	router.HandleFunc("GET /api/v1/admin/status", middleware.RequirePermission(middleware.PermissionManageSystem, r.handler.GetStatus))
+}
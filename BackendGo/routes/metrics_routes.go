@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"CryptoLens_Backend/metrics"
+)
+
+type MetricsRoutes struct{}
+
+func NewMetricsRoutes() *MetricsRoutes {
+	return &MetricsRoutes{}
+}
+
+func (r *MetricsRoutes) Register(router *Router) {
+	router.Handle("GET /metrics", metrics.Handler())
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"CryptoLens_Backend/handlers"
+	"CryptoLens_Backend/middleware"
+)
+
+type NotificationRoutes struct {
+	handler *handlers.NotificationHandler
+}
+
+func NewNotificationRoutes(handler *handlers.NotificationHandler) *NotificationRoutes {
+	return &NotificationRoutes{
+		handler: handler,
+	}
+}
+
+func (r *NotificationRoutes) Register(router *Router) {
+	router.HandleFunc("GET /api/v1/user/notifications/settings", middleware.AuthMiddleware(r.handler.GetSettings))
+	router.HandleFunc("PUT /api/v1/user/notifications/channels", middleware.RequireSession(r.handler.UpsertChannel))
+	router.HandleFunc("DELETE /api/v1/user/notifications/channels/{channel}", middleware.RequireSession(r.handler.RemoveChannel))
+	router.HandleFunc("PUT /api/v1/user/notifications/preferences", middleware.RequireSession(r.handler.UpdatePreference))
+	router.HandleFunc("POST /api/v1/user/notifications/test", middleware.RequireSession(r.handler.SendTest))
+}
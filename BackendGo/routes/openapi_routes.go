@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"CryptoLens_Backend/openapi"
+)
+
+type OpenAPIRoutes struct{}
+
+func NewOpenAPIRoutes() *OpenAPIRoutes {
+	return &OpenAPIRoutes{}
+}
+
+func (r *OpenAPIRoutes) Register(router *Router) {
+	router.Handle("GET /api/v1/openapi.json", openapi.Handler())
+}
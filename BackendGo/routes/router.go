@@ -0,0 +1,110 @@
+package routes
+
+import (
+	"CryptoLens_Backend/httpapi"
+	"CryptoLens_Backend/middleware"
+	"net/http"
+)
+
+// Router регистрирует маршруты с методом и параметрами пути (синтаксис шаблонов ServeMux Go 1.22)
+// и оборачивает их общими middleware: восстановление после паники, ID запроса и журнал запросов
+type Router struct {
+	mux      *http.ServeMux
+	patterns []string
+}
+
+// NewRouter создает пустой роутер
+func NewRouter() *Router {
+	return &Router{mux: http.NewServeMux()}
+}
+
+// HandleFunc регистрирует обработчик для шаблона вида "GET /api/v1/user/strategies/{id}"
+func (rt *Router) HandleFunc(pattern string, handler http.HandlerFunc) {
+	rt.mux.HandleFunc(pattern, handler)
+	rt.patterns = append(rt.patterns, pattern)
+}
+
+// Handle регистрирует http.Handler для шаблона
+func (rt *Router) Handle(pattern string, handler http.Handler) {
+	rt.mux.Handle(pattern, handler)
+	rt.patterns = append(rt.patterns, pattern)
+}
+
+// Patterns возвращает зарегистрированные шаблоны в порядке регистрации
+func (rt *Router) Patterns() []string {
+	return append([]string(nil), rt.patterns...)
+}
+
+// Handler возвращает обработчик для сервера
+func (rt *Router) Handler() http.Handler {
+	return middleware.Chain(http.HandlerFunc(rt.serveHTTP),
+		middleware.RequestID,
+		middleware.AccessLog,
+		middleware.Recover,
+	)
+}
+
+// serveHTTP передает запрос в ServeMux. Ответы 404 и 405 самого ServeMux текстовые,
+// поэтому для запросов без подходящего маршрута ошибка формируется здесь в общем формате.
+func (rt *Router) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, pattern := rt.mux.Handler(r); pattern != "" {
+		rt.mux.ServeHTTP(w, r)
+		return
+	}
+
+	// Статус и заголовок Allow знает только ServeMux: отдаем ему запрос с подменным ответом
+	probe := &unmatchedResponse{header: http.Header{}}
+	rt.mux.ServeHTTP(probe, r)
+	if probe.status == http.StatusMethodNotAllowed {
+		w.Header().Set("Allow", probe.header.Get("Allow"))
+		httpapi.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	httpapi.Error(w, "Not found", http.StatusNotFound)
+}
+
+// unmatchedResponse принимает ответ ServeMux на запрос без маршрута
+type unmatchedResponse struct {
+	header http.Header
+	status int
+}
+
+func (u *unmatchedResponse) Header() http.Header { return u.header }
+
+func (u *unmatchedResponse) WriteHeader(status int) {
+	if u.status == 0 {
+		u.status = status
+	}
+}
+
+func (u *unmatchedResponse) Write(b []byte) (int, error) {
+	if u.status == 0 {
+		u.status = http.StatusOK
+	}
+	return len(b), nil
+}
+
+// NewContractRouter регистрирует все группы маршрутов без обработчиков. Такой роутер не обслуживает
+// запросы и нужен только для сверки шаблонов со спецификацией OpenAPI.
+func NewContractRouter() *Router {
+	router := NewRouter()
+	for _, group := range []interface{ Register(*Router) }{
+		NewUserRoutes(nil),
+		NewUserAPIKeyRoutes(nil),
+		NewUserInstrumentRoutes(nil),
+		NewUserStrategyRoutes(nil),
+		NewBybitRoutes(nil),
+		NewBybitAccountRoutes(nil),
+		NewNotificationRoutes(nil),
+		NewTelegramRoutes(nil),
+		NewWebhookRoutes(nil),
+		NewMetricsRoutes(),
+		NewOpenAPIRoutes(),
+		NewHealthRoutes(nil),
+		NewAdminRoutes(nil),
+		NewStreamRoutes(nil),
+	} {
+		group.Register(router)
+	}
+	return router
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"CryptoLens_Backend/handlers"
+	"CryptoLens_Backend/middleware"
+)
+
+type StreamRoutes struct {
+	streamHandler *handlers.StreamHandler
+}
+
+func NewStreamRoutes(streamHandler *handlers.StreamHandler) *StreamRoutes {
+	return &StreamRoutes{
+		streamHandler: streamHandler,
+	}
+}
+
+func (r *StreamRoutes) Register(router *Router) {
+	// WebSocket-поток событий аккаунтов и рыночных данных
+	router.HandleFunc("GET /api/v1/stream", middleware.StreamAuthMiddleware(r.streamHandler.Connect))
+}
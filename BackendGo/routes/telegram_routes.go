@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"CryptoLens_Backend/handlers"
+	"CryptoLens_Backend/middleware"
+)
+
+type TelegramRoutes struct {
+	handler *handlers.TelegramHandler
+}
+
+func NewTelegramRoutes(handler *handlers.TelegramHandler) *TelegramRoutes {
+	return &TelegramRoutes{
+		handler: handler,
+	}
+}
+
+func (r *TelegramRoutes) Register(router *Router) {
+	router.HandleFunc("POST /api/v1/user/telegram/link", middleware.RequireSession(r.handler.CreateLinkCode))
+	router.HandleFunc("GET /api/v1/user/telegram/chats", middleware.AuthMiddleware(r.handler.GetChats))
+	router.HandleFunc("DELETE /api/v1/user/telegram/chats/{chat_id}", middleware.RequireSession(r.handler.UnlinkChat))
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"CryptoLens_Backend/handlers"
+	"CryptoLens_Backend/middleware"
+)
+
+type UserAPIKeyRoutes struct {
+	handler *handlers.UserAPIKeyHandler
+}
+
+func NewUserAPIKeyRoutes(handler *handlers.UserAPIKeyHandler) *UserAPIKeyRoutes {
+	return &UserAPIKeyRoutes{
+		handler: handler,
+	}
+}
+
+func (r *UserAPIKeyRoutes) Register(router *Router) {
+	// Ключами управляют только из сессии: ключ не может выпустить другой ключ
+	router.HandleFunc("GET /api/v1/user/api-keys", middleware.RequireSession(r.handler.GetKeys))
+	router.HandleFunc("POST /api/v1/user/api-keys", middleware.RequireSession(r.handler.CreateKey))
+	router.HandleFunc("DELETE /api/v1/user/api-keys/{id}", middleware.RequireSession(r.handler.RemoveKey))
+}
@@ -3,7 +3,7 @@ package routes
 import (
 	"CryptoLens_Backend/handlers"
 	"CryptoLens_Backend/middleware"
-	"net/http"
+	"CryptoLens_Backend/models"
 )
 
 type UserInstrumentRoutes struct {
@@ -16,9 +16,9 @@ func NewUserInstrumentRoutes(handler *handlers.UserInstrumentHandler) *UserInstr
 	}
 }
 
-func (r *UserInstrumentRoutes) Register() {
-	http.HandleFunc("/api/v1/user/instruments/add", middleware.AuthMiddleware(r.handler.AddInstrument))
-	http.HandleFunc("/api/v1/user/instruments/list", middleware.AuthMiddleware(r.handler.GetUserInstruments))
-	http.HandleFunc("/api/v1/user/instruments/status", middleware.AuthMiddleware(r.handler.UpdateInstrumentStatus))
-	http.HandleFunc("/api/v1/user/instruments/remove", middleware.AuthMiddleware(r.handler.RemoveInstrument))
+func (r *UserInstrumentRoutes) Register(router *Router) {
+	router.HandleFunc("GET /api/v1/user/instruments", middleware.AuthMiddleware(r.handler.GetUserInstruments))
+	router.HandleFunc("POST /api/v1/user/instruments", middleware.RequireScope(models.APIKeyScopeTrade, r.handler.AddInstrument))
+	router.HandleFunc("PATCH /api/v1/user/instruments/{id}", middleware.RequireScope(models.APIKeyScopeTrade, r.handler.UpdateInstrumentStatus))
+	router.HandleFunc("DELETE /api/v1/user/instruments/{id}", middleware.RequireScope(models.APIKeyScopeTrade, r.handler.RemoveInstrument))
 }
@@ -3,7 +3,6 @@ package routes
 import (
 	"CryptoLens_Backend/handlers"
 	"CryptoLens_Backend/middleware"
-	"net/http"
 )
 
 type UserRoutes struct {
@@ -16,12 +15,26 @@ func NewUserRoutes(handler *handlers.UserHandler) *UserRoutes {
 	}
 }
 
-func (r *UserRoutes) Register() {
+func (r *UserRoutes) Register(router *Router) {
 	// Публичные маршруты (без аутентификации)
-	http.HandleFunc("/api/v1/user/register", r.handler.Register)
-	http.HandleFunc("/api/v1/user/login", r.handler.Login)
+	router.HandleFunc("POST /api/v1/user/register", r.handler.Register)
+	router.HandleFunc("POST /api/v1/user/login", r.handler.Login)
+	router.HandleFunc("POST /api/v1/user/token/refresh", r.handler.RefreshToken)
+	router.HandleFunc("POST /api/v1/user/email/verify", r.handler.VerifyEmail)
+	router.HandleFunc("POST /api/v1/user/password/reset", r.handler.RequestPasswordReset)
+	router.HandleFunc("POST /api/v1/user/password/reset/confirm", r.handler.ConfirmPasswordReset)
 
 	// Защищенные маршруты (требуют аутентификации)
-	http.HandleFunc("/api/v1/user/logout", middleware.AuthMiddleware(r.handler.Logout))
-	http.HandleFunc("/api/v1/user/account", middleware.AuthMiddleware(r.handler.GetAccount))
-} 
\ No newline at end of file
+	router.HandleFunc("POST /api/v1/user/logout", middleware.RequireSession(r.handler.Logout))
+	router.HandleFunc("GET /api/v1/user/account", middleware.AuthMiddleware(r.handler.GetAccount))
+	router.HandleFunc("GET /api/v1/user/sessions", middleware.RequireSession(r.handler.GetSessions))
+	router.HandleFunc("DELETE /api/v1/user/sessions/{id}", middleware.RequireSession(r.handler.RevokeSession))
+	router.HandleFunc("POST /api/v1/user/email/resend", middleware.RequireSession(r.handler.ResendVerification))
+
+	// Второй фактор настраивается только из сессии: API-ключ не должен его отключать
+	router.HandleFunc("GET /api/v1/user/2fa", middleware.RequireSession(r.handler.GetTwoFactorStatus))
+	router.HandleFunc("POST /api/v1/user/2fa/setup", middleware.RequireSession(r.handler.SetupTwoFactor))
+	router.HandleFunc("POST /api/v1/user/2fa/enable", middleware.RequireSession(r.handler.EnableTwoFactor))
+	router.HandleFunc("POST /api/v1/user/2fa/disable", middleware.RequireSession(r.handler.DisableTwoFactor))
+	router.HandleFunc("POST /api/v1/user/2fa/recovery-codes", middleware.RequireSession(r.handler.RegenerateRecoveryCodes))
+}
@@ -3,7 +3,7 @@ package routes
 import (
 	"CryptoLens_Backend/handlers"
 	"CryptoLens_Backend/middleware"
-	"net/http"
+	"CryptoLens_Backend/models"
 )
 
 type UserStrategyRoutes struct {
@@ -16,9 +16,9 @@ func NewUserStrategyRoutes(handler *handlers.UserStrategyHandler) *UserStrategyR
 	}
 }
 
-func (r *UserStrategyRoutes) Register() {
-	http.HandleFunc("/api/v1/user/strategies", middleware.AuthMiddleware(r.handler.GetUserStrategies))
-	http.HandleFunc("/api/v1/user/strategies/add", middleware.AuthMiddleware(r.handler.AddStrategy))
-	http.HandleFunc("/api/v1/user/strategies/update", middleware.AuthMiddleware(r.handler.UpdateStrategyStatus))
-	http.HandleFunc("/api/v1/user/strategies/remove", middleware.AuthMiddleware(r.handler.RemoveStrategy))
-} 
\ No newline at end of file
+func (r *UserStrategyRoutes) Register(router *Router) {
+	router.HandleFunc("GET /api/v1/user/strategies", middleware.AuthMiddleware(r.handler.GetUserStrategies))
+	router.HandleFunc("POST /api/v1/user/strategies", middleware.RequireScope(models.APIKeyScopeTrade, r.handler.AddStrategy))
+	router.HandleFunc("PATCH /api/v1/user/strategies/{id}", middleware.RequireScope(models.APIKeyScopeTrade, r.handler.UpdateStrategyStatus))
+	router.HandleFunc("DELETE /api/v1/user/strategies/{id}", middleware.RequireScope(models.APIKeyScopeTrade, r.handler.RemoveStrategy))
+}
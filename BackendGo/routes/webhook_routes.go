@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"CryptoLens_Backend/handlers"
+	"CryptoLens_Backend/middleware"
+)
+
+type WebhookRoutes struct {
+	handler *handlers.WebhookHandler
+}
+
+func NewWebhookRoutes(handler *handlers.WebhookHandler) *WebhookRoutes {
+	return &WebhookRoutes{
+		handler: handler,
+	}
+}
+
+func (r *WebhookRoutes) Register(router *Router) {
+	router.HandleFunc("GET /api/v1/user/webhooks", middleware.AuthMiddleware(r.handler.GetEndpoints))
+	router.HandleFunc("POST /api/v1/user/webhooks", middleware.RequireSession(r.handler.CreateEndpoint))
+	router.HandleFunc("DELETE /api/v1/user/webhooks/{id}", middleware.RequireSession(r.handler.RemoveEndpoint))
+	router.HandleFunc("POST /api/v1/user/webhooks/{id}/test", middleware.RequireSession(r.handler.SendTest))
+	router.HandleFunc("GET /api/v1/user/webhooks/deliveries", middleware.AuthMiddleware(r.handler.GetDeliveries))
+	router.HandleFunc("POST /api/v1/user/webhooks/deliveries/{id}/retry", middleware.RequireSession(r.handler.RetryDelivery))
+}
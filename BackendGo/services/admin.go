@@ -0,0 +1,147 @@
+package services
+
+import (
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/repositories"
+	"CryptoLens_Backend/types"
+	"CryptoLens_Shared/logger"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// AdminService выполняет действия администратора над чужими учетными записями и стратегиями
+type AdminService struct {
+	userRepo        *repositories.UserRepository
+	auditRepo       types.AuthAuditRepositoryInterface
+	userService     types.UserServiceInterface
+	strategies      types.UserStrategyServiceInterface
+	telegramService types.TelegramServiceInterface
+}
+
+// NewAdminService создает новый сервис администрирования
+func NewAdminService(
+	userRepo *repositories.UserRepository,
+	auditRepo types.AuthAuditRepositoryInterface,
+	userService types.UserServiceInterface,
+	strategies types.UserStrategyServiceInterface,
+	telegramService types.TelegramServiceInterface,
+) *AdminService {
+	return &AdminService{
+		userRepo:        userRepo,
+		auditRepo:       auditRepo,
+		userService:     userService,
+		strategies:      strategies,
+		telegramService: telegramService,
+	}
+}
+
+// ListUsers возвращает страницу списка пользователей
+func (s *AdminService) ListUsers(ctx context.Context, limit, offset int) ([]models.AdminUserResponse, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	users, err := s.userRepo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]models.AdminUserResponse, len(users))
+	for i := range users {
+		response[i] = toAdminUserResponse(&users[i])
+	}
+	return response, nil
+}
+
+// SetUserDisabled отключает пользователя или снимает отключение. Отключенный пользователь
+// теряет все сессии, его стратегии останавливаются, а чаты Telegram отвязываются.
+func (s *AdminService) SetUserDisabled(ctx context.Context, adminID, userID string, disabled bool) (*models.AdminUserResponse, error) {
+	if disabled && adminID == userID {
+		return nil, models.Invalid("нельзя отключить собственную учетную запись")
+	}
+	if err := s.userRepo.SetDisabled(ctx, userID, disabled); err != nil {
+		return nil, err
+	}
+
+	ctx = logger.WithFields(ctx, logger.FieldUserID, userID)
+	if disabled {
+		// Вход и обновление токенов уже запрещены, закрываем то, что было открыто до отключения
+		if err := s.userService.RevokeAllSessions(ctx, userID); err != nil {
+			return nil, fmt.Errorf("пользователь отключен, но сессии не отозваны: %w", err)
+		}
+		if err := s.strategies.StopUserStrategies(ctx, userID, "учетная запись отключена администратором"); err != nil {
+			return nil, fmt.Errorf("пользователь отключен, но стратегии не остановлены: %w", err)
+		}
+		if err := s.unlinkChats(ctx, userID); err != nil {
+			return nil, fmt.Errorf("пользователь отключен, но чаты Telegram не отвязаны: %w", err)
+		}
+	}
+	logger.InfoCtx(ctx, "Администратор %s изменил отключение пользователя: disabled=%t", adminID, disabled)
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	response := toAdminUserResponse(user)
+	return &response, nil
+}
+
+// GetUserStrategies возвращает стратегии любого пользователя
+func (s *AdminService) GetUserStrategies(ctx context.Context, userID string) ([]models.UserStrategy, error) {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.strategies.GetUserStrategies(ctx, userID)
+}
+
+// StopStrategy останавливает стратегию любого пользователя
+func (s *AdminService) StopStrategy(ctx context.Context, adminID, strategyID, reason string) (*models.UserStrategy, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		reason = "остановлена администратором"
+	}
+	strategy, err := s.strategies.StopStrategy(ctx, strategyID, reason)
+	if err != nil {
+		return nil, err
+	}
+	logger.InfoCtx(logger.WithFields(ctx, logger.FieldUserID, strategy.UserID, logger.FieldStrategyID, strategy.ID),
+		"Администратор %s остановил стратегию %s: %s", adminID, strategy.StrategyName, reason)
+	return strategy, nil
+}
+
+// GetAuthEvents возвращает журнал аутентификации, при userID — только события пользователя
+func (s *AdminService) GetAuthEvents(ctx context.Context, userID string, limit int) ([]models.AuthAuditEntry, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	return s.auditRepo.List(ctx, userID, limit)
+}
+
+// unlinkChats отвязывает чаты Telegram, чтобы через бота нельзя было управлять стратегиями
+func (s *AdminService) unlinkChats(ctx context.Context, userID string) error {
+	chats, err := s.telegramService.GetChats(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, chat := range chats {
+		if err := s.telegramService.UnlinkChat(ctx, userID, chat.ChatID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toAdminUserResponse(user *models.User) models.AdminUserResponse {
+	return models.AdminUserResponse{
+		ID:              user.ID,
+		Nickname:        user.Nickname,
+		Email:           user.Email,
+		Role:            user.Role,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		DisabledAt:      user.DisabledAt,
+		CreatedAt:       user.CreatedAt,
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"CryptoLens_Backend/config"
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/storages"
+	"CryptoLens_Shared/logger"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// loginFailuresTTL сколько помнить неудачные входы: блокировка растет, пока неудачи идут чаще раза в сутки
+const loginFailuresTTL = 24 * time.Hour
+
+// ErrAuthUnavailable возвращается, когда ограничения частоты нельзя проверить, например Redis недоступен.
+// Вход в этом случае запрещается, а не пропускается без защиты.
+var ErrAuthUnavailable = errors.New("authentication is temporarily unavailable")
+
+// RateLimitError сообщает, что попытка отклонена ограничением частоты или блокировкой аккаунта
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return "too many attempts, try again later"
+}
+
+// checkRegisterAllowed ограничивает число регистраций с одного IP
+func (s *UserService) checkRegisterAllowed(ctx context.Context, email string, meta models.SessionMeta) error {
+	cfg := config.Get().Auth
+	count, retryAfter, err := storages.IncrementRateLimit(ctx, "register:"+meta.IPAddress, cfg.RegisterIPWindow.Std())
+	if err != nil {
+		logger.ErrorCtx(ctx, "Ошибка проверки лимита регистраций: %v", err)
+		return ErrAuthUnavailable
+	}
+	if count > int64(cfg.RegisterIPLimit) {
+		// В журнал попадает только первое превышение в окне, чтобы атака не заполняла таблицу
+		if count == int64(cfg.RegisterIPLimit)+1 {
+			s.audit(ctx, nil, email, models.AuthEventRegisterLimited, meta)
+		}
+		return &RateLimitError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// checkLoginAllowed ограничивает попытки входа с одного IP и не пускает в заблокированный аккаунт.
+// Пароль заблокированного аккаунта не проверяется, чтобы блокировка не служила оракулом.
+func (s *UserService) checkLoginAllowed(ctx context.Context, email string, meta models.SessionMeta) error {
+	cfg := config.Get().Auth
+	count, retryAfter, err := storages.IncrementRateLimit(ctx, "login:"+meta.IPAddress, cfg.LoginIPWindow.Std())
+	if err != nil {
+		logger.ErrorCtx(ctx, "Ошибка проверки лимита входов: %v", err)
+		return ErrAuthUnavailable
+	}
+	if count > int64(cfg.LoginIPLimit) {
+		if count == int64(cfg.LoginIPLimit)+1 {
+			s.audit(ctx, nil, email, models.AuthEventLoginLimited, meta)
+		}
+		return &RateLimitError{RetryAfter: retryAfter}
+	}
+
+	locked, err := storages.GetLoginLock(ctx, email)
+	if err != nil {
+		logger.ErrorCtx(ctx, "Ошибка проверки блокировки входа: %v", err)
+		return ErrAuthUnavailable
+	}
+	if locked > 0 {
+		s.audit(ctx, nil, email, models.AuthEventLoginLocked, meta)
+		return &RateLimitError{RetryAfter: locked}
+	}
+	return nil
+}
+
+// loginFailed учитывает неудачный вход и блокирует аккаунт, когда неудач подряд становится слишком много.
+// Неудачи считаются и для несуществующих email, чтобы по ответам нельзя было узнать, есть ли аккаунт.
+func (s *UserService) loginFailed(ctx context.Context, user *models.User, email string, meta models.SessionMeta) {
+	var userID *string
+	if user != nil {
+		userID = &user.ID
+	}
+	s.audit(ctx, userID, email, models.AuthEventLoginFailed, meta)
+
+	cfg := config.Get().Auth
+	failures, err := storages.RecordLoginFailure(ctx, email, loginFailuresTTL)
+	if err != nil {
+		logger.ErrorCtx(ctx, "Ошибка учета неудачного входа: %v", err)
+		return
+	}
+	if failures < int64(cfg.LockoutThreshold) {
+		return
+	}
+
+	lock := lockoutDuration(failures-int64(cfg.LockoutThreshold), cfg.LockoutBase.Std(), cfg.LockoutMax.Std())
+	if err := storages.LockLogin(ctx, email, lock); err != nil {
+		logger.ErrorCtx(ctx, "Ошибка блокировки входа: %v", err)
+		return
+	}
+	s.audit(ctx, userID, email, models.AuthEventAccountLocked, meta)
+	logger.WarnCtx(ctx, "Вход в аккаунт %s заблокирован на %s после %d неудачных попыток", email, lock, failures)
+
+	// Владельцу сообщаем только о первой блокировке серии
+	if user != nil && failures == int64(cfg.LockoutThreshold) {
+		s.securityAlert(ctx, user.ID, "Вход временно заблокирован",
+			fmt.Sprintf("После %d неудачных попыток входа вход заблокирован на %s. Если это были не вы, смените пароль.", failures, lock),
+			meta)
+	}
+}
+
+// loginSucceeded сбрасывает счетчик неудач и сообщает о входе с IP, которого раньше не было у пользователя
+func (s *UserService) loginSucceeded(ctx context.Context, user *models.User, email string, meta models.SessionMeta) {
+	if err := storages.ResetLoginFailures(ctx, email); err != nil {
+		logger.ErrorCtx(ctx, "Ошибка сброса неудачных входов: %v", err)
+	}
+
+	hasHistory, knownIP, err := s.auditRepo.GetLoginHistory(ctx, user.ID, meta.IPAddress)
+	if err != nil {
+		logger.ErrorCtx(ctx, "Ошибка получения истории входов: %v", err)
+	} else if hasHistory && !knownIP {
+		s.securityAlert(ctx, user.ID, "Вход с нового IP-адреса",
+			"Выполнен вход в аккаунт с адреса, с которого вы раньше не входили. Если это были не вы, завершите сессию и смените пароль.",
+			meta)
+	}
+	s.audit(ctx, &user.ID, email, models.AuthEventLoginSucceeded, meta)
+}
+
+// audit записывает событие в журнал. Ошибка записи не должна мешать входу, поэтому только логируется.
+func (s *UserService) audit(ctx context.Context, userID *string, email, event string, meta models.SessionMeta) {
+	entry := models.AuthAuditEntry{
+		UserID:    userID,
+		Email:     email,
+		Event:     event,
+		IPAddress: meta.IPAddress,
+		UserAgent: meta.UserAgent,
+	}
+	if err := s.auditRepo.Record(ctx, entry); err != nil {
+		logger.ErrorCtx(ctx, "Ошибка записи события %s в журнал аутентификации: %v", event, err)
+	}
+}
+
+// securityAlert уведомляет пользователя о подозрительной активности
+func (s *UserService) securityAlert(ctx context.Context, userID, title, message string, meta models.SessionMeta) {
+	s.notifier.Notify(ctx, models.NotificationEvent{
+		Type:    models.EventSecurityAlert,
+		UserID:  userID,
+		Title:   title,
+		Message: message,
+		Fields: map[string]string{
+			"ip_address": meta.IPAddress,
+			"user_agent": meta.UserAgent,
+		},
+	})
+}
+
+// lockoutDuration удваивает базовую блокировку за каждую неудачу сверх порога, не превышая максимум
+func lockoutDuration(extraFailures int64, base, max time.Duration) time.Duration {
+	lock := base
+	for i := int64(0); i < extraFailures && lock < max; i++ {
+		lock *= 2
+	}
+	if lock > max {
+		lock = max
+	}
+	return lock
+}
+
+// normalizeEmail приводит email к виду, по которому считаются попытки входа
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -1,87 +1,86 @@
 package services
 
 import (
-	"CryptoLens_Backend/env"
+	"CryptoLens_Backend/config"
 	"CryptoLens_Backend/integration/bybit"
-	"CryptoLens_Backend/logger"
 	"CryptoLens_Backend/models"
 	"CryptoLens_Backend/repositories"
-	"CryptoLens_Backend/trading"
 	"CryptoLens_Backend/types"
+	"CryptoLens_Shared/logger"
 	"context"
 	"database/sql"
 	"fmt"
 	"github.com/shopspring/decimal"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
+const (
+	publicSubscriptionsSyncInterval = 30 * time.Second // плановая сверка публичных подписок с активными инструментами
+	publicSubscriptionsRetryDelay   = 5 * time.Second  // повтор сверки после ошибки
+)
+
 type BybitService struct {
 	bybitClient         bybit.Client
-	wsClient            *bybit.WebSocketClient
-	privateWsClients    map[string]*bybit.WebSocketClient // Карта приватных клиентов по userID
+	publicWs            *bybit.WebSocketPool             // Публичные каналы, распределенные по нескольким соединениям
+	privateWsClients    map[int64]*bybit.WebSocketClient // Карта приватных клиентов по ID аккаунта Bybit
+	privateWsAccounts   map[int64]bybit.BybitAccount     // Аккаунт, с ключом которого открыто приватное соединение
+	privateWsRefresh    chan struct{}                    // Внеочередная сверка приватных соединений с аккаунтами
+	publicWsRefresh     chan struct{}                    // Внеочередная сверка публичных подписок с инструментами
+	publicMutex         sync.Mutex
+	publicSymbols       map[string]bool // Символы, на каналы которых подписаны публичные соединения
+	publicSyncedAt      time.Time       // Время последней успешной сверки публичных подписок
+	publicSyncError     string          // Ошибка последней сверки, пусто после успешной
 	db                  *sql.DB
-	userService         *UserService
 	bybitInstrumentRepo *repositories.BybitInstrumentRepository
 	userInstrumentRepo  *repositories.UserInstrumentRepository
 	bybitAccountRepo    types.BybitAccountRepositoryInterface
 	wsHandler           types.BybitWebSocketHandlerInterface
 	strategyManager     types.StrategyManagerInterface
 	userStrategyService types.UserStrategyServiceInterface
+	notifier            types.NotifierInterface
 	wsMutex             sync.Mutex
 }
 
 func NewBybitService(
 	bybitClient bybit.Client,
 	db *sql.DB,
-	userService *UserService,
+	bybitAccountRepo types.BybitAccountRepositoryInterface,
 	wsHandler types.BybitWebSocketHandlerInterface,
+	strategyManager types.StrategyManagerInterface,
+	userStrategyService types.UserStrategyServiceInterface,
+	notifier types.NotifierInterface,
 ) *BybitService {
-	recvWindow, _ := strconv.Atoi(env.GetBybitRecvWindow())
-	apiMode := env.GetBybitApiMode()
-	wsURL := env.GetBybitWsUrl() + "/v5/public/spot"
-	if apiMode == "test" {
-		wsURL = env.GetBybitWsTestUrl() + "/v5/public/spot"
-	}
-	wsClient := bybit.NewWebSocketClient(wsURL, recvWindow, "", "")
-	strategyManager := trading.NewStrategyManager(
-		bybitClient,
-		repositories.NewUserInstrumentRepository(db),
-		repositories.NewBybitAccountRepository(db),
-	)
-	userStrategyRepo := repositories.NewUserStrategyRepository(db)
-	userStrategyService := NewUserStrategyService(
-		userStrategyRepo,
-		strategyManager,
-		repositories.NewBybitInstrumentRepository(db),
-	)
+	cfg := config.Get().Bybit
+	publicWs := bybit.NewWebSocketPool(cfg.StreamURL()+"/v5/public/spot", cfg.RecvWindow, cfg.WSTopicsPerConnection)
 
 	return &BybitService{
 		bybitClient:         bybitClient,
-		wsClient:            wsClient,
-		privateWsClients:    make(map[string]*bybit.WebSocketClient),
+		publicWs:            publicWs,
+		privateWsClients:    make(map[int64]*bybit.WebSocketClient),
+		privateWsAccounts:   make(map[int64]bybit.BybitAccount),
+		privateWsRefresh:    make(chan struct{}, 1),
+		publicWsRefresh:     make(chan struct{}, 1),
+		publicSymbols:       make(map[string]bool),
 		db:                  db,
-		userService:         userService,
 		bybitInstrumentRepo: repositories.NewBybitInstrumentRepository(db),
 		userInstrumentRepo:  repositories.NewUserInstrumentRepository(db),
-		bybitAccountRepo:    repositories.NewBybitAccountRepository(db),
+		bybitAccountRepo:    bybitAccountRepo,
 		wsHandler:           wsHandler,
 		strategyManager:     strategyManager,
 		userStrategyService: userStrategyService,
+		notifier:            notifier,
 	}
 }
 
-func (s *BybitService) GetWalletBalance(ctx context.Context, token string) (*bybit.BybitWalletBalance, error) {
-	// Получаем ID пользователя из токена
-	userID, err := s.userService.validateToken(token)
-	if err != nil {
-		return nil, err
-	}
-
+// GetWalletBalance возвращает баланс аккаунта пользователя.
+// Если accountID равен 0, используется единственный активный аккаунт.
+func (s *BybitService) GetWalletBalance(ctx context.Context, userID string, accountID int64) (*bybit.BybitWalletBalance, error) {
 	// Получаем аккаунт Bybit пользователя
-	account, err := s.bybitAccountRepo.GetActiveAccountByUserID(ctx, userID)
+	account, err := s.bybitAccountRepo.GetActiveAccount(ctx, userID, accountID)
 	if err != nil {
 		return nil, err
 	}
@@ -95,15 +94,14 @@ func (s *BybitService) GetWalletBalance(ctx context.Context, token string) (*byb
 	return balance, nil
 }
 
-func (s *BybitService) GetFeeRate(ctx context.Context, token string, category string, symbol string, baseCoin string) (*bybit.BybitFeeRateResponse, error) {
-	// Получаем ID пользователя из токена
-	userID, err := s.userService.validateToken(token)
-	if err != nil {
-		return nil, err
-	}
+// GetWalletBalances возвращает балансы всех активных аккаунтов пользователя и их сумму по монетам
+func (s *BybitService) GetWalletBalances(ctx context.Context, userID string) (*models.WalletBalancesResponse, error) {
+	return s.strategyManager.GetWalletBalances(ctx, userID)
+}
 
+func (s *BybitService) GetFeeRate(ctx context.Context, userID string, accountID int64, category string, symbol string, baseCoin string) (*bybit.BybitFeeRateResponse, error) {
 	// Получаем аккаунт Bybit пользователя
-	account, err := s.bybitAccountRepo.GetActiveAccountByUserID(ctx, userID)
+	account, err := s.bybitAccountRepo.GetActiveAccount(ctx, userID, accountID)
 	if err != nil {
 		return nil, err
 	}
@@ -130,19 +128,22 @@ func (s *BybitService) GetInstruments(ctx context.Context, category string) ([]m
 }
 
 func (s *BybitService) StartInstrumentsUpdate(ctx context.Context) {
-	interval, err := time.ParseDuration(env.GetBybitInstrumentsUpdateInterval())
-	if err != nil {
-		interval = 5 * time.Minute // значение по умолчанию
-		logger.LogError("Failed to parse BYBIT_INSTRUMENTS_UPDATE_INTERVAL, using default: %v", err)
-	}
-
+	interval := config.Get().Bybit.InstrumentsUpdateInterval.Std()
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	changed := config.Changed()
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case <-changed:
+			changed = config.Changed()
+			if next := config.Get().Bybit.InstrumentsUpdateInterval.Std(); next != interval {
+				interval = next
+				ticker.Reset(interval)
+				logger.LogInfo("Интервал обновления инструментов изменен на %s", interval)
+			}
 		case <-ticker.C:
 			if err := s.updateInstruments(ctx); err != nil {
 				logger.LogError("Error updating instruments: %v", err)
@@ -250,67 +251,151 @@ func (s *BybitService) UpdateInstruments(ctx context.Context) error {
 	return nil
 }
 
-// StartWebSocket запускает WebSocket-соединение и подписку на каналы
+// StartWebSocket запускает публичные WebSocket-соединения и поддерживает подписки на каналы активных
+// инструментов: сверка выполняется по расписанию и после изменения инструментов пользователями
 func (s *BybitService) StartWebSocket(ctx context.Context) {
+	// Соединения пула сами восстанавливают подписки, стратегии приостанавливаются на время обрыва
+	s.publicWs.SetEventHandler(func(event bybit.ConnectionEvent) {
+		s.strategyManager.HandleConnection(ctx, 0, event)
+	})
+	s.publicWs.Start(ctx, s.wsHandler.HandleMessage)
+
 	go func() {
 		for {
+			wait := publicSubscriptionsSyncInterval
+			err := s.syncPublicSubscriptions(ctx)
+			s.publicMutex.Lock()
+			if err != nil {
+				s.publicSyncError = err.Error()
+				wait = publicSubscriptionsRetryDelay
+			} else {
+				s.publicSyncError = ""
+				s.publicSyncedAt = time.Now()
+			}
+			s.publicMutex.Unlock()
+			if err != nil {
+				logger.LogError("Ошибка сверки публичных подписок: %v", err)
+			}
+
 			select {
 			case <-ctx.Done():
+				// Закрываем соединения, чтобы прервать ожидающее чтение
+				s.publicWs.Close()
 				return
-			default:
-				// Получаем активные инструменты через репозиторий
-				activeSymbols, err := s.userInstrumentRepo.GetActiveInstruments(ctx)
-				if err != nil {
-					logger.LogError("Failed to get active instruments: %v", err)
-					time.Sleep(5 * time.Second)
-					continue
-				}
+			case <-s.publicWsRefresh:
+			case <-time.After(wait):
+			}
+		}
+	}()
+}
 
-				// Формируем каналы для подписки
-				var publicChannels []string
-				for _, symbol := range activeSymbols {
-					publicChannels = append(publicChannels,
-						fmt.Sprintf("tickers.%s", symbol),
-						fmt.Sprintf("orderbook.50.%s", symbol),
-						fmt.Sprintf("publicTrade.%s", symbol),
-					)
-				}
+// syncPublicSubscriptions подписывается на каналы новых активных инструментов и отписывается от каналов
+// инструментов, которые больше никому не нужны. Отправляются только изменения.
+func (s *BybitService) syncPublicSubscriptions(ctx context.Context) error {
+	activeSymbols, err := s.userInstrumentRepo.GetActiveInstruments(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get active instruments: %w", err)
+	}
+	if len(activeSymbols) == 0 && len(s.publicSymbols) == 0 {
+		logger.LogInfo("Нет активных инструментов для подписки")
+		return nil
+	}
 
-				// Логируем каналы
-				if len(publicChannels) == 0 {
-					logger.LogInfo("Нет активных инструментов для подписки")
-					time.Sleep(5 * time.Second)
-					continue
-				}
-				logger.LogInfo("Подписываемся на каналы для %d активных инструментов: %v", len(activeSymbols), publicChannels)
+	active := make(map[string]bool, len(activeSymbols))
+	var added []string
+	for _, symbol := range activeSymbols {
+		active[symbol] = true
+		if !s.publicSymbols[symbol] {
+			added = append(added, symbol)
+		}
+	}
+	var removed []string
+	for symbol := range s.publicSymbols {
+		if !active[symbol] {
+			removed = append(removed, symbol)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
 
-				// Подключаемся к WebSocket
-				if err := s.wsClient.Connect(ctx); err != nil {
-					logger.LogError("Failed to connect to WebSocket: %v", err)
-					time.Sleep(5 * time.Second)
-					continue
-				}
+	if len(removed) > 0 {
+		if err := s.publicWs.Unsubscribe(ctx, publicChannels(removed)); err != nil {
+			return fmt.Errorf("failed to unsubscribe from public channels: %w", err)
+		}
+		s.publicMutex.Lock()
+		for _, symbol := range removed {
+			delete(s.publicSymbols, symbol)
+		}
+		s.publicMutex.Unlock()
+		logger.LogInfo("Отписались от каналов инструментов: %v", removed)
+	}
+	if len(added) > 0 {
+		if err := s.publicWs.Subscribe(ctx, publicChannels(added)); err != nil {
+			return fmt.Errorf("failed to subscribe to public channels: %w", err)
+		}
+		s.publicMutex.Lock()
+		for _, symbol := range added {
+			s.publicSymbols[symbol] = true
+		}
+		s.publicMutex.Unlock()
+		logger.LogInfo("Подписались на каналы инструментов: %v, всего инструментов: %d", added, len(active))
+	}
+	return nil
+}
 
-				// Запускаем обработку сообщений
-				s.wsClient.StartMessageHandler(context.Background(), s.wsHandler.HandleMessage)
+// publicChannels возвращает публичные каналы Bybit, на которые подписывается каждый инструмент
+func publicChannels(symbols []string) []string {
+	channels := make([]string, 0, len(symbols)*3)
+	for _, symbol := range symbols {
+		channels = append(channels,
+			fmt.Sprintf("tickers.%s", symbol),
+			fmt.Sprintf("orderbook.50.%s", symbol),
+			fmt.Sprintf("publicTrade.%s", symbol),
+		)
+	}
+	return channels
+}
 
-				// Подписываемся на публичные каналы
-				if err := s.wsClient.Subscribe(ctx, publicChannels); err != nil {
-					logger.LogError("Failed to subscribe to public channels: %v", err)
-					s.wsClient.Close()
-					time.Sleep(5 * time.Second)
-					continue
-				}
+// RefreshPublicSubscriptions запускает сверку публичных подписок, не дожидаясь очередной проверки
+func (s *BybitService) RefreshPublicSubscriptions() {
+	select {
+	case s.publicWsRefresh <- struct{}{}:
+	default:
+	}
+}
 
-				// Логируем успешную подписку
-				logger.LogInfo("Успешно подписались на %d каналов для %d активных инструментов", len(publicChannels), len(activeSymbols))
+// GetPublicSubscriptions возвращает инструменты и каналы, на которые подписано публичное соединение
+func (s *BybitService) GetPublicSubscriptions() models.PublicSubscriptionsStatus {
+	s.publicMutex.Lock()
+	defer s.publicMutex.Unlock()
 
-				// Ждем завершения контекста
-				<-ctx.Done()
-				return
-			}
+	status := models.PublicSubscriptionsStatus{
+		Symbols: make([]string, 0, len(s.publicSymbols)),
+		Error:   s.publicSyncError,
+	}
+	for symbol := range s.publicSymbols {
+		status.Symbols = append(status.Symbols, symbol)
+	}
+	sort.Strings(status.Symbols)
+	status.Channels = publicChannels(status.Symbols)
+	connections := s.publicWs.Connections()
+	status.Connections = make([]models.PublicConnectionStatus, 0, len(connections))
+	for _, conn := range connections {
+		connStatus := models.PublicConnectionStatus{
+			Connected: conn.Connected,
+			Channels:  conn.Channels,
 		}
-	}()
+		if !conn.LastMessageAt.IsZero() {
+			lastMessageAt := conn.LastMessageAt
+			connStatus.LastMessageAt = &lastMessageAt
+		}
+		status.Connections = append(status.Connections, connStatus)
+	}
+	if !s.publicSyncedAt.IsZero() {
+		syncedAt := s.publicSyncedAt
+		status.SyncedAt = &syncedAt
+	}
+	return status
 }
 
 // StartPrivateWebSocket запускает приватные WebSocket-соединения для активных аккаунтов
@@ -331,34 +416,34 @@ func (s *BybitService) StartPrivateWebSocket(ctx context.Context) {
 				}
 
 				s.wsMutex.Lock()
-				// Закрываем соединения для неактивных аккаунтов
-				for userID := range s.privateWsClients {
-					if !s.isAccountActive(userID, accounts) {
-						if client, exists := s.privateWsClients[userID]; exists {
-							client.Close()
-							delete(s.privateWsClients, userID)
-							logger.LogInfo("Закрыто приватное WebSocket-соединение для userID: %s", userID)
-						}
+				// Закрываем соединения для неактивных аккаунтов и аккаунтов со смененными ключами
+				for accountID, client := range s.privateWsClients {
+					if !s.isConnectionCurrent(accountID, accounts) {
+						client.Close()
+						s.forgetPrivateWs(accountID)
+						logger.LogInfo("Закрыто приватное WebSocket-соединение для аккаунта: %d", accountID)
 					}
 				}
 
-				// Создаем соединения для активных аккаунтов
-				apiMode := env.GetBybitApiMode()
-				privateWsURL := env.GetBybitWsUrl() + "/v5/private"
-				if apiMode == "test" {
-					privateWsURL = env.GetBybitWsTestUrl() + "/v5/private"
-				}
-				recvWindow, _ := strconv.Atoi(env.GetBybitRecvWindow())
+				// Создаем соединения для активных аккаунтов, каждое — в окружении своего аккаунта
+				recvWindow := config.Get().Bybit.RecvWindow
 
 				for _, account := range accounts {
-					if _, exists := s.privateWsClients[account.UserID]; !exists {
+					if _, exists := s.privateWsClients[account.ID]; !exists {
+						endpoints, err := s.bybitClient.Endpoints(&account)
+						if err != nil {
+							logger.LogError("Failed to resolve private WebSocket URL for account %d: %v", account.ID, err)
+							continue
+						}
+						privateWsURL := endpoints.Stream + "/v5/private"
 						wsClient := bybit.NewWebSocketClient(privateWsURL, recvWindow, account.APIKey, account.APISecret)
-						s.privateWsClients[account.UserID] = wsClient
+						s.privateWsClients[account.ID] = wsClient
+						s.privateWsAccounts[account.ID] = account
 
 						// Подключаемся и подписываемся
 						if err := wsClient.Connect(ctx); err != nil {
-							logger.LogError("Failed to connect to private WebSocket for userID %s: %v", account.UserID, err)
-							delete(s.privateWsClients, account.UserID)
+							logger.LogError("Failed to connect to private WebSocket for account %d (userID %s): %v", account.ID, account.UserID, err)
+							s.forgetPrivateWs(account.ID)
 							continue
 						}
 
@@ -368,50 +453,126 @@ func (s *BybitService) StartPrivateWebSocket(ctx context.Context) {
 							//"execution.fast.spot",
 							"wallet",
 						}
-						// Передаем userID в обработчик
-						userID := account.UserID
+						// Передаем пользователя и аккаунт в обработчик
+						userID, accountID := account.UserID, account.ID
+						wsClient.SetEventHandler(func(event bybit.ConnectionEvent) {
+							if event.State == bybit.ConnectionDisconnected {
+								s.notifyPrivateWsDisconnected(ctx, userID, accountID, event.Err)
+							}
+							s.strategyManager.HandleConnection(ctx, accountID, event)
+						})
 						wsClient.StartMessageHandler(ctx, func(ctx context.Context, msg bybit.WebSocketMessage) {
-							s.wsHandler.HandlePrivateMessage(ctx, msg, userID)
+							s.wsHandler.HandlePrivateMessage(ctx, msg, userID, accountID)
 						})
 
 						if err := wsClient.Subscribe(ctx, privateChannels); err != nil {
-							logger.LogError("Failed to subscribe to private channels for userID %s: %v", account.UserID, err)
+							logger.LogError("Failed to subscribe to private channels for account %d (userID %s): %v", account.ID, account.UserID, err)
 							wsClient.Close()
-							delete(s.privateWsClients, account.UserID)
+							s.forgetPrivateWs(account.ID)
 							continue
 						}
 
-						logger.LogInfo("Успешно подключились к приватному WebSocket для userID: %s", account.UserID)
+						logger.LogInfo("Успешно подключились к приватному WebSocket для аккаунта %d (userID: %s)", account.ID, account.UserID)
+						// Новое соединение заменяет прежнее, которое могло закрыться оборванным: стратегии аккаунта
+						// возобновляют работу и сверяют активные ордера
+						s.strategyManager.HandleConnection(ctx, accountID, bybit.ConnectionEvent{State: bybit.ConnectionRestored, Private: true})
 					}
 				}
 				s.wsMutex.Unlock()
 
-				time.Sleep(30 * time.Second) // Проверяем аккаунты каждые 30 секунд
+				// Проверяем аккаунты каждые 30 секунд или сразу после изменения ключей
+				select {
+				case <-ctx.Done():
+				case <-s.privateWsRefresh:
+				case <-time.After(30 * time.Second):
+				}
 			}
 		}
 	}()
 }
 
-// isAccountActive проверяет, активен ли аккаунт
-func (s *BybitService) isAccountActive(userID string, accounts []bybit.BybitAccount) bool {
+// notifyPrivateWsDisconnected уведомляет пользователя о разрыве приватного WebSocket-соединения
+func (s *BybitService) notifyPrivateWsDisconnected(ctx context.Context, userID string, accountID int64, err error) {
+	s.notifier.Notify(ctx, models.NotificationEvent{
+		Type:    models.EventWSDisconnected,
+		UserID:  userID,
+		Title:   "Потеряно соединение с Bybit",
+		Message: "Приватное WebSocket-соединение разорвано, обновления ордеров временно не поступают",
+		Fields: map[string]string{
+			"account_id": strconv.FormatInt(accountID, 10),
+			"error":      err.Error(),
+		},
+	})
+}
+
+// RefreshPrivateWebSockets запускает сверку приватных соединений, не дожидаясь очередной проверки
+func (s *BybitService) RefreshPrivateWebSockets() {
+	select {
+	case s.privateWsRefresh <- struct{}{}:
+	default:
+	}
+}
+
+// isConnectionCurrent проверяет, что соединение открыто для активного аккаунта с текущим ключом и окружением
+func (s *BybitService) isConnectionCurrent(accountID int64, accounts []bybit.BybitAccount) bool {
+	connected := s.privateWsAccounts[accountID]
 	for _, account := range accounts {
-		if account.UserID == userID {
-			return true
+		if account.ID == accountID {
+			return account.APIKey == connected.APIKey && account.Environment == connected.Environment
 		}
 	}
 	return false
 }
 
+// forgetPrivateWs удаляет сведения о приватном соединении аккаунта
+func (s *BybitService) forgetPrivateWs(accountID int64) {
+	delete(s.privateWsClients, accountID)
+	delete(s.privateWsAccounts, accountID)
+}
+
 // closePrivateWebSockets закрывает все приватные WebSocket-соединения
 func (s *BybitService) closePrivateWebSockets() {
 	s.wsMutex.Lock()
 	defer s.wsMutex.Unlock()
 
-	for userID, client := range s.privateWsClients {
+	for accountID, client := range s.privateWsClients {
 		client.Close()
-		delete(s.privateWsClients, userID)
-		logger.LogInfo("Закрыто приватное WebSocket-соединение для userID: %s", userID)
+		s.forgetPrivateWs(accountID)
+		logger.LogInfo("Закрыто приватное WebSocket-соединение для аккаунта: %d", accountID)
+	}
+}
+
+// IsPublicWebSocketConnected сообщает, установлены ли все публичные WebSocket-соединения
+func (s *BybitService) IsPublicWebSocketConnected() bool {
+	return s.publicWs.IsConnected()
+}
+
+// GetPrivateWebSocketStatuses возвращает состояние приватных WebSocket-соединений по аккаунтам
+func (s *BybitService) GetPrivateWebSocketStatuses() []models.PrivateWebSocketStatus {
+	s.wsMutex.Lock()
+	defer s.wsMutex.Unlock()
+
+	now := time.Now()
+	statuses := make([]models.PrivateWebSocketStatus, 0, len(s.privateWsClients))
+	for accountID, client := range s.privateWsClients {
+		account := s.privateWsAccounts[accountID]
+		status := models.PrivateWebSocketStatus{
+			AccountID: accountID,
+			UserID:    account.UserID,
+			Connected: client.IsConnected(),
+		}
+		if endpoints, err := s.bybitClient.Endpoints(&account); err == nil {
+			status.Environment = endpoints.Environment
+		}
+		if lastMessageAt := client.LastMessageAt(); !lastMessageAt.IsZero() {
+			age := now.Sub(lastMessageAt).Seconds()
+			status.LastMessageAt = &lastMessageAt
+			status.AgeSeconds = &age
+		}
+		statuses = append(statuses, status)
 	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].AccountID < statuses[j].AccountID })
+	return statuses
 }
 
 func (s *BybitService) GetStrategyManager() types.StrategyManagerInterface {
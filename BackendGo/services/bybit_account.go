@@ -0,0 +1,299 @@
+package services
+
+import (
+	"CryptoLens_Backend/integration/bybit"
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/types"
+	"CryptoLens_Shared/logger"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// credentialsCheckTimeout ограничение на проверку ключей через API Bybit
+const credentialsCheckTimeout = 15 * time.Second
+
+// BybitAccountService управляет API-ключами Bybit пользователя.
+// Ключи проверяются запросом к Bybit до сохранения, а приватные WebSocket-соединения
+// пересобираются сразу после любого изменения.
+type BybitAccountService struct {
+	accountRepo types.BybitAccountRepositoryInterface
+	bybitClient bybit.Client
+	streams     types.PrivateStreamRefresherInterface
+	strategies  types.AccountStrategyStopperInterface
+	guard       types.CredentialsGuardInterface
+}
+
+// NewBybitAccountService создает сервис управления API-ключами Bybit
+func NewBybitAccountService(
+	accountRepo types.BybitAccountRepositoryInterface,
+	bybitClient bybit.Client,
+	streams types.PrivateStreamRefresherInterface,
+	strategies types.AccountStrategyStopperInterface,
+	guard types.CredentialsGuardInterface,
+) *BybitAccountService {
+	return &BybitAccountService{
+		accountRepo: accountRepo,
+		bybitClient: bybitClient,
+		streams:     streams,
+		strategies:  strategies,
+		guard:       guard,
+	}
+}
+
+// GetAccounts возвращает аккаунты пользователя без секретов
+func (s *BybitAccountService) GetAccounts(ctx context.Context, userID string) ([]models.BybitAccountResponse, error) {
+	accounts, err := s.accountRepo.GetAccountsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	response := make([]models.BybitAccountResponse, 0, len(accounts))
+	for i := range accounts {
+		response = append(response, s.toResponse(&accounts[i]))
+	}
+	return response, nil
+}
+
+// AddAccount проверяет ключи и сохраняет аккаунт
+func (s *BybitAccountService) AddAccount(ctx context.Context, userID string, req models.CreateBybitAccountRequest, meta models.SessionMeta) (*models.BybitAccountResponse, error) {
+	// Ключи биржи подключают только владельцы подтвержденного адреса: на него приходит сброс пароля
+	if err := s.guard.EnsureEmailVerified(ctx, userID); err != nil {
+		return nil, err
+	}
+	req.APIKey = strings.TrimSpace(req.APIKey)
+	req.APISecret = strings.TrimSpace(req.APISecret)
+	req.Label = strings.TrimSpace(req.Label)
+	req.Environment = strings.ToLower(strings.TrimSpace(req.Environment))
+	if req.AccountType == "" {
+		req.AccountType = "UNIFIED"
+	}
+	if req.Environment == "" {
+		// Новый аккаунт закрепляется за текущим окружением по умолчанию, чтобы смена
+		// BYBIT_API_MODE не переносила его ключи в другое окружение
+		req.Environment = s.accountEnvironment(&bybit.BybitAccount{})
+	}
+	if req.APIKey == "" || req.APISecret == "" {
+		return nil, models.Invalid("api_key and api_secret are required")
+	}
+	if req.AccountType != "UNIFIED" && req.AccountType != "SPOT" && req.AccountType != "FUTURES" {
+		return nil, models.Invalid("account_type must be one of UNIFIED, SPOT, FUTURES")
+	}
+	if len(req.Label) > 100 {
+		return nil, models.Invalid("label must be at most 100 characters")
+	}
+	if !bybit.IsValidEnvironment(req.Environment) {
+		return nil, models.Invalid("environment must be one of mainnet, testnet, demo")
+	}
+
+	// Один и тот же ключ дважды дал бы два приватных потока с одинаковыми событиями
+	existing, err := s.accountRepo.GetAccountsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range existing {
+		if existing[i].APIKey == req.APIKey && s.accountEnvironment(&existing[i]) == req.Environment {
+			return nil, models.Conflict("Bybit account with this API key already exists")
+		}
+	}
+	// Код проверяется после разбора запроса: принятый код погашается, и ошибка во вводе
+	// заставила бы ждать следующий
+	if err := s.guard.VerifySecondFactor(ctx, userID, req.OTPCode, meta); err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyCredentials(ctx, &bybit.BybitAccount{
+		UserID:      userID,
+		APIKey:      req.APIKey,
+		APISecret:   req.APISecret,
+		AccountType: req.AccountType,
+		Environment: req.Environment,
+	}); err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepo.CreateAccount(ctx, userID, req.APIKey, req.APISecret, req.AccountType, req.Label, req.Environment)
+	if err != nil {
+		return nil, err
+	}
+	logger.InfoCtx(logger.WithFields(ctx, logger.FieldAccountID, account.ID), "Добавлен аккаунт Bybit")
+	s.streams.RefreshPrivateWebSockets()
+
+	response := s.toResponse(account)
+	return &response, nil
+}
+
+// RotateCredentials проверяет новые ключи и заменяет ими ключи аккаунта
+func (s *BybitAccountService) RotateCredentials(ctx context.Context, userID string, id int64, req models.RotateBybitAccountRequest, meta models.SessionMeta) (*models.BybitAccountResponse, error) {
+	if err := s.guard.EnsureEmailVerified(ctx, userID); err != nil {
+		return nil, err
+	}
+	req.APIKey = strings.TrimSpace(req.APIKey)
+	req.APISecret = strings.TrimSpace(req.APISecret)
+	req.Environment = strings.ToLower(strings.TrimSpace(req.Environment))
+	if req.APIKey == "" || req.APISecret == "" {
+		return nil, models.Invalid("api_key and api_secret are required")
+	}
+	if req.Environment != "" && !bybit.IsValidEnvironment(req.Environment) {
+		return nil, models.Invalid("environment must be one of mainnet, testnet, demo")
+	}
+
+	account, err := s.accountRepo.GetAccount(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	environment := account.Environment
+	if req.Environment != "" {
+		environment = req.Environment
+	}
+	if err := s.guard.VerifySecondFactor(ctx, userID, req.OTPCode, meta); err != nil {
+		return nil, err
+	}
+	if err := s.verifyCredentials(ctx, &bybit.BybitAccount{
+		ID:          account.ID,
+		UserID:      userID,
+		APIKey:      req.APIKey,
+		APISecret:   req.APISecret,
+		AccountType: account.AccountType,
+		Environment: environment,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.accountRepo.UpdateCredentials(ctx, userID, id, req.APIKey, req.APISecret, req.Environment); err != nil {
+		return nil, err
+	}
+	logger.InfoCtx(logger.WithFields(ctx, logger.FieldAccountID, id), "Ключи аккаунта Bybit заменены")
+	s.streams.RefreshPrivateWebSockets()
+
+	return s.getAccountResponse(ctx, userID, id)
+}
+
+// SetStatus включает или отключает аккаунт. Отключение останавливает стратегии аккаунта,
+// поэтому требует того же подтверждения, что и смена ключей.
+func (s *BybitAccountService) SetStatus(ctx context.Context, userID string, id int64, req models.SetBybitAccountStatusRequest, meta models.SessionMeta) (*models.BybitAccountResponse, error) {
+	if err := s.confirmAccountChange(ctx, userID, id, req.OTPCode, meta); err != nil {
+		return nil, err
+	}
+	isActive := req.IsActive
+	if err := s.accountRepo.SetActive(ctx, userID, id, isActive); err != nil {
+		return nil, err
+	}
+	logger.InfoCtx(logger.WithFields(ctx, logger.FieldAccountID, id), "Аккаунт Bybit is_active=%t", isActive)
+	if !isActive {
+		s.stopAccountStrategies(ctx, userID, id, "аккаунт Bybit отключен")
+	}
+	s.streams.RefreshPrivateWebSockets()
+
+	return s.getAccountResponse(ctx, userID, id)
+}
+
+// RemoveAccount удаляет аккаунт вместе с секретом после того же подтверждения, что и смена ключей
+func (s *BybitAccountService) RemoveAccount(ctx context.Context, userID string, id int64, req models.RemoveBybitAccountRequest, meta models.SessionMeta) error {
+	if err := s.confirmAccountChange(ctx, userID, id, req.OTPCode, meta); err != nil {
+		return err
+	}
+	if err := s.accountRepo.DeleteAccount(ctx, userID, id); err != nil {
+		return err
+	}
+	logger.InfoCtx(logger.WithFields(ctx, logger.FieldAccountID, id), "Аккаунт Bybit удален")
+	s.stopAccountStrategies(ctx, userID, id, "аккаунт Bybit удален")
+	s.streams.RefreshPrivateWebSockets()
+	return nil
+}
+
+// confirmAccountChange требует подтвержденный email и код второго фактора: иначе украденная сессия
+// могла бы отключить торговый аккаунт и остановить его стратегии. Сначала проверяется, что аккаунт
+// существует, чтобы не погасить код ради запроса, который все равно не выполнится.
+func (s *BybitAccountService) confirmAccountChange(ctx context.Context, userID string, id int64, otpCode string, meta models.SessionMeta) error {
+	if err := s.guard.EnsureEmailVerified(ctx, userID); err != nil {
+		return err
+	}
+	if _, err := s.accountRepo.GetAccount(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.guard.VerifySecondFactor(ctx, userID, otpCode, meta)
+}
+
+// stopAccountStrategies останавливает стратегии аккаунта. Ошибка только логируется:
+// изменение аккаунта уже сохранено.
+func (s *BybitAccountService) stopAccountStrategies(ctx context.Context, userID string, id int64, reason string) {
+	if err := s.strategies.StopAccountStrategies(ctx, userID, id, reason); err != nil {
+		logger.ErrorCtx(logger.WithFields(ctx, logger.FieldAccountID, id), "Ошибка остановки стратегий аккаунта: %v", err)
+	}
+}
+
+// verifyCredentials проверяет, что ключ действует, позволяет торговать на споте,
+// не дает права вывода средств и открывает доступ к балансу
+func (s *BybitAccountService) verifyCredentials(ctx context.Context, account *bybit.BybitAccount) error {
+	ctx, cancel := context.WithTimeout(ctx, credentialsCheckTimeout)
+	defer cancel()
+
+	info, err := s.bybitClient.GetAPIKeyInfo(ctx, account)
+	if err != nil {
+		// Сбой сети или лимит запросов не говорят о том, что ключ недействителен
+		if bybit.ClassifyError(err) != bybit.ErrorClassAuth {
+			return fmt.Errorf("failed to check the API key with Bybit: %w", err)
+		}
+		logger.WarnCtx(ctx, "Bybit отклонил ключ при проверке: %v", err)
+		return models.Invalid("Bybit rejected the API key")
+	}
+	if info.ReadOnly != 0 {
+		return models.Invalid("API key is read-only, spot trading permission is required")
+	}
+	if !info.HasPermission("Spot", "SpotTrade") {
+		return models.Invalid("API key has no spot trading permission")
+	}
+	if info.HasPermission("Wallet", "Withdraw") {
+		return models.Invalid("API key must not have withdrawal permission")
+	}
+
+	if _, err := s.bybitClient.GetWalletBalance(ctx, account); err != nil {
+		if bybit.ClassifyError(err) == bybit.ErrorClassAuth {
+			logger.WarnCtx(ctx, "Ключ не дает доступа к балансу: %v", err)
+			return models.Invalid("API key has no access to the wallet balance")
+		}
+		return fmt.Errorf("failed to read wallet balance with the API key: %w", err)
+	}
+	return nil
+}
+
+func (s *BybitAccountService) getAccountResponse(ctx context.Context, userID string, id int64) (*models.BybitAccountResponse, error) {
+	account, err := s.accountRepo.GetAccount(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	response := s.toResponse(account)
+	return &response, nil
+}
+
+// accountEnvironment возвращает окружение, в котором фактически работает аккаунт
+func (s *BybitAccountService) accountEnvironment(account *bybit.BybitAccount) string {
+	endpoints, err := s.bybitClient.Endpoints(account)
+	if err != nil {
+		return account.Environment
+	}
+	return endpoints.Environment
+}
+
+func (s *BybitAccountService) toResponse(account *bybit.BybitAccount) models.BybitAccountResponse {
+	return models.BybitAccountResponse{
+		ID:          account.ID,
+		APIKey:      maskAPIKey(account.APIKey),
+		AccountType: account.AccountType,
+		Label:       account.Label,
+		Environment: s.accountEnvironment(account),
+		IsActive:    account.IsActive,
+		CreatedAt:   account.CreatedAt,
+		UpdatedAt:   account.UpdatedAt,
+	}
+}
+
+// maskAPIKey оставляет видимыми только последние символы ключа, чтобы пользователь мог его узнать
+func maskAPIKey(apiKey string) string {
+	const visible = 4
+	if len(apiKey) <= visible {
+		return strings.Repeat("*", len(apiKey))
+	}
+	return strings.Repeat("*", len(apiKey)-visible) + apiKey[len(apiKey)-visible:]
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"CryptoLens_Backend/integration/bybit"
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/types"
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeGuard требует код "123456", если у пользователя включен второй фактор
+type fakeGuard struct {
+	twoFactor bool
+}
+
+func (g *fakeGuard) EnsureEmailVerified(context.Context, string) error {
+	return nil
+}
+
+func (g *fakeGuard) VerifySecondFactor(_ context.Context, _ string, code string, _ models.SessionMeta) error {
+	switch {
+	case !g.twoFactor:
+		return nil
+	case code == "":
+		return ErrTwoFactorRequired
+	case code != "123456":
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+type fakeAccountRepo struct {
+	types.BybitAccountRepositoryInterface
+	accounts map[int64]*bybit.BybitAccount
+}
+
+func (r *fakeAccountRepo) GetAccount(_ context.Context, userID string, id int64) (*bybit.BybitAccount, error) {
+	account := r.accounts[id]
+	if account == nil || account.UserID != userID {
+		return nil, models.NotFound("Bybit account not found")
+	}
+	return account, nil
+}
+
+func (r *fakeAccountRepo) SetActive(_ context.Context, _ string, id int64, isActive bool) error {
+	r.accounts[id].IsActive = isActive
+	return nil
+}
+
+func (r *fakeAccountRepo) DeleteAccount(_ context.Context, _ string, id int64) error {
+	delete(r.accounts, id)
+	return nil
+}
+
+type fakeStreams struct{}
+
+func (fakeStreams) RefreshPrivateWebSockets() {}
+
+type fakeStopper struct {
+	stopped []int64
+}
+
+func (s *fakeStopper) StopAccountStrategies(_ context.Context, _ string, accountID int64, _ string) error {
+	s.stopped = append(s.stopped, accountID)
+	return nil
+}
+
+func newTestAccountService() (*BybitAccountService, *fakeAccountRepo, *fakeStopper) {
+	repo := &fakeAccountRepo{accounts: map[int64]*bybit.BybitAccount{
+		1: {ID: 1, UserID: "user-1", APIKey: "key", IsActive: true},
+	}}
+	stopper := &fakeStopper{}
+	client := bybit.NewClient(map[string]bybit.Endpoints{bybit.EnvironmentMainnet: {REST: "https://api.bybit.com"}}, bybit.EnvironmentMainnet, 5000)
+	return NewBybitAccountService(repo, client, fakeStreams{}, stopper, &fakeGuard{twoFactor: true}), repo, stopper
+}
+
+func TestDisableAccountRequiresSecondFactor(t *testing.T) {
+	s, repo, stopper := newTestAccountService()
+	ctx := context.Background()
+
+	for _, code := range []string{"", "000000"} {
+		_, err := s.SetStatus(ctx, "user-1", 1, models.SetBybitAccountStatusRequest{IsActive: false, OTPCode: code}, models.SessionMeta{})
+		if !errors.Is(err, ErrTwoFactorRequired) && !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Fatalf("SetStatus with code %q: error = %v, want a second-factor error", code, err)
+		}
+	}
+	if !repo.accounts[1].IsActive || len(stopper.stopped) != 0 {
+		t.Fatal("the account was disabled without a valid code")
+	}
+
+	if _, err := s.SetStatus(ctx, "user-1", 1, models.SetBybitAccountStatusRequest{IsActive: false, OTPCode: "123456"}, models.SessionMeta{}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.accounts[1].IsActive || len(stopper.stopped) != 1 {
+		t.Error("the account was not disabled with a valid code")
+	}
+}
+
+func TestRemoveAccountRequiresSecondFactor(t *testing.T) {
+	s, repo, stopper := newTestAccountService()
+	ctx := context.Background()
+
+	err := s.RemoveAccount(ctx, "user-1", 1, models.RemoveBybitAccountRequest{}, models.SessionMeta{})
+	if !errors.Is(err, ErrTwoFactorRequired) {
+		t.Fatalf("RemoveAccount without a code: error = %v, want ErrTwoFactorRequired", err)
+	}
+	if repo.accounts[1] == nil {
+		t.Fatal("the account was removed without a code")
+	}
+
+	// Чужой аккаунт не найден еще до проверки кода
+	err = s.RemoveAccount(ctx, "user-2", 1, models.RemoveBybitAccountRequest{OTPCode: "123456"}, models.SessionMeta{})
+	if !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("RemoveAccount of another user: error = %v, want models.ErrNotFound", err)
+	}
+
+	if err := s.RemoveAccount(ctx, "user-1", 1, models.RemoveBybitAccountRequest{OTPCode: "123456"}, models.SessionMeta{}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.accounts[1] != nil || len(stopper.stopped) != 1 {
+		t.Error("the account was not removed with a valid code")
+	}
+}
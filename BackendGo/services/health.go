@@ -0,0 +1,230 @@
+package services
+
+import (
+	"CryptoLens_Backend/config"
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/repositories"
+	"CryptoLens_Backend/storages"
+	"CryptoLens_Backend/types"
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	healthCheckTimeout        = 2 * time.Second // ограничение на проверку одной зависимости
+	publicStreamStaleAfter    = time.Minute     // символ без сообщений дольше этого считается зависшим
+	instrumentsStaleAfterRuns = 3               // допустимое число пропущенных обновлений справочника
+)
+
+// HealthService собирает состояние зависимостей и фоновых процессов
+type HealthService struct {
+	db                  *sql.DB
+	bybitService        types.BybitServiceInterface
+	wsHandler           types.BybitWebSocketHandlerInterface
+	strategyManager     types.StrategyManagerInterface
+	userInstrumentRepo  *repositories.UserInstrumentRepository
+	bybitInstrumentRepo *repositories.BybitInstrumentRepository
+	startedAt           time.Time
+}
+
+// NewHealthService создает сервис проверки состояния
+func NewHealthService(
+	db *sql.DB,
+	bybitService types.BybitServiceInterface,
+	wsHandler types.BybitWebSocketHandlerInterface,
+	strategyManager types.StrategyManagerInterface,
+	userInstrumentRepo *repositories.UserInstrumentRepository,
+	bybitInstrumentRepo *repositories.BybitInstrumentRepository,
+) *HealthService {
+	return &HealthService{
+		db:                  db,
+		bybitService:        bybitService,
+		wsHandler:           wsHandler,
+		strategyManager:     strategyManager,
+		userInstrumentRepo:  userInstrumentRepo,
+		bybitInstrumentRepo: bybitInstrumentRepo,
+		startedAt:           time.Now(),
+	}
+}
+
+// Ready проверяет зависимости, без которых сервис не может обрабатывать запросы
+func (s *HealthService) Ready(ctx context.Context) *models.ReadinessStatus {
+	database := s.checkDatabase(ctx)
+	redis := s.checkRedis(ctx)
+
+	status := models.HealthStatusOK
+	if database.Status != models.HealthStatusOK || redis.Status != models.HealthStatusOK {
+		status = models.HealthStatusDown
+	}
+	return &models.ReadinessStatus{
+		Status: status,
+		Components: map[string]models.ComponentStatus{
+			"database": database,
+			"redis":    redis,
+		},
+	}
+}
+
+// Status возвращает подробное состояние всех компонентов
+func (s *HealthService) Status(ctx context.Context) *models.SystemStatus {
+	now := time.Now()
+	status := &models.SystemStatus{
+		CheckedAt:         now,
+		UptimeSeconds:     now.Sub(s.startedAt).Seconds(),
+		Database:          s.checkDatabase(ctx),
+		Redis:             s.checkRedis(ctx),
+		PrivateWebSockets: s.bybitService.GetPrivateWebSocketStatuses(),
+		Strategies:        s.strategiesStatus(),
+	}
+	// Без базы данных список активных символов и время обновления справочника недоступны
+	if status.Database.Status == models.HealthStatusOK {
+		status.PublicWebSocket = s.publicWebSocketStatus(ctx, now)
+		status.Instruments = s.instrumentsStatus(ctx, now)
+	} else {
+		status.PublicWebSocket = models.PublicWebSocketStatus{
+			Status:        models.HealthStatusDown,
+			Connected:     s.bybitService.IsPublicWebSocketConnected(),
+			Symbols:       []models.SymbolStreamStatus{},
+			Subscriptions: s.bybitService.GetPublicSubscriptions(),
+		}
+		status.Instruments = models.InstrumentsStatus{Status: models.HealthStatusDown, Error: "database unavailable"}
+	}
+
+	switch {
+	case status.Database.Status != models.HealthStatusOK || status.Redis.Status != models.HealthStatusOK:
+		status.Status = models.HealthStatusDown
+	case status.PublicWebSocket.Status != models.HealthStatusOK || status.Instruments.Status != models.HealthStatusOK:
+		status.Status = models.HealthStatusDegraded
+	default:
+		status.Status = models.HealthStatusOK
+	}
+	for _, private := range status.PrivateWebSockets {
+		if !private.Connected && status.Status == models.HealthStatusOK {
+			status.Status = models.HealthStatusDegraded
+		}
+	}
+
+	return status
+}
+
+func (s *HealthService) checkDatabase(ctx context.Context) models.ComponentStatus {
+	return checkComponent(ctx, s.db.PingContext)
+}
+
+func (s *HealthService) checkRedis(ctx context.Context) models.ComponentStatus {
+	return checkComponent(ctx, storages.Ping)
+}
+
+// checkComponent выполняет проверку с ограничением по времени и замеряет задержку
+func checkComponent(ctx context.Context, ping func(ctx context.Context) error) models.ComponentStatus {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := ping(ctx)
+	status := models.ComponentStatus{
+		Status:    models.HealthStatusOK,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		status.Status = models.HealthStatusDown
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// publicWebSocketStatus сопоставляет активные инструменты с подписками и временем последнего сообщения по ним
+func (s *HealthService) publicWebSocketStatus(ctx context.Context, now time.Time) models.PublicWebSocketStatus {
+	status := models.PublicWebSocketStatus{
+		Status:        models.HealthStatusOK,
+		Connected:     s.bybitService.IsPublicWebSocketConnected(),
+		Symbols:       []models.SymbolStreamStatus{},
+		Subscriptions: s.bybitService.GetPublicSubscriptions(),
+	}
+	subscribed := make(map[string]bool, len(status.Subscriptions.Symbols))
+	for _, symbol := range status.Subscriptions.Symbols {
+		subscribed[symbol] = true
+	}
+
+	symbols, err := s.userInstrumentRepo.GetActiveInstruments(ctx)
+	if err != nil {
+		status.Status = models.HealthStatusDegraded
+		return status
+	}
+	if len(symbols) > 0 && !status.Connected {
+		// Пока часть соединений пула работает, данные по их каналам продолжают поступать
+		status.Status = models.HealthStatusDown
+		for _, conn := range status.Subscriptions.Connections {
+			if conn.Connected {
+				status.Status = models.HealthStatusDegraded
+				break
+			}
+		}
+	}
+
+	lastMessages := s.wsHandler.GetLastMessageTimes()
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		stream := models.SymbolStreamStatus{Symbol: symbol, Status: models.HealthStatusDown, Subscribed: subscribed[symbol]}
+		if lastMessageAt, ok := lastMessages[symbol]; ok {
+			age := now.Sub(lastMessageAt)
+			ageSeconds := age.Seconds()
+			stream.LastMessageAt = &lastMessageAt
+			stream.AgeSeconds = &ageSeconds
+			stream.Status = models.HealthStatusOK
+			// Сообщения по символу без подписки — остаток прежней подписки, новых данных не будет
+			if age > publicStreamStaleAfter || !stream.Subscribed {
+				stream.Status = models.HealthStatusDegraded
+			}
+		}
+		if stream.Status != models.HealthStatusOK && status.Status == models.HealthStatusOK {
+			status.Status = models.HealthStatusDegraded
+		}
+		status.Symbols = append(status.Symbols, stream)
+	}
+	return status
+}
+
+// instrumentsStatus проверяет, что справочник инструментов обновляется по расписанию
+func (s *HealthService) instrumentsStatus(ctx context.Context, now time.Time) models.InstrumentsStatus {
+	updatedAt, err := s.bybitInstrumentRepo.GetLastUpdatedAt(ctx)
+	if err != nil {
+		return models.InstrumentsStatus{Status: models.HealthStatusDown, Error: err.Error()}
+	}
+	if updatedAt == nil {
+		return models.InstrumentsStatus{Status: models.HealthStatusDegraded, Error: "instruments have never been refreshed"}
+	}
+
+	age := now.Sub(*updatedAt)
+	ageSeconds := age.Seconds()
+	status := models.InstrumentsStatus{
+		Status:        models.HealthStatusOK,
+		LastRefreshAt: updatedAt,
+		AgeSeconds:    &ageSeconds,
+	}
+	// Интервал читается при каждой проверке, так как может измениться при перезагрузке конфигурации
+	interval := config.Get().Bybit.InstrumentsUpdateInterval.Std()
+	if age > interval*instrumentsStaleAfterRuns {
+		status.Status = models.HealthStatusDegraded
+	}
+	return status
+}
+
+// strategiesStatus считает стратегии, запущенные в менеджере, по типам
+func (s *HealthService) strategiesStatus() models.StrategiesStatus {
+	status := models.StrategiesStatus{ByType: make(map[string]int)}
+	for _, strategies := range s.strategyManager.GetStrategiesInfo() {
+		if len(strategies) == 0 {
+			continue
+		}
+		status.Users++
+		for _, name := range strategies {
+			status.ByType[strings.TrimPrefix(name, "*trading.")]++
+			status.Running++
+		}
+	}
+	return status
+}
@@ -8,6 +8,15 @@ import (
 
 // ValidateToken проверяет JWT токен и возвращает ID пользователя
 func ValidateToken(tokenString string) (string, error) {
+	claims, err := ParseToken(tokenString)
+	if err != nil {
+		return "", err
+	}
+	return claims.UserID, nil
+}
+
+// ParseToken проверяет JWT токен и возвращает его claims, включая ID сессии
+func ParseToken(tokenString string) (*Claims, error) {
 	// Убираем префикс "Bearer " если он есть
 	tokenString = strings.TrimPrefix(tokenString, "Bearer ")
 
@@ -17,14 +26,14 @@ func ValidateToken(tokenString string) (string, error) {
 	})
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if !token.Valid {
-		return "", errors.New("invalid token")
+		return nil, errors.New("invalid token")
 	}
 
-	return claims.UserID, nil
+	return claims, nil
 }
 
 // jwtKey используется для подписи и проверки токенов
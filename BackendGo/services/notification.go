@@ -0,0 +1,299 @@
+package services
+
+import (
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/netguard"
+	"CryptoLens_Backend/types"
+	"CryptoLens_Shared/logger"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// NotificationService маршрутизирует события по каналам доставки согласно подпискам пользователей
+type NotificationService struct {
+	notificationRepo types.NotificationRepositoryInterface
+	tradeLogRepo     types.TradeLogRepositoryInterface
+	telegramChatRepo types.TelegramChatRepositoryInterface
+	channels         map[string]types.NotificationChannel
+	queue            chan models.NotificationEvent
+	summaryHour      int
+	mutex            sync.RWMutex
+}
+
+// NewNotificationService создает новый сервис уведомлений
+func NewNotificationService(
+	notificationRepo types.NotificationRepositoryInterface,
+	tradeLogRepo types.TradeLogRepositoryInterface,
+	telegramChatRepo types.TelegramChatRepositoryInterface,
+	summaryHour int,
+) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		tradeLogRepo:     tradeLogRepo,
+		telegramChatRepo: telegramChatRepo,
+		channels:         make(map[string]types.NotificationChannel),
+		queue:            make(chan models.NotificationEvent, 1000), // Буфер на 1000 событий
+		summaryHour:      summaryHour,
+	}
+}
+
+// RegisterChannel подключает канал доставки
+func (s *NotificationService) RegisterChannel(channel types.NotificationChannel) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.channels[channel.Name()] = channel
+}
+
+// getChannel возвращает подключенный канал по имени
+func (s *NotificationService) getChannel(name string) (types.NotificationChannel, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	channel, ok := s.channels[name]
+	return channel, ok
+}
+
+// Notify ставит событие в очередь доставки без блокировки вызывающего
+func (s *NotificationService) Notify(ctx context.Context, event models.NotificationEvent) {
+	if event.UserID == "" {
+		logger.LogWarn("Уведомление без userID отброшено: Type=%s", event.Type)
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	select {
+	case s.queue <- event:
+	default:
+		logger.LogWarn("Очередь уведомлений переполнена, событие отброшено: Type=%s, UserID=%s", event.Type, event.UserID)
+	}
+}
+
+// Start запускает доставку уведомлений и ежедневную сводку
+func (s *NotificationService) Start(ctx context.Context) {
+	go s.processQueue(ctx)
+	go s.startDailySummary(ctx)
+}
+
+// processQueue обрабатывает очередь событий
+func (s *NotificationService) processQueue(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-s.queue:
+			s.deliver(ctx, event)
+		}
+	}
+}
+
+// Flush доставляет события, оставшиеся в очереди после остановки доставки
+func (s *NotificationService) Flush(ctx context.Context) {
+	for ctx.Err() == nil {
+		select {
+		case event := <-s.queue:
+			s.deliver(ctx, event)
+		default:
+			return
+		}
+	}
+	logger.LogWarn("Остановка: не доставлено уведомлений: %d", len(s.queue))
+}
+
+// deliver отправляет событие во все каналы, на которые подписан пользователь
+func (s *NotificationService) deliver(ctx context.Context, event models.NotificationEvent) {
+	subscribed, err := s.notificationRepo.GetSubscribedChannels(ctx, event.UserID, event.Type)
+	if err != nil {
+		logger.LogError("Ошибка получения каналов уведомлений для userID %s: %v", event.UserID, err)
+		return
+	}
+
+	for _, sub := range subscribed {
+		channel, ok := s.getChannel(sub.Channel)
+		if !ok {
+			logger.LogDebug("Канал уведомлений %s не подключен, пропускаем", sub.Channel)
+			continue
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		if err := channel.Send(sendCtx, sub.Target, event); err != nil {
+			logger.LogError("Ошибка доставки уведомления %s через %s для userID %s: %v",
+				event.Type, sub.Channel, event.UserID, err)
+		}
+		cancel()
+	}
+}
+
+// startDailySummary раз в сутки в заданный час (UTC) рассылает сводку по сделкам
+func (s *NotificationService) startDailySummary(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	var lastSent string
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			now = now.UTC()
+			day := now.Format("2006-01-02")
+			if now.Hour() != s.summaryHour || lastSent == day {
+				continue
+			}
+			lastSent = day
+			s.sendDailySummaries(ctx, now.Add(-24*time.Hour), now)
+		}
+	}
+}
+
+// sendDailySummaries формирует сводку за период для всех пользователей с активными каналами
+func (s *NotificationService) sendDailySummaries(ctx context.Context, from, to time.Time) {
+	userIDs, err := s.notificationRepo.GetUserIDsWithActiveChannels(ctx)
+	if err != nil {
+		logger.LogError("Ошибка получения пользователей для ежедневной сводки: %v", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		summary, err := s.tradeLogRepo.GetSummary(ctx, userID, from, to)
+		if err != nil {
+			logger.LogError("Ошибка расчета ежедневной сводки для userID %s: %v", userID, err)
+			continue
+		}
+		fields := map[string]string{
+			"buy_volume":  summary.BuyVolume.String(),
+			"sell_volume": summary.SellVolume.String(),
+			"fees":        summary.Fees.String(),
+		}
+
+		// Если торговля шла на нескольких аккаунтах, добавляем разбивку по каждому
+		accounts, err := s.tradeLogRepo.GetAccountSummaries(ctx, userID, from, to)
+		if err != nil {
+			logger.LogError("Ошибка расчета сводки по аккаунтам для userID %s: %v", userID, err)
+		} else if len(accounts) > 1 {
+			for _, account := range accounts {
+				fields[fmt.Sprintf("account_%d", account.AccountID)] = fmt.Sprintf(
+					"сделок %d, покупки %s, продажи %s, комиссии %s",
+					account.Trades, account.BuyVolume.String(), account.SellVolume.String(), account.Fees.String())
+			}
+		}
+
+		s.Notify(ctx, models.NotificationEvent{
+			Type:    models.EventDailySummary,
+			UserID:  userID,
+			Title:   "Ежедневная сводка",
+			Message: fmt.Sprintf("Сделок за сутки: %d", summary.Trades),
+			Fields:  fields,
+			Time:    to,
+		})
+	}
+}
+
+// GetSettings возвращает каналы и подписки пользователя
+func (s *NotificationService) GetSettings(ctx context.Context, userID string) (*models.NotificationSettingsResponse, error) {
+	channels, err := s.notificationRepo.GetChannelsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	preferences, err := s.notificationRepo.GetPreferencesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if channels == nil {
+		channels = []models.NotificationChannel{}
+	}
+	if preferences == nil {
+		preferences = []models.NotificationPreference{}
+	}
+	return &models.NotificationSettingsResponse{
+		Channels:    channels,
+		Preferences: preferences,
+		EventTypes:  models.NotificationEventTypes,
+	}, nil
+}
+
+// UpsertChannel проверяет и сохраняет канал доставки пользователя.
+// Telegram-каналом может быть только чат, который пользователь привязал командой /link:
+// иначе уведомления о торговле можно было бы направить в чужой чат или группу с ботом.
+func (s *NotificationService) UpsertChannel(ctx context.Context, userID string, req models.UpsertNotificationChannelRequest) (*models.NotificationChannel, error) {
+	switch req.Channel {
+	case models.ChannelTelegram:
+		chatID, err := strconv.ParseInt(req.Target, 10, 64)
+		if err != nil {
+			return nil, models.Invalid("telegram target must be a numeric chat_id")
+		}
+		chat, err := s.telegramChatRepo.GetByChatID(ctx, chatID)
+		if err != nil {
+			return nil, err
+		}
+		if chat == nil || chat.UserID != userID {
+			return nil, models.Invalid("telegram chat %d is not linked to your account, send /link to the bot first", chatID)
+		}
+	case models.ChannelWebhook:
+		if err := netguard.ValidateURL(ctx, req.Target); err != nil {
+			return nil, models.Invalid("webhook target: %v", err)
+		}
+	default:
+		return nil, models.Invalid("unknown notification channel: %s", req.Channel)
+	}
+	return s.notificationRepo.UpsertChannel(ctx, userID, req.Channel, req.Target, req.IsActive)
+}
+
+// RemoveChannel удаляет канал доставки пользователя
+func (s *NotificationService) RemoveChannel(ctx context.Context, userID string, channel string) error {
+	return s.notificationRepo.DeleteChannel(ctx, userID, channel)
+}
+
+// UpdatePreference включает или выключает тип события для канала
+func (s *NotificationService) UpdatePreference(ctx context.Context, userID string, req models.UpdateNotificationPreferenceRequest) error {
+	if !isKnownEventType(req.EventType) {
+		return models.Invalid("unknown event type: %s", req.EventType)
+	}
+	if req.Channel != models.ChannelTelegram && req.Channel != models.ChannelWebhook {
+		return models.Invalid("unknown notification channel: %s", req.Channel)
+	}
+	return s.notificationRepo.SetPreference(ctx, userID, req.EventType, req.Channel, req.IsEnabled)
+}
+
+// SendTest синхронно отправляет тестовое сообщение во все активные каналы пользователя
+func (s *NotificationService) SendTest(ctx context.Context, userID string) error {
+	channels, err := s.notificationRepo.GetChannelsByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	event := models.NotificationEvent{
+		Type:    "test",
+		UserID:  userID,
+		Title:   "Тестовое уведомление",
+		Message: "Канал уведомлений CryptoLens настроен",
+		Time:    time.Now(),
+	}
+
+	var errs []error
+	for _, sub := range channels {
+		if !sub.IsActive {
+			continue
+		}
+		channel, ok := s.getChannel(sub.Channel)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: channel is not configured on server", sub.Channel))
+			continue
+		}
+		if err := channel.Send(ctx, sub.Target, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sub.Channel, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func isKnownEventType(eventType string) bool {
+	for _, t := range models.NotificationEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
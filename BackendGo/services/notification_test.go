@@ -0,0 +1,64 @@
+package services
+
+import (
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/types"
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeTelegramChatRepo struct {
+	types.TelegramChatRepositoryInterface
+	chats map[int64]*models.TelegramChat
+}
+
+func (r *fakeTelegramChatRepo) GetByChatID(_ context.Context, chatID int64) (*models.TelegramChat, error) {
+	return r.chats[chatID], nil
+}
+
+type fakeNotificationRepo struct {
+	types.NotificationRepositoryInterface
+	saved []string
+}
+
+func (r *fakeNotificationRepo) UpsertChannel(_ context.Context, userID, channel, target string, isActive bool) (*models.NotificationChannel, error) {
+	r.saved = append(r.saved, target)
+	return &models.NotificationChannel{UserID: userID, Channel: channel, Target: target, IsActive: isActive}, nil
+}
+
+func TestUpsertTelegramChannelRequiresLinkedChat(t *testing.T) {
+	notificationRepo := &fakeNotificationRepo{}
+	chatRepo := &fakeTelegramChatRepo{chats: map[int64]*models.TelegramChat{
+		100: {ChatID: 100, UserID: "user-1"},
+		200: {ChatID: 200, UserID: "user-2"},
+	}}
+	s := NewNotificationService(notificationRepo, nil, chatRepo, 9)
+
+	tests := []struct {
+		name   string
+		target string
+		ok     bool
+	}{
+		{"linked chat", "100", true},
+		{"unlinked chat", "300", false},
+		{"chat of another user", "200", false},
+		{"not a chat id", "@channel", false},
+	}
+	for _, tt := range tests {
+		req := models.UpsertNotificationChannelRequest{Channel: models.ChannelTelegram, Target: tt.target, IsActive: true}
+		_, err := s.UpsertChannel(context.Background(), "user-1", req)
+		if tt.ok {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		if !errors.Is(err, models.ErrInvalid) {
+			t.Errorf("%s: error = %v, want models.ErrInvalid", tt.name, err)
+		}
+	}
+	if len(notificationRepo.saved) != 1 || notificationRepo.saved[0] != "100" {
+		t.Errorf("saved targets = %v, want only the linked chat", notificationRepo.saved)
+	}
+}
@@ -0,0 +1,731 @@
+package services
+
+import (
+	"CryptoLens_Backend/httpapi"
+	"CryptoLens_Backend/integration/bybit"
+	"CryptoLens_Backend/metrics"
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/storages"
+	"CryptoLens_Backend/types"
+	"CryptoLens_Shared/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	streamSendBuffer        = 256              // событий аккаунта в очереди одного подключения
+	streamMaxConnsPerUser   = 5                // при превышении закрывается самое старое подключение
+	streamMaxTopics         = 50               // публичных подписок на одно подключение
+	streamMaxMessageSize    = 4096             // размер команды клиента
+	streamWriteWait         = 10 * time.Second // на запись одного сообщения
+	streamPongWait          = 60 * time.Second // без ответа на ping подключение считается потерянным
+	streamPingInterval      = 50 * time.Second
+	streamSessionCheck      = time.Minute // проверка отзыва сессии на других экземплярах сервера
+	streamCloseSlowConsumer = "slow consumer"
+	streamCloseShutdown     = "server shutting down"
+	streamCloseRevoked      = "session revoked"
+	streamCloseExpired      = "token expired"
+)
+
+// StreamHub рассылает события аккаунтов и рыночные данные по WebSocket-подключениям клиентов.
+//
+// События аккаунта (ордера, исполнения, баланс, стратегии, PnL) нельзя терять, поэтому у каждого
+// подключения своя очередь; клиент, который не успевает ее разбирать, отключается и при повторном
+// подключении сверяется через REST. Тикеры и книги ордеров — снимки состояния: для медленного
+// клиента непрочитанный снимок заменяется следующим, и он получает только последнее состояние.
+type StreamHub struct {
+	userInstrumentRepo types.UserInstrumentRepositoryInterface
+
+	mutex   sync.RWMutex
+	clients map[string][]*streamClient            // Подключения пользователя в порядке подключения
+	topics  map[string]map[*streamClient]struct{} // Подписчики публичного топика
+	tickers map[string][]byte                     // Последнее сообщение тикера по топику для новых подписчиков
+	closed  bool                                  // Сервер останавливается: новые подключения сразу закрываются
+	serving sync.WaitGroup                        // Подключения, которые еще обслуживает Serve
+
+	booksMutex sync.Mutex
+	books      map[string]*orderBook // Книги ордеров по символу, собранные из снимков и дельт Bybit
+}
+
+// NewStreamHub создает сервис WebSocket-потока клиентов
+func NewStreamHub(userInstrumentRepo types.UserInstrumentRepositoryInterface) *StreamHub {
+	hub := &StreamHub{
+		userInstrumentRepo: userInstrumentRepo,
+		clients:            make(map[string][]*streamClient),
+		topics:             make(map[string]map[*streamClient]struct{}),
+		tickers:            make(map[string][]byte),
+		books:              make(map[string]*orderBook),
+	}
+	metrics.NewGaugeFunc("cryptolens_stream_connections", "Open client stream connections.", func() float64 {
+		return float64(hub.connectionCount())
+	})
+	return hub
+}
+
+// streamClient подключение клиента к потоку
+type streamClient struct {
+	userID    string
+	sessionID string    // Сессия токена; пусто для API-ключей и старых токенов
+	expiresAt time.Time // Срок токена; нулевой у API-ключей
+	conn      *websocket.Conn
+	send      chan []byte // События аккаунта и ответы на команды
+
+	publicMutex sync.Mutex
+	public      map[string][]byte // Последнее неотправленное сообщение по публичному топику
+	publicReady chan struct{}
+
+	topics map[string]struct{} // Подписки подключения; защищены мьютексом StreamHub
+
+	closeOnce   sync.Once
+	done        chan struct{}
+	closeCode   int
+	closeReason string
+}
+
+func newStreamClient(userID, sessionID string, expiresAt time.Time, conn *websocket.Conn) *streamClient {
+	return &streamClient{
+		userID:      userID,
+		sessionID:   sessionID,
+		expiresAt:   expiresAt,
+		conn:        conn,
+		send:        make(chan []byte, streamSendBuffer),
+		public:      make(map[string][]byte),
+		publicReady: make(chan struct{}, 1),
+		topics:      make(map[string]struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// close просит писателя закрыть подключение с кодом и причиной; повторные вызовы игнорируются
+func (c *streamClient) close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		c.closeReason = reason
+		close(c.done)
+	})
+}
+
+// enqueue ставит событие аккаунта в очередь. Переполненная очередь означает, что клиент отстал
+// и часть событий все равно потеряет, поэтому подключение закрывается.
+func (c *streamClient) enqueue(payload []byte) {
+	select {
+	case c.send <- payload:
+	case <-c.done:
+	default:
+		metrics.StreamDisconnects.WithLabelValues("slow_consumer").Inc()
+		logger.LogWarn("Поток клиента userID %s отстал, подключение закрывается", c.userID)
+		c.close(websocket.CloseTryAgainLater, streamCloseSlowConsumer)
+	}
+}
+
+// offerPublic сохраняет последнее сообщение публичного топика до отправки
+func (c *streamClient) offerPublic(topic, eventType string, payload []byte) {
+	c.publicMutex.Lock()
+	if _, pending := c.public[topic]; pending {
+		metrics.StreamConflated.WithLabelValues(eventType).Inc()
+	}
+	c.public[topic] = payload
+	c.publicMutex.Unlock()
+
+	select {
+	case c.publicReady <- struct{}{}:
+	default:
+	}
+}
+
+// takePublic забирает накопленные сообщения публичных топиков
+func (c *streamClient) takePublic() map[string][]byte {
+	c.publicMutex.Lock()
+	defer c.publicMutex.Unlock()
+	pending := c.public
+	c.public = make(map[string][]byte, len(pending))
+	return pending
+}
+
+// Serve обслуживает подключение: читает команды клиента и пишет ему события до закрытия
+func (h *StreamHub) Serve(ctx context.Context, conn *websocket.Conn, userID, sessionID string, expiresAt time.Time) {
+	h.serving.Add(1)
+	defer h.serving.Done()
+	client := newStreamClient(userID, sessionID, expiresAt, conn)
+	h.register(client)
+	defer h.unregister(client)
+	logger.InfoCtx(ctx, "Клиент подключился к потоку")
+
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		client.writeLoop()
+	}()
+	go h.watchAccess(ctx, client)
+
+	h.readLoop(ctx, client)
+	client.close(websocket.CloseNormalClosure, "")
+	<-writerDone
+	conn.Close()
+	logger.InfoCtx(ctx, "Клиент отключился от потока: %s", client.closeReason)
+}
+
+// watchAccess закрывает подключение при остановке сервера, истечении токена и отзыве сессии.
+// Отзыв через этот экземпляр закрывает подключение сразу (CloseSession, CloseUser), а отзыв
+// через другой экземпляр обнаруживается по списку отозванных сессий в Redis.
+func (h *StreamHub) watchAccess(ctx context.Context, client *streamClient) {
+	var expired <-chan time.Time
+	if !client.expiresAt.IsZero() {
+		timer := time.NewTimer(time.Until(client.expiresAt))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	var check <-chan time.Time
+	if client.sessionID != "" {
+		ticker := time.NewTicker(streamSessionCheck)
+		defer ticker.Stop()
+		check = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			client.close(websocket.CloseGoingAway, streamCloseShutdown)
+			return
+		case <-client.done:
+			return
+		case <-expired:
+			metrics.StreamDisconnects.WithLabelValues("token_expired").Inc()
+			client.close(websocket.ClosePolicyViolation, streamCloseExpired)
+			return
+		case <-check:
+			revoked, err := storages.IsSessionRevoked(ctx, client.sessionID)
+			if err != nil {
+				// Redis недоступен: подключение остается открытым до следующей проверки или истечения токена
+				logger.WarnCtx(ctx, "Ошибка проверки отзыва сессии потока: %v", err)
+				continue
+			}
+			if revoked {
+				metrics.StreamDisconnects.WithLabelValues("session_revoked").Inc()
+				client.close(websocket.ClosePolicyViolation, streamCloseRevoked)
+				return
+			}
+		}
+	}
+}
+
+// readLoop разбирает команды клиента, пока подключение не закроется
+func (h *StreamHub) readLoop(ctx context.Context, client *streamClient) {
+	conn := client.conn
+	conn.SetReadLimit(streamMaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(streamPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(streamPongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logger.DebugCtx(ctx, "Чтение из потока клиента прервано: %v", err)
+			}
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(streamPongWait))
+
+		var req models.StreamRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			h.reply(client, models.StreamReply{Type: models.StreamReplyError, Code: httpapi.CodeInvalidBody, Message: "Invalid command"})
+			continue
+		}
+		h.reply(client, h.handleRequest(ctx, client, req))
+	}
+}
+
+// handleRequest выполняет команду клиента и возвращает ответ
+func (h *StreamHub) handleRequest(ctx context.Context, client *streamClient, req models.StreamRequest) models.StreamReply {
+	switch req.Op {
+	case models.StreamOpPing:
+		return models.StreamReply{Type: models.StreamReplyPong, ID: req.ID}
+
+	case models.StreamOpSubscribe:
+		if err := h.checkTopics(ctx, client.userID, req.Topics); err != nil {
+			return streamErrorReply(req.ID, err)
+		}
+		topics, err := h.subscribe(client, req.Topics)
+		if err != nil {
+			return streamErrorReply(req.ID, err)
+		}
+		h.sendCurrentState(client, req.Topics)
+		return models.StreamReply{Type: models.StreamReplySubscribed, ID: req.ID, Topics: topics}
+
+	case models.StreamOpUnsubscribe:
+		return models.StreamReply{Type: models.StreamReplyUnsubscribed, ID: req.ID, Topics: h.unsubscribe(client, req.Topics)}
+
+	default:
+		return models.StreamReply{Type: models.StreamReplyError, ID: req.ID, Code: httpapi.CodeBadRequest, Message: fmt.Sprintf("Unknown op %q", req.Op)}
+	}
+}
+
+// streamError ошибка команды с машиночитаемым кодом
+type streamError struct {
+	code    string
+	message string
+}
+
+func (e *streamError) Error() string {
+	return e.message
+}
+
+func streamErrorReply(id string, err error) models.StreamReply {
+	var streamErr *streamError
+	if errors.As(err, &streamErr) {
+		return models.StreamReply{Type: models.StreamReplyError, ID: id, Code: streamErr.code, Message: streamErr.message}
+	}
+	return models.StreamReply{Type: models.StreamReplyError, ID: id, Code: httpapi.CodeInternal, Message: "Internal error"}
+}
+
+// checkTopics проверяет, что топики публичные и относятся к активным инструментам пользователя
+func (h *StreamHub) checkTopics(ctx context.Context, userID string, topics []string) error {
+	if len(topics) == 0 {
+		return &streamError{code: httpapi.CodeBadRequest, message: "No topics to subscribe"}
+	}
+	symbols, err := h.userInstrumentRepo.GetActiveInstrumentsByUserID(ctx, userID)
+	if err != nil {
+		logger.ErrorCtx(ctx, "Ошибка получения инструментов для подписки на поток: %v", err)
+		return &streamError{code: httpapi.CodeUnavailable, message: "Instruments are unavailable, try again later"}
+	}
+	allowed := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		allowed[symbol] = true
+	}
+	for _, topic := range topics {
+		symbol, ok := topicSymbol(topic)
+		if !ok {
+			return &streamError{code: httpapi.CodeBadRequest, message: fmt.Sprintf("Unknown topic %q", topic)}
+		}
+		if !allowed[symbol] {
+			return &streamError{code: httpapi.CodeForbidden, message: fmt.Sprintf("%s is not among your active instruments", symbol)}
+		}
+	}
+	return nil
+}
+
+// topicSymbol возвращает символ публичного топика
+func topicSymbol(topic string) (string, bool) {
+	for _, prefix := range []string{models.StreamTopicTickers, models.StreamTopicOrderBook} {
+		if symbol, ok := strings.CutPrefix(topic, prefix); ok && symbol != "" {
+			return symbol, true
+		}
+	}
+	return "", false
+}
+
+// subscribe добавляет подписки и возвращает все подписки подключения
+func (h *StreamHub) subscribe(client *streamClient, topics []string) ([]string, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	added := 0
+	for _, topic := range topics {
+		if _, ok := client.topics[topic]; !ok {
+			added++
+		}
+	}
+	if len(client.topics)+added > streamMaxTopics {
+		return nil, &streamError{code: httpapi.CodeBadRequest, message: fmt.Sprintf("At most %d topics per connection", streamMaxTopics)}
+	}
+
+	for _, topic := range topics {
+		client.topics[topic] = struct{}{}
+		if h.topics[topic] == nil {
+			h.topics[topic] = make(map[*streamClient]struct{})
+		}
+		h.topics[topic][client] = struct{}{}
+	}
+	return clientTopics(client), nil
+}
+
+// unsubscribe удаляет подписки и возвращает оставшиеся
+func (h *StreamHub) unsubscribe(client *streamClient, topics []string) []string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for _, topic := range topics {
+		h.removeSubscriber(client, topic)
+	}
+	return clientTopics(client)
+}
+
+// removeSubscriber удаляет подписку; вызывается под мьютексом StreamHub
+func (h *StreamHub) removeSubscriber(client *streamClient, topic string) {
+	delete(client.topics, topic)
+	if subscribers := h.topics[topic]; subscribers != nil {
+		delete(subscribers, client)
+		if len(subscribers) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+}
+
+func clientTopics(client *streamClient) []string {
+	topics := make([]string, 0, len(client.topics))
+	for topic := range client.topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// sendCurrentState отправляет новому подписчику последнее известное состояние топиков,
+// не дожидаясь следующего обновления
+func (h *StreamHub) sendCurrentState(client *streamClient, topics []string) {
+	for _, topic := range topics {
+		symbol, _ := topicSymbol(topic)
+		if strings.HasPrefix(topic, models.StreamTopicTickers) {
+			h.mutex.RLock()
+			payload := h.tickers[topic]
+			h.mutex.RUnlock()
+			if payload != nil {
+				client.offerPublic(topic, models.StreamEventTicker, payload)
+			}
+			continue
+		}
+
+		h.booksMutex.Lock()
+		book := h.books[symbol]
+		var snapshot bybit.OrderBookMessage
+		if book != nil {
+			snapshot = book.snapshot()
+		}
+		h.booksMutex.Unlock()
+		if book == nil {
+			continue
+		}
+		if payload, err := encodeStreamMessage(models.StreamEventOrderBook, topic, 0, snapshot); err == nil {
+			client.offerPublic(topic, models.StreamEventOrderBook, payload)
+		}
+	}
+}
+
+// register добавляет подключение; сверх лимита закрывается самое старое подключение пользователя
+func (h *StreamHub) register(client *streamClient) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.closed {
+		client.close(websocket.CloseGoingAway, streamCloseShutdown)
+	}
+	conns := append(h.clients[client.userID], client)
+	for len(conns) > streamMaxConnsPerUser {
+		metrics.StreamDisconnects.WithLabelValues("replaced").Inc()
+		conns[0].close(websocket.ClosePolicyViolation, "too many connections, closing the oldest one")
+		conns = conns[1:]
+	}
+	h.clients[client.userID] = conns
+}
+
+// unregister удаляет подключение и его подписки
+func (h *StreamHub) unregister(client *streamClient) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for topic := range client.topics {
+		h.removeSubscriber(client, topic)
+	}
+	conns := h.clients[client.userID]
+	for i, c := range conns {
+		if c == client {
+			conns = append(conns[:i:i], conns[i+1:]...)
+			break
+		}
+	}
+	if len(conns) == 0 {
+		delete(h.clients, client.userID)
+	} else {
+		h.clients[client.userID] = conns
+	}
+}
+
+// CloseSession закрывает подключения, открытые с токенами отозванной сессии
+func (h *StreamHub) CloseSession(sessionID string) {
+	if sessionID == "" {
+		return
+	}
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	for _, conns := range h.clients {
+		for _, client := range conns {
+			if client.sessionID == sessionID {
+				metrics.StreamDisconnects.WithLabelValues("session_revoked").Inc()
+				client.close(websocket.ClosePolicyViolation, streamCloseRevoked)
+			}
+		}
+	}
+}
+
+// CloseUser закрывает все подключения пользователя, например после отзыва всех его сессий или отключения
+func (h *StreamHub) CloseUser(userID string) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	for _, client := range h.clients[userID] {
+		metrics.StreamDisconnects.WithLabelValues("session_revoked").Inc()
+		client.close(websocket.ClosePolicyViolation, streamCloseRevoked)
+	}
+}
+
+// Shutdown закрывает все подключения с кодом 1001, чтобы клиенты переподключились к другому экземпляру,
+// и ждет, пока писатели отправят кадр закрытия. http.Server.Shutdown эти подключения не отслеживает.
+func (h *StreamHub) Shutdown(ctx context.Context) error {
+	h.mutex.Lock()
+	h.closed = true
+	for _, conns := range h.clients {
+		for _, client := range conns {
+			client.close(websocket.CloseGoingAway, streamCloseShutdown)
+		}
+	}
+	h.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.serving.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("stream connections not closed: %w", ctx.Err())
+	}
+}
+
+func (h *StreamHub) connectionCount() int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	count := 0
+	for _, conns := range h.clients {
+		count += len(conns)
+	}
+	return count
+}
+
+// HasSubscribers сообщает, подключен ли пользователь к потоку
+func (h *StreamHub) HasSubscribers(userID string) bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return len(h.clients[userID]) > 0
+}
+
+// Publish отправляет событие аккаунта всем подключениям пользователя
+func (h *StreamHub) Publish(userID string, accountID int64, eventType string, data interface{}) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	conns := h.clients[userID]
+	if len(conns) == 0 {
+		return
+	}
+	payload, err := encodeStreamMessage(eventType, "", accountID, data)
+	if err != nil {
+		logger.LogError("Ошибка формирования события потока %s: %v", eventType, err)
+		return
+	}
+	for _, client := range conns {
+		client.enqueue(payload)
+	}
+	metrics.StreamMessages.WithLabelValues(eventType).Add(float64(len(conns)))
+}
+
+// PublishTicker отправляет тикер подписчикам и запоминает его для новых подписчиков
+func (h *StreamHub) PublishTicker(ticker bybit.TickerMessage) {
+	topic := models.StreamTopicTickers + ticker.Symbol
+	payload, err := encodeStreamMessage(models.StreamEventTicker, topic, 0, ticker)
+	if err != nil {
+		logger.LogError("Ошибка формирования тикера для потока: %v", err)
+		return
+	}
+
+	h.mutex.Lock()
+	h.tickers[topic] = payload
+	h.mutex.Unlock()
+	h.publishPublic(topic, models.StreamEventTicker, payload)
+}
+
+// PublishOrderBook обновляет книгу ордеров и отправляет подписчикам ее текущее состояние.
+// Клиенты всегда получают книгу целиком, поэтому пропущенное из-за замены сообщение не ломает ее.
+func (h *StreamHub) PublishOrderBook(updateType string, update bybit.OrderBookMessage) {
+	h.booksMutex.Lock()
+	book := h.books[update.Symbol]
+	if book == nil {
+		book = newOrderBook(update.Symbol)
+		h.books[update.Symbol] = book
+	}
+	book.apply(updateType, update)
+	topic := models.StreamTopicOrderBook + update.Symbol
+	if !h.hasTopicSubscribers(topic) {
+		h.booksMutex.Unlock()
+		return
+	}
+	snapshot := book.snapshot()
+	h.booksMutex.Unlock()
+
+	payload, err := encodeStreamMessage(models.StreamEventOrderBook, topic, 0, snapshot)
+	if err != nil {
+		logger.LogError("Ошибка формирования книги ордеров для потока: %v", err)
+		return
+	}
+	h.publishPublic(topic, models.StreamEventOrderBook, payload)
+}
+
+func (h *StreamHub) hasTopicSubscribers(topic string) bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return len(h.topics[topic]) > 0
+}
+
+// publishPublic передает сообщение публичного топика его подписчикам
+func (h *StreamHub) publishPublic(topic, eventType string, payload []byte) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	subscribers := h.topics[topic]
+	for client := range subscribers {
+		client.offerPublic(topic, eventType, payload)
+	}
+	if len(subscribers) > 0 {
+		metrics.StreamMessages.WithLabelValues(eventType).Add(float64(len(subscribers)))
+	}
+}
+
+// reply отправляет ответ на команду через очередь событий, чтобы сохранить порядок сообщений
+func (h *StreamHub) reply(client *streamClient, reply models.StreamReply) {
+	payload, err := json.Marshal(reply)
+	if err != nil {
+		logger.LogError("Ошибка формирования ответа потока: %v", err)
+		return
+	}
+	client.enqueue(payload)
+}
+
+func encodeStreamMessage(eventType, topic string, accountID int64, data interface{}) ([]byte, error) {
+	return json.Marshal(models.StreamMessage{
+		Type:      eventType,
+		Topic:     topic,
+		AccountID: accountID,
+		Time:      time.Now().UTC(),
+		Data:      data,
+	})
+}
+
+// writeLoop единственный писатель в подключение: события, снимки публичных топиков и ping
+func (c *streamClient) writeLoop() {
+	ping := time.NewTicker(streamPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			message := websocket.FormatCloseMessage(c.closeCode, c.closeReason)
+			c.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(streamWriteWait))
+			// Клиенту дается время ответить на закрытие, дальше читатель прервется по таймауту
+			c.conn.SetReadDeadline(time.Now().Add(streamWriteWait))
+			return
+
+		case payload := <-c.send:
+			if !c.write(websocket.TextMessage, payload) {
+				return
+			}
+
+		case <-c.publicReady:
+			for _, payload := range c.takePublic() {
+				if !c.write(websocket.TextMessage, payload) {
+					return
+				}
+			}
+
+		case <-ping.C:
+			if !c.write(websocket.PingMessage, nil) {
+				return
+			}
+		}
+	}
+}
+
+// write отправляет сообщение; при ошибке подключение закрывается
+func (c *streamClient) write(messageType int, payload []byte) bool {
+	c.conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+	if err := c.conn.WriteMessage(messageType, payload); err != nil {
+		c.close(websocket.CloseAbnormalClosure, err.Error())
+		// Читатель ждет следующего сообщения клиента, закрытие соединения его разблокирует
+		c.conn.Close()
+		return false
+	}
+	return true
+}
+
+// orderBook книга ордеров, собранная из снимков и дельт Bybit
+type orderBook struct {
+	symbol   string
+	bids     map[string]string // Цена -> количество
+	asks     map[string]string
+	updateID int64
+	seq      int64
+}
+
+func newOrderBook(symbol string) *orderBook {
+	return &orderBook{symbol: symbol, bids: make(map[string]string), asks: make(map[string]string)}
+}
+
+// apply применяет обновление: снимок заменяет книгу, в дельте нулевое количество удаляет уровень
+func (b *orderBook) apply(updateType string, update bybit.OrderBookMessage) {
+	if updateType != "delta" {
+		b.bids = make(map[string]string, len(update.Bids))
+		b.asks = make(map[string]string, len(update.Asks))
+	}
+	applyLevels(b.bids, update.Bids)
+	applyLevels(b.asks, update.Asks)
+	b.updateID = update.UpdateID
+	b.seq = update.Seq
+}
+
+func applyLevels(side map[string]string, levels [][2]string) {
+	for _, level := range levels {
+		price, qty := level[0], level[1]
+		if q, err := decimal.NewFromString(qty); err == nil && q.IsZero() {
+			delete(side, price)
+			continue
+		}
+		side[price] = qty
+	}
+}
+
+// snapshot возвращает книгу целиком: биды по убыванию цены, аски по возрастанию
+func (b *orderBook) snapshot() bybit.OrderBookMessage {
+	return bybit.OrderBookMessage{
+		Symbol:   b.symbol,
+		Bids:     sortedLevels(b.bids, true),
+		Asks:     sortedLevels(b.asks, false),
+		UpdateID: b.updateID,
+		Seq:      b.seq,
+	}
+}
+
+func sortedLevels(side map[string]string, descending bool) [][2]string {
+	type level struct {
+		price decimal.Decimal
+		entry [2]string
+	}
+	levels := make([]level, 0, len(side))
+	for price, qty := range side {
+		levels = append(levels, level{price: parseDecimal(price), entry: [2]string{price, qty}})
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		if descending {
+			return levels[i].price.GreaterThan(levels[j].price)
+		}
+		return levels[i].price.LessThan(levels[j].price)
+	})
+	result := make([][2]string, len(levels))
+	for i, l := range levels {
+		result[i] = l.entry
+	}
+	return result
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/storages"
+	"CryptoLens_Backend/types"
+	"CryptoLens_Shared/logger"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// telegramLinkCodeTTL время жизни одноразового кода привязки
+const telegramLinkCodeTTL = 10 * time.Minute
+
+// telegramLinkCodeAlphabet алфавит кода без похожих символов (0/O, 1/I)
+const telegramLinkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// TelegramService привязывает чаты Telegram к пользователям по одноразовому коду
+type TelegramService struct {
+	chatRepo         types.TelegramChatRepositoryInterface
+	notificationRepo types.NotificationRepositoryInterface
+}
+
+// NewTelegramService создает новый сервис привязки чатов Telegram
+func NewTelegramService(
+	chatRepo types.TelegramChatRepositoryInterface,
+	notificationRepo types.NotificationRepositoryInterface,
+) *TelegramService {
+	return &TelegramService{
+		chatRepo:         chatRepo,
+		notificationRepo: notificationRepo,
+	}
+}
+
+// CreateLinkCode выдает одноразовый код, который пользователь отправляет боту командой /link
+func (s *TelegramService) CreateLinkCode(ctx context.Context, userID string, scope string) (*models.TelegramLinkResponse, error) {
+	if scope == "" {
+		scope = models.TelegramScopeRead
+	}
+	if scope != models.TelegramScopeRead && scope != models.TelegramScopeTrade {
+		return nil, models.Invalid("unknown scope: %s", scope)
+	}
+
+	code, err := generateLinkCode(8)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate link code: %w", err)
+	}
+
+	link := models.TelegramLinkCode{UserID: userID, Scope: scope}
+	if err := storages.SaveTelegramLinkCode(ctx, code, link, telegramLinkCodeTTL); err != nil {
+		return nil, err
+	}
+
+	return &models.TelegramLinkResponse{
+		Code:      code,
+		Command:   "/link " + code,
+		Scope:     scope,
+		ExpiresIn: int(telegramLinkCodeTTL.Seconds()),
+	}, nil
+}
+
+// LinkChat привязывает чат по коду и подключает его как канал уведомлений пользователя
+func (s *TelegramService) LinkChat(ctx context.Context, code string, chatID int64, username string) (*models.TelegramChat, error) {
+	link, err := storages.ConsumeTelegramLinkCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		logger.LogDebug("Код привязки Telegram не найден: %v", err)
+		return nil, models.Invalid("код привязки недействителен или истек")
+	}
+
+	chat, err := s.chatRepo.Link(ctx, chatID, link.UserID, link.Scope, username)
+	if err != nil {
+		return nil, err
+	}
+
+	target := strconv.FormatInt(chatID, 10)
+	if _, err := s.notificationRepo.UpsertChannel(ctx, link.UserID, models.ChannelTelegram, target, true); err != nil {
+		logger.LogError("Ошибка подключения канала уведомлений Telegram для userID %s: %v", link.UserID, err)
+	}
+
+	logger.LogInfo("Чат Telegram %d привязан к userID %s (scope=%s)", chatID, link.UserID, link.Scope)
+	return chat, nil
+}
+
+// GetChat возвращает привязку чата или nil, если чат не привязан
+func (s *TelegramService) GetChat(ctx context.Context, chatID int64) (*models.TelegramChat, error) {
+	return s.chatRepo.GetByChatID(ctx, chatID)
+}
+
+// GetChats возвращает все чаты пользователя
+func (s *TelegramService) GetChats(ctx context.Context, userID string) ([]models.TelegramChat, error) {
+	chats, err := s.chatRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if chats == nil {
+		chats = []models.TelegramChat{}
+	}
+	return chats, nil
+}
+
+// UnlinkChat отвязывает чат пользователя
+func (s *TelegramService) UnlinkChat(ctx context.Context, userID string, chatID int64) error {
+	return s.chatRepo.Unlink(ctx, userID, chatID)
+}
+
+// generateLinkCode генерирует случайный код заданной длины
+func generateLinkCode(length int) (string, error) {
+	max := big.NewInt(int64(len(telegramLinkCodeAlphabet)))
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = telegramLinkCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcKey секрет из тестовых векторов RFC 4226 и RFC 6238 (SHA1)
+var rfcKey = []byte("12345678901234567890")
+
+func TestHOTPVectors(t *testing.T) {
+	// RFC 4226, приложение D
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range want {
+		if got := hotp(rfcKey, int64(counter)); got != code {
+			t.Errorf("hotp(%d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestTOTPVectors(t *testing.T) {
+	// RFC 6238, приложение B: последние шесть цифр восьмизначных кодов
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	secret := totpEncoding.EncodeToString(rfcKey)
+	for _, tt := range tests {
+		now := time.Unix(tt.unix, 0)
+		step, ok := matchTOTP(secret, tt.code, now)
+		if !ok {
+			t.Errorf("matchTOTP(%s) at %d rejected", tt.code, tt.unix)
+			continue
+		}
+		if want := tt.unix / int64(totpPeriod.Seconds()); step != want {
+			t.Errorf("matchTOTP(%s) at %d step = %d, want %d", tt.code, tt.unix, step, want)
+		}
+	}
+}
+
+func TestMatchTOTPSkew(t *testing.T) {
+	secret := totpEncoding.EncodeToString(rfcKey)
+	now := time.Unix(59, 0) // Шаг 1
+
+	tests := []struct {
+		name string
+		code string
+		step int64
+		ok   bool
+	}{
+		{"previous step", "755224", 0, true},
+		{"next step", "359152", 2, true},
+		{"two steps ahead", "969429", 0, false},
+		{"wrong length", "28708", 0, false},
+		{"wrong code", "000000", 0, false},
+	}
+	for _, tt := range tests {
+		step, ok := matchTOTP(secret, tt.code, now)
+		if ok != tt.ok || step != tt.step {
+			t.Errorf("%s: matchTOTP(%s) = %d, %t, want %d, %t", tt.name, tt.code, step, ok, tt.step, tt.ok)
+		}
+	}
+
+	if _, ok := matchTOTP(secret+"=", "287082", now); ok {
+		t.Error("matchTOTP accepted an invalid secret")
+	}
+	if _, ok := matchTOTP(strings.ToLower(secret), "287082", now); !ok {
+		t.Error("matchTOTP rejected a lower-case secret")
+	}
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	want := hashRecoveryCode("abcde-fghij")
+	for _, code := range []string{"ABCDE-FGHIJ", "abcdefghij", " abcde fghij ", "AbCdE-fGhIj"} {
+		if got := hashRecoveryCode(code); got != want {
+			t.Errorf("hashRecoveryCode(%q) differs from the normalized code", code)
+		}
+	}
+	if hashRecoveryCode("abcde-fghik") == want {
+		t.Error("different recovery codes have the same hash")
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("got %d codes, want %d", len(codes), recoveryCodeCount)
+	}
+	format := regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`)
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("code %q does not match xxxxx-xxxxx", code)
+		}
+		if isTOTPCode(code) {
+			t.Errorf("code %q is taken for a TOTP code", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q is repeated", code)
+		}
+		seen[code] = true
+	}
+}
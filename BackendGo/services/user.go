@@ -1,50 +1,90 @@
 package services
 
 import (
+	"CryptoLens_Backend/config"
+	"CryptoLens_Backend/integration/mail"
 	"CryptoLens_Backend/models"
 	"CryptoLens_Backend/repositories"
+	"CryptoLens_Backend/storages"
+	"CryptoLens_Backend/types"
+	"CryptoLens_Shared/logger"
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
-	"strings"
 	"time"
 )
 
+// errInvalidRefreshToken не раскрывает, почему токен не подошел: истек, отозван или не существует
+var errInvalidRefreshToken = models.Unauthorized("invalid refresh token")
+
 type UserService struct {
-	userRepo *repositories.UserRepository
-	jwtKey   []byte
-	db       *sql.DB
+	userRepo    *repositories.UserRepository
+	sessionRepo types.UserSessionRepositoryInterface
+	auditRepo   types.AuthAuditRepositoryInterface
+	totpRepo    types.UserTOTPRepositoryInterface
+	notifier    types.NotifierInterface
+	streams     types.StreamSessionCloserInterface
+	mailer      mail.Client // nil, если отправка писем не настроена
+	jwtKey      []byte
+	db          *sql.DB
 }
 
 type Claims struct {
-	UserID string `json:"user_id"`
+	UserID    string `json:"user_id"`
+	SessionID string `json:"sid,omitempty"`  // Сессия, которую можно отозвать; у старых токенов отсутствует
+	Role      string `json:"role,omitempty"` // Роль на момент выпуска токена; без роли токен получает права обычного пользователя
 	jwt.RegisteredClaims
 }
 
-func NewUserService(userRepo *repositories.UserRepository, jwtKey []byte, db *sql.DB) *UserService {
+func NewUserService(
+	userRepo *repositories.UserRepository,
+	sessionRepo types.UserSessionRepositoryInterface,
+	auditRepo types.AuthAuditRepositoryInterface,
+	totpRepo types.UserTOTPRepositoryInterface,
+	notifier types.NotifierInterface,
+	streams types.StreamSessionCloserInterface,
+	mailer mail.Client,
+	jwtKey []byte,
+	db *sql.DB,
+) *UserService {
 	SetJWTKey(jwtKey)
 	return &UserService{
-		userRepo: userRepo,
-		jwtKey:   jwtKey,
-		db:       db,
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		auditRepo:   auditRepo,
+		totpRepo:    totpRepo,
+		notifier:    notifier,
+		streams:     streams,
+		mailer:      mailer,
+		jwtKey:      jwtKey,
+		db:          db,
 	}
 }
 
-func (s *UserService) Register(ctx context.Context, req models.RegisterRequest) (*models.RegisterResponse, error) {
+func (s *UserService) Register(ctx context.Context, req models.RegisterRequest, meta models.SessionMeta) (*models.RegisterResponse, error) {
+	if err := s.checkRegisterAllowed(ctx, normalizeEmail(req.Email), meta); err != nil {
+		return nil, err
+	}
+
 	// Проверяем, существует ли пользователь с таким email
 	exists, err := s.userRepo.ExistsByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, err
 	}
 	if exists {
-		return nil, errors.New("user with this email already exists")
+		return nil, models.Conflict("user with this email already exists")
 	}
 
 	// Получаем ID типа пользователя "user"
 	var userTypeID string
-	err = s.db.QueryRowContext(ctx, "SELECT id FROM user_types WHERE name = 'user'").Scan(&userTypeID)
+	err = s.db.QueryRowContext(ctx, "SELECT id FROM user_types WHERE name = $1", models.RoleUser).Scan(&userTypeID)
 	if err != nil {
 		return nil, errors.New("failed to get user type")
 	}
@@ -61,77 +101,220 @@ func (s *UserService) Register(ctx context.Context, req models.RegisterRequest)
 		Email:      req.Email,
 		Password:   string(hashedPassword),
 		UserTypeID: userTypeID,
+		Role:       models.RoleUser,
 		CreatedAt:  &time.Time{},
 	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
 		return nil, err
 	}
+	s.audit(ctx, &user.ID, normalizeEmail(user.Email), models.AuthEventRegistered, meta)
 
-	// Генерируем JWT токен
-	token, err := s.generateToken(user)
+	// Письмо уходит в фоне: недоступность SMTP не должна мешать регистрации,
+	// а повторить отправку можно через /api/v1/user/email/resend
+	if s.mailer != nil {
+		go func(ctx context.Context) {
+			ctx, cancel := context.WithTimeout(ctx, mailSendTimeout)
+			defer cancel()
+			if err := s.sendVerificationEmail(ctx, user); err != nil {
+				logger.ErrorCtx(ctx, "Ошибка отправки письма для подтверждения email: %v", err)
+			}
+		}(logger.WithFields(context.WithoutCancel(ctx), logger.FieldUserID, user.ID))
+	}
+
+	// Открываем сессию и выдаем токены
+	tokens, err := s.openSession(ctx, user, meta)
 	if err != nil {
 		return nil, err
 	}
 
 	return &models.RegisterResponse{
-		User:  *user,
-		Token: token,
+		User:         *user,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
 	}, nil
 }
 
-func (s *UserService) Login(ctx context.Context, req models.LoginRequest) (*models.LoginResponse, error) {
+func (s *UserService) Login(ctx context.Context, req models.LoginRequest, meta models.SessionMeta) (*models.LoginResponse, error) {
+	email := normalizeEmail(req.Email)
+	if err := s.checkLoginAllowed(ctx, email, meta); err != nil {
+		return nil, err
+	}
+
 	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		s.loginFailed(ctx, nil, email, meta)
+		return nil, models.Unauthorized("invalid credentials")
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return nil, errors.New("invalid credentials")
+		s.loginFailed(ctx, user, email, meta)
+		return nil, models.Unauthorized("invalid credentials")
+	}
+	if user.DisabledAt != nil {
+		return nil, models.Unauthorized("account is disabled")
+	}
+	// Неверный код считается неудачным входом, а отсутствующий — нет: клиент узнает о втором
+	// факторе только после верного пароля и повторяет запрос с кодом
+	if err := s.VerifySecondFactor(ctx, user.ID, req.OTPCode, meta); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			s.loginFailed(ctx, user, email, meta)
+		}
+		return nil, err
 	}
 
-	token, err := s.generateToken(user)
+	s.loginSucceeded(ctx, user, email, meta)
+	return s.openSession(ctx, user, meta)
+}
+
+// RefreshToken обменивает refresh-токен на новую пару токенов. Refresh-токен одноразовый:
+// предъявление уже замененного токена означает, что он утек, и сессия отзывается.
+func (s *UserService) RefreshToken(ctx context.Context, refreshToken string, meta models.SessionMeta) (*models.LoginResponse, error) {
+	hash := hashRefreshToken(refreshToken)
+	session, err := s.sessionRepo.GetByTokenHash(ctx, hash)
+	if err != nil {
+		return nil, errInvalidRefreshToken
+	}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, errInvalidRefreshToken
+	}
+	if session.RefreshTokenHash != hash {
+		logger.WarnCtx(logger.WithFields(ctx, logger.FieldUserID, session.UserID),
+			"Повторное использование refresh-токена, сессия %s отозвана", session.ID)
+		s.audit(ctx, &session.UserID, "", models.AuthEventRefreshTokenReuse, meta)
+		s.securityAlert(ctx, session.UserID, "Сессия отозвана",
+			"Уже использованный refresh-токен предъявлен повторно, возможно, он был похищен. Сессия завершена, войдите заново.",
+			meta)
+		if err := s.RevokeSession(ctx, session.UserID, session.ID); err != nil {
+			logger.ErrorCtx(ctx, "Ошибка отзыва сессии %s: %v", session.ID, err)
+		}
+		return nil, errInvalidRefreshToken
+	}
+
+	newToken, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := s.sessionRepo.Rotate(ctx, session.ID, hash, hashRefreshToken(newToken), meta)
 	if err != nil {
 		return nil, err
 	}
+	if !rotated {
+		// Токен успели обменять параллельным запросом или сессию отозвали
+		return nil, errInvalidRefreshToken
+	}
 
-	return &models.LoginResponse{
-		AccessToken: token,
-		TokenType:   "bearer",
-		ExpiresIn:   3600,
-	}, nil
+	user, err := s.userRepo.GetByID(ctx, session.UserID)
+	if err != nil || user.DisabledAt != nil {
+		return nil, errInvalidRefreshToken
+	}
+	return s.tokenResponse(user, session, newToken)
 }
 
-func (s *UserService) Logout(ctx context.Context, token string) (*models.LogoutResponse, error) {
-	// В данном случае просто возвращаем успешный ответ
-	// В реальном приложении здесь можно добавить токен в черный список
+// Logout отзывает сессию, которой принадлежит токен запроса
+func (s *UserService) Logout(ctx context.Context, userID, sessionID string) (*models.LogoutResponse, error) {
+	// Токены, выданные до появления сессий, не отзываются и истекают сами
+	if sessionID != "" {
+		if err := s.RevokeSession(ctx, userID, sessionID); err != nil {
+			return nil, err
+		}
+	}
 	return &models.LogoutResponse{
 		Status:  "Success",
 		Message: "Successfully logged out",
 	}, nil
 }
 
-func (s *UserService) GetAccount(ctx context.Context, token string) (*models.User, error) {
-	// Валидируем токен и получаем ID пользователя
-	userID, err := s.validateToken(token)
+// GetSessions возвращает активные сессии пользователя, отмечая текущую
+func (s *UserService) GetSessions(ctx context.Context, userID, currentSessionID string) ([]models.UserSession, error) {
+	sessions, err := s.sessionRepo.GetActiveByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
 
-	user, err := s.userRepo.GetByID(ctx, userID)
+// RevokeSession отзывает сессию: refresh-токен перестает действовать сразу,
+// уже выданные access-токены отклоняются по списку отозванных сессий в Redis,
+// а открытые с ними подключения к потоку закрываются
+func (s *UserService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	if err := s.sessionRepo.Revoke(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	s.streams.CloseSession(sessionID)
+	if err := storages.RevokeSession(ctx, sessionID, config.Get().Auth.AccessTokenTTL.Std()); err != nil {
+		return fmt.Errorf("session revoked, but its access tokens stay valid until expiry: %w", err)
+	}
+	return nil
+}
+
+// RevokeAllSessions отзывает все сессии пользователя, например при его отключении
+func (s *UserService) RevokeAllSessions(ctx context.Context, userID string) error {
+	sessionIDs, err := s.sessionRepo.RevokeAll(ctx, userID)
+	if err != nil {
+		return err
+	}
+	// Закрываются и подключения по API-ключам: при отключении пользователя доступа не должно остаться
+	s.streams.CloseUser(userID)
+	ttl := config.Get().Auth.AccessTokenTTL.Std()
+	var denyErr error
+	for _, sessionID := range sessionIDs {
+		if err := storages.RevokeSession(ctx, sessionID, ttl); err != nil {
+			denyErr = err
+		}
+	}
+	if denyErr != nil {
+		return fmt.Errorf("sessions revoked, but some access tokens stay valid until expiry: %w", denyErr)
+	}
+	return nil
+}
+
+// openSession открывает новую сессию пользователя и выдает пару токенов
+func (s *UserService) openSession(ctx context.Context, user *models.User, meta models.SessionMeta) (*models.LoginResponse, error) {
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(config.Get().Auth.RefreshTokenTTL.Std())
+	session, err := s.sessionRepo.Create(ctx, user.ID, hashRefreshToken(refreshToken), meta, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return s.tokenResponse(user, session, refreshToken)
+}
+
+// tokenResponse выпускает access-токен сессии и собирает ответ с токенами
+func (s *UserService) tokenResponse(user *models.User, session *models.UserSession, refreshToken string) (*models.LoginResponse, error) {
+	accessTTL := config.Get().Auth.AccessTokenTTL.Std()
+	token, err := s.generateToken(user, session.ID, accessTTL)
 	if err != nil {
 		return nil, err
 	}
+	return &models.LoginResponse{
+		AccessToken:      token,
+		TokenType:        "bearer",
+		ExpiresIn:        int(accessTTL.Seconds()),
+		RefreshToken:     refreshToken,
+		RefreshExpiresIn: int(time.Until(session.ExpiresAt).Seconds()),
+	}, nil
+}
 
-	return user, nil
+// GetAccount возвращает профиль пользователя, прошедшего аутентификацию по токену или API-ключу
+func (s *UserService) GetAccount(ctx context.Context, userID string) (*models.User, error) {
+	return s.userRepo.GetByID(ctx, userID)
 }
 
-func (s *UserService) generateToken(user *models.User) (string, error) {
+func (s *UserService) generateToken(user *models.User, sessionID string, ttl time.Duration) (string, error) {
 	now := time.Now()
 	claims := Claims{
-		UserID: user.ID,
+		UserID:    user.ID,
+		SessionID: sessionID,
+		Role:      user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    "crypto-lens",
@@ -143,22 +326,17 @@ func (s *UserService) generateToken(user *models.User) (string, error) {
 	return token.SignedString(s.jwtKey)
 }
 
-func (s *UserService) validateToken(tokenString string) (string, error) {
-	// Убираем префикс "Bearer " если он есть
-	tokenString = strings.TrimPrefix(tokenString, "Bearer ")
-
-	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return s.jwtKey, nil
-	})
-
-	if err != nil {
-		return "", err
-	}
-
-	if !token.Valid {
-		return "", errors.New("invalid token")
+// generateRefreshToken создает случайный refresh-токен
+func generateRefreshToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
 	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
 
-	return claims.UserID, nil
-} 
\ No newline at end of file
+// hashRefreshToken хеширует refresh-токен для хранения: сам токен в БД не попадает
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/repositories"
+	"CryptoLens_Backend/types"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	apiKeyPrefixLength = 8  // символов ключа после clk_, которые хранятся открыто для списка ключей
+	maxAPIKeysPerUser  = 20 // ключей на пользователя
+)
+
+// errInvalidAPIKey не раскрывает, почему ключ не подошел: истек, удален или не существует
+var errInvalidAPIKey = errors.New("invalid api key")
+
+// UserAPIKeyService управляет персональными API-ключами и проверяет их при аутентификации
+type UserAPIKeyService struct {
+	apiKeyRepo types.UserAPIKeyRepositoryInterface
+	userRepo   *repositories.UserRepository
+}
+
+// NewUserAPIKeyService создает новый сервис API-ключей
+func NewUserAPIKeyService(apiKeyRepo types.UserAPIKeyRepositoryInterface, userRepo *repositories.UserRepository) *UserAPIKeyService {
+	return &UserAPIKeyService{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+	}
+}
+
+// CreateKey создает ключ и возвращает его единственный раз: в БД остается только хеш
+func (s *UserAPIKeyService) CreateKey(ctx context.Context, userID string, req models.CreateAPIKeyRequest) (*models.CreateAPIKeyResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len([]rune(name)) > 100 {
+		return nil, models.Invalid("name must be 1-100 characters long")
+	}
+	scopes, err := s.validateScopes(ctx, userID, req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, models.Invalid("expires_at must be in the future")
+	}
+
+	keys, err := s.apiKeyRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) >= maxAPIKeysPerUser {
+		return nil, models.Conflict("api key limit reached (%d), remove unused keys first", maxAPIKeysPerUser)
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	secret := base64.RawURLEncoding.EncodeToString(buf)
+	key := models.APIKeyPrefix + secret
+
+	apiKey, err := s.apiKeyRepo.Create(ctx, userID, name, secret[:apiKeyPrefixLength], hashAPIKey(key), scopes, req.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CreateAPIKeyResponse{APIKey: apiKey, Key: key}, nil
+}
+
+// GetKeys возвращает ключи пользователя без их значений
+func (s *UserAPIKeyService) GetKeys(ctx context.Context, userID string) ([]models.APIKey, error) {
+	return s.apiKeyRepo.GetByUserID(ctx, userID)
+}
+
+// RemoveKey удаляет ключ; запросы с ним сразу перестают приниматься
+func (s *UserAPIKeyService) RemoveKey(ctx context.Context, userID, id string) error {
+	return s.apiKeyRepo.Delete(ctx, userID, id)
+}
+
+// Authenticate проверяет ключ из заголовка Authorization и отмечает время его использования
+func (s *UserAPIKeyService) Authenticate(ctx context.Context, key string) (*models.APIKeyIdentity, error) {
+	if !strings.HasPrefix(key, models.APIKeyPrefix) {
+		return nil, errInvalidAPIKey
+	}
+	identity, err := s.apiKeyRepo.Authenticate(ctx, hashAPIKey(key))
+	if err != nil {
+		return nil, errInvalidAPIKey
+	}
+	return identity, nil
+}
+
+// validateScopes проверяет области ключа и убирает повторы. Область admin доступна только администраторам.
+func (s *UserAPIKeyService) validateScopes(ctx context.Context, userID string, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, models.Invalid("at least one scope is required")
+	}
+
+	seen := make(map[string]bool)
+	var scopes []string
+	for _, scope := range requested {
+		if !isKnownAPIKeyScope(scope) {
+			return nil, models.Invalid("unknown scope: %s", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if seen[models.APIKeyScopeAdmin] {
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if user.Role != models.RoleAdmin {
+			return nil, models.Invalid("admin scope is available to administrators only")
+		}
+	}
+	return scopes, nil
+}
+
+func isKnownAPIKeyScope(scope string) bool {
+	for _, known := range models.APIKeyScopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
+
+// hashAPIKey хеширует API-ключ для хранения и поиска. Ключ случайный и длинный, поэтому соль не нужна.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"CryptoLens_Backend/config"
+	"CryptoLens_Backend/integration/mail"
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/storages"
+	"CryptoLens_Shared/logger"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Назначения токенов из писем: токен одного назначения не принимается в другом
+const (
+	tokenPurposeVerifyEmail   = "verify_email"
+	tokenPurposeResetPassword = "reset_password"
+)
+
+// Ограничения на отправку писем. Лимит по email для сброса пароля не сообщается клиенту,
+// чтобы по ответу нельзя было узнать, зарегистрирован ли адрес.
+const (
+	verificationResendLimit  = 5
+	verificationResendWindow = time.Hour
+	passwordResetEmailLimit  = 3
+	passwordResetEmailWindow = time.Hour
+	mailSendTimeout          = 30 * time.Second
+)
+
+var (
+	// ErrEmailNotVerified возвращается операциям, которые требуют подтвержденного email
+	ErrEmailNotVerified = errors.New("email is not verified")
+	// ErrMailDisabled возвращается, когда отправка писем не настроена
+	ErrMailDisabled = errors.New("email delivery is not configured")
+
+	// errInvalidActionToken не раскрывает, почему токен из письма не подошел
+	errInvalidActionToken = models.Invalid("invalid or expired token")
+)
+
+// actionClaims токен из письма. Fingerprint привязывает токен к текущему состоянию аккаунта:
+// к email для подтверждения и к хешу пароля для сброса, поэтому после смены пароля ссылка сброса
+// перестает действовать.
+type actionClaims struct {
+	Purpose     string `json:"purpose"`
+	Fingerprint string `json:"fp"`
+	jwt.RegisteredClaims
+}
+
+// VerifyEmail подтверждает email по токену из письма
+func (s *UserService) VerifyEmail(ctx context.Context, token string, meta models.SessionMeta) error {
+	claims, err := s.parseActionToken(token, tokenPurposeVerifyEmail)
+	if err != nil {
+		return err
+	}
+	user, err := s.userRepo.GetByID(ctx, claims.Subject)
+	if err != nil || user.DisabledAt != nil || claims.Fingerprint != fingerprint(normalizeEmail(user.Email)) {
+		return errInvalidActionToken
+	}
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+	if err := s.userRepo.MarkEmailVerified(ctx, user.ID); err != nil {
+		return err
+	}
+	s.audit(ctx, &user.ID, normalizeEmail(user.Email), models.AuthEventEmailVerified, meta)
+	return nil
+}
+
+// ResendVerification повторно отправляет письмо для подтверждения email
+func (s *UserService) ResendVerification(ctx context.Context, userID string) error {
+	if s.mailer == nil {
+		return ErrMailDisabled
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return models.Conflict("email is already verified")
+	}
+
+	count, retryAfter, err := storages.IncrementRateLimit(ctx, "verify_email:"+user.ID, verificationResendWindow)
+	if err != nil {
+		logger.ErrorCtx(ctx, "Ошибка проверки лимита писем подтверждения: %v", err)
+		return ErrAuthUnavailable
+	}
+	if count > verificationResendLimit {
+		return &RateLimitError{RetryAfter: retryAfter}
+	}
+	return s.sendVerificationEmail(ctx, user)
+}
+
+// RequestPasswordReset отправляет ссылку для сброса пароля. Ответ не зависит от того,
+// существует ли аккаунт: письмо отправляется в фоне, а неизвестный email просто пропускается.
+func (s *UserService) RequestPasswordReset(ctx context.Context, email string, meta models.SessionMeta) error {
+	if s.mailer == nil {
+		return ErrMailDisabled
+	}
+	lookupEmail := strings.TrimSpace(email)
+	email = normalizeEmail(email)
+	// С одного IP запросов сброса не больше, чем попыток входа
+	cfg := config.Get().Auth
+	count, retryAfter, err := storages.IncrementRateLimit(ctx, "password_reset:"+meta.IPAddress, cfg.LoginIPWindow.Std())
+	if err != nil {
+		logger.ErrorCtx(ctx, "Ошибка проверки лимита сброса пароля: %v", err)
+		return ErrAuthUnavailable
+	}
+	if count > int64(cfg.LoginIPLimit) {
+		return &RateLimitError{RetryAfter: retryAfter}
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, lookupEmail)
+	if err != nil || user.DisabledAt != nil {
+		s.audit(ctx, nil, email, models.AuthEventResetRequested, meta)
+		return nil
+	}
+	s.audit(ctx, &user.ID, email, models.AuthEventResetRequested, meta)
+
+	// Лимит по адресу защищает владельца от потока писем с разных IP
+	count, _, err = storages.IncrementRateLimit(ctx, "password_reset_email:"+email, passwordResetEmailWindow)
+	if err != nil {
+		logger.ErrorCtx(ctx, "Ошибка проверки лимита сброса пароля: %v", err)
+		return ErrAuthUnavailable
+	}
+	if count > passwordResetEmailLimit {
+		return nil
+	}
+
+	ttl := config.Get().Mail.PasswordResetTTL.Std()
+	token, err := s.issueActionToken(user.ID, tokenPurposeResetPassword, fingerprint(user.Password), ttl)
+	if err != nil {
+		return err
+	}
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "CryptoLens: сброс пароля",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\n"+
+			"Для аккаунта CryptoLens запрошен сброс пароля. Чтобы задать новый пароль, перейдите по ссылке:\n\n%s\n\n"+
+			"Ссылка действует %s и сработает один раз. Если вы не запрашивали сброс, просто проигнорируйте письмо: пароль останется прежним.\n",
+			user.Nickname, s.mailLink("reset-password", token), humanizeTTL(ttl)),
+	}
+	userCtx := logger.WithFields(context.WithoutCancel(ctx), logger.FieldUserID, user.ID)
+	go s.sendMail(userCtx, msg)
+	return nil
+}
+
+// ConfirmPasswordReset задает новый пароль по токену из письма. Все сессии пользователя
+// отзываются, а блокировка входа снимается: владелец подтвердил доступ к почте.
+func (s *UserService) ConfirmPasswordReset(ctx context.Context, req models.ConfirmPasswordResetRequest, meta models.SessionMeta) error {
+	if len(req.Password) < 8 {
+		return models.Invalid("password must be at least 8 characters")
+	}
+	if req.Password != req.PasswordConfirmation {
+		return models.Invalid("password confirmation does not match")
+	}
+	claims, err := s.parseActionToken(req.Token, tokenPurposeResetPassword)
+	if err != nil {
+		return err
+	}
+	user, err := s.userRepo.GetByID(ctx, claims.Subject)
+	if err != nil || user.DisabledAt != nil || claims.Fingerprint != fingerprint(user.Password) {
+		return errInvalidActionToken
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	updated, err := s.userRepo.UpdatePassword(ctx, user.ID, user.Password, string(hashedPassword))
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errInvalidActionToken
+	}
+
+	ctx = logger.WithFields(ctx, logger.FieldUserID, user.ID)
+	email := normalizeEmail(user.Email)
+	s.audit(ctx, &user.ID, email, models.AuthEventPasswordReset, meta)
+	logger.InfoCtx(ctx, "Пароль сброшен по ссылке из письма")
+
+	// Переход по ссылке из письма подтверждает и сам адрес
+	if user.EmailVerifiedAt == nil {
+		if err := s.userRepo.MarkEmailVerified(ctx, user.ID); err != nil {
+			logger.ErrorCtx(ctx, "Ошибка подтверждения email после сброса пароля: %v", err)
+		}
+	}
+	if err := s.RevokeAllSessions(ctx, user.ID); err != nil {
+		logger.ErrorCtx(ctx, "Ошибка отзыва сессий после сброса пароля: %v", err)
+	}
+	if err := storages.ResetLoginFailures(ctx, email); err != nil {
+		logger.ErrorCtx(ctx, "Ошибка сброса неудачных входов: %v", err)
+	}
+	if err := storages.UnlockLogin(ctx, email); err != nil {
+		logger.ErrorCtx(ctx, "Ошибка снятия блокировки входа: %v", err)
+	}
+	s.securityAlert(ctx, user.ID, "Пароль изменен",
+		"Пароль аккаунта сброшен по ссылке из письма, все сессии завершены. Если это были не вы, немедленно свяжитесь с поддержкой.",
+		meta)
+	return nil
+}
+
+// EnsureEmailVerified возвращает ErrEmailNotVerified, если подтверждение email включено,
+// а пользователь его еще не прошел
+func (s *UserService) EnsureEmailVerified(ctx context.Context, userID string) error {
+	if !config.Get().Auth.RequireEmailVerification {
+		return nil
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt == nil {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// sendVerificationEmail отправляет ссылку для подтверждения email
+func (s *UserService) sendVerificationEmail(ctx context.Context, user *models.User) error {
+	ttl := config.Get().Mail.VerificationTTL.Std()
+	token, err := s.issueActionToken(user.ID, tokenPurposeVerifyEmail, fingerprint(normalizeEmail(user.Email)), ttl)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "CryptoLens: подтвердите email",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\n"+
+			"Подтвердите адрес электронной почты, чтобы подключить API-ключи биржи. Перейдите по ссылке:\n\n%s\n\n"+
+			"Ссылка действует %s. Если вы не регистрировались в CryptoLens, просто проигнорируйте письмо.\n",
+			user.Nickname, s.mailLink("verify-email", token), humanizeTTL(ttl)),
+	})
+}
+
+// sendMail отправляет письмо в фоне и только логирует ошибку
+func (s *UserService) sendMail(ctx context.Context, msg mail.Message) {
+	ctx, cancel := context.WithTimeout(ctx, mailSendTimeout)
+	defer cancel()
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		logger.ErrorCtx(ctx, "Ошибка отправки письма %q: %v", msg.Subject, err)
+	}
+}
+
+// mailLink собирает ссылку на страницу клиента с токеном в параметре запроса
+func (s *UserService) mailLink(page, token string) string {
+	base := strings.TrimRight(config.Get().Mail.LinkBaseURL, "/")
+	return base + "/" + page + "?token=" + url.QueryEscape(token)
+}
+
+// humanizeTTL записывает срок действия ссылки для текста письма
+func humanizeTTL(ttl time.Duration) string {
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		return fmt.Sprintf("%d ч", int(ttl.Hours()))
+	}
+	return fmt.Sprintf("%d мин", int(ttl.Minutes()))
+}
+
+// issueActionToken выпускает подписанный токен для ссылки из письма
+func (s *UserService) issueActionToken(userID, purpose, fp string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := actionClaims{
+		Purpose:     purpose,
+		Fingerprint: fp,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    "crypto-lens",
+			Subject:   userID,
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.actionTokenKey())
+}
+
+// parseActionToken проверяет подпись, срок и назначение токена из письма
+func (s *UserService) parseActionToken(token, purpose string) (*actionClaims, error) {
+	claims := &actionClaims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return s.actionTokenKey(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil || !parsed.Valid || claims.Purpose != purpose || claims.Subject == "" {
+		return nil, errInvalidActionToken
+	}
+	return claims, nil
+}
+
+// actionTokenKey выводит из JWT_SECRET отдельный ключ, чтобы токен из письма
+// нельзя было предъявить как access-токен и наоборот
+func (s *UserService) actionTokenKey() []byte {
+	mac := hmac.New(sha256.New, s.jwtKey)
+	mac.Write([]byte("crypto-lens:action-token"))
+	return mac.Sum(nil)
+}
+
+// fingerprint короткий отпечаток значения, к которому привязан токен; само значение в токен не попадает
+func fingerprint(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:16])
+}
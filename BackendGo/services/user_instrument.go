@@ -1,12 +1,11 @@
 package services
 
 import (
-	"CryptoLens_Backend/logger"
 	"CryptoLens_Backend/models"
 	"CryptoLens_Backend/repositories"
 	"CryptoLens_Backend/types"
+	"CryptoLens_Shared/logger"
 	"context"
-	"errors"
 	"fmt"
 )
 
@@ -14,17 +13,20 @@ type UserInstrumentService struct {
 	userInstrumentRepo *repositories.UserInstrumentRepository
 	bybitInstrumentRepo *repositories.BybitInstrumentRepository
 	strategyManager types.StrategyManagerInterface
+	streams types.PublicStreamRefresherInterface
 }
 
 func NewUserInstrumentService(
 	userInstrumentRepo *repositories.UserInstrumentRepository,
 	bybitInstrumentRepo *repositories.BybitInstrumentRepository,
 	strategyManager types.StrategyManagerInterface,
+	streams types.PublicStreamRefresherInterface,
 ) *UserInstrumentService {
 	return &UserInstrumentService{
 		userInstrumentRepo: userInstrumentRepo,
 		bybitInstrumentRepo: bybitInstrumentRepo,
 		strategyManager: strategyManager,
+		streams: streams,
 	}
 }
 
@@ -37,7 +39,7 @@ func (s *UserInstrumentService) AddInstrument(ctx context.Context, userID string
 		return nil, err
 	}
 	if !exists {
-		return nil, errors.New("instrument not found in Bybit")
+		return nil, models.Invalid("instrument not found in Bybit")
 	}
 
 	// Проверяем, не добавлен ли уже этот инструмент пользователю
@@ -47,7 +49,7 @@ func (s *UserInstrumentService) AddInstrument(ctx context.Context, userID string
 		return nil, err
 	}
 	if exists {
-		return nil, errors.New("instrument already added for this user")
+		return nil, models.Conflict("instrument already added for this user")
 	}
 
 	// Создаем связь пользователя с инструментом
@@ -56,6 +58,9 @@ func (s *UserInstrumentService) AddInstrument(ctx context.Context, userID string
 		return nil, err
 	}
 
+	// Подписываемся на рыночные данные инструмента, не дожидаясь плановой сверки
+	s.streams.RefreshPublicSubscriptions()
+
 	// Обновляем символы в StrategyManager
 	if err := s.strategyManager.UpdateUserInstruments(ctx, userID); err != nil {
 		return nil, fmt.Errorf("failed to update user instruments in strategy manager: %w", err)
@@ -85,19 +90,14 @@ func (s *UserInstrumentService) GetUserInstruments(ctx context.Context, userID s
 }
 
 // UpdateInstrumentStatus обновляет статус инструмента пользователя
-func (s *UserInstrumentService) UpdateInstrumentStatus(ctx context.Context, id string, isActive bool) error {
-	if err := s.userInstrumentRepo.Update(ctx, id, isActive); err != nil {
+func (s *UserInstrumentService) UpdateInstrumentStatus(ctx context.Context, userID, id string, isActive bool) error {
+	if err := s.userInstrumentRepo.Update(ctx, userID, id, isActive); err != nil {
 		return err
 	}
-
-	// Получаем userID для обновления StrategyManager
-	instrument, err := s.userInstrumentRepo.GetByID(ctx, id)
-	if err != nil {
-		return fmt.Errorf("failed to get instrument for update: %w", err)
-	}
+	s.streams.RefreshPublicSubscriptions()
 
 	// Обновляем символы в StrategyManager
-	if err := s.strategyManager.UpdateUserInstruments(ctx, instrument.UserID); err != nil {
+	if err := s.strategyManager.UpdateUserInstruments(ctx, userID); err != nil {
 		return fmt.Errorf("failed to update user instruments in strategy manager: %w", err)
 	}
 
@@ -105,21 +105,16 @@ func (s *UserInstrumentService) UpdateInstrumentStatus(ctx context.Context, id s
 }
 
 // RemoveInstrument удаляет инструмент у пользователя
-func (s *UserInstrumentService) RemoveInstrument(ctx context.Context, id string) error {
-	// Получаем userID перед удалением
-	instrument, err := s.userInstrumentRepo.GetByID(ctx, id)
-	if err != nil {
-		return fmt.Errorf("failed to get instrument for removal: %w", err)
-	}
-
-	if err := s.userInstrumentRepo.Delete(ctx, id); err != nil {
+func (s *UserInstrumentService) RemoveInstrument(ctx context.Context, userID, id string) error {
+	if err := s.userInstrumentRepo.Delete(ctx, userID, id); err != nil {
 		return err
 	}
+	s.streams.RefreshPublicSubscriptions()
 
 	// Обновляем символы в StrategyManager
-	if err := s.strategyManager.UpdateUserInstruments(ctx, instrument.UserID); err != nil {
+	if err := s.strategyManager.UpdateUserInstruments(ctx, userID); err != nil {
 		return fmt.Errorf("failed to update user instruments in strategy manager: %w", err)
 	}
 
 	return nil
-} 
\ No newline at end of file
+}
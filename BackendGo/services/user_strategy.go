@@ -1,12 +1,12 @@
 package services
 
 import (
-	"CryptoLens_Backend/logger"
 	"CryptoLens_Backend/models"
 	"CryptoLens_Backend/repositories"
 	"CryptoLens_Backend/trading"
+	"CryptoLens_Backend/types"
+	"CryptoLens_Shared/logger"
 	"context"
-	"errors"
 	"fmt"
 	"time"
 )
@@ -15,32 +15,113 @@ type UserStrategyService struct {
 	userStrategyRepo    *repositories.UserStrategyRepository
 	strategyManager     *trading.StrategyManager
 	bybitInstrumentRepo *repositories.BybitInstrumentRepository
+	bybitAccountRepo    types.BybitAccountRepositoryInterface
+	notifier            types.NotifierInterface
+	webhooks            types.WebhookPublisherInterface
+	stream              types.StreamPublisherInterface
 }
 
 func NewUserStrategyService(
 	userStrategyRepo *repositories.UserStrategyRepository,
 	strategyManager *trading.StrategyManager,
 	bybitInstrumentRepo *repositories.BybitInstrumentRepository,
+	bybitAccountRepo types.BybitAccountRepositoryInterface,
+	notifier types.NotifierInterface,
+	webhooks types.WebhookPublisherInterface,
+	stream types.StreamPublisherInterface,
 ) *UserStrategyService {
 	return &UserStrategyService{
 		userStrategyRepo:    userStrategyRepo,
 		strategyManager:     strategyManager,
 		bybitInstrumentRepo: bybitInstrumentRepo,
+		bybitAccountRepo:    bybitAccountRepo,
+		notifier:            notifier,
+		webhooks:            webhooks,
+		stream:              stream,
 	}
 }
 
-func (s *UserStrategyService) AddStrategy(ctx context.Context, userID string, strategyName string) (*models.UserStrategy, error) {
-	// Проверяем, существует ли уже такая стратегия у пользователя
-	exists, err := s.userStrategyRepo.Exists(ctx, userID, strategyName)
+// newStrategy создает экземпляр стратегии на аккаунте, к которому она привязана
+func (s *UserStrategyService) newStrategy(st models.UserStrategy) (types.Strategy, error) {
+	if st.BybitAccountID == nil {
+		return nil, fmt.Errorf("стратегия %s не привязана к аккаунту Bybit", st.StrategyName)
+	}
+	switch st.StrategyName {
+	case "test":
+		return trading.NewTestStrategy(st.UserID, *st.BybitAccountID), nil
+	case "spread_scalping":
+		return trading.NewSpreadScalpingStrategy(
+			st.UserID,             // userID
+			*st.BybitAccountID,    // accountID
+			"BTCUSDT",             // symbol
+			s.strategyManager,     // manager
+			s.bybitInstrumentRepo, // instrumentRepo
+		), nil
+	default:
+		return nil, fmt.Errorf("неизвестная стратегия: %s", st.StrategyName)
+	}
+}
+
+// startStrategy создает стратегию, добавляет ее в менеджер и запускает
+func (s *UserStrategyService) startStrategy(ctx context.Context, st models.UserStrategy) error {
+	strategy, err := s.newStrategy(st)
+	if err != nil {
+		return err
+	}
+	s.strategyManager.AddStrategy(st.UserID, strategy)
+	go strategy.Start(logger.WithFields(ctx, logger.FieldStrategyID, st.ID))
+	return nil
+}
+
+// publishLifecycle отправляет событие жизненного цикла стратегии в вебхуки и поток пользователя
+func (s *UserStrategyService) publishLifecycle(ctx context.Context, strategy *models.UserStrategy, action string) {
+	event := models.StrategyLifecycleEvent{
+		StrategyID:   strategy.ID,
+		StrategyName: strategy.StrategyName,
+		Action:       action,
+	}
+	s.webhooks.Publish(ctx, strategy.UserID, models.WebhookEventStrategy, event)
+
+	var accountID int64
+	if strategy.BybitAccountID != nil {
+		accountID = *strategy.BybitAccountID
+	}
+	s.stream.Publish(strategy.UserID, accountID, models.StreamEventStrategy, event)
+}
+
+// notifyStrategyStopped уведомляет пользователя об остановке стратегии
+func (s *UserStrategyService) notifyStrategyStopped(ctx context.Context, strategy *models.UserStrategy, reason string) {
+	s.notifier.Notify(ctx, models.NotificationEvent{
+		Type:    models.EventStrategyStopped,
+		UserID:  strategy.UserID,
+		Title:   "Стратегия остановлена",
+		Message: fmt.Sprintf("Стратегия %s остановлена: %s", strategy.StrategyName, reason),
+		Fields: map[string]string{
+			"strategy_id":   strategy.ID,
+			"strategy_name": strategy.StrategyName,
+		},
+	})
+}
+
+// AddStrategy добавляет стратегию на аккаунт Bybit пользователя.
+// Если accountID равен 0, используется единственный активный аккаунт.
+func (s *UserStrategyService) AddStrategy(ctx context.Context, userID string, strategyName string, accountID int64) (*models.UserStrategy, error) {
+	account, err := s.bybitAccountRepo.GetActiveAccount(ctx, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверяем, существует ли уже такая стратегия на аккаунте
+	exists, err := s.userStrategyRepo.Exists(ctx, userID, account.ID, strategyName)
 	if err != nil {
 		return nil, err
 	}
 	if exists {
-		return nil, errors.New("стратегия уже добавлена")
+		return nil, models.Conflict("стратегия уже добавлена")
 	}
 
 	// Создаем запись в БД
-	strategy, err := s.userStrategyRepo.Create(ctx, userID, strategyName)
+	strategy, err := s.userStrategyRepo.Create(ctx, userID, account.ID, strategyName)
 	if err != nil {
 		return nil, err
 	}
@@ -60,26 +141,13 @@ func (s *UserStrategyService) AddStrategy(ctx context.Context, userID string, st
 	// Создаем и добавляем все активные стратегии
 	for _, st := range activeStrategies {
 		if st.IsActive {
-			switch st.StrategyName {
-			case "test":
-				testStrategy := trading.NewTestStrategy(st.UserID)
-				s.strategyManager.AddStrategy(st.UserID, testStrategy)
-				go testStrategy.Start(ctx)
-			case "spread_scalping":
-				spreadStrategy := trading.NewSpreadScalpingStrategy(
-					strategy.UserID,       // userID
-					"BTCUSDT",             // symbol
-					s.strategyManager,     // manager
-					s.bybitInstrumentRepo, // instrumentRepo
-				)
-				s.strategyManager.AddStrategy(strategy.UserID, spreadStrategy)
-				go spreadStrategy.Start(ctx)
-			default:
-				logger.LogError("Неизвестная стратегия при добавлении: %s", st.StrategyName)
+			if err := s.startStrategy(ctx, st); err != nil {
+				logger.LogError("Ошибка запуска стратегии при добавлении: %v", err)
 			}
 		}
 	}
 
+	s.publishLifecycle(ctx, strategy, "added")
 	return strategy, nil
 }
 
@@ -87,91 +155,110 @@ func (s *UserStrategyService) GetUserStrategies(ctx context.Context, userID stri
 	return s.userStrategyRepo.GetByUserID(ctx, userID)
 }
 
-func (s *UserStrategyService) UpdateStrategyStatus(ctx context.Context, id string, isActive bool) error {
+func (s *UserStrategyService) UpdateStrategyStatus(ctx context.Context, userID, id string, isActive bool) error {
 	// Получаем информацию о стратегии перед обновлением
-	strategy, err := s.userStrategyRepo.GetByID(ctx, id)
+	strategy, err := s.userStrategyRepo.GetUserStrategy(ctx, userID, id)
 	if err != nil {
 		return fmt.Errorf("ошибка при получении стратегии: %w", err)
 	}
 
+	// Стратегию можно запустить только на активном аккаунте
+	if isActive {
+		if strategy.BybitAccountID == nil {
+			return models.Conflict("стратегия %s не привязана к аккаунту Bybit", strategy.StrategyName)
+		}
+		if _, err := s.bybitAccountRepo.GetActiveAccount(ctx, strategy.UserID, *strategy.BybitAccountID); err != nil {
+			return err
+		}
+	}
+
 	// Обновляем статус в БД
-	if err := s.userStrategyRepo.Update(ctx, id, isActive); err != nil {
+	if err := s.userStrategyRepo.Update(ctx, userID, id, isActive); err != nil {
 		return err
 	}
 
 	// Обновляем состояние в StrategyManager
 	if isActive {
 		// Если стратегия активируется, создаем и добавляем её
-		switch strategy.StrategyName {
-		case "test":
-			testStrategy := trading.NewTestStrategy(strategy.UserID)
-			s.strategyManager.AddStrategy(strategy.UserID, testStrategy)
-			go testStrategy.Start(ctx)
-		case "spread_scalping":
-			spreadStrategy := trading.NewSpreadScalpingStrategy(
-				strategy.UserID,       // userID
-				"BTCUSDT",             // symbol
-				s.strategyManager,     // manager
-				s.bybitInstrumentRepo, // instrumentRepo
-			)
-			s.strategyManager.AddStrategy(strategy.UserID, spreadStrategy)
-			go spreadStrategy.Start(ctx)
-		default:
-			logger.LogError("Неизвестная стратегия при активации: %s", strategy.StrategyName)
+		if err := s.startStrategy(ctx, *strategy); err != nil {
+			logger.LogError("Ошибка запуска стратегии при активации: %v", err)
 		}
+		s.publishLifecycle(ctx, strategy, "started")
 	} else {
-		// Если стратегия деактивируется, удаляем все стратегии пользователя
-		// и создаем заново только активные
-		strategies := s.strategyManager.GetStrategies(strategy.UserID)
-		for _, st := range strategies {
-			s.strategyManager.RemoveStrategy(strategy.UserID, st)
+		if err := s.restartActiveStrategies(strategy.UserID); err != nil {
+			return err
 		}
-
-		// Получаем все активные стратегии пользователя
-		activeStrategies, err := s.userStrategyRepo.GetByUserID(ctx, strategy.UserID)
-		if err != nil {
-			return fmt.Errorf("ошибка при получении активных стратегий: %w", err)
+		if strategy.IsActive {
+			s.notifyStrategyStopped(ctx, strategy, "деактивирована пользователем")
 		}
+		s.publishLifecycle(ctx, strategy, "stopped")
+	}
+
+	logger.LogInfo("Конечное состояние стратегий в менеджере: %+v", s.strategyManager.GetStrategiesInfo())
+	return nil
+}
 
-		// Создаем новый контекст для пересоздания стратегий
-		recreateCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
-		// Создаем и добавляем активные стратегии
-		for _, st := range activeStrategies {
-			if st.IsActive {
-				switch st.StrategyName {
-				case "test":
-					testStrategy := trading.NewTestStrategy(st.UserID)
-					s.strategyManager.AddStrategy(st.UserID, testStrategy)
-					go testStrategy.Start(recreateCtx)
-				case "spread_scalping":
-					spreadStrategy := trading.NewSpreadScalpingStrategy(
-						strategy.UserID,       // userID
-						"BTCUSDT",             // symbol
-						s.strategyManager,     // manager
-						s.bybitInstrumentRepo, // instrumentRepo
-					)
-					s.strategyManager.AddStrategy(strategy.UserID, spreadStrategy)
-					go spreadStrategy.Start(recreateCtx)
-				}
+// StopStrategy останавливает стратегию любого пользователя по ID и сообщает владельцу причину
+func (s *UserStrategyService) StopStrategy(ctx context.Context, id string, reason string) (*models.UserStrategy, error) {
+	strategy, err := s.userStrategyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !strategy.IsActive {
+		return strategy, nil
+	}
+
+	if err := s.userStrategyRepo.Update(ctx, strategy.UserID, id, false); err != nil {
+		return nil, err
+	}
+	if err := s.restartActiveStrategies(strategy.UserID); err != nil {
+		return nil, err
+	}
+	s.notifyStrategyStopped(ctx, strategy, reason)
+	s.publishLifecycle(ctx, strategy, "stopped")
+
+	strategy.IsActive = false
+	return strategy, nil
+}
+
+// restartActiveStrategies удаляет все стратегии пользователя из менеджера
+// и создает заново только активные
+func (s *UserStrategyService) restartActiveStrategies(userID string) error {
+	strategies := s.strategyManager.GetStrategies(userID)
+	for _, st := range strategies {
+		s.strategyManager.RemoveStrategy(userID, st)
+	}
+
+	// Создаем новый контекст для пересоздания стратегий
+	recreateCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Получаем все активные стратегии пользователя
+	activeStrategies, err := s.userStrategyRepo.GetByUserID(recreateCtx, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при получении активных стратегий: %w", err)
+	}
+
+	// Создаем и добавляем активные стратегии
+	for _, st := range activeStrategies {
+		if st.IsActive {
+			if err := s.startStrategy(recreateCtx, st); err != nil {
+				logger.LogError("Ошибка перезапуска стратегии: %v", err)
 			}
 		}
 	}
-
-	logger.LogInfo("Конечное состояние стратегий в менеджере: %+v", s.strategyManager.GetStrategiesInfo())
 	return nil
 }
 
-func (s *UserStrategyService) RemoveStrategy(ctx context.Context, id string) error {
+func (s *UserStrategyService) RemoveStrategy(ctx context.Context, userID, id string) error {
 	// Получаем информацию о стратегии перед удалением
-	strategy, err := s.userStrategyRepo.GetByID(ctx, id)
+	strategy, err := s.userStrategyRepo.GetUserStrategy(ctx, userID, id)
 	if err != nil {
 		return fmt.Errorf("ошибка при получении стратегии: %w", err)
 	}
 
 	// Удаляем стратегию из БД
-	if err := s.userStrategyRepo.Delete(ctx, id); err != nil {
+	if err := s.userStrategyRepo.Delete(ctx, userID, id); err != nil {
 		return err
 	}
 
@@ -190,24 +277,17 @@ func (s *UserStrategyService) RemoveStrategy(ctx context.Context, id string) err
 	// Создаем и добавляем активные стратегии
 	for _, st := range activeStrategies {
 		if st.IsActive {
-			switch st.StrategyName {
-			case "test":
-				testStrategy := trading.NewTestStrategy(st.UserID)
-				s.strategyManager.AddStrategy(st.UserID, testStrategy)
-				go testStrategy.Start(ctx)
-			case "spread_scalping":
-				spreadStrategy := trading.NewSpreadScalpingStrategy(
-					strategy.UserID,       // userID
-					"BTCUSDT",             // symbol
-					s.strategyManager,     // manager
-					s.bybitInstrumentRepo, // instrumentRepo
-				)
-				s.strategyManager.AddStrategy(strategy.UserID, spreadStrategy)
-				go spreadStrategy.Start(ctx)
+			if err := s.startStrategy(ctx, st); err != nil {
+				logger.LogError("Ошибка перезапуска стратегии: %v", err)
 			}
 		}
 	}
 
+	if strategy.IsActive {
+		s.notifyStrategyStopped(ctx, strategy, "удалена пользователем")
+	}
+	s.publishLifecycle(ctx, strategy, "removed")
+
 	logger.LogInfo("Конечное состояние стратегий в менеджере: %+v", s.strategyManager.GetStrategiesInfo())
 	return nil
 }
@@ -229,24 +309,58 @@ func (s *UserStrategyService) LoadActiveStrategies(ctx context.Context) error {
 
 	// Для каждой стратегии создаем соответствующий экземпляр и добавляем в менеджер
 	for _, strategy := range strategies {
-		switch strategy.StrategyName {
-		case "test":
-			testStrategy := trading.NewTestStrategy(strategy.UserID)
-			s.strategyManager.AddStrategy(strategy.UserID, testStrategy)
-			go testStrategy.Start(ctx)
-		case "spread_scalping":
-			spreadStrategy := trading.NewSpreadScalpingStrategy(
-				strategy.UserID,       // userID
-				"BTCUSDT",             // symbol
-				s.strategyManager,     // manager
-				s.bybitInstrumentRepo, // instrumentRepo
-			)
-			s.strategyManager.AddStrategy(strategy.UserID, spreadStrategy)
-			go spreadStrategy.Start(ctx)
-		default:
-			logger.LogError("Неизвестная стратегия при загрузке: %s", strategy.StrategyName)
+		if err := s.startStrategy(ctx, strategy); err != nil {
+			logger.LogError("Ошибка запуска стратегии при загрузке: %v", err)
 		}
 	}
 
 	return nil
 }
+
+// StopAccountStrategies останавливает стратегии, работающие на аккаунте, который отключен или удален.
+// Стратегии других аккаунтов пользователя продолжают работать.
+func (s *UserStrategyService) StopAccountStrategies(ctx context.Context, userID string, accountID int64, reason string) error {
+	return s.stopStrategies(ctx, userID, reason, func(strategyAccountID int64) bool {
+		return strategyAccountID == accountID
+	})
+}
+
+// StopUserStrategies останавливает все стратегии пользователя, например при отключении его учетной записи
+func (s *UserStrategyService) StopUserStrategies(ctx context.Context, userID string, reason string) error {
+	return s.stopStrategies(ctx, userID, reason, func(int64) bool {
+		return true
+	})
+}
+
+// stopStrategies деактивирует активные стратегии пользователя на подходящих аккаунтах
+// и убирает их из менеджера
+func (s *UserStrategyService) stopStrategies(ctx context.Context, userID string, reason string, matchAccount func(accountID int64) bool) error {
+	strategies, err := s.userStrategyRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при получении стратегий: %w", err)
+	}
+
+	var stopped []models.UserStrategy
+	for _, st := range strategies {
+		if st.IsActive && st.BybitAccountID != nil && matchAccount(*st.BybitAccountID) {
+			if err := s.userStrategyRepo.Update(ctx, userID, st.ID, false); err != nil {
+				return err
+			}
+			stopped = append(stopped, st)
+		}
+	}
+
+	// Копируем список: RemoveStrategy меняет срез менеджера
+	running := append([]types.Strategy(nil), s.strategyManager.GetStrategies(userID)...)
+	for _, instance := range running {
+		if matchAccount(instance.AccountID()) {
+			s.strategyManager.RemoveStrategy(userID, instance)
+		}
+	}
+
+	for i := range stopped {
+		s.notifyStrategyStopped(ctx, &stopped[i], reason)
+		s.publishLifecycle(ctx, &stopped[i], "stopped")
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/storages"
+	"CryptoLens_Shared/logger"
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"time"
+)
+
+// Попытки ввода второго фактора ограничиваются отдельно от входа:
+// шесть цифр перебираются быстро, если не ограничивать число попыток
+const (
+	secondFactorAttemptLimit  = 10
+	secondFactorAttemptWindow = 15 * time.Minute
+)
+
+var (
+	// ErrTwoFactorRequired возвращается, когда второй фактор включен, а код не передан
+	ErrTwoFactorRequired = errors.New("two-factor code required")
+	// ErrInvalidTwoFactorCode возвращается на неверный, просроченный или уже использованный код
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+)
+
+// GetTwoFactorStatus возвращает состояние второго фактора пользователя
+func (s *UserService) GetTwoFactorStatus(ctx context.Context, userID string) (*models.TwoFactorStatus, error) {
+	totp, err := s.totpRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	status := &models.TwoFactorStatus{}
+	if totp == nil || totp.ConfirmedAt == nil {
+		return status, nil
+	}
+	status.Enabled = true
+	status.EnabledAt = totp.ConfirmedAt
+	status.RecoveryCodesLeft, err = s.totpRepo.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// SetupTwoFactor создает секрет для приложения-аутентификатора. Второй фактор включается
+// только после подтверждения кодом, до этого вход работает как прежде.
+func (s *UserService) SetupTwoFactor(ctx context.Context, userID string) (*models.TwoFactorSetupResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.totpRepo.SaveSecret(ctx, userID, secret); err != nil {
+		return nil, err
+	}
+	return &models.TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: totpProvisioningURI(secret, user.Email),
+	}, nil
+}
+
+// EnableTwoFactor подтверждает настройку кодом из приложения и выдает коды восстановления
+func (s *UserService) EnableTwoFactor(ctx context.Context, userID, code string, meta models.SessionMeta) (*models.RecoveryCodesResponse, error) {
+	totp, err := s.totpRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if totp == nil {
+		return nil, models.Conflict("two-factor setup is not started")
+	}
+	if totp.ConfirmedAt != nil {
+		return nil, models.Conflict("two-factor authentication is already enabled")
+	}
+	if err := s.checkSecondFactorAttempts(ctx, userID); err != nil {
+		return nil, err
+	}
+	step, ok := matchTOTP(totp.Secret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	s.refundSecondFactorAttempt(ctx, userID)
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	confirmed, err := s.totpRepo.Confirm(ctx, userID, step, hashes)
+	if err != nil {
+		return nil, err
+	}
+	if !confirmed {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	s.audit(ctx, &userID, "", models.AuthEventTwoFactorEnabled, meta)
+	s.securityAlert(ctx, userID, "Двухфакторная аутентификация включена",
+		"Для входа и изменения ключей биржи теперь нужен код из приложения. Сохраните коды восстановления в надежном месте.",
+		meta)
+	return &models.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTwoFactor отключает второй фактор. Нужны и пароль, и код: одной украденной сессии недостаточно.
+func (s *UserService) DisableTwoFactor(ctx context.Context, userID string, req models.DisableTwoFactorRequest, meta models.SessionMeta) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return models.Invalid("invalid password")
+	}
+	totp, err := s.enabledTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkSecondFactor(ctx, totp, req.Code, meta); err != nil {
+		return err
+	}
+	if err := s.totpRepo.Delete(ctx, userID); err != nil {
+		return err
+	}
+
+	s.audit(ctx, &userID, normalizeEmail(user.Email), models.AuthEventTwoFactorDisabled, meta)
+	s.securityAlert(ctx, userID, "Двухфакторная аутентификация отключена",
+		"Для входа снова достаточно пароля. Если это были не вы, смените пароль и включите второй фактор заново.",
+		meta)
+	return nil
+}
+
+// RegenerateRecoveryCodes заменяет коды восстановления; прежние коды перестают действовать
+func (s *UserService) RegenerateRecoveryCodes(ctx context.Context, userID, code string, meta models.SessionMeta) (*models.RecoveryCodesResponse, error) {
+	totp, err := s.enabledTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkSecondFactor(ctx, totp, code, meta); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.totpRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return &models.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// VerifySecondFactor проверяет код перед входом и чувствительными операциями.
+// Пользователям без второго фактора код не нужен.
+func (s *UserService) VerifySecondFactor(ctx context.Context, userID, code string, meta models.SessionMeta) error {
+	totp, err := s.totpRepo.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if totp == nil || totp.ConfirmedAt == nil {
+		return nil
+	}
+	return s.checkSecondFactor(ctx, totp, code, meta)
+}
+
+// enabledTOTP возвращает включенный второй фактор пользователя
+func (s *UserService) enabledTOTP(ctx context.Context, userID string) (*models.UserTOTP, error) {
+	totp, err := s.totpRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if totp == nil || totp.ConfirmedAt == nil {
+		return nil, models.Conflict("two-factor authentication is not enabled")
+	}
+	return totp, nil
+}
+
+// checkSecondFactor принимает код из приложения или код восстановления. Каждый код действует один раз:
+// для TOTP запоминается последний принятый шаг времени, код восстановления погашается.
+func (s *UserService) checkSecondFactor(ctx context.Context, totp *models.UserTOTP, code string, meta models.SessionMeta) error {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ErrTwoFactorRequired
+	}
+	if err := s.checkSecondFactorAttempts(ctx, totp.UserID); err != nil {
+		return err
+	}
+
+	if isTOTPCode(code) {
+		if step, ok := matchTOTP(totp.Secret, code, time.Now()); ok {
+			used, err := s.totpRepo.UseStep(ctx, totp.UserID, step)
+			if err != nil {
+				return err
+			}
+			if used {
+				s.refundSecondFactorAttempt(ctx, totp.UserID)
+				return nil
+			}
+		}
+	} else {
+		used, err := s.totpRepo.UseRecoveryCode(ctx, totp.UserID, hashRecoveryCode(code))
+		if err != nil {
+			return err
+		}
+		if used {
+			s.refundSecondFactorAttempt(ctx, totp.UserID)
+			s.recoveryCodeUsed(ctx, totp.UserID, meta)
+			return nil
+		}
+	}
+
+	s.audit(ctx, &totp.UserID, "", models.AuthEventTwoFactorFailed, meta)
+	return ErrInvalidTwoFactorCode
+}
+
+// checkSecondFactorAttempts ограничивает число неверных кодов для пользователя. Попытка учитывается
+// до проверки, чтобы параллельные запросы не обошли лимит, а принятый код возвращает ее
+// через refundSecondFactorAttempt: обычный вход с кодом лимит не расходует.
+func (s *UserService) checkSecondFactorAttempts(ctx context.Context, userID string) error {
+	count, retryAfter, err := storages.IncrementRateLimit(ctx, "two_factor:"+userID, secondFactorAttemptWindow)
+	if err != nil {
+		logger.ErrorCtx(ctx, "Ошибка проверки лимита попыток второго фактора: %v", err)
+		return ErrAuthUnavailable
+	}
+	if count > secondFactorAttemptLimit {
+		return &RateLimitError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// refundSecondFactorAttempt возвращает попытку, учтенную checkSecondFactorAttempts, после принятого кода
+func (s *UserService) refundSecondFactorAttempt(ctx context.Context, userID string) {
+	if err := storages.RefundRateLimit(ctx, "two_factor:"+userID); err != nil {
+		logger.ErrorCtx(ctx, "Ошибка возврата попытки второго фактора: %v", err)
+	}
+}
+
+// recoveryCodeUsed сообщает владельцу о входе по коду восстановления: обычно это значит,
+// что телефон потерян, и стоит настроить второй фактор заново
+func (s *UserService) recoveryCodeUsed(ctx context.Context, userID string, meta models.SessionMeta) {
+	s.audit(ctx, &userID, "", models.AuthEventRecoveryCodeUsed, meta)
+	left, err := s.totpRepo.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		logger.ErrorCtx(ctx, "Ошибка подсчета кодов восстановления: %v", err)
+		return
+	}
+	s.securityAlert(ctx, userID, "Использован код восстановления",
+		fmt.Sprintf("Код восстановления принят вместо кода из приложения, осталось кодов: %d. Если это были не вы, смените пароль.", left),
+		meta)
+}
+
+// newRecoveryCodes создает коды восстановления и их хеши для хранения
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = hashRecoveryCode(code)
+	}
+	return codes, hashes, nil
+}
@@ -0,0 +1,348 @@
+package services
+
+import (
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/netguard"
+	"CryptoLens_Backend/types"
+	"CryptoLens_Shared/logger"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	webhookBatchSize      = 50               // доставок за один проход очереди
+	webhookWorkers        = 10               // параллельных отправок
+	webhookLease          = 2 * time.Minute  // на сколько откладывается взятая в работу доставка
+	webhookPollInterval   = 5 * time.Second  // период опроса очереди
+	webhookMaxAttempts    = 10               // после этого доставка считается проваленной
+	webhookBaseBackoff    = 30 * time.Second // задержка перед первым повтором
+	webhookMaxBackoff     = 6 * time.Hour    // максимальная задержка между повторами
+	webhookMaxErrorLength = 500              // длина сохраняемого текста ошибки
+)
+
+// WebhookService публикует события в исходящие вебхуки пользователей.
+// События сохраняются в таблицу webhook_deliveries и отправляются фоновым обработчиком
+// с повторами по экспоненциальной задержке, поэтому переживают перезапуск сервиса.
+type WebhookService struct {
+	webhookRepo types.WebhookRepositoryInterface
+	httpClient  *http.Client
+	wake        chan struct{}
+}
+
+// NewWebhookService создает новый сервис исходящих вебхуков
+func NewWebhookService(webhookRepo types.WebhookRepositoryInterface, timeout time.Duration) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		httpClient:  newWebhookClient(timeout),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// newWebhookClient создает клиент, который подключается только к публичным адресам
+func newWebhookClient(timeout time.Duration) *http.Client {
+	client := netguard.NewClient(timeout)
+	// Редиректы не выполняем: подпись относится к исходному адресу
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return client
+}
+
+// Publish ставит событие в очередь доставки для всех подписанных эндпоинтов пользователя
+func (s *WebhookService) Publish(ctx context.Context, userID string, eventType string, data interface{}) {
+	endpoints, err := s.webhookRepo.GetSubscribedEndpoints(ctx, userID, eventType)
+	if err != nil {
+		logger.LogError("Ошибка получения вебхуков для userID %s: %v", userID, err)
+		return
+	}
+	if len(endpoints) == 0 {
+		return
+	}
+
+	payload, err := newWebhookPayload(userID, eventType, data)
+	if err != nil {
+		logger.LogError("Ошибка формирования события вебхука %s: %v", eventType, err)
+		return
+	}
+
+	for _, endpoint := range endpoints {
+		if err := s.webhookRepo.EnqueueDelivery(ctx, endpoint.ID, userID, eventType, payload); err != nil {
+			logger.LogError("Ошибка постановки вебхука в очередь для эндпоинта %s: %v", endpoint.ID, err)
+		}
+	}
+	s.notifyWorker()
+}
+
+// Start запускает фоновую доставку из очереди
+func (s *WebhookService) Start(ctx context.Context) {
+	go s.processQueue(ctx)
+}
+
+// notifyWorker будит обработчик очереди без ожидания следующего опроса
+func (s *WebhookService) notifyWorker() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// processQueue периодически забирает и отправляет доставки, время которых наступило
+func (s *WebhookService) processQueue(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+
+		for {
+			deliveries, err := s.webhookRepo.ClaimDueDeliveries(ctx, webhookBatchSize, webhookLease)
+			if err != nil {
+				logger.LogError("Ошибка получения очереди вебхуков: %v", err)
+				break
+			}
+			s.deliverBatch(ctx, deliveries)
+			if len(deliveries) < webhookBatchSize {
+				break
+			}
+		}
+	}
+}
+
+// deliverBatch отправляет пачку доставок с ограничением параллелизма
+func (s *WebhookService) deliverBatch(ctx context.Context, deliveries []models.WebhookDelivery) {
+	sem := make(chan struct{}, webhookWorkers)
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(d models.WebhookDelivery) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.deliver(ctx, d)
+		}(delivery)
+	}
+	wg.Wait()
+}
+
+// deliver выполняет одну попытку доставки и фиксирует результат
+func (s *WebhookService) deliver(ctx context.Context, delivery models.WebhookDelivery) {
+	statusCode, err := s.send(ctx, delivery)
+	if err == nil {
+		if err := s.webhookRepo.MarkDelivered(ctx, delivery.ID, statusCode); err != nil {
+			logger.LogError("Ошибка обновления доставки вебхука %s: %v", delivery.ID, err)
+		}
+		return
+	}
+
+	var code *int
+	if statusCode != 0 {
+		code = &statusCode
+	}
+	errMsg := err.Error()
+	if len(errMsg) > webhookMaxErrorLength {
+		errMsg = errMsg[:webhookMaxErrorLength]
+	}
+
+	attempt := delivery.Attempts + 1
+	var nextAttemptAt *time.Time
+	if attempt < webhookMaxAttempts {
+		next := time.Now().Add(webhookBackoff(attempt))
+		nextAttemptAt = &next
+		logger.LogWarn("Доставка вебхука %s не удалась (попытка %d), повтор в %s: %v",
+			delivery.ID, attempt, next.Format(time.RFC3339), err)
+	} else {
+		logger.LogError("Доставка вебхука %s провалена после %d попыток: %v", delivery.ID, attempt, err)
+	}
+
+	if err := s.webhookRepo.MarkAttemptFailed(ctx, delivery.ID, code, errMsg, nextAttemptAt); err != nil {
+		logger.LogError("Ошибка обновления доставки вебхука %s: %v", delivery.ID, err)
+	}
+}
+
+// send отправляет подписанный запрос и возвращает код ответа
+func (s *WebhookService) send(ctx context.Context, delivery models.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CryptoLens-Webhooks/1.0")
+	req.Header.Set("X-CryptoLens-Event", delivery.EventType)
+	req.Header.Set("X-CryptoLens-Delivery", delivery.ID)
+	req.Header.Set("X-CryptoLens-Signature", SignWebhookPayload(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload формирует значение заголовка X-CryptoLens-Signature.
+// Подписывается строка "<timestamp>.<body>", что позволяет получателю отклонять старые запросы.
+func SignWebhookPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff возвращает задержку перед повтором: 30s, 1m, 2m, ... но не более 6h
+func webhookBackoff(attempt int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if backoff >= webhookMaxBackoff {
+			return webhookMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// CreateEndpoint регистрирует эндпоинт. Секрет возвращается только в ответе на создание.
+func (s *WebhookService) CreateEndpoint(ctx context.Context, userID string, req models.CreateWebhookEndpointRequest) (*models.CreateWebhookEndpointResponse, error) {
+	if err := netguard.ValidateURL(ctx, req.URL); err != nil {
+		return nil, models.Invalid("%v", err)
+	}
+	for _, eventType := range req.EventTypes {
+		if !isKnownWebhookEvent(eventType) {
+			return nil, models.Invalid("unknown event type: %s", eventType)
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		secret, err = randomHex(32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate secret: %w", err)
+		}
+		secret = "whsec_" + secret
+	} else if len(secret) < 16 || len(secret) > 128 {
+		return nil, models.Invalid("secret must be 16-128 characters long")
+	}
+
+	endpoint, err := s.webhookRepo.CreateEndpoint(ctx, userID, req.URL, secret, req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CreateWebhookEndpointResponse{Endpoint: endpoint, Secret: secret}, nil
+}
+
+// GetEndpoints возвращает эндпоинты пользователя
+func (s *WebhookService) GetEndpoints(ctx context.Context, userID string) ([]models.WebhookEndpoint, error) {
+	endpoints, err := s.webhookRepo.GetEndpointsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if endpoints == nil {
+		endpoints = []models.WebhookEndpoint{}
+	}
+	return endpoints, nil
+}
+
+// RemoveEndpoint удаляет эндпоинт пользователя
+func (s *WebhookService) RemoveEndpoint(ctx context.Context, userID string, id string) error {
+	return s.webhookRepo.DeleteEndpoint(ctx, userID, id)
+}
+
+// SendTest ставит в очередь тестовое событие для эндпоинта
+func (s *WebhookService) SendTest(ctx context.Context, userID string, id string) error {
+	endpoint, err := s.webhookRepo.GetEndpointByID(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	payload, err := newWebhookPayload(userID, models.WebhookEventTest, map[string]string{
+		"message": "CryptoLens webhook endpoint is configured",
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.webhookRepo.EnqueueDelivery(ctx, endpoint.ID, userID, models.WebhookEventTest, payload); err != nil {
+		return err
+	}
+	s.notifyWorker()
+	return nil
+}
+
+// GetDeliveries возвращает журнал доставки
+func (s *WebhookService) GetDeliveries(ctx context.Context, userID string, endpointID string, limit int) ([]models.WebhookDelivery, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	deliveries, err := s.webhookRepo.GetDeliveries(ctx, userID, endpointID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+	return deliveries, nil
+}
+
+// RetryDelivery возвращает проваленную доставку в очередь
+func (s *WebhookService) RetryDelivery(ctx context.Context, userID string, id string) error {
+	if err := s.webhookRepo.RetryDelivery(ctx, userID, id); err != nil {
+		return err
+	}
+	s.notifyWorker()
+	return nil
+}
+
+// newWebhookPayload сериализует событие в тело запроса
+func newWebhookPayload(userID, eventType string, data interface{}) ([]byte, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(models.WebhookPayload{
+		ID:        "evt_" + id,
+		Type:      eventType,
+		UserID:    userID,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+}
+
+func isKnownWebhookEvent(eventType string) bool {
+	for _, t := range models.WebhookEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// randomHex возвращает n случайных байт в шестнадцатеричном виде
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -3,6 +3,7 @@ package storages
 import (
 	"CryptoLens_Backend/integration/bybit"
 	"CryptoLens_Backend/integration/redis"
+	"CryptoLens_Backend/models"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -114,9 +115,9 @@ func GetPublicTrades(ctx context.Context, symbol string, limit int64) ([]bybit.T
 
 // Приватные методы для работы с Redis
 
-// SavePrivateOrder сохраняет данные приватного ордера
-func SavePrivateOrder(ctx context.Context, userID string, orderID string, order bybit.OrderMessage) error {
-	key := fmt.Sprintf("private:%s:order:%s", userID, orderID)
+// SavePrivateOrder сохраняет данные приватного ордера аккаунта
+func SavePrivateOrder(ctx context.Context, accountID int64, orderID string, order bybit.OrderMessage) error {
+	key := fmt.Sprintf("private:account:%d:order:%s", accountID, orderID)
 	data, err := json.Marshal(order)
 	if err != nil {
 		return fmt.Errorf("failed to marshal order: %w", err)
@@ -125,9 +126,9 @@ func SavePrivateOrder(ctx context.Context, userID string, orderID string, order
 	return redis.Client.Set(ctx, key, data, 24*time.Hour).Err()
 }
 
-// SavePrivateExecution сохраняет данные приватного исполнения
-func SavePrivateExecution(ctx context.Context, userID string, execID string, execution bybit.ExecutionMessage) error {
-	key := fmt.Sprintf("private:%s:execution:%s", userID, execID)
+// SavePrivateExecution сохраняет данные приватного исполнения аккаунта
+func SavePrivateExecution(ctx context.Context, accountID int64, execID string, execution bybit.ExecutionMessage) error {
+	key := fmt.Sprintf("private:account:%d:execution:%s", accountID, execID)
 	data, err := json.Marshal(execution)
 	if err != nil {
 		return fmt.Errorf("failed to marshal execution: %w", err)
@@ -136,9 +137,9 @@ func SavePrivateExecution(ctx context.Context, userID string, execID string, exe
 	return redis.Client.Set(ctx, key, data, 24*time.Hour).Err()
 }
 
-// SavePrivateWallet сохраняет данные приватного кошелька
-func SavePrivateWallet(ctx context.Context, userID string, wallet bybit.WalletMessage) error {
-	key := fmt.Sprintf("private:%s:wallet", userID)
+// SavePrivateWallet сохраняет данные приватного кошелька аккаунта
+func SavePrivateWallet(ctx context.Context, accountID int64, wallet bybit.WalletMessage) error {
+	key := fmt.Sprintf("private:account:%d:wallet", accountID)
 	data, err := json.Marshal(wallet)
 	if err != nil {
 		return fmt.Errorf("failed to marshal wallet: %w", err)
@@ -147,9 +148,9 @@ func SavePrivateWallet(ctx context.Context, userID string, wallet bybit.WalletMe
 	return redis.Client.Set(ctx, key, data, 1*time.Hour).Err()
 }
 
-// GetPrivateOrder получает данные приватного ордера
-func GetPrivateOrder(ctx context.Context, userID string, orderID string) (*bybit.OrderMessage, error) {
-	key := fmt.Sprintf("private:%s:order:%s", userID, orderID)
+// GetPrivateOrder получает данные приватного ордера аккаунта
+func GetPrivateOrder(ctx context.Context, accountID int64, orderID string) (*bybit.OrderMessage, error) {
+	key := fmt.Sprintf("private:account:%d:order:%s", accountID, orderID)
 	data, err := redis.Client.Get(ctx, key).Bytes()
 		if err != nil {
 		return nil, fmt.Errorf("failed to get order: %w", err)
@@ -163,9 +164,9 @@ func GetPrivateOrder(ctx context.Context, userID string, orderID string) (*bybit
 	return &order, nil
 }
 
-// GetPrivateExecution получает данные приватного исполнения
-func GetPrivateExecution(ctx context.Context, userID string, execID string) (*bybit.ExecutionMessage, error) {
-	key := fmt.Sprintf("private:%s:execution:%s", userID, execID)
+// GetPrivateExecution получает данные приватного исполнения аккаунта
+func GetPrivateExecution(ctx context.Context, accountID int64, execID string) (*bybit.ExecutionMessage, error) {
+	key := fmt.Sprintf("private:account:%d:execution:%s", accountID, execID)
 	data, err := redis.Client.Get(ctx, key).Bytes()
 			if err != nil {
 		return nil, fmt.Errorf("failed to get execution: %w", err)
@@ -179,9 +180,9 @@ func GetPrivateExecution(ctx context.Context, userID string, execID string) (*by
 	return &execution, nil
 			}
 
-// GetPrivateWallet получает данные приватного кошелька
-func GetPrivateWallet(ctx context.Context, userID string) (*bybit.WalletMessage, error) {
-	key := fmt.Sprintf("private:%s:wallet", userID)
+// GetPrivateWallet получает данные приватного кошелька аккаунта
+func GetPrivateWallet(ctx context.Context, accountID int64) (*bybit.WalletMessage, error) {
+	key := fmt.Sprintf("private:account:%d:wallet", accountID)
 	data, err := redis.Client.Get(ctx, key).Bytes()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get wallet: %w", err)
@@ -283,6 +284,130 @@ func GetOrderBookHistory(ctx context.Context, symbol string, limit int64) ([]byb
 	return orderBooks, nil
 }
 
+// SaveTelegramLinkCode сохраняет одноразовый код привязки чата Telegram
+func SaveTelegramLinkCode(ctx context.Context, code string, link models.TelegramLinkCode, ttl time.Duration) error {
+	key := fmt.Sprintf("telegram:link:%s", code)
+	data, err := json.Marshal(link)
+	if err != nil {
+		return fmt.Errorf("failed to marshal telegram link code: %w", err)
+	}
+	return redis.Client.Set(ctx, key, data, ttl).Err()
+}
+
+// ConsumeTelegramLinkCode получает и сразу удаляет код привязки, чтобы его нельзя было использовать повторно
+func ConsumeTelegramLinkCode(ctx context.Context, code string) (*models.TelegramLinkCode, error) {
+	key := fmt.Sprintf("telegram:link:%s", code)
+	data, err := redis.Client.GetDel(ctx, key).Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get telegram link code: %w", err)
+	}
+
+	var link models.TelegramLinkCode
+	if err := json.Unmarshal(data, &link); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal telegram link code: %w", err)
+	}
+
+	return &link, nil
+}
+
+// RevokeSession заносит сессию в список отозванных. Запись живет, пока не истекут
+// выданные для сессии access-токены.
+func RevokeSession(ctx context.Context, sessionID string, ttl time.Duration) error {
+	key := fmt.Sprintf("auth:revoked_session:%s", sessionID)
+	return redis.Client.Set(ctx, key, 1, ttl).Err()
+}
+
+// IsSessionRevoked проверяет, отозвана ли сессия
+func IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	key := fmt.Sprintf("auth:revoked_session:%s", sessionID)
+	exists, err := redis.Client.Exists(ctx, key).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check revoked session: %w", err)
+	}
+	return exists > 0, nil
+}
+
+// IncrementRateLimit увеличивает счетчик попыток в фиксированном окне и возвращает его значение
+// и время до начала следующего окна
+func IncrementRateLimit(ctx context.Context, name string, window time.Duration) (int64, time.Duration, error) {
+	key := fmt.Sprintf("auth:ratelimit:%s", name)
+	count, err := redis.Client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to increment rate limit: %w", err)
+	}
+	ttl, err := redis.Client.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get rate limit ttl: %w", err)
+	}
+	// Новый счетчик или счетчик, потерявший срок после сбоя между INCR и EXPIRE
+	if ttl < 0 {
+		if err := redis.Client.Expire(ctx, key, window).Err(); err != nil {
+			return 0, 0, fmt.Errorf("failed to set rate limit window: %w", err)
+		}
+		ttl = window
+	}
+	return count, ttl, nil
+}
+
+// refundRateLimitScript уменьшает счетчик, только если окно еще не истекло: иначе DECR создал бы
+// бессрочный ключ с отрицательным значением
+const refundRateLimitScript = `if redis.call("EXISTS", KEYS[1]) == 1 then return redis.call("DECR", KEYS[1]) end return 0`
+
+// RefundRateLimit возвращает попытку, учтенную IncrementRateLimit, когда она не должна расходовать лимит
+func RefundRateLimit(ctx context.Context, name string) error {
+	key := fmt.Sprintf("auth:ratelimit:%s", name)
+	if err := redis.Client.Eval(ctx, refundRateLimitScript, []string{key}).Err(); err != nil {
+		return fmt.Errorf("failed to refund rate limit: %w", err)
+	}
+	return nil
+}
+
+// RecordLoginFailure увеличивает число неудачных входов подряд для аккаунта.
+// Счетчик сбрасывается успешным входом или через ttl после последней неудачи.
+func RecordLoginFailure(ctx context.Context, email string, ttl time.Duration) (int64, error) {
+	key := fmt.Sprintf("auth:login_failures:%s", email)
+	count, err := redis.Client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to record login failure: %w", err)
+	}
+	if err := redis.Client.Expire(ctx, key, ttl).Err(); err != nil {
+		return 0, fmt.Errorf("failed to set login failures ttl: %w", err)
+	}
+	return count, nil
+}
+
+// ResetLoginFailures сбрасывает счетчик неудачных входов аккаунта
+func ResetLoginFailures(ctx context.Context, email string) error {
+	return redis.Client.Del(ctx, fmt.Sprintf("auth:login_failures:%s", email)).Err()
+}
+
+// UnlockLogin снимает блокировку входа, например после сброса пароля владельцем
+func UnlockLogin(ctx context.Context, email string) error {
+	return redis.Client.Del(ctx, fmt.Sprintf("auth:login_lock:%s", email)).Err()
+}
+
+// LockLogin запрещает вход в аккаунт на заданное время
+func LockLogin(ctx context.Context, email string, duration time.Duration) error {
+	return redis.Client.Set(ctx, fmt.Sprintf("auth:login_lock:%s", email), 1, duration).Err()
+}
+
+// GetLoginLock возвращает оставшееся время блокировки входа, 0 — вход разрешен
+func GetLoginLock(ctx context.Context, email string) (time.Duration, error) {
+	ttl, err := redis.Client.PTTL(ctx, fmt.Sprintf("auth:login_lock:%s", email)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check login lock: %w", err)
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// Ping проверяет доступность Redis
+func Ping(ctx context.Context) error {
+	return redis.Client.Ping(ctx).Err()
+}
+
 // Close закрывает соединение с Redis
 func Close() error {
 	return redis.Client.Close()
@@ -0,0 +1,246 @@
+package telegrambot
+
+import (
+	"CryptoLens_Backend/integration/telegram"
+	"CryptoLens_Backend/models"
+	"CryptoLens_Backend/types"
+	"CryptoLens_Shared/logger"
+	"context"
+	"errors"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// commandTimeout ограничивает время выполнения одной команды
+const commandTimeout = 30 * time.Second
+
+// internalErrorText ответ на ошибку, текст которой нельзя показывать в чате
+const internalErrorText = "Внутренняя ошибка, попробуйте позже"
+
+// commandRequest содержит данные входящей команды
+type commandRequest struct {
+	// appCtx живет все время работы бота. Используется для запуска стратегий,
+	// которые не должны останавливаться по завершении команды.
+	appCtx   context.Context
+	chatID   int64
+	username string
+	chat     *models.TelegramChat // nil, если чат не привязан
+	args     []string
+}
+
+// command описывает команду бота
+type command struct {
+	name        string
+	usage       string
+	description string
+	scope       string // требуемые права; пустая строка — команда доступна без привязки
+	handler     func(ctx context.Context, req commandRequest) (string, error)
+}
+
+// Bot обрабатывает команды управления стратегиями из Telegram
+type Bot struct {
+	client                telegram.Client
+	telegramService       types.TelegramServiceInterface
+	userStrategyService   types.UserStrategyServiceInterface
+	userInstrumentService types.UserInstrumentServiceInterface
+	strategyManager       types.StrategyManagerInterface
+	tradeLogRepo          types.TradeLogRepositoryInterface
+	pollTimeout           int
+	commands              []command
+}
+
+// NewBot создает новый бот
+func NewBot(
+	client telegram.Client,
+	telegramService types.TelegramServiceInterface,
+	userStrategyService types.UserStrategyServiceInterface,
+	userInstrumentService types.UserInstrumentServiceInterface,
+	strategyManager types.StrategyManagerInterface,
+	tradeLogRepo types.TradeLogRepositoryInterface,
+	pollTimeout int,
+) *Bot {
+	b := &Bot{
+		client:                client,
+		telegramService:       telegramService,
+		userStrategyService:   userStrategyService,
+		userInstrumentService: userInstrumentService,
+		strategyManager:       strategyManager,
+		tradeLogRepo:          tradeLogRepo,
+		pollTimeout:           pollTimeout,
+	}
+	b.commands = b.registerCommands()
+	return b
+}
+
+// Start запускает получение обновлений методом long polling
+func (b *Bot) Start(ctx context.Context) {
+	go b.poll(ctx)
+}
+
+// poll получает обновления и обрабатывает их по очереди
+func (b *Bot) poll(ctx context.Context) {
+	logger.LogInfo("Telegram-бот запущен")
+	var offset int64
+	for {
+		select {
+		case <-ctx.Done():
+			logger.LogInfo("Telegram-бот остановлен")
+			return
+		default:
+		}
+
+		updates, err := b.client.GetUpdates(ctx, offset, b.pollTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			logger.LogError("Ошибка получения обновлений Telegram: %v", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+			if update.Message == nil || update.Message.Text == "" {
+				continue
+			}
+			b.handleMessage(ctx, update.Message)
+		}
+	}
+}
+
+// handleMessage разбирает команду, проверяет права и отправляет ответ
+func (b *Bot) handleMessage(appCtx context.Context, msg *telegram.Message) {
+	ctx, cancel := context.WithTimeout(appCtx, commandTimeout)
+	defer cancel()
+
+	// Команды управления счетом принимаются только в личной переписке
+	if msg.Chat.Type != "private" {
+		b.reply(ctx, msg.Chat.ID, "Бот работает только в личных сообщениях")
+		return
+	}
+
+	name, args := parseCommand(msg.Text)
+	if name == "" {
+		b.reply(ctx, msg.Chat.ID, "Отправьте /help, чтобы увидеть список команд")
+		return
+	}
+
+	cmd, ok := b.findCommand(name)
+	if !ok {
+		b.reply(ctx, msg.Chat.ID, "Неизвестная команда. Отправьте /help, чтобы увидеть список команд")
+		return
+	}
+
+	req := commandRequest{
+		appCtx: appCtx,
+		chatID: msg.Chat.ID,
+		args:   args,
+	}
+	if msg.From != nil {
+		req.username = msg.From.Username
+	}
+
+	if cmd.scope != "" {
+		chat, err := b.telegramService.GetChat(ctx, msg.Chat.ID)
+		if err != nil {
+			logger.LogError("Ошибка получения привязки чата %d: %v", msg.Chat.ID, err)
+			b.reply(ctx, msg.Chat.ID, internalErrorText)
+			return
+		}
+		if chat == nil {
+			b.reply(ctx, msg.Chat.ID, "Чат не привязан к аккаунту CryptoLens. Получите код привязки в API и отправьте <code>/link КОД</code>")
+			return
+		}
+		if !hasScope(chat.Scope, cmd.scope) {
+			logger.LogWarn("Отказано в команде /%s для чата %d (scope=%s)", cmd.name, msg.Chat.ID, chat.Scope)
+			b.reply(ctx, msg.Chat.ID, "Недостаточно прав: команда доступна только чатам с правом <b>"+cmd.scope+"</b>")
+			return
+		}
+		req.chat = chat
+	}
+
+	text, err := cmd.handler(ctx, req)
+	if err != nil {
+		text = errorText(req, "выполнения команды /"+cmd.name, err)
+		if text != internalErrorText {
+			text = "Ошибка: " + text
+		}
+	}
+	b.reply(ctx, msg.Chat.ID, text)
+}
+
+// errorText возвращает текст ошибки для чата. Сообщения models.Error показываются как есть,
+// остальные ошибки только пишутся в лог: в них бывают SQL, адреса и ответы Bybit.
+func errorText(req commandRequest, action string, err error) string {
+	var clientErr *models.Error
+	if errors.As(err, &clientErr) {
+		return html.EscapeString(clientErr.Message)
+	}
+	logger.LogError("Ошибка %s для чата %d (user_id=%s): %v", action, req.chatID, req.userID(), err)
+	return internalErrorText
+}
+
+// unavailableText строка отчета о данных, которые не удалось получить
+func unavailableText(req commandRequest, action string, err error) string {
+	text := errorText(req, action, err)
+	if text == internalErrorText {
+		return "недоступно\n"
+	}
+	return "недоступно: " + text + "\n"
+}
+
+// userID возвращает ID пользователя привязанного чата или пустую строку
+func (r commandRequest) userID() string {
+	if r.chat == nil {
+		return ""
+	}
+	return r.chat.UserID
+}
+
+// reply отправляет ответ в чат
+func (b *Bot) reply(ctx context.Context, chatID int64, text string) {
+	if err := b.client.SendMessage(ctx, strconv.FormatInt(chatID, 10), text); err != nil {
+		logger.LogError("Ошибка отправки ответа в чат %d: %v", chatID, err)
+	}
+}
+
+// findCommand ищет команду по имени
+func (b *Bot) findCommand(name string) (command, bool) {
+	for _, cmd := range b.commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// parseCommand выделяет имя команды и аргументы. Суффикс @botname отбрасывается.
+func parseCommand(text string) (string, []string) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return "", nil
+	}
+	name := strings.TrimPrefix(fields[0], "/")
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	return strings.ToLower(name), fields[1:]
+}
+
+// hasScope проверяет, покрывают ли права чата требуемые права команды
+func hasScope(granted, required string) bool {
+	switch required {
+	case models.TelegramScopeRead:
+		return granted == models.TelegramScopeRead || granted == models.TelegramScopeTrade
+	case models.TelegramScopeTrade:
+		return granted == models.TelegramScopeTrade
+	default:
+		return false
+	}
+}
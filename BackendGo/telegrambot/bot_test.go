@@ -0,0 +1,30 @@
+package telegrambot
+
+import (
+	"CryptoLens_Backend/models"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestErrorText(t *testing.T) {
+	req := commandRequest{chatID: 42, chat: &models.TelegramChat{UserID: "user-1"}}
+
+	clientErr := fmt.Errorf("link: %w", models.Invalid("код <b>истек</b>"))
+	if got := errorText(req, "привязки", clientErr); got != "код &lt;b&gt;истек&lt;/b&gt;" {
+		t.Errorf("errorText(client error) = %q", got)
+	}
+
+	internal := fmt.Errorf("ошибка при получении стратегий: %w", errors.New(`pq: relation "user_strategies" does not exist`))
+	got := errorText(req, "получения стратегий", internal)
+	if got != internalErrorText {
+		t.Errorf("errorText(internal error) = %q, want the generic text", got)
+	}
+	if strings.Contains(unavailableText(req, "получения стратегий", internal), "pq:") {
+		t.Error("unavailableText leaked the internal error")
+	}
+	if got := unavailableText(commandRequest{chatID: 42}, "получения балансов", models.NotFound("аккаунт не найден")); got != "недоступно: аккаунт не найден\n" {
+		t.Errorf("unavailableText(client error) = %q", got)
+	}
+}
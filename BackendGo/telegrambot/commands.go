@@ -0,0 +1,414 @@
+package telegrambot
+
+import (
+	"CryptoLens_Backend/models"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// knownStrategies стратегии, которые можно запустить из бота
+var knownStrategies = []string{"test", "spread_scalping"}
+
+// registerCommands описывает команды бота и требуемые для них права
+func (b *Bot) registerCommands() []command {
+	return []command{
+		{name: "start", description: "приветствие и список команд", handler: b.handleHelp},
+		{name: "help", description: "список команд", handler: b.handleHelp},
+		{name: "link", usage: "КОД", description: "привязать чат к аккаунту CryptoLens", handler: b.handleLink},
+		{name: "status", description: "стратегии, открытые ордера и балансы", scope: models.TelegramScopeRead, handler: b.handleStatus},
+		{name: "pnl", usage: "[24h|7d] [ACCOUNT_ID]", description: "результат торговли за период", scope: models.TelegramScopeRead, handler: b.handlePnL},
+		{name: "instruments", description: "выбранные инструменты", scope: models.TelegramScopeRead, handler: b.handleInstruments},
+		{name: "start_strategy", usage: "ИМЯ [ACCOUNT_ID]", description: "запустить стратегию", scope: models.TelegramScopeTrade, handler: b.handleStartStrategy},
+		{name: "stop_strategy", usage: "ИМЯ [ACCOUNT_ID]", description: "остановить стратегию", scope: models.TelegramScopeTrade, handler: b.handleStopStrategy},
+		{name: "cancel_all", description: "отменить все открытые ордера", scope: models.TelegramScopeTrade, handler: b.handleCancelAll},
+	}
+}
+
+// handleHelp выводит список команд
+func (b *Bot) handleHelp(ctx context.Context, req commandRequest) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("<b>CryptoLens</b>\n\n")
+	for _, cmd := range b.commands {
+		if cmd.name == "start" {
+			continue
+		}
+		sb.WriteString("/" + cmd.name)
+		if cmd.usage != "" {
+			sb.WriteString(" " + html.EscapeString(cmd.usage))
+		}
+		sb.WriteString(" — " + cmd.description)
+		if cmd.scope == models.TelegramScopeTrade {
+			sb.WriteString(" <i>(trade)</i>")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+// handleLink привязывает чат по одноразовому коду
+func (b *Bot) handleLink(ctx context.Context, req commandRequest) (string, error) {
+	if len(req.args) != 1 {
+		return "Использование: <code>/link КОД</code>", nil
+	}
+	chat, err := b.telegramService.LinkChat(ctx, req.args[0], req.chatID, req.username)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Чат привязан к аккаунту CryptoLens. Права: <b>%s</b>", html.EscapeString(chat.Scope)), nil
+}
+
+// handleStatus выводит стратегии, открытые ордера и балансы
+func (b *Bot) handleStatus(ctx context.Context, req commandRequest) (string, error) {
+	userID := req.chat.UserID
+	var sb strings.Builder
+
+	sb.WriteString("<b>Стратегии</b>\n")
+	strategies, err := b.userStrategyService.GetUserStrategies(ctx, userID)
+	switch {
+	case err != nil:
+		sb.WriteString(unavailableText(req, "получения стратегий", err))
+	case len(strategies) == 0:
+		sb.WriteString("нет\n")
+	default:
+		for _, st := range strategies {
+			state := "остановлена"
+			if st.IsActive {
+				state = "активна"
+			}
+			sb.WriteString(fmt.Sprintf("• %s — %s%s\n", html.EscapeString(st.StrategyName), state, formatStrategyAccount(st)))
+		}
+	}
+	sb.WriteString(fmt.Sprintf("Запущено в менеджере: %d\n", len(b.strategyManager.GetStrategies(userID))))
+
+	sb.WriteString("\n<b>Открытые ордера</b>\n")
+	orders, err := b.strategyManager.GetOpenOrders(ctx, userID)
+	switch {
+	case err != nil:
+		sb.WriteString(unavailableText(req, "получения открытых ордеров", err))
+	case len(orders) == 0:
+		sb.WriteString("нет\n")
+	default:
+		for _, order := range orders {
+			sb.WriteString(fmt.Sprintf("• %s %s %s @ %s [%s]\n",
+				html.EscapeString(order.Symbol), order.Side, order.LeavesQty, order.Price,
+				html.EscapeString(accountName(order.AccountID, order.Label))))
+		}
+	}
+
+	sb.WriteString("\n<b>Балансы</b>\n")
+	balances, err := b.strategyManager.GetWalletBalances(ctx, userID)
+	if err != nil {
+		sb.WriteString(unavailableText(req, "получения балансов", err))
+		return sb.String(), nil
+	}
+	// Ошибки выводим всегда, разбивку по аккаунтам — только если аккаунтов несколько
+	for _, account := range balances.Accounts {
+		if account.Error != "" {
+			sb.WriteString(fmt.Sprintf("• [%s] %s", html.EscapeString(accountName(account.AccountID, account.Label)),
+				unavailableText(req, fmt.Sprintf("получения баланса аккаунта %d", account.AccountID), errors.New(account.Error))))
+		}
+	}
+	if len(balances.Accounts) > 1 {
+		for _, account := range balances.Accounts {
+			if account.Balance == nil {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("<i>%s</i>\n", html.EscapeString(accountName(account.AccountID, account.Label))))
+			printed := 0
+			for _, list := range account.Balance.List {
+				for _, coin := range list.Coins {
+					walletBalance, err := decimal.NewFromString(coin.WalletBalance)
+					if err != nil || walletBalance.IsZero() {
+						continue
+					}
+					sb.WriteString(fmt.Sprintf("  %s: %s (в ордерах %s)\n",
+						html.EscapeString(coin.Coin), walletBalance.String(), coin.Locked))
+					printed++
+				}
+			}
+			if printed == 0 {
+				sb.WriteString("  нет\n")
+			}
+		}
+		sb.WriteString("<i>Итого</i>\n")
+	}
+	printed := 0
+	for _, coin := range balances.Totals {
+		if coin.WalletBalance.IsZero() {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("• %s: %s (в ордерах %s)\n",
+			html.EscapeString(coin.Coin), coin.WalletBalance.String(), coin.Locked.String()))
+		printed++
+	}
+	if printed == 0 {
+		sb.WriteString("нет\n")
+	}
+
+	return sb.String(), nil
+}
+
+// handlePnL выводит результат торговли по инструментам за период — по всем аккаунтам или по одному
+func (b *Bot) handlePnL(ctx context.Context, req commandRequest) (string, error) {
+	const usage = "Использование: <code>/pnl 24h</code>, <code>/pnl 7d</code> или <code>/pnl 7d ACCOUNT_ID</code>"
+	if len(req.args) > 2 {
+		return usage, nil
+	}
+	period := 24 * time.Hour
+	if len(req.args) > 0 {
+		parsed, err := parsePeriod(req.args[0])
+		if err != nil {
+			return usage, nil
+		}
+		period = parsed
+	}
+	var accountID int64
+	if len(req.args) > 1 {
+		parsed, err := parseAccountID(req.args[1])
+		if err != nil {
+			return usage, nil
+		}
+		accountID = parsed
+	}
+
+	to := time.Now()
+	pnl, err := b.tradeLogRepo.GetPnL(ctx, req.chat.UserID, accountID, to.Add(-period), to)
+	if err != nil {
+		return "", err
+	}
+	if len(pnl) == 0 {
+		return "Сделок за период нет", nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<b>PnL за %s</b>", html.EscapeString(formatPeriod(period))))
+	if accountID != 0 {
+		sb.WriteString(fmt.Sprintf(", аккаунт #%d", accountID))
+	}
+	sb.WriteString("\n")
+	total := decimal.Zero
+	totalFees := decimal.Zero
+	for _, p := range pnl {
+		realized := p.RealizedPnL()
+		total = total.Add(realized)
+		totalFees = totalFees.Add(p.Fees)
+		sb.WriteString(fmt.Sprintf("• %s: %s (сделок %d, комиссии %s)\n",
+			html.EscapeString(p.Symbol), realized.StringFixed(4), p.Trades, p.Fees.StringFixed(4)))
+	}
+	sb.WriteString(fmt.Sprintf("\nИтого: <b>%s</b>, комиссии %s", total.StringFixed(4), totalFees.StringFixed(4)))
+	return sb.String(), nil
+}
+
+// handleInstruments выводит выбранные пользователем инструменты
+func (b *Bot) handleInstruments(ctx context.Context, req commandRequest) (string, error) {
+	instruments, err := b.userInstrumentService.GetUserInstruments(ctx, req.chat.UserID)
+	if err != nil {
+		return "", err
+	}
+	if len(instruments) == 0 {
+		return "Инструменты не выбраны", nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<b>Инструменты</b>\n")
+	for _, instrument := range instruments {
+		state := "выключен"
+		if instrument.IsActive {
+			state = "активен"
+		}
+		sb.WriteString(fmt.Sprintf("• %s — %s\n", html.EscapeString(instrument.Symbol), state))
+	}
+	return sb.String(), nil
+}
+
+// handleStartStrategy запускает стратегию, при необходимости добавляя ее пользователю.
+// Без ACCOUNT_ID используется единственный активный аккаунт Bybit.
+func (b *Bot) handleStartStrategy(ctx context.Context, req commandRequest) (string, error) {
+	if len(req.args) < 1 || len(req.args) > 2 {
+		return "Использование: <code>/start_strategy ИМЯ [ACCOUNT_ID]</code>. Доступны: " + strings.Join(knownStrategies, ", "), nil
+	}
+	name := req.args[0]
+	if !isKnownStrategy(name) {
+		return "Неизвестная стратегия. Доступны: " + strings.Join(knownStrategies, ", "), nil
+	}
+	accountID, err := strategyAccountArg(req.args)
+	if err != nil {
+		return "Неверный ACCOUNT_ID", nil
+	}
+
+	strategy, err := b.findUserStrategy(ctx, req.chat.UserID, name, accountID)
+	if err != nil {
+		return "", err
+	}
+	if strategy != nil && strategy.IsActive {
+		return fmt.Sprintf("Стратегия %s уже запущена", name), nil
+	}
+
+	// Стратегии живут дольше команды, поэтому запускаются в контексте бота
+	if strategy == nil {
+		strategy, err = b.userStrategyService.AddStrategy(req.appCtx, req.chat.UserID, name, accountID)
+		if err != nil {
+			return "", err
+		}
+	}
+	if err := b.userStrategyService.UpdateStrategyStatus(req.appCtx, req.chat.UserID, strategy.ID, true); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Стратегия %s запущена", name), nil
+}
+
+// handleStopStrategy останавливает стратегию пользователя
+func (b *Bot) handleStopStrategy(ctx context.Context, req commandRequest) (string, error) {
+	if len(req.args) < 1 || len(req.args) > 2 {
+		return "Использование: <code>/stop_strategy ИМЯ [ACCOUNT_ID]</code>", nil
+	}
+	name := req.args[0]
+	accountID, err := strategyAccountArg(req.args)
+	if err != nil {
+		return "Неверный ACCOUNT_ID", nil
+	}
+
+	strategy, err := b.findUserStrategy(ctx, req.chat.UserID, name, accountID)
+	if err != nil {
+		return "", err
+	}
+	if strategy == nil {
+		return fmt.Sprintf("Стратегия %s не добавлена", html.EscapeString(name)), nil
+	}
+	if !strategy.IsActive {
+		return fmt.Sprintf("Стратегия %s уже остановлена", html.EscapeString(name)), nil
+	}
+
+	if err := b.userStrategyService.UpdateStrategyStatus(req.appCtx, req.chat.UserID, strategy.ID, false); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Стратегия %s остановлена", html.EscapeString(name)), nil
+}
+
+// handleCancelAll отменяет все открытые ордера по активным инструментам
+func (b *Bot) handleCancelAll(ctx context.Context, req commandRequest) (string, error) {
+	cancelled, err := b.strategyManager.CancelAllOrders(ctx, req.chat.UserID)
+	if len(cancelled) == 0 && err != nil {
+		return "", err
+	}
+
+	text := "Ордера отменены"
+	if len(cancelled) > 0 {
+		text += ": " + html.EscapeString(strings.Join(cancelled, ", "))
+	} else {
+		text = "Нет активных инструментов"
+	}
+	if err != nil {
+		text += "\nНе удалось отменить: " + errorText(req, "отмены ордеров", err)
+	}
+	return text, nil
+}
+
+// findUserStrategy ищет стратегию пользователя по имени и аккаунту. Возвращает nil, если стратегия не добавлена.
+// Без аккаунта (accountID == 0) подходит только единственная стратегия с таким именем.
+func (b *Bot) findUserStrategy(ctx context.Context, userID, name string, accountID int64) (*models.UserStrategy, error) {
+	strategies, err := b.userStrategyService.GetUserStrategies(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var found *models.UserStrategy
+	for i := range strategies {
+		st := &strategies[i]
+		if st.StrategyName != name {
+			continue
+		}
+		if accountID != 0 {
+			if st.BybitAccountID != nil && *st.BybitAccountID == accountID {
+				return st, nil
+			}
+			continue
+		}
+		if found != nil {
+			return nil, models.Invalid("стратегия %s добавлена на нескольких аккаунтах, укажите ACCOUNT_ID", name)
+		}
+		found = st
+	}
+	return found, nil
+}
+
+// strategyAccountArg возвращает необязательный ACCOUNT_ID — второй аргумент команды
+func strategyAccountArg(args []string) (int64, error) {
+	if len(args) < 2 {
+		return 0, nil
+	}
+	return parseAccountID(args[1])
+}
+
+// parseAccountID разбирает положительный ID аккаунта Bybit
+func parseAccountID(value string) (int64, error) {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if id <= 0 {
+		return 0, errors.New("account id must be positive")
+	}
+	return id, nil
+}
+
+// accountName название аккаунта для вывода: метка или #ID
+func accountName(id int64, label string) string {
+	if label != "" {
+		return label
+	}
+	return fmt.Sprintf("#%d", id)
+}
+
+// formatStrategyAccount подпись аккаунта, на котором работает стратегия
+func formatStrategyAccount(st models.UserStrategy) string {
+	if st.BybitAccountID == nil {
+		return ", аккаунт не указан"
+	}
+	return fmt.Sprintf(", аккаунт #%d", *st.BybitAccountID)
+}
+
+func isKnownStrategy(name string) bool {
+	for _, s := range knownStrategies {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePeriod разбирает период вида 12h, 30m или 7d
+func parsePeriod(value string) (time.Duration, error) {
+	var period time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		period = time.Duration(n) * 24 * time.Hour
+	} else {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, err
+		}
+		period = parsed
+	}
+	if period <= 0 || period > 365*24*time.Hour {
+		return 0, errors.New("period out of range")
+	}
+	return period, nil
+}
+
+// formatPeriod форматирует период в днях, если он кратен суткам
+func formatPeriod(period time.Duration) string {
+	if period%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", int(period/(24*time.Hour)))
+	}
+	return period.String()
+}
@@ -0,0 +1,21 @@
+package trading
+
+import (
+	"CryptoLens_Backend/metrics"
+	"CryptoLens_Backend/types"
+	"strings"
+)
+
+// RegisterStrategyMetrics публикует число запущенных стратегий по типам
+func RegisterStrategyMetrics(manager types.StrategyManagerInterface) {
+	metrics.NewGaugeVecFunc("cryptolens_active_strategies", "Strategies running in the strategy manager by type.", "strategy", func() map[string]float64 {
+		counts := make(map[string]float64)
+		for _, strategies := range manager.GetStrategiesInfo() {
+			for _, name := range strategies {
+				// GetStrategiesInfo возвращает имена типов вида *trading.SpreadScalpingStrategy
+				counts[strings.TrimPrefix(name, "*trading.")]++
+			}
+		}
+		return counts
+	})
+}
@@ -1,21 +1,32 @@
 package trading
 
 import (
+	"CryptoLens_Backend/config"
 	"CryptoLens_Backend/integration/bybit"
-	"CryptoLens_Backend/logger"
+	"CryptoLens_Backend/models"
 	"CryptoLens_Backend/storages"
 	"CryptoLens_Backend/types"
+	"CryptoLens_Shared/logger"
 	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/shopspring/decimal"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
+// minQuoteBalance минимальный баланс USDT, при котором стратегия выставляет ордера на покупку
+var minQuoteBalance = decimal.NewFromFloat(10)
+
+// apiErrorPause пауза в выставлении ордеров после отказа Bybit по лимиту запросов или балансу
+const apiErrorPause = 10 * time.Second
+
 // SpreadScalpingStrategy реализует стратегию спред-скальпинга
 type SpreadScalpingStrategy struct {
 	userID         string
+	accountID      int64 // Аккаунт Bybit, на котором стратегия торгует
 	symbol         string
 	manager        *StrategyManager
 	minSpread      decimal.Decimal                          // Минимальный спред
@@ -25,21 +36,31 @@ type SpreadScalpingStrategy struct {
 	buyPrice       decimal.Decimal                          // Цена покупки
 	buyQty         decimal.Decimal                          // Объем покупки
 	activeOrderID  string                                   // ID активного ордера
+	limitHit       bool                                     // Стратегия уперлась в лимит баланса
+	pausedUntil    time.Time                                // Ордера не выставляются до этого времени после отказа API
+	halted         bool                                     // Bybit отклонил ключ API: остановка запрошена у сервиса стратегий
 	baseCoin       string                                   // Базовая монета (например, BTC)
 	instrumentRepo types.BybitInstrumentRepositoryInterface // Репозиторий
 	msgChan        chan interface{}                         // Канал для сообщений
 	stopChan       chan struct{}                            // Канал для остановки
+	resyncChan     chan struct{}                            // Сверить активный ордер после восстановления приватного соединения
+	marketDown     atomic.Bool                              // Публичное соединение потеряно: книга ордеров устарела
+	accountDown    atomic.Bool                              // Приватное соединение потеряно: исполнения не поступают
+	logCtx         context.Context                          // Поля логов стратегии вне цикла обработки
 }
 
 // NewSpreadScalpingStrategy создает новую стратегию
 func NewSpreadScalpingStrategy(
-	userID, symbol string,
+	userID string,
+	accountID int64,
+	symbol string,
 	manager *StrategyManager,
 	instrumentRepo types.BybitInstrumentRepositoryInterface,
 ) *SpreadScalpingStrategy {
 	baseCoin := symbol[:len(symbol)-4] // Например, BTC из BTCUSDT
 	return &SpreadScalpingStrategy{
 		userID:         userID,
+		accountID:      accountID,
 		symbol:         symbol,
 		manager:        manager,
 		minSpread:      decimal.NewFromFloat(1),     // Начальное значение, обновится
@@ -50,6 +71,13 @@ func NewSpreadScalpingStrategy(
 		instrumentRepo: instrumentRepo,
 		msgChan:        make(chan interface{}, 1000), // Буфер на 1000 сообщений
 		stopChan:       make(chan struct{}),
+		resyncChan:     make(chan struct{}, 1),
+		logCtx: logger.WithFields(context.Background(),
+			logger.FieldUserID, userID,
+			logger.FieldAccountID, accountID,
+			logger.FieldStrategy, "spread_scalping",
+			logger.FieldSymbol, symbol,
+		),
 	}
 }
 
@@ -67,7 +95,7 @@ func (s *SpreadScalpingStrategy) updateParameters(ctx context.Context) error {
 		return fmt.Errorf("failed to get ticker: %w", err)
 	}
 	lastPrice, _ := decimal.NewFromString(ticker.LastPrice)
-	logger.LogInfo("SpreadScalping [%s] lastPrice: %s", s.userID, lastPrice.String())
+	logger.InfoCtx(ctx, "SpreadScalping lastPrice: %s", lastPrice.String())
 
 	// Рассчитываем minSpread (0.02% от цены или минимум 1 USDT)
 	calculatedSpread := lastPrice.Mul(decimal.NewFromFloat(0.0002))
@@ -77,10 +105,10 @@ func (s *SpreadScalpingStrategy) updateParameters(ctx context.Context) error {
 	} else {
 		s.minSpread = minSpread
 	}
-	logger.LogDebug("SpreadScalping [%s] рассчитанный minSpread: %s", s.userID, s.minSpread.String())
+	logger.DebugCtx(ctx, "SpreadScalping рассчитанный minSpread: %s", s.minSpread.String())
 
 	// Рассчитываем quantity (10% баланса USDT, минимум minOrderQty)
-	wallet, err := s.manager.GetWalletBalance(ctx, s.userID)
+	wallet, err := s.manager.GetWalletBalance(ctx, s.userID, s.accountID)
 	if err != nil {
 		return fmt.Errorf("failed to get wallet: %w", err)
 	}
@@ -92,36 +120,36 @@ func (s *SpreadScalpingStrategy) updateParameters(ctx context.Context) error {
 			break
 		}
 	}
-	logger.LogInfo("SpreadScalping [%s] usdtBalance: %s", s.userID, usdtBalance.String())
+	logger.InfoCtx(ctx, "SpreadScalping usdtBalance: %s", usdtBalance.String())
 
 	targetValue := usdtBalance.Mul(decimal.NewFromFloat(0.1)) // 10% баланса
 	quantity := targetValue.Div(lastPrice)                    // В BTC
-	logger.LogDebug("SpreadScalping [%s] начальный объем (quantity): %s", s.userID, quantity.String())
+	logger.DebugCtx(ctx, "SpreadScalping начальный объем (quantity): %s", quantity.String())
 
 	// Проверяем минимальный размер ордера
 	if quantity.LessThan(instrument.MinOrderQty) {
 		quantity = instrument.MinOrderQty
-		logger.LogDebug("SpreadScalping [%s] объем (quantity) скорректирован до минимального (minOrderQty): %s", s.userID, quantity.String())
+		logger.DebugCtx(ctx, "SpreadScalping объем (quantity) скорректирован до минимального (minOrderQty): %s", quantity.String())
 	}
 
 	// Проверяем максимальный размер ордера
 	if quantity.GreaterThan(instrument.MaxOrderQty) {
 		quantity = instrument.MaxOrderQty
-		logger.LogDebug("SpreadScalping [%s] объем (quantity) скорректирован до максимального (maxOrderQty): %s", s.userID, quantity.String())
+		logger.DebugCtx(ctx, "SpreadScalping объем (quantity) скорректирован до максимального (maxOrderQty): %s", quantity.String())
 	}
 
 	// Проверяем минимальную стоимость ордера
 	minOrderAmt := lastPrice.Mul(quantity)
 	if minOrderAmt.LessThan(instrument.MinOrderAmt) {
 		quantity = instrument.MinOrderAmt.Div(lastPrice)
-		logger.LogDebug("SpreadScalping [%s] объем (quantity) скорректирован по минимальной стоимости (minOrderAmt): %s", s.userID, quantity.String())
+		logger.DebugCtx(ctx, "SpreadScalping объем (quantity) скорректирован по минимальной стоимости (minOrderAmt): %s", quantity.String())
 	}
 
 	// Проверяем максимальную стоимость ордера
 	maxOrderAmt := lastPrice.Mul(quantity)
 	if maxOrderAmt.GreaterThan(instrument.MaxOrderAmt) {
 		quantity = instrument.MaxOrderAmt.Div(lastPrice)
-		logger.LogDebug("SpreadScalping [%s] объем (quantity) скорректирован по максимальной стоимости (maxOrderAmt): %s", s.userID, quantity.String())
+		logger.DebugCtx(ctx, "SpreadScalping объем (quantity) скорректирован по максимальной стоимости (maxOrderAmt): %s", quantity.String())
 	}
 
 	// Округляем до basePrecision
@@ -132,7 +160,7 @@ func (s *SpreadScalpingStrategy) updateParameters(ctx context.Context) error {
 	}
 	quantity = quantity.Round(precisionPlaces)
 
-	logger.LogDebug("SpreadScalping [%s] объем (quantity) округлен до точности базовой монеты (basePrecision): %s", s.userID, quantity.String())
+	logger.DebugCtx(ctx, "SpreadScalping объем (quantity) округлен до точности базовой монеты (basePrecision): %s", quantity.String())
 
 	s.quantity = quantity
 
@@ -141,10 +169,9 @@ func (s *SpreadScalpingStrategy) updateParameters(ctx context.Context) error {
 	tradeValue := lastPrice.Mul(s.quantity)
 	fees := tradeValue.Mul(feeRate)
 	s.minProfit = fees.Add(decimal.NewFromFloat(0.1)) // Комиссии + 0.1 USDT
-	logger.LogDebug("SpreadScalping [%s] рассчитанная минимальная прибыль (minProfit): %s (комиссии (fees): %s)", s.userID, s.minProfit.String(), fees.String())
+	logger.DebugCtx(ctx, "SpreadScalping рассчитанная минимальная прибыль (minProfit): %s (комиссии (fees): %s)", s.minProfit.String(), fees.String())
 
-	logger.LogInfo("SpreadScalping [%s] обновлены параметры: minSpread=%s (%.4f%%), minProfit=%s, quantity=%s, lastPrice=%s, orderValue=%s USDT",
-		s.userID,
+	logger.InfoCtx(ctx, "SpreadScalping обновлены параметры: minSpread=%s (%.4f%%), minProfit=%s, quantity=%s, lastPrice=%s, orderValue=%s USDT",
 		s.minSpread.String(),
 		s.minSpread.Div(lastPrice).Mul(decimal.NewFromInt(100)).InexactFloat64(),
 		s.minProfit.String(),
@@ -154,37 +181,58 @@ func (s *SpreadScalpingStrategy) updateParameters(ctx context.Context) error {
 	return nil
 }
 
+// AccountID возвращает ID аккаунта Bybit стратегии
+func (s *SpreadScalpingStrategy) AccountID() int64 {
+	return s.accountID
+}
+
 // Start запускает стратегию
 func (s *SpreadScalpingStrategy) Start(ctx context.Context) {
-	logger.LogInfo("SpreadScalping [%s] запущена для %s", s.userID, s.symbol)
+	// Поля из ctx (например, strategy_id) дополняются полями стратегии
+	runCtx := logger.WithFields(ctx,
+		logger.FieldUserID, s.userID,
+		logger.FieldAccountID, s.accountID,
+		logger.FieldStrategy, "spread_scalping",
+		logger.FieldSymbol, s.symbol,
+	)
+	logger.InfoCtx(runCtx, "SpreadScalping запущена для %s", s.symbol)
 
 	// Создаем новый контекст для инициализации параметров
-	strategyCtx, cancel := context.WithCancel(context.Background())
+	strategyCtx, cancel := context.WithCancel(context.WithoutCancel(runCtx))
 	defer cancel()
 
 	// Обновляем параметры при старте
 	if err := s.updateParameters(strategyCtx); err != nil {
-		logger.LogError("SpreadScalping [%s] ошибка инициализации параметров: %v", s.userID, err)
+		logger.ErrorCtx(strategyCtx, "SpreadScalping ошибка инициализации параметров: %v", err)
 	}
 
 	// Запускаем обработчик сообщений
-	go s.processMessages()
+	go s.processMessages(context.WithoutCancel(runCtx))
 
 	// Периодическое обновление параметров
 	go func() {
-		ticker := time.NewTicker(5 * time.Minute)
+		interval := config.Get().Strategies.ParametersUpdateInterval.Std()
+		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
+		changed := config.Changed()
 		for {
 			select {
 			case <-ctx.Done():
 				return
 			case <-s.stopChan:
 				return
+			case <-changed:
+				changed = config.Changed()
+				if next := config.Get().Strategies.ParametersUpdateInterval.Std(); next != interval {
+					interval = next
+					ticker.Reset(interval)
+					logger.InfoCtx(runCtx, "SpreadScalping интервал обновления параметров изменен на %s", interval)
+				}
 			case <-ticker.C:
 				// Создаем новый контекст для каждого обновления
-				updateCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				updateCtx, cancel := context.WithTimeout(context.WithoutCancel(runCtx), 5*time.Second)
 				if err := s.updateParameters(updateCtx); err != nil {
-					logger.LogError("SpreadScalping [%s] ошибка обновления параметров: %v", s.userID, err)
+					logger.ErrorCtx(updateCtx, "SpreadScalping ошибка обновления параметров: %v", err)
 				}
 				cancel()
 			}
@@ -195,30 +243,60 @@ func (s *SpreadScalpingStrategy) Start(ctx context.Context) {
 // Stop останавливает стратегию
 func (s *SpreadScalpingStrategy) Stop(ctx context.Context) {
 	close(s.stopChan)
-	if s.activeOrderID != "" {
-		if err := s.manager.CancelOrder(ctx, s.userID, s.symbol, s.activeOrderID); err != nil {
-			logger.LogError("SpreadScalping [%s] ошибка отмены ордера %s при остановке: %v", s.userID, s.activeOrderID, err)
+	if s.activeOrderID != "" && s.manager.keepOrdersOnStop() {
+		logger.InfoCtx(logger.WithFields(s.logCtx, logger.FieldOrderID, s.activeOrderID), "SpreadScalping ордер %s оставлен на бирже при остановке сервера", s.activeOrderID)
+	} else if s.activeOrderID != "" {
+		if err := s.manager.CancelOrder(ctx, s.userID, s.accountID, s.symbol, s.activeOrderID); err != nil {
+			logger.ErrorCtx(logger.WithFields(s.logCtx, logger.FieldOrderID, s.activeOrderID), "SpreadScalping ошибка отмены ордера %s при остановке: %v", s.activeOrderID, err)
 		}
 		s.activeOrderID = ""
 	}
-	logger.LogInfo("SpreadScalping [%s] остановлена", s.userID)
+	logger.InfoCtx(s.logCtx, "SpreadScalping остановлена")
+}
+
+// reportLimitHit уведомляет пользователя, что торговля остановлена лимитом баланса.
+// Уведомление отправляется один раз до тех пор, пока баланс не восстановится.
+func (s *SpreadScalpingStrategy) reportLimitHit(ctx context.Context, coin string, balance, required decimal.Decimal) {
+	if s.limitHit {
+		return
+	}
+	s.limitHit = true
+	s.manager.Notify(ctx, models.NotificationEvent{
+		Type:    models.EventRiskLimitHit,
+		UserID:  s.userID,
+		Title:   "Достигнут лимит баланса",
+		Message: fmt.Sprintf("SpreadScalping %s приостановила выставление ордеров: недостаточно %s", s.symbol, coin),
+		Fields: map[string]string{
+			"account_id": strconv.FormatInt(s.accountID, 10),
+			"symbol":     s.symbol,
+			"coin":       coin,
+			"balance":    balance.String(),
+			"required":   required.String(),
+		},
+	})
 }
 
-// processMessages обрабатывает сообщения из канала
-func (s *SpreadScalpingStrategy) processMessages() {
+// processMessages обрабатывает сообщения из канала. ctx не отменяется при остановке стратегии
+// и несет поля логов; выход из цикла происходит по stopChan.
+func (s *SpreadScalpingStrategy) processMessages(ctx context.Context) {
 	for {
 		select {
 		case <-s.stopChan:
 			return
+		case <-s.resyncChan:
+			s.resyncActiveOrder(ctx)
 		case msg := <-s.msgChan:
-			ctx := context.Background()
 			switch m := msg.(type) {
 			case bybit.TickerMessage:
-				logger.LogInfo("SpreadScalping [%s] получен тикер: %s, цена: %s", s.userID, m.Symbol, m.LastPrice)
+				logger.InfoCtx(ctx, "SpreadScalping получен тикер: %s, цена: %s", m.Symbol, m.LastPrice)
 			case bybit.OrderBookMessage:
+				// Без соединений книга ордеров может быть устаревшей, а исполнения — неизвестными
+				if s.marketDown.Load() || s.accountDown.Load() || !s.canTrade() {
+					continue
+				}
 				spread, err := storages.GetOrderBookSpread(ctx, s.symbol)
 				if err != nil {
-					logger.LogError("SpreadScalping [%s] ошибка получения спреда: %v", s.userID, err)
+					logger.ErrorCtx(ctx, "SpreadScalping ошибка получения спреда: %v", err)
 					continue
 				}
 				if spread.LessThan(s.minSpread) {
@@ -226,9 +304,10 @@ func (s *SpreadScalpingStrategy) processMessages() {
 				}
 
 				// Проверяем баланс
-				wallet, err := s.manager.GetWalletBalance(ctx, s.userID)
+				wallet, err := s.manager.GetWalletBalance(ctx, s.userID, s.accountID)
 				if err != nil {
-					logger.LogError("SpreadScalping [%s] ошибка получения кошелька: %v", s.userID, err)
+					logger.ErrorCtx(ctx, "SpreadScalping ошибка получения кошелька: %v", err)
+					s.handleAPIError(ctx, err)
 					continue
 				}
 
@@ -243,19 +322,16 @@ func (s *SpreadScalpingStrategy) processMessages() {
 							}
 						}
 					}
-					if freeBalance.LessThan(decimal.NewFromFloat(10)) {
-						logger.LogInfo("SpreadScalping [%s] недостаточно средств: %s USDT", s.userID, freeBalance.String())
+					if freeBalance.LessThan(minQuoteBalance) {
+						logger.InfoCtx(ctx, "SpreadScalping недостаточно средств: %s USDT", freeBalance.String())
+						s.reportLimitHit(ctx, "USDT", freeBalance, minQuoteBalance)
 						continue
 					}
+					s.limitHit = false
 
-					// Отменяем существующий ордер, если есть
-					if s.activeOrderID != "" {
-						if err := s.manager.CancelOrder(ctx, s.userID, s.symbol, s.activeOrderID); err != nil {
-							logger.LogError("SpreadScalping [%s] ошибка отмены ордера %s: %v", s.userID, s.activeOrderID, err)
-						} else {
-							logger.LogInfo("SpreadScalping [%s] ордер отменен: %s", s.userID, s.activeOrderID)
-							s.activeOrderID = ""
-						}
+					// Отменяем существующий ордер, если есть; без отмены новый не выставляем
+					if s.activeOrderID != "" && !s.cancelActiveOrder(ctx) {
+						continue
 					}
 
 					// Размещаем лимитный ордер на покупку чуть выше лучшего бида
@@ -264,16 +340,17 @@ func (s *SpreadScalpingStrategy) processMessages() {
 						buyPrice := bidPrice.Add(decimal.NewFromFloat(0.01))
 						priceStr := buyPrice.String()
 						quantityStr := s.quantity.String()
-						order, err := s.manager.CreateOrder(ctx, s.userID, s.symbol, "Buy", "Limit", quantityStr, &priceStr)
+						order, err := s.manager.CreateOrder(ctx, s.userID, s.accountID, s.symbol, "Buy", "Limit", quantityStr, &priceStr)
 						if err != nil {
-							logger.LogError("SpreadScalping [%s] ошибка создания ордера на покупку: %v", s.userID, err)
+							logger.ErrorCtx(ctx, "SpreadScalping ошибка создания ордера на покупку: %v", err)
+							s.handleAPIError(ctx, err)
 						} else {
 							s.activeOrderID = order.OrderID
-							logger.LogInfo("SpreadScalping [%s] создан ордер на покупку: %s по цене %s, ID: %s", s.userID, s.symbol, priceStr, order.OrderID)
+							logger.InfoCtx(logger.WithFields(ctx, logger.FieldOrderID, order.OrderID), "SpreadScalping создан ордер на покупку: %s по цене %s, ID: %s", s.symbol, priceStr, order.OrderID)
 						}
 					} else {
 						orderBookJSON, _ := json.MarshalIndent(m, "", "  ")
-						logger.LogError("SpreadScalping [%s] книга ордеров пуста: %s", s.userID, string(orderBookJSON))
+						logger.ErrorCtx(ctx, "SpreadScalping книга ордеров пуста: %s", string(orderBookJSON))
 					}
 				} else {
 					// Проверяем баланс базовой монеты для продажи
@@ -287,18 +364,15 @@ func (s *SpreadScalpingStrategy) processMessages() {
 						}
 					}
 					if freeBalance.LessThan(s.quantity) {
-						logger.LogInfo("SpreadScalping [%s] недостаточно средств %s: %s", s.userID, s.baseCoin, freeBalance.String())
+						logger.InfoCtx(ctx, "SpreadScalping недостаточно средств %s: %s", s.baseCoin, freeBalance.String())
+						s.reportLimitHit(ctx, s.baseCoin, freeBalance, s.quantity)
 						continue
 					}
+					s.limitHit = false
 
-					// Отменяем существующий ордер, если есть
-					if s.activeOrderID != "" {
-						if err := s.manager.CancelOrder(ctx, s.userID, s.symbol, s.activeOrderID); err != nil {
-							logger.LogError("SpreadScalping [%s] ошибка отмены ордера %s: %v", s.userID, s.activeOrderID, err)
-						} else {
-							logger.LogInfo("SpreadScalping [%s] ордер отменен: %s", s.userID, s.activeOrderID)
-							s.activeOrderID = ""
-						}
+					// Отменяем существующий ордер, если есть; без отмены новый не выставляем
+					if s.activeOrderID != "" && !s.cancelActiveOrder(ctx) {
+						continue
 					}
 
 					// Размещаем лимитный ордер на продажу чуть ниже лучшего аска
@@ -307,55 +381,172 @@ func (s *SpreadScalpingStrategy) processMessages() {
 						sellPrice := askPrice.Sub(decimal.NewFromFloat(0.01))
 						// Проверяем минимальную прибыль
 						if sellPrice.Sub(s.buyPrice).Mul(s.buyQty).LessThan(s.minProfit) {
-							logger.LogInfo("SpreadScalping [%s] потенциальная прибыль слишком мала: %s", s.userID, sellPrice.Sub(s.buyPrice).Mul(s.buyQty).String())
+							logger.InfoCtx(ctx, "SpreadScalping потенциальная прибыль слишком мала: %s", sellPrice.Sub(s.buyPrice).Mul(s.buyQty).String())
 							continue
 						}
 						priceStr := sellPrice.String()
 						quantityStr := s.quantity.String()
-						order, err := s.manager.CreateOrder(ctx, s.userID, s.symbol, "Sell", "Limit", quantityStr, &priceStr)
+						order, err := s.manager.CreateOrder(ctx, s.userID, s.accountID, s.symbol, "Sell", "Limit", quantityStr, &priceStr)
 						if err != nil {
-							logger.LogError("SpreadScalping [%s] ошибка создания ордера на продажу: %v", s.userID, err)
+							logger.ErrorCtx(ctx, "SpreadScalping ошибка создания ордера на продажу: %v", err)
+							s.handleAPIError(ctx, err)
 						} else {
 							s.activeOrderID = order.OrderID
-							logger.LogInfo("SpreadScalping [%s] создан ордер на продажу: %s по цене %s, ID: %s", s.userID, s.symbol, priceStr, order.OrderID)
+							logger.InfoCtx(logger.WithFields(ctx, logger.FieldOrderID, order.OrderID), "SpreadScalping создан ордер на продажу: %s по цене %s, ID: %s", s.symbol, priceStr, order.OrderID)
 						}
 					}
 				}
 			case bybit.TradeMessage:
 				// Игнорируем, так как стратегия ориентирована на книгу ордеров
 			case bybit.OrderMessage:
-				logger.LogInfo("SpreadScalping [%s] обновление ордера: %s, статус: %s", s.userID, m.OrderID, m.OrderStatus)
+				logger.InfoCtx(logger.WithFields(ctx, logger.FieldOrderID, m.OrderID), "SpreadScalping обновление ордера: %s, статус: %s", m.OrderID, m.OrderStatus)
 				if m.OrderID == s.activeOrderID {
 					if m.OrderStatus == "Filled" || m.OrderStatus == "Cancelled" {
 						s.activeOrderID = ""
 					}
 				}
 			case bybit.ExecutionMessage:
-				logger.LogInfo("SpreadScalping [%s] исполнение: %s, цена: %s, объем: %s, сторона: %s",
-					s.userID, m.ExecID, m.ExecPrice, m.ExecQty, m.Side)
+				logger.InfoCtx(logger.WithFields(ctx, logger.FieldOrderID, m.OrderID), "SpreadScalping исполнение: %s, цена: %s, объем: %s, сторона: %s",
+					m.ExecID, m.ExecPrice, m.ExecQty, m.Side)
 				if m.Symbol == s.symbol {
-					if m.Side == "Buy" && s.isBuying {
-						// Фиксируем цену и объем покупки
-						s.buyPrice, _ = decimal.NewFromString(m.ExecPrice)
-						s.buyQty, _ = decimal.NewFromString(m.ExecQty)
-						s.isBuying = false
-						logger.LogInfo("SpreadScalping [%s] покупка исполнена, переходим к продаже: цена=%s, объем=%s",
-							s.userID, s.buyPrice.String(), s.buyQty.String())
-					} else if m.Side == "Sell" && !s.isBuying {
-						// Сбрасываем состояние после продажи
-						s.isBuying = true
-						s.buyPrice = decimal.Zero
-						s.buyQty = decimal.Zero
-						logger.LogInfo("SpreadScalping [%s] продажа исполнена, возвращаемся к покупке", s.userID)
-					}
+					price, _ := decimal.NewFromString(m.ExecPrice)
+					qty, _ := decimal.NewFromString(m.ExecQty)
+					s.applyFill(ctx, m.Side, price, qty)
 				}
 			case bybit.WalletMessage:
-				logger.LogInfo("SpreadScalping [%s] обновление кошелька", s.userID)
+				logger.InfoCtx(ctx, "SpreadScalping обновление кошелька")
 			}
 		}
 	}
 }
 
+// cancelActiveOrder отменяет активный ордер и сообщает, можно ли выставлять новый. Если Bybit ордер
+// уже не знает, он исполнен или отменен: состояние сверяется через API, а новый ордер ждет следующей книги.
+func (s *SpreadScalpingStrategy) cancelActiveOrder(ctx context.Context) bool {
+	orderCtx := logger.WithFields(ctx, logger.FieldOrderID, s.activeOrderID)
+	err := s.manager.CancelOrder(ctx, s.userID, s.accountID, s.symbol, s.activeOrderID)
+	switch {
+	case err == nil:
+		logger.InfoCtx(orderCtx, "SpreadScalping ордер отменен: %s", s.activeOrderID)
+		s.activeOrderID = ""
+		return true
+	case bybit.IsRetCode(err, bybit.RetCodeOrderNotExists, bybit.RetCodeSpotOrderNotExists):
+		logger.WarnCtx(orderCtx, "SpreadScalping ордер %s уже закрыт на бирже, сверяем состояние", s.activeOrderID)
+		// Пока исход ордера неизвестен, его нельзя забывать: исполнение изменило бы позицию
+		if !s.resyncActiveOrder(ctx) {
+			s.pausedUntil = time.Now().Add(apiErrorPause)
+			return false
+		}
+		s.activeOrderID = ""
+		return false
+	default:
+		logger.ErrorCtx(orderCtx, "SpreadScalping ошибка отмены ордера %s: %v", s.activeOrderID, err)
+		s.handleAPIError(ctx, err)
+		return false
+	}
+}
+
+// handleAPIError реагирует на ошибку Bybit по ее классу: лимит запросов и нехватка средств приостанавливают
+// выставление ордеров, отказ ключа останавливает стратегии аккаунта через сервис стратегий.
+// Временные сбои повторятся на следующей книге.
+func (s *SpreadScalpingStrategy) handleAPIError(ctx context.Context, err error) {
+	switch bybit.ClassifyError(err) {
+	case bybit.ErrorClassRateLimited:
+		s.pausedUntil = time.Now().Add(apiErrorPause)
+		logger.WarnCtx(ctx, "SpreadScalping превышен лимит запросов Bybit, пауза %s", apiErrorPause)
+	case bybit.ErrorClassAuth:
+		if s.halted {
+			return
+		}
+		s.halted = true
+		logger.ErrorCtx(ctx, "SpreadScalping торговля остановлена: Bybit отклонил ключ API: %v", err)
+		s.manager.StopAccountStrategies(ctx, s.userID, s.accountID, "Bybit отклонил ключ API аккаунта. Проверьте ключ и запустите стратегию снова")
+	case bybit.ErrorClassRejected:
+		// Кошелек мог еще не учесть последнее исполнение; ждем, пока баланс обновится
+		if bybit.IsRetCode(err, bybit.RetCodeInsufficientBalance) {
+			s.pausedUntil = time.Now().Add(apiErrorPause)
+		}
+	}
+}
+
+// canTrade сообщает, что выставление ордеров не приостановлено после ошибки API
+func (s *SpreadScalpingStrategy) canTrade() bool {
+	return !s.halted && !time.Now().Before(s.pausedUntil)
+}
+
+// applyFill переключает стратегию между покупкой и продажей после исполнения
+func (s *SpreadScalpingStrategy) applyFill(ctx context.Context, side string, price, qty decimal.Decimal) {
+	if side == "Buy" && s.isBuying {
+		// Фиксируем цену и объем покупки
+		s.buyPrice = price
+		s.buyQty = qty
+		s.isBuying = false
+		logger.InfoCtx(ctx, "SpreadScalping покупка исполнена, переходим к продаже: цена=%s, объем=%s",
+			s.buyPrice.String(), s.buyQty.String())
+	} else if side == "Sell" && !s.isBuying {
+		// Сбрасываем состояние после продажи
+		s.isBuying = true
+		s.buyPrice = decimal.Zero
+		s.buyQty = decimal.Zero
+		logger.InfoCtx(ctx, "SpreadScalping продажа исполнена, возвращаемся к покупке")
+	}
+}
+
+// resyncActiveOrder сверяет активный ордер через API: пока приватного соединения не было,
+// ордер мог исполниться или быть отменен, а сообщения об этом потеряны. Возвращает false, если
+// состояние ордера получить не удалось.
+func (s *SpreadScalpingStrategy) resyncActiveOrder(ctx context.Context) bool {
+	if s.activeOrderID == "" {
+		return true
+	}
+	orderCtx := logger.WithFields(ctx, logger.FieldOrderID, s.activeOrderID)
+	order, err := s.manager.GetOrder(ctx, s.userID, s.accountID, s.symbol, s.activeOrderID)
+	if err != nil {
+		logger.ErrorCtx(orderCtx, "SpreadScalping ошибка сверки ордера %s: %v", s.activeOrderID, err)
+		return false
+	}
+	if order == nil {
+		logger.WarnCtx(orderCtx, "SpreadScalping ордер %s не найден при сверке", s.activeOrderID)
+		return true
+	}
+
+	logger.InfoCtx(orderCtx, "SpreadScalping сверка ордера %s: статус %s", order.OrderID, order.OrderStatus)
+	switch order.OrderStatus {
+	case "Filled", "Cancelled", "Rejected", "PartiallyFilledCanceled":
+		// Отмененный ордер мог успеть исполниться частично
+		qty, _ := decimal.NewFromString(order.CumExecQty)
+		if qty.IsPositive() {
+			value, _ := decimal.NewFromString(order.CumExecValue)
+			s.applyFill(ctx, order.Side, value.Div(qty), qty)
+		}
+		s.activeOrderID = ""
+	}
+	return true
+}
+
+// OnConnection приостанавливает выставление ордеров, пока соединения нет. После восстановления
+// приватного соединения активный ордер сверяется через API.
+func (s *SpreadScalpingStrategy) OnConnection(ctx context.Context, event bybit.ConnectionEvent) {
+	down := event.State == bybit.ConnectionDisconnected
+	if event.Private {
+		s.accountDown.Store(down)
+		if !down {
+			select {
+			case s.resyncChan <- struct{}{}:
+			default:
+			}
+		}
+	} else {
+		s.marketDown.Store(down)
+	}
+
+	if down {
+		logger.WarnCtx(s.logCtx, "SpreadScalping приостановлена: потеряно соединение (приватное: %t)", event.Private)
+	} else if !s.marketDown.Load() && !s.accountDown.Load() {
+		logger.InfoCtx(s.logCtx, "SpreadScalping возобновлена после восстановления соединения")
+	}
+}
+
 // OnTicker обрабатывает тикер
 func (s *SpreadScalpingStrategy) OnTicker(ctx context.Context, ticker bybit.TickerMessage) {
 	if ticker.Symbol != s.symbol {
@@ -363,9 +554,9 @@ func (s *SpreadScalpingStrategy) OnTicker(ctx context.Context, ticker bybit.Tick
 	}
 	select {
 	case s.msgChan <- ticker:
-		logger.LogDebug("SpreadScalping [%s] тикер отправлен в канал: %s", s.userID, ticker.Symbol)
+		logger.DebugCtx(s.logCtx, "SpreadScalping тикер отправлен в канал: %s", ticker.Symbol)
 	default:
-		logger.LogWarn("SpreadScalping [%s] канал переполнен, тикер отброшен: %s", s.userID, ticker.Symbol)
+		logger.WarnCtx(s.logCtx, "SpreadScalping канал переполнен, тикер отброшен: %s", ticker.Symbol)
 	}
 }
 
@@ -376,9 +567,9 @@ func (s *SpreadScalpingStrategy) OnOrderBook(ctx context.Context, orderBook bybi
 	}
 	select {
 	case s.msgChan <- orderBook:
-		logger.LogDebug("SpreadScalping [%s] книга ордеров отправлена в канал: %s", s.userID, orderBook.Symbol)
+		logger.DebugCtx(s.logCtx, "SpreadScalping книга ордеров отправлена в канал: %s", orderBook.Symbol)
 	default:
-		logger.LogWarn("SpreadScalping [%s] канал переполнен, книга ордеров отброшена: %s", s.userID, orderBook.Symbol)
+		logger.WarnCtx(s.logCtx, "SpreadScalping канал переполнен, книга ордеров отброшена: %s", orderBook.Symbol)
 	}
 }
 
@@ -389,9 +580,9 @@ func (s *SpreadScalpingStrategy) OnTrade(ctx context.Context, trade bybit.TradeM
 	}
 	select {
 	case s.msgChan <- trade:
-		logger.LogDebug("SpreadScalping [%s] сделка отправлена в канал: %s", s.userID, trade.Symbol)
+		logger.DebugCtx(s.logCtx, "SpreadScalping сделка отправлена в канал: %s", trade.Symbol)
 	default:
-		logger.LogWarn("SpreadScalping [%s] канал переполнен, сделка отброшена: %s", s.userID, trade.Symbol)
+		logger.WarnCtx(s.logCtx, "SpreadScalping канал переполнен, сделка отброшена: %s", trade.Symbol)
 	}
 }
 
@@ -402,9 +593,9 @@ func (s *SpreadScalpingStrategy) OnOrder(ctx context.Context, order bybit.OrderM
 	}
 	select {
 	case s.msgChan <- order:
-		logger.LogDebug("SpreadScalping [%s] ордер отправлен в канал: %s", s.userID, order.Symbol)
+		logger.DebugCtx(s.logCtx, "SpreadScalping ордер отправлен в канал: %s", order.Symbol)
 	default:
-		logger.LogWarn("SpreadScalping [%s] канал переполнен, ордер отброшен: %s", s.userID, order.Symbol)
+		logger.WarnCtx(s.logCtx, "SpreadScalping канал переполнен, ордер отброшен: %s", order.Symbol)
 	}
 }
 
@@ -415,9 +606,9 @@ func (s *SpreadScalpingStrategy) OnExecution(ctx context.Context, execution bybi
 	}
 	select {
 	case s.msgChan <- execution:
-		logger.LogDebug("SpreadScalping [%s] исполнение отправлено в канал: %s", s.userID, execution.Symbol)
+		logger.DebugCtx(s.logCtx, "SpreadScalping исполнение отправлено в канал: %s", execution.Symbol)
 	default:
-		logger.LogWarn("SpreadScalping [%s] канал переполнен, исполнение отброшено: %s", s.userID, execution.Symbol)
+		logger.WarnCtx(s.logCtx, "SpreadScalping канал переполнен, исполнение отброшено: %s", execution.Symbol)
 	}
 }
 
@@ -425,8 +616,8 @@ func (s *SpreadScalpingStrategy) OnExecution(ctx context.Context, execution bybi
 func (s *SpreadScalpingStrategy) OnWallet(ctx context.Context, wallet bybit.WalletMessage) {
 	select {
 	case s.msgChan <- wallet:
-		logger.LogDebug("SpreadScalping [%s] кошелек отправлен в канал", s.userID)
+		logger.DebugCtx(s.logCtx, "SpreadScalping кошелек отправлен в канал")
 	default:
-		logger.LogWarn("SpreadScalping [%s] канал переполнен, кошелек отброшен", s.userID)
+		logger.WarnCtx(s.logCtx, "SpreadScalping канал переполнен, кошелек отброшен")
 	}
 }
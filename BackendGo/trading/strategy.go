@@ -1,16 +1,25 @@
 package trading
 
 import (
+	"CryptoLens_Backend/config"
 	"CryptoLens_Backend/integration/bybit"
-	"CryptoLens_Backend/logger"
+	"CryptoLens_Backend/models"
 	"CryptoLens_Backend/storages"
 	"CryptoLens_Backend/types"
+	"CryptoLens_Shared/logger"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/shopspring/decimal"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// ErrOrdersDisabled возвращается при попытке выставить ордер во время остановки сервера
+var ErrOrdersDisabled = errors.New("new orders are disabled: server is shutting down")
+
 // StrategyManager управляет стратегиями
 type StrategyManager struct {
 	strategies         map[string][]types.Strategy // userID -> список стратегий
@@ -18,29 +27,73 @@ type StrategyManager struct {
 	bybitClient        bybit.Client
 	userInstrumentRepo types.UserInstrumentRepositoryInterface
 	bybitAccountRepo   types.BybitAccountRepositoryInterface
+	notifier           types.NotifierInterface
+	accountStopper     types.AccountStrategyStopperInterface // Сервис стратегий: деактивирует их в БД и убирает из менеджера
 	mutex              sync.Mutex
+	stopping           atomic.Bool                     // Сервер останавливается: новые ордера не выставляются
+	disconnected       map[int64]bybit.ConnectionEvent // Потерянные соединения: 0 — публичное, иначе ID аккаунта
 }
 
 // NewStrategyManager создает новый менеджер стратегий
-func NewStrategyManager(client bybit.Client, userInstrumentRepo types.UserInstrumentRepositoryInterface, bybitAccountRepo types.BybitAccountRepositoryInterface) *StrategyManager {
+func NewStrategyManager(
+	client bybit.Client,
+	userInstrumentRepo types.UserInstrumentRepositoryInterface,
+	bybitAccountRepo types.BybitAccountRepositoryInterface,
+	notifier types.NotifierInterface,
+) *StrategyManager {
 	return &StrategyManager{
 		strategies:         make(map[string][]types.Strategy),
 		userInstruments:    make(map[string][]string),
+		disconnected:       make(map[int64]bybit.ConnectionEvent),
 		bybitClient:        client,
 		userInstrumentRepo: userInstrumentRepo,
 		bybitAccountRepo:   bybitAccountRepo,
+		notifier:           notifier,
 	}
 }
 
-// getBybitAccount получает аккаунт Bybit для пользователя
-func (m *StrategyManager) getBybitAccount(ctx context.Context, userID string) (*bybit.BybitAccount, error) {
-	return m.bybitAccountRepo.GetActiveAccountByUserID(ctx, userID)
+// Notify передает событие стратегии в подсистему уведомлений
+func (m *StrategyManager) Notify(ctx context.Context, event models.NotificationEvent) {
+	m.notifier.Notify(ctx, event)
+}
+
+// SetAccountStopper подключает сервис стратегий, через который стратегии останавливают работу на аккаунте.
+// Сервис создается после менеджера и зависит от него, поэтому передается отдельно.
+func (m *StrategyManager) SetAccountStopper(stopper types.AccountStrategyStopperInterface) {
+	m.accountStopper = stopper
 }
 
-// CreateOrder создает ордер
-func (m *StrategyManager) CreateOrder(ctx context.Context, userID, symbol, side, orderType, qty string, price *string) (*bybit.BybitOrderResponse, error) {
+// StopAccountStrategies останавливает все стратегии аккаунта через сервис стратегий: они деактивируются в БД,
+// убираются из менеджера, а пользователь получает уведомление. Остановка выполняется в отдельной горутине,
+// потому что ее вызывает сама стратегия, а RemoveStrategy вызывает ее Stop.
+func (m *StrategyManager) StopAccountStrategies(ctx context.Context, userID string, accountID int64, reason string) {
+	if m.accountStopper == nil {
+		logger.ErrorCtx(ctx, "Сервис стратегий не подключен, стратегии аккаунта %d не остановлены: %s", accountID, reason)
+		return
+	}
+	stopCtx := context.WithoutCancel(ctx)
+	go func() {
+		ctx, cancel := context.WithTimeout(stopCtx, 30*time.Second)
+		defer cancel()
+		if err := m.accountStopper.StopAccountStrategies(ctx, userID, accountID, reason); err != nil {
+			logger.ErrorCtx(ctx, "Ошибка остановки стратегий аккаунта %d: %v", accountID, err)
+		}
+	}()
+}
+
+// getBybitAccount получает активный аккаунт Bybit пользователя, на котором работает стратегия
+func (m *StrategyManager) getBybitAccount(ctx context.Context, userID string, accountID int64) (*bybit.BybitAccount, error) {
+	return m.bybitAccountRepo.GetActiveAccount(ctx, userID, accountID)
+}
+
+// CreateOrder создает ордер на аккаунте пользователя
+func (m *StrategyManager) CreateOrder(ctx context.Context, userID string, accountID int64, symbol, side, orderType, qty string, price *string) (*bybit.BybitOrderResponse, error) {
+	if m.stopping.Load() {
+		return nil, ErrOrdersDisabled
+	}
+
 	// Получаем аккаунт Bybit пользователя
-	account, err := m.getBybitAccount(ctx, userID)
+	account, err := m.getBybitAccount(ctx, userID, accountID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get Bybit account: %w", err)
 	}
@@ -54,28 +107,139 @@ func (m *StrategyManager) CreateOrder(ctx context.Context, userID, symbol, side,
 	return order, nil
 }
 
-// CancelOrder отменяет ордер
-func (m *StrategyManager) CancelOrder(ctx context.Context, userID, symbol, orderID string) error {
+// CancelOrder отменяет ордер на аккаунте пользователя
+func (m *StrategyManager) CancelOrder(ctx context.Context, userID string, accountID int64, symbol, orderID string) error {
 	// Получаем аккаунт Bybit пользователя
-	account, err := m.getBybitAccount(ctx, userID)
+	account, err := m.getBybitAccount(ctx, userID, accountID)
 	if err != nil {
 		return fmt.Errorf("failed to get Bybit account: %w", err)
 	}
 
 	// Отменяем ордер через клиент Bybit
-	if err, _ := m.bybitClient.CancelOrder(ctx, account, symbol, orderID); err != nil {
+	if _, err := m.bybitClient.CancelOrder(ctx, account, symbol, orderID); err != nil {
 		return fmt.Errorf("failed to cancel order: %w", err)
 	}
 
 	return nil
 }
 
+// GetOrder получает текущее состояние ордера через API. Открытые ордера ищутся среди активных,
+// исполненные и отмененные — в истории ордеров. Если ордер не найден нигде, возвращается nil.
+func (m *StrategyManager) GetOrder(ctx context.Context, userID string, accountID int64, symbol, orderID string) (*bybit.BybitOrder, error) {
+	account, err := m.getBybitAccount(ctx, userID, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Bybit account: %w", err)
+	}
+
+	response, err := m.bybitClient.GetOpenOrders(ctx, account, symbol, &orderID, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	if len(response.List) > 0 {
+		return &response.List[0], nil
+	}
+
+	response, err = m.bybitClient.GetOrderHistory(ctx, account, symbol, &orderID, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order history: %w", err)
+	}
+	if len(response.List) == 0 {
+		return nil, nil
+	}
+	return &response.List[0], nil
+}
+
+// getUserAccounts получает активные аккаунты пользователя; отсутствие аккаунтов считается ошибкой
+func (m *StrategyManager) getUserAccounts(ctx context.Context, userID string) ([]bybit.BybitAccount, error) {
+	accounts, err := m.bybitAccountRepo.GetActiveAccountsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Bybit accounts: %w", err)
+	}
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("Bybit account not found for user %s", userID)
+	}
+	return accounts, nil
+}
+
+// GetOpenOrders получает открытые ордера пользователя по его активным инструментам на всех активных аккаунтах
+func (m *StrategyManager) GetOpenOrders(ctx context.Context, userID string) ([]models.AccountOrder, error) {
+	accounts, err := m.getUserAccounts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols, err := m.userInstrumentRepo.GetActiveInstrumentsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active instruments for user %s: %w", userID, err)
+	}
+
+	var orders []models.AccountOrder
+	for i := range accounts {
+		account := &accounts[i]
+		for _, symbol := range symbols {
+			response, err := m.bybitClient.GetOpenOrders(ctx, account, symbol, nil, 50)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get open orders for %s on account %s: %w", symbol, account.DisplayName(), err)
+			}
+			for _, order := range response.List {
+				orders = append(orders, models.AccountOrder{
+					AccountID:  account.ID,
+					Label:      account.Label,
+					BybitOrder: order,
+				})
+			}
+		}
+	}
+
+	return orders, nil
+}
+
+// CancelAllOrders отменяет все ордера пользователя по его активным инструментам на всех активных аккаунтах.
+// Возвращает символы, по которым отмена прошла успешно; при нескольких аккаунтах к символу добавляется аккаунт.
+func (m *StrategyManager) CancelAllOrders(ctx context.Context, userID string) ([]string, error) {
+	accounts, err := m.getUserAccounts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols, err := m.userInstrumentRepo.GetActiveInstrumentsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active instruments for user %s: %w", userID, err)
+	}
+
+	var cancelled []string
+	var errs []error
+	for i := range accounts {
+		account := &accounts[i]
+		for _, symbol := range symbols {
+			name := symbol
+			if len(accounts) > 1 {
+				name = fmt.Sprintf("%s (%s)", symbol, account.DisplayName())
+			}
+			if _, err := m.bybitClient.CancelAllOrders(ctx, account, symbol); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				continue
+			}
+			cancelled = append(cancelled, name)
+		}
+	}
+
+	return cancelled, errors.Join(errs...)
+}
+
 // AddStrategy добавляет стратегию для пользователя
 func (m *StrategyManager) AddStrategy(userID string, strategy types.Strategy) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 	m.strategies[userID] = append(m.strategies[userID], strategy)
 
+	// Стратегия, добавленная без соединения, узнает об этом сразу
+	for accountID, event := range m.disconnected {
+		if accountID == 0 || accountID == strategy.AccountID() {
+			strategy.OnConnection(context.Background(), event)
+		}
+	}
+
 	// Обновляем список активных инструментов
 	ctx := context.Background()
 	symbols, err := m.userInstrumentRepo.GetActiveInstrumentsByUserID(ctx, userID)
@@ -86,17 +250,26 @@ func (m *StrategyManager) AddStrategy(userID string, strategy types.Strategy) {
 	m.userInstruments[userID] = symbols
 }
 
-// RemoveStrategy удаляет стратегию для пользователя
+// RemoveStrategy удаляет стратегию для пользователя и останавливает ее
 func (m *StrategyManager) RemoveStrategy(userID string, strategy types.Strategy) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	removed := false
 	strategies := m.strategies[userID]
 	for i, s := range strategies {
 		if s == strategy {
 			m.strategies[userID] = append(strategies[:i], strategies[i+1:]...)
+			removed = true
 			break
 		}
 	}
+	m.mutex.Unlock()
+
+	// Останавливаем вне блокировки: Stop может отменять ордера через API
+	if removed {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		strategy.Stop(ctx)
+	}
 }
 
 // UpdateUserInstruments обновляет список активных символов пользователя
@@ -112,10 +285,9 @@ func (m *StrategyManager) UpdateUserInstruments(ctx context.Context, userID stri
 	return nil
 }
 
-// isSymbolRelevant проверяет, относится ли символ к активным инструментам пользователя
+// isSymbolRelevant проверяет, относится ли символ к активным инструментам пользователя.
+// Вызывающий должен удерживать m.mutex.
 func (m *StrategyManager) isSymbolRelevant(userID, symbol string) bool {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
 	for _, s := range m.userInstruments[userID] {
 		if s == symbol {
 			return true
@@ -163,40 +335,66 @@ func (m *StrategyManager) HandleTrade(ctx context.Context, trade bybit.TradeMess
 	}
 }
 
-// HandleOrder обрабатывает ордер
-func (m *StrategyManager) HandleOrder(ctx context.Context, order bybit.OrderMessage) {
+// HandleOrder передает обновление ордера стратегиям аккаунта, на котором он выставлен
+func (m *StrategyManager) HandleOrder(ctx context.Context, accountID int64, order bybit.OrderMessage) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 	for userID, strategies := range m.strategies {
 		if m.isSymbolRelevant(userID, order.Symbol) {
 			for _, s := range strategies {
-				s.OnOrder(ctx, order)
+				if s.AccountID() == accountID {
+					s.OnOrder(ctx, order)
+				}
 			}
 		}
 	}
 }
 
-// HandleExecution обрабатывает исполнение
-func (m *StrategyManager) HandleExecution(ctx context.Context, execution bybit.ExecutionMessage) {
+// HandleExecution передает исполнение стратегиям аккаунта
+func (m *StrategyManager) HandleExecution(ctx context.Context, accountID int64, execution bybit.ExecutionMessage) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 	for userID, strategies := range m.strategies {
 		if m.isSymbolRelevant(userID, execution.Symbol) {
 			for _, s := range strategies {
-				s.OnExecution(ctx, execution)
+				if s.AccountID() == accountID {
+					s.OnExecution(ctx, execution)
+				}
 			}
 		}
 	}
 }
 
-// HandleWallet обрабатывает обновление кошелька
-func (m *StrategyManager) HandleWallet(ctx context.Context, wallet bybit.WalletMessage) {
+// HandleWallet передает обновление кошелька стратегиям аккаунта
+func (m *StrategyManager) HandleWallet(ctx context.Context, accountID int64, wallet bybit.WalletMessage) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
 	for _, strategies := range m.strategies {
 		for _, s := range strategies {
-			s.OnWallet(ctx, wallet)
+			if s.AccountID() == accountID {
+				s.OnWallet(ctx, wallet)
+			}
+		}
+	}
+}
+
+// HandleConnection передает событие WebSocket-соединения стратегиям: о публичном соединении — всем,
+// о приватном — стратегиям аккаунта. accountID 0 обозначает публичное соединение.
+func (m *StrategyManager) HandleConnection(ctx context.Context, accountID int64, event bybit.ConnectionEvent) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if event.State == bybit.ConnectionDisconnected {
+		m.disconnected[accountID] = event
+	} else {
+		delete(m.disconnected, accountID)
+	}
+	for _, strategies := range m.strategies {
+		for _, s := range strategies {
+			if accountID == 0 || s.AccountID() == accountID {
+				s.OnConnection(ctx, event)
+			}
 		}
 	}
 }
@@ -212,15 +410,40 @@ func (m *StrategyManager) Start(ctx context.Context) {
 	}
 }
 
-// Stop останавливает все стратегии
+// DisableOrders запрещает выставлять новые ордера; отмена ордеров остается доступной
+func (m *StrategyManager) DisableOrders() {
+	m.stopping.Store(true)
+}
+
+// keepOrdersOnStop сообщает, что стратегии при остановке сервера должны оставить свои ордера на бирже
+func (m *StrategyManager) keepOrdersOnStop() bool {
+	return m.stopping.Load() && !config.Get().Strategies.CancelOrdersOnShutdown
+}
+
+// Stop останавливает все стратегии при остановке сервера. Новые ордера запрещаются, активные
+// отменяются, если это разрешено STRATEGY_CANCEL_ORDERS_ON_SHUTDOWN.
 func (m *StrategyManager) Stop(ctx context.Context) {
+	m.DisableOrders()
+
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	var stopping []types.Strategy
 	for _, strategies := range m.strategies {
-		for _, s := range strategies {
+		stopping = append(stopping, strategies...)
+	}
+	m.strategies = make(map[string][]types.Strategy)
+	m.mutex.Unlock()
+
+	// Останавливаем вне блокировки, как и RemoveStrategy
+	var wg sync.WaitGroup
+	for _, s := range stopping {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
 			s.Stop(ctx)
-		}
+		}()
 	}
+	wg.Wait()
+	logger.LogInfo("Остановлено стратегий: %d", len(stopping))
 }
 
 // GetStrategies возвращает все стратегии пользователя
@@ -270,22 +493,23 @@ func (m *StrategyManager) GetPublicTrades(ctx context.Context, symbol string, li
 	return storages.GetPublicTrades(ctx, symbol, limit)
 }
 
-func (m *StrategyManager) GetPrivateOrder(ctx context.Context, userID, orderID string) (*bybit.OrderMessage, error) {
-	return storages.GetPrivateOrder(ctx, userID, orderID)
+func (m *StrategyManager) GetPrivateOrder(ctx context.Context, accountID int64, orderID string) (*bybit.OrderMessage, error) {
+	return storages.GetPrivateOrder(ctx, accountID, orderID)
 }
 
-func (m *StrategyManager) GetPrivateExecution(ctx context.Context, userID, execID string) (*bybit.ExecutionMessage, error) {
-	return storages.GetPrivateExecution(ctx, userID, execID)
+func (m *StrategyManager) GetPrivateExecution(ctx context.Context, accountID int64, execID string) (*bybit.ExecutionMessage, error) {
+	return storages.GetPrivateExecution(ctx, accountID, execID)
 }
 
-func (m *StrategyManager) GetPrivateWallet(ctx context.Context, userID string) (*bybit.WalletMessage, error) {
-	return storages.GetPrivateWallet(ctx, userID)
+func (m *StrategyManager) GetPrivateWallet(ctx context.Context, accountID int64) (*bybit.WalletMessage, error) {
+	return storages.GetPrivateWallet(ctx, accountID)
 }
 
-// GetWalletBalance получает баланс кошелька через API
-func (m *StrategyManager) GetWalletBalance(ctx context.Context, userID string) (*bybit.BybitWalletBalance, error) {
+// GetWalletBalance получает баланс кошелька аккаунта через API.
+// Если accountID равен 0, используется единственный активный аккаунт пользователя.
+func (m *StrategyManager) GetWalletBalance(ctx context.Context, userID string, accountID int64) (*bybit.BybitWalletBalance, error) {
 	// Получаем аккаунт Bybit пользователя
-	account, err := m.getBybitAccount(ctx, userID)
+	account, err := m.getBybitAccount(ctx, userID, accountID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get Bybit account: %w", err)
 	}
@@ -298,3 +522,60 @@ func (m *StrategyManager) GetWalletBalance(ctx context.Context, userID string) (
 
 	return balance, nil
 }
+
+// GetWalletBalances получает балансы всех активных аккаунтов пользователя и суммирует их по монетам.
+// Ошибка одного аккаунта не мешает вернуть балансы остальных.
+func (m *StrategyManager) GetWalletBalances(ctx context.Context, userID string) (*models.WalletBalancesResponse, error) {
+	accounts, err := m.getUserAccounts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.WalletBalancesResponse{
+		Accounts: make([]models.AccountWalletBalance, 0, len(accounts)),
+		Totals:   []models.CoinBalance{},
+	}
+	totals := make(map[string]*models.CoinBalance)
+	var coins []string
+	for i := range accounts {
+		account := &accounts[i]
+		item := models.AccountWalletBalance{AccountID: account.ID, Label: account.Label}
+		balance, err := m.bybitClient.GetWalletBalance(ctx, account)
+		if err != nil {
+			item.Error = err.Error()
+			response.Accounts = append(response.Accounts, item)
+			continue
+		}
+		item.Balance = balance
+		response.Accounts = append(response.Accounts, item)
+
+		for _, accountBalance := range balance.List {
+			for _, coin := range accountBalance.Coins {
+				total, ok := totals[coin.Coin]
+				if !ok {
+					total = &models.CoinBalance{Coin: coin.Coin}
+					totals[coin.Coin] = total
+					coins = append(coins, coin.Coin)
+				}
+				total.WalletBalance = total.WalletBalance.Add(parseDecimal(coin.WalletBalance))
+				total.Locked = total.Locked.Add(parseDecimal(coin.Locked))
+				total.USDValue = total.USDValue.Add(parseDecimal(coin.USDValue))
+			}
+		}
+	}
+
+	sort.Strings(coins)
+	for _, coin := range coins {
+		response.Totals = append(response.Totals, *totals[coin])
+	}
+	return response, nil
+}
+
+// parseDecimal разбирает число из ответа Bybit; пустая строка считается нулем
+func parseDecimal(value string) decimal.Decimal {
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Zero
+	}
+	return d
+}
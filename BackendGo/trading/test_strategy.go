@@ -2,22 +2,29 @@ package trading
 
 import (
 	"CryptoLens_Backend/integration/bybit"
-	"CryptoLens_Backend/logger"
+	"CryptoLens_Shared/logger"
 	"context"
 )
 
 // TestStrategy - тестовая стратегия для проверки работы системы
 type TestStrategy struct {
-	userID string
+	userID    string
+	accountID int64
 }
 
 // NewTestStrategy создает новую тестовую стратегию
-func NewTestStrategy(userID string) *TestStrategy {
+func NewTestStrategy(userID string, accountID int64) *TestStrategy {
 	return &TestStrategy{
-		userID: userID,
+		userID:    userID,
+		accountID: accountID,
 	}
 }
 
+// AccountID возвращает ID аккаунта Bybit стратегии
+func (s *TestStrategy) AccountID() int64 {
+	return s.accountID
+}
+
 // OnTicker обрабатывает обновление тикера
 func (s *TestStrategy) OnTicker(ctx context.Context, ticker bybit.TickerMessage) {
 	logger.LogInfo("TestStrategy [%s] получил тикер: %s, цена: %s", s.userID, ticker.Symbol, ticker.LastPrice)
@@ -52,6 +59,11 @@ func (s *TestStrategy) OnWallet(ctx context.Context, wallet bybit.WalletMessage)
 	logger.LogInfo("TestStrategy [%s] получил обновление кошелька", s.userID)
 }
 
+// OnConnection обрабатывает обрыв и восстановление соединения
+func (s *TestStrategy) OnConnection(ctx context.Context, event bybit.ConnectionEvent) {
+	logger.LogInfo("TestStrategy [%s] соединение: %s, приватное: %t", s.userID, event.State, event.Private)
+}
+
 // Start запускает стратегию
 func (s *TestStrategy) Start(ctx context.Context) {
 	logger.LogInfo("TestStrategy [%s] запущена", s.userID)
@@ -0,0 +1,15 @@
+package types
+
+import (
+	"CryptoLens_Backend/models"
+	"context"
+)
+
+// AdminServiceInterface определяет интерфейс административных операций над пользователями и стратегиями
+type AdminServiceInterface interface {
+	ListUsers(ctx context.Context, limit, offset int) ([]models.AdminUserResponse, error)
+	SetUserDisabled(ctx context.Context, adminID, userID string, disabled bool) (*models.AdminUserResponse, error)
+	GetUserStrategies(ctx context.Context, userID string) ([]models.UserStrategy, error)
+	StopStrategy(ctx context.Context, adminID, strategyID, reason string) (*models.UserStrategy, error)
+	GetAuthEvents(ctx context.Context, userID string, limit int) ([]models.AuthAuditEntry, error)
+}
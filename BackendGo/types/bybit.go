@@ -6,23 +6,29 @@ import (
 	"context"
 	"github.com/shopspring/decimal"
 	"net/http"
+	"time"
 )
 
 // BybitServiceInterface определяет интерфейс для сервиса Bybit
 type BybitServiceInterface interface {
-	GetWalletBalance(ctx context.Context, token string) (*bybit.BybitWalletBalance, error)
-	GetFeeRate(ctx context.Context, token string, category string, symbol string, baseCoin string) (*bybit.BybitFeeRateResponse, error)
+	GetWalletBalance(ctx context.Context, userID string, accountID int64) (*bybit.BybitWalletBalance, error)
+	GetWalletBalances(ctx context.Context, userID string) (*models.WalletBalancesResponse, error)
+	GetFeeRate(ctx context.Context, userID string, accountID int64, category string, symbol string, baseCoin string) (*bybit.BybitFeeRateResponse, error)
 	GetInstruments(ctx context.Context, category string) ([]models.BybitInstrument, error)
 	StartInstrumentsUpdate(ctx context.Context)
 	StartWebSocket(ctx context.Context)
 	StartPrivateWebSocket(ctx context.Context)
 	GetStrategyManager() StrategyManagerInterface
 	GetUserStrategyService() UserStrategyServiceInterface
+	IsPublicWebSocketConnected() bool
+	GetPublicSubscriptions() models.PublicSubscriptionsStatus
+	GetPrivateWebSocketStatuses() []models.PrivateWebSocketStatus
 }
 
 // BybitHandlerInterface определяет интерфейс для обработчика Bybit
 type BybitHandlerInterface interface {
 	GetWalletBalance(w http.ResponseWriter, r *http.Request)
+	GetWalletBalances(w http.ResponseWriter, r *http.Request)
 	GetFeeRate(w http.ResponseWriter, r *http.Request)
 	GetInstruments(w http.ResponseWriter, r *http.Request)
 }
@@ -30,7 +36,10 @@ type BybitHandlerInterface interface {
 // BybitWebSocketHandlerInterface определяет интерфейс для обработчика WebSocket сообщений
 type BybitWebSocketHandlerInterface interface {
 	HandleMessage(ctx context.Context, msg bybit.WebSocketMessage)
-	HandlePrivateMessage(ctx context.Context, msg bybit.WebSocketMessage, userID string)
+	HandlePrivateMessage(ctx context.Context, msg bybit.WebSocketMessage, userID string, accountID int64)
+	GetLastMessageTimes() map[string]time.Time
+	// Drain прекращает прием сообщений и дожидается обработки полученных
+	Drain(ctx context.Context) error
 }
 
 // StrategyManagerInterface определяет интерфейс для менеджера стратегий
@@ -41,11 +50,13 @@ type StrategyManagerInterface interface {
 	HandleTicker(ctx context.Context, ticker bybit.TickerMessage)
 	HandleOrderBook(ctx context.Context, orderBook bybit.OrderBookMessage)
 	HandleTrade(ctx context.Context, trade bybit.TradeMessage)
-	HandleOrder(ctx context.Context, order bybit.OrderMessage)
-	HandleExecution(ctx context.Context, execution bybit.ExecutionMessage)
-	HandleWallet(ctx context.Context, wallet bybit.WalletMessage)
+	HandleOrder(ctx context.Context, accountID int64, order bybit.OrderMessage)
+	HandleExecution(ctx context.Context, accountID int64, execution bybit.ExecutionMessage)
+	HandleWallet(ctx context.Context, accountID int64, wallet bybit.WalletMessage)
+	HandleConnection(ctx context.Context, accountID int64, event bybit.ConnectionEvent)
 	Start(ctx context.Context)
 	Stop(ctx context.Context)
+	DisableOrders()
 	GetStrategies(userID string) []Strategy
 	GetStrategiesInfo() map[string][]string
 	GetTicker(ctx context.Context, symbol string) (*bybit.TickerMessage, error)
@@ -54,17 +65,50 @@ type StrategyManagerInterface interface {
 	GetOrderBookHistory(ctx context.Context, symbol string, limit int64) ([]bybit.OrderBookMessage, error)
 	GetOrderBookSpread(ctx context.Context, symbol string) (decimal.Decimal, error)
 	GetPublicTrades(ctx context.Context, symbol string, limit int64) ([]bybit.TradeMessage, error)
-	GetPrivateOrder(ctx context.Context, userID, orderID string) (*bybit.OrderMessage, error)
-	GetPrivateExecution(ctx context.Context, userID, execID string) (*bybit.ExecutionMessage, error)
-	GetPrivateWallet(ctx context.Context, userID string) (*bybit.WalletMessage, error)
-	GetWalletBalance(ctx context.Context, userID string) (*bybit.BybitWalletBalance, error)
+	GetPrivateOrder(ctx context.Context, accountID int64, orderID string) (*bybit.OrderMessage, error)
+	GetPrivateExecution(ctx context.Context, accountID int64, execID string) (*bybit.ExecutionMessage, error)
+	GetPrivateWallet(ctx context.Context, accountID int64) (*bybit.WalletMessage, error)
+	GetWalletBalance(ctx context.Context, userID string, accountID int64) (*bybit.BybitWalletBalance, error)
+	GetWalletBalances(ctx context.Context, userID string) (*models.WalletBalancesResponse, error)
+	GetOpenOrders(ctx context.Context, userID string) ([]models.AccountOrder, error)
+	CancelAllOrders(ctx context.Context, userID string) ([]string, error)
+}
+
+// PrivateStreamRefresherInterface запускает внеочередную сверку приватных WebSocket-соединений с аккаунтами
+type PrivateStreamRefresherInterface interface {
+	RefreshPrivateWebSockets()
+}
+
+// PublicStreamRefresherInterface запускает внеочередную сверку публичных WebSocket-подписок с активными инструментами
+type PublicStreamRefresherInterface interface {
+	RefreshPublicSubscriptions()
+}
+
+// AccountStrategyStopperInterface останавливает стратегии, привязанные к аккаунту Bybit
+type AccountStrategyStopperInterface interface {
+	StopAccountStrategies(ctx context.Context, userID string, accountID int64, reason string) error
+}
+
+// BybitAccountServiceInterface определяет интерфейс управления API-ключами Bybit пользователя
+type BybitAccountServiceInterface interface {
+	GetAccounts(ctx context.Context, userID string) ([]models.BybitAccountResponse, error)
+	AddAccount(ctx context.Context, userID string, req models.CreateBybitAccountRequest, meta models.SessionMeta) (*models.BybitAccountResponse, error)
+	RotateCredentials(ctx context.Context, userID string, id int64, req models.RotateBybitAccountRequest, meta models.SessionMeta) (*models.BybitAccountResponse, error)
+	SetStatus(ctx context.Context, userID string, id int64, req models.SetBybitAccountStatusRequest, meta models.SessionMeta) (*models.BybitAccountResponse, error)
+	RemoveAccount(ctx context.Context, userID string, id int64, req models.RemoveBybitAccountRequest, meta models.SessionMeta) error
 }
 
 // BybitAccountRepositoryInterface определяет методы для работы с аккаунтами Bybit
 type BybitAccountRepositoryInterface interface {
-	GetActiveAccountByUserID(ctx context.Context, userID string) (*bybit.BybitAccount, error)
+	GetActiveAccount(ctx context.Context, userID string, id int64) (*bybit.BybitAccount, error)
+	GetActiveAccountsByUserID(ctx context.Context, userID string) ([]bybit.BybitAccount, error)
 	GetActiveAccounts(ctx context.Context) ([]bybit.BybitAccount, error)
-	CreateAccount(ctx context.Context, userID string, apiKey, apiSecret, accountType string) (*bybit.BybitAccount, error)
-	UpdateAccount(ctx context.Context, userID string, apiKey, apiSecret, accountType string, isActive bool) error
-	DeleteAccount(ctx context.Context, userID string) error
+	GetAccountsByUserID(ctx context.Context, userID string) ([]bybit.BybitAccount, error)
+	GetAccount(ctx context.Context, userID string, id int64) (*bybit.BybitAccount, error)
+	CreateAccount(ctx context.Context, userID string, apiKey, apiSecret, accountType, label, environment string) (*bybit.BybitAccount, error)
+	UpdateCredentials(ctx context.Context, userID string, id int64, apiKey, apiSecret, environment string) error
+	SetActive(ctx context.Context, userID string, id int64, isActive bool) error
+	DeleteAccount(ctx context.Context, userID string, id int64) error
+	CountSecretsToReencrypt(ctx context.Context) (int, error)
+	ReencryptSecrets(ctx context.Context) (*models.SecretsReencryptResult, error)
 }
@@ -0,0 +1,12 @@
+package types
+
+import (
+	"CryptoLens_Backend/models"
+	"context"
+)
+
+// HealthServiceInterface определяет интерфейс сервиса проверки состояния
+type HealthServiceInterface interface {
+	Ready(ctx context.Context) *models.ReadinessStatus
+	Status(ctx context.Context) *models.SystemStatus
+}
@@ -10,12 +10,13 @@ type BybitInstrumentRepositoryInterface interface {
 }
 
 type UserStrategyRepositoryInterface interface {
-	Create(ctx context.Context, userID string, strategyName string) (*models.UserStrategy, error)
+	Create(ctx context.Context, userID string, accountID int64, strategyName string) (*models.UserStrategy, error)
 	GetByID(ctx context.Context, id string) (*models.UserStrategy, error)
+	GetUserStrategy(ctx context.Context, userID, id string) (*models.UserStrategy, error)
 	GetByUserID(ctx context.Context, userID string) ([]models.UserStrategy, error)
-	Update(ctx context.Context, id string, isActive bool) error
-	Delete(ctx context.Context, id string) error
-	Exists(ctx context.Context, userID string, strategyName string) (bool, error)
+	Update(ctx context.Context, userID, id string, isActive bool) error
+	Delete(ctx context.Context, userID, id string) error
+	Exists(ctx context.Context, userID string, accountID int64, strategyName string) (bool, error)
 	GetActiveStrategies(ctx context.Context) ([]models.UserStrategy, error)
 	DeactivateAllStrategies(ctx context.Context) error
 }
@@ -0,0 +1,44 @@
+package types
+
+import (
+	"CryptoLens_Backend/models"
+	"context"
+)
+
+// NotificationChannel определяет интерфейс канала доставки уведомлений
+type NotificationChannel interface {
+	// Name возвращает имя канала (telegram, webhook, ...)
+	Name() string
+	// Send доставляет событие на адрес получателя (chat_id, URL и т.п.)
+	Send(ctx context.Context, target string, event models.NotificationEvent) error
+}
+
+// NotifierInterface определяет интерфейс для отправки событий в подсистему уведомлений
+type NotifierInterface interface {
+	Notify(ctx context.Context, event models.NotificationEvent)
+}
+
+// NotificationServiceInterface определяет интерфейс сервиса уведомлений
+type NotificationServiceInterface interface {
+	NotifierInterface
+	RegisterChannel(channel NotificationChannel)
+	Start(ctx context.Context)
+	// Flush доставляет события, оставшиеся в очереди после остановки
+	Flush(ctx context.Context)
+	GetSettings(ctx context.Context, userID string) (*models.NotificationSettingsResponse, error)
+	UpsertChannel(ctx context.Context, userID string, req models.UpsertNotificationChannelRequest) (*models.NotificationChannel, error)
+	RemoveChannel(ctx context.Context, userID string, channel string) error
+	UpdatePreference(ctx context.Context, userID string, req models.UpdateNotificationPreferenceRequest) error
+	SendTest(ctx context.Context, userID string) error
+}
+
+// NotificationRepositoryInterface определяет методы для работы с настройками уведомлений
+type NotificationRepositoryInterface interface {
+	UpsertChannel(ctx context.Context, userID, channel, target string, isActive bool) (*models.NotificationChannel, error)
+	GetChannelsByUserID(ctx context.Context, userID string) ([]models.NotificationChannel, error)
+	DeleteChannel(ctx context.Context, userID, channel string) error
+	SetPreference(ctx context.Context, userID, eventType, channel string, isEnabled bool) error
+	GetPreferencesByUserID(ctx context.Context, userID string) ([]models.NotificationPreference, error)
+	GetSubscribedChannels(ctx context.Context, userID, eventType string) ([]models.NotificationChannel, error)
+	GetUserIDsWithActiveChannels(ctx context.Context) ([]string, error)
+}
@@ -19,8 +19,13 @@ type Strategy interface {
 	OnExecution(ctx context.Context, execution bybit.ExecutionMessage)
 	// OnWallet вызывается при обновлении баланса
 	OnWallet(ctx context.Context, wallet bybit.WalletMessage)
+	// OnConnection вызывается при обрыве и восстановлении WebSocket-соединения: публичного или
+	// приватного соединения аккаунта стратегии. Пока соединения нет, стратегия не должна торговать.
+	OnConnection(ctx context.Context, event bybit.ConnectionEvent)
 	// Start запускает стратегию
 	Start(ctx context.Context)
 	// Stop останавливает стратегию
 	Stop(ctx context.Context)
+	// AccountID возвращает ID аккаунта Bybit, на котором торгует стратегия
+	AccountID() int64
 } 
\ No newline at end of file
@@ -0,0 +1,38 @@
+package types
+
+import (
+	"CryptoLens_Backend/integration/bybit"
+	"context"
+	"github.com/gorilla/websocket"
+	"time"
+)
+
+// StreamPublisherInterface определяет интерфейс для отправки событий в WebSocket-поток клиентов
+type StreamPublisherInterface interface {
+	// Publish отправляет событие всем подключениям пользователя; accountID 0 — событие не относится к аккаунту
+	Publish(userID string, accountID int64, eventType string, data interface{})
+	PublishTicker(ticker bybit.TickerMessage)
+	// PublishOrderBook применяет снимок или дельту Bybit (updateType snapshot/delta) к книге ордеров
+	PublishOrderBook(updateType string, orderBook bybit.OrderBookMessage)
+	// HasSubscribers сообщает, подключен ли пользователь, чтобы не готовить события впустую
+	HasSubscribers(userID string) bool
+}
+
+// StreamSessionCloserInterface закрывает подключения к потоку, когда доступ пользователя отозван
+type StreamSessionCloserInterface interface {
+	// CloseSession закрывает подключения, открытые с токенами сессии
+	CloseSession(sessionID string)
+	// CloseUser закрывает все подключения пользователя, в том числе открытые с API-ключами
+	CloseUser(userID string)
+}
+
+// StreamHubInterface определяет интерфейс сервиса WebSocket-потока клиентов
+type StreamHubInterface interface {
+	StreamPublisherInterface
+	StreamSessionCloserInterface
+	// Serve обслуживает соединение пользователя до его закрытия. sessionID пуст для API-ключей
+	// и старых токенов, нулевой expiresAt означает, что срок доступа не ограничен.
+	Serve(ctx context.Context, conn *websocket.Conn, userID, sessionID string, expiresAt time.Time)
+	// Shutdown закрывает подключения при остановке сервера
+	Shutdown(ctx context.Context) error
+}
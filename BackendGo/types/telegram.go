@@ -0,0 +1,23 @@
+package types
+
+import (
+	"CryptoLens_Backend/models"
+	"context"
+)
+
+// TelegramServiceInterface определяет интерфейс сервиса привязки чатов Telegram
+type TelegramServiceInterface interface {
+	CreateLinkCode(ctx context.Context, userID string, scope string) (*models.TelegramLinkResponse, error)
+	LinkChat(ctx context.Context, code string, chatID int64, username string) (*models.TelegramChat, error)
+	GetChat(ctx context.Context, chatID int64) (*models.TelegramChat, error)
+	GetChats(ctx context.Context, userID string) ([]models.TelegramChat, error)
+	UnlinkChat(ctx context.Context, userID string, chatID int64) error
+}
+
+// TelegramChatRepositoryInterface определяет методы для работы с привязанными чатами
+type TelegramChatRepositoryInterface interface {
+	Link(ctx context.Context, chatID int64, userID, scope, username string) (*models.TelegramChat, error)
+	GetByChatID(ctx context.Context, chatID int64) (*models.TelegramChat, error)
+	GetByUserID(ctx context.Context, userID string) ([]models.TelegramChat, error)
+	Unlink(ctx context.Context, userID string, chatID int64) error
+}
@@ -2,10 +2,15 @@ package types
 
 import (
 	"CryptoLens_Backend/integration/bybit"
+	"CryptoLens_Backend/models"
 	"context"
+	"time"
 )
 
 // TradeLogRepositoryInterface определяет методы для работы с логами торговли
 type TradeLogRepositoryInterface interface {
-	SaveExecution(ctx context.Context, userID string, exec bybit.ExecutionMessage) error
+	SaveExecution(ctx context.Context, userID string, accountID int64, exec bybit.ExecutionMessage) error
+	GetSummary(ctx context.Context, userID string, from, to time.Time) (*models.TradeSummary, error)
+	GetAccountSummaries(ctx context.Context, userID string, from, to time.Time) ([]models.AccountTradeSummary, error)
+	GetPnL(ctx context.Context, userID string, accountID int64, from, to time.Time) ([]models.SymbolPnL, error)
 } 
\ No newline at end of file
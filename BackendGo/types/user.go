@@ -3,28 +3,97 @@ package types
 import (
 	"CryptoLens_Backend/models"
 	"context"
+	"time"
 )
 
 type UserServiceInterface interface {
-	Register(ctx context.Context, req models.RegisterRequest) (*models.RegisterResponse, error)
-	Login(ctx context.Context, req models.LoginRequest) (*models.LoginResponse, error)
-	Logout(ctx context.Context, token string) (*models.LogoutResponse, error)
-	GetAccount(ctx context.Context, token string) (*models.User, error)
+	Register(ctx context.Context, req models.RegisterRequest, meta models.SessionMeta) (*models.RegisterResponse, error)
+	Login(ctx context.Context, req models.LoginRequest, meta models.SessionMeta) (*models.LoginResponse, error)
+	RefreshToken(ctx context.Context, refreshToken string, meta models.SessionMeta) (*models.LoginResponse, error)
+	Logout(ctx context.Context, userID, sessionID string) (*models.LogoutResponse, error)
+	GetAccount(ctx context.Context, userID string) (*models.User, error)
+	GetSessions(ctx context.Context, userID, currentSessionID string) ([]models.UserSession, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	RevokeAllSessions(ctx context.Context, userID string) error
+	VerifyEmail(ctx context.Context, token string, meta models.SessionMeta) error
+	ResendVerification(ctx context.Context, userID string) error
+	RequestPasswordReset(ctx context.Context, email string, meta models.SessionMeta) error
+	ConfirmPasswordReset(ctx context.Context, req models.ConfirmPasswordResetRequest, meta models.SessionMeta) error
+	EnsureEmailVerified(ctx context.Context, userID string) error
+	GetTwoFactorStatus(ctx context.Context, userID string) (*models.TwoFactorStatus, error)
+	SetupTwoFactor(ctx context.Context, userID string) (*models.TwoFactorSetupResponse, error)
+	EnableTwoFactor(ctx context.Context, userID, code string, meta models.SessionMeta) (*models.RecoveryCodesResponse, error)
+	DisableTwoFactor(ctx context.Context, userID string, req models.DisableTwoFactorRequest, meta models.SessionMeta) error
+	RegenerateRecoveryCodes(ctx context.Context, userID, code string, meta models.SessionMeta) (*models.RecoveryCodesResponse, error)
+	VerifySecondFactor(ctx context.Context, userID, code string, meta models.SessionMeta) error
+}
+
+// CredentialsGuardInterface проверяет, может ли пользователь подключать и менять ключи биржи:
+// email должен быть подтвержден, а при включенном втором факторе нужен код из приложения
+type CredentialsGuardInterface interface {
+	EnsureEmailVerified(ctx context.Context, userID string) error
+	VerifySecondFactor(ctx context.Context, userID, code string, meta models.SessionMeta) error
+}
+
+// UserSessionRepositoryInterface определяет методы для работы с сессиями и refresh-токенами
+type UserSessionRepositoryInterface interface {
+	Create(ctx context.Context, userID, tokenHash string, meta models.SessionMeta, expiresAt time.Time) (*models.UserSession, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.UserSession, error)
+	Rotate(ctx context.Context, id, oldHash, newHash string, meta models.SessionMeta) (bool, error)
+	GetActiveByUserID(ctx context.Context, userID string) ([]models.UserSession, error)
+	Revoke(ctx context.Context, userID, id string) error
+	RevokeAll(ctx context.Context, userID string) ([]string, error)
+}
+
+// UserAPIKeyServiceInterface определяет методы управления персональными API-ключами
+type UserAPIKeyServiceInterface interface {
+	CreateKey(ctx context.Context, userID string, req models.CreateAPIKeyRequest) (*models.CreateAPIKeyResponse, error)
+	GetKeys(ctx context.Context, userID string) ([]models.APIKey, error)
+	RemoveKey(ctx context.Context, userID, id string) error
+	Authenticate(ctx context.Context, key string) (*models.APIKeyIdentity, error)
+}
+
+// UserAPIKeyRepositoryInterface определяет методы для работы с хешами API-ключей
+type UserAPIKeyRepositoryInterface interface {
+	Create(ctx context.Context, userID, name, prefix, keyHash string, scopes []string, expiresAt *time.Time) (*models.APIKey, error)
+	GetByUserID(ctx context.Context, userID string) ([]models.APIKey, error)
+	Delete(ctx context.Context, userID, id string) error
+	Authenticate(ctx context.Context, keyHash string) (*models.APIKeyIdentity, error)
+}
+
+// AuthAuditRepositoryInterface определяет методы журнала событий аутентификации
+type AuthAuditRepositoryInterface interface {
+	Record(ctx context.Context, entry models.AuthAuditEntry) error
+	GetLoginHistory(ctx context.Context, userID, ipAddress string) (hasHistory bool, knownIP bool, err error)
+	List(ctx context.Context, userID string, limit int) ([]models.AuthAuditEntry, error)
+}
+
+// UserTOTPRepositoryInterface определяет методы для работы с секретами TOTP и кодами восстановления
+type UserTOTPRepositoryInterface interface {
+	Get(ctx context.Context, userID string) (*models.UserTOTP, error)
+	SaveSecret(ctx context.Context, userID, secret string) error
+	Confirm(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) (bool, error)
+	UseStep(ctx context.Context, userID string, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID string) (int, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error
+	Delete(ctx context.Context, userID string) error
+	CountSecretsToReencrypt(ctx context.Context) (int, error)
+	ReencryptSecrets(ctx context.Context) (*models.SecretsReencryptResult, error)
 }
 
 type UserInstrumentServiceInterface interface {
 	AddInstrument(ctx context.Context, userID string, symbol string) (*models.UserInstrument, error)
 	GetUserInstruments(ctx context.Context, userID string) ([]models.UserInstrument, error)
-	UpdateInstrumentStatus(ctx context.Context, id string, isActive bool) error
-	RemoveInstrument(ctx context.Context, id string) error
+	UpdateInstrumentStatus(ctx context.Context, userID, id string, isActive bool) error
+	RemoveInstrument(ctx context.Context, userID, id string) error
 }
 
 type UserInstrumentRepositoryInterface interface {
 	Create(ctx context.Context, userID string, symbol string) (*models.UserInstrument, error)
 	GetByUserID(ctx context.Context, userID string) ([]models.UserInstrument, error)
-	GetByID(ctx context.Context, id string) (*models.UserInstrument, error)
-	Update(ctx context.Context, id string, isActive bool) error
-	Delete(ctx context.Context, id string) error
+	Update(ctx context.Context, userID, id string, isActive bool) error
+	Delete(ctx context.Context, userID, id string) error
 	Exists(ctx context.Context, userID string, symbol string) (bool, error)
 	GetActiveInstruments(ctx context.Context) ([]string, error)
 	GetActiveInstrumentsByUserID(ctx context.Context, userID string) ([]string, error)
@@ -6,11 +6,14 @@ import (
 )
 
 type UserStrategyServiceInterface interface {
-	AddStrategy(ctx context.Context, userID string, strategyName string) (*models.UserStrategy, error)
+	AddStrategy(ctx context.Context, userID string, strategyName string, accountID int64) (*models.UserStrategy, error)
 	GetUserStrategies(ctx context.Context, userID string) ([]models.UserStrategy, error)
-	UpdateStrategyStatus(ctx context.Context, id string, isActive bool) error
-	RemoveStrategy(ctx context.Context, id string) error
+	UpdateStrategyStatus(ctx context.Context, userID, id string, isActive bool) error
+	RemoveStrategy(ctx context.Context, userID, id string) error
 	GetActiveStrategies(ctx context.Context) ([]models.UserStrategy, error)
 	LoadActiveStrategies(ctx context.Context) error
 	DeactivateAllStrategies(ctx context.Context) error
+	StopAccountStrategies(ctx context.Context, userID string, accountID int64, reason string) error
+	StopUserStrategies(ctx context.Context, userID string, reason string) error
+	StopStrategy(ctx context.Context, id string, reason string) (*models.UserStrategy, error)
 }
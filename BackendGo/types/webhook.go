@@ -0,0 +1,41 @@
+package types
+
+import (
+	"CryptoLens_Backend/models"
+	"context"
+	"time"
+)
+
+// WebhookPublisherInterface определяет интерфейс для публикации событий в исходящие вебхуки
+type WebhookPublisherInterface interface {
+	Publish(ctx context.Context, userID string, eventType string, data interface{})
+}
+
+// WebhookServiceInterface определяет интерфейс сервиса исходящих вебхуков
+type WebhookServiceInterface interface {
+	WebhookPublisherInterface
+	Start(ctx context.Context)
+	CreateEndpoint(ctx context.Context, userID string, req models.CreateWebhookEndpointRequest) (*models.CreateWebhookEndpointResponse, error)
+	GetEndpoints(ctx context.Context, userID string) ([]models.WebhookEndpoint, error)
+	RemoveEndpoint(ctx context.Context, userID string, id string) error
+	SendTest(ctx context.Context, userID string, id string) error
+	GetDeliveries(ctx context.Context, userID string, endpointID string, limit int) ([]models.WebhookDelivery, error)
+	RetryDelivery(ctx context.Context, userID string, id string) error
+}
+
+// WebhookRepositoryInterface определяет методы для работы с эндпоинтами и очередью доставки
+type WebhookRepositoryInterface interface {
+	CreateEndpoint(ctx context.Context, userID, url, secret string, eventTypes []string) (*models.WebhookEndpoint, error)
+	GetEndpointByID(ctx context.Context, userID, id string) (*models.WebhookEndpoint, error)
+	GetEndpointsByUserID(ctx context.Context, userID string) ([]models.WebhookEndpoint, error)
+	GetSubscribedEndpoints(ctx context.Context, userID, eventType string) ([]models.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, userID, id string) error
+	EnqueueDelivery(ctx context.Context, endpointID, userID, eventType string, payload []byte) error
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	MarkDelivered(ctx context.Context, id string, statusCode int) error
+	MarkAttemptFailed(ctx context.Context, id string, statusCode *int, errMsg string, nextAttemptAt *time.Time) error
+	GetDeliveries(ctx context.Context, userID, endpointID string, limit int) ([]models.WebhookDelivery, error)
+	RetryDelivery(ctx context.Context, userID, id string) error
+	CountSecretsToReencrypt(ctx context.Context) (int, error)
+	ReencryptSecrets(ctx context.Context) (*models.SecretsReencryptResult, error)
+}
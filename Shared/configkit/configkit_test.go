@@ -0,0 +1,123 @@
+package configkit
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Server struct {
+		Port int `yaml:"port" env:"TEST_PORT"`
+	} `yaml:"server"`
+	Token   Secret   `yaml:"token" env:"TEST_TOKEN"`
+	Timeout Duration `yaml:"timeout" env:"TEST_TIMEOUT" reload:"true"`
+	Debug   bool     `yaml:"debug" env:"TEST_DEBUG" reload:"true"`
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFileThenEnv(t *testing.T) {
+	path := writeFile(t, "config.yaml", "server:\n  port: 8080\ntoken: from-file\ntimeout: 5m\n")
+	src := Source{"CONFIG_FILE": path, "TEST_PORT": " 9090 ", "TEST_DEBUG": "true", "TEST_TOKEN": ""}
+
+	cfg := &testConfig{Timeout: Duration(time.Minute)}
+	if err := Load(src, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Port != 9090 {
+		t.Errorf("Port = %d, want 9090 from the environment", cfg.Server.Port)
+	}
+	if cfg.Token.Value() != "from-file" {
+		t.Errorf("Token = %q, an empty variable must not override the file", cfg.Token.Value())
+	}
+	if cfg.Timeout.Std() != 5*time.Minute {
+		t.Errorf("Timeout = %s, want 5m", cfg.Timeout)
+	}
+	if !cfg.Debug {
+		t.Error("Debug = false, want true")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  Source
+	}{
+		{"unknown key", Source{"CONFIG_FILE": writeFile(t, "config.yaml", "unknown: 1\n")}},
+		{"unsupported extension", Source{"CONFIG_FILE": writeFile(t, "config.toml", "")}},
+		{"invalid integer", Source{"TEST_PORT": "eighty"}},
+		{"invalid duration", Source{"TEST_TIMEOUT": "5"}},
+	}
+	for _, tt := range tests {
+		if err := Load(tt.src, &testConfig{}); err == nil {
+			t.Errorf("%s: Load succeeded", tt.name)
+		}
+	}
+}
+
+func TestSecretIsMasked(t *testing.T) {
+	if s := Secret("value").String(); s != secretMask {
+		t.Errorf("String = %q, want the mask", s)
+	}
+	data, err := Secret("value").MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `"`+secretMask+`"` {
+		t.Errorf("MarshalJSON = %s, want the mask", data)
+	}
+}
+
+func TestStoreReload(t *testing.T) {
+	next := &testConfig{Timeout: Duration(time.Minute)}
+	store := NewStore(func() (*testConfig, error) {
+		cfg := *next
+		return &cfg, nil
+	})
+	if _, err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	changed := store.Changed()
+
+	next = &testConfig{Timeout: Duration(2 * time.Minute)}
+	next.Server.Port = 9090
+	applied, skipped, err := store.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(applied, []string{"TEST_TIMEOUT"}) || !reflect.DeepEqual(skipped, []string{"TEST_PORT"}) {
+		t.Errorf("applied = %v, skipped = %v", applied, skipped)
+	}
+	cfg := store.Get()
+	if cfg.Timeout.Std() != 2*time.Minute || cfg.Server.Port != 0 {
+		t.Errorf("Get = %+v, want only the reloadable field changed", cfg)
+	}
+	select {
+	case <-changed:
+	default:
+		t.Error("Changed channel was not closed")
+	}
+
+	applied, _, err = store.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("second reload applied %v", applied)
+	}
+	select {
+	case <-store.Changed():
+		t.Error("Changed channel closed without changes")
+	default:
+	}
+}
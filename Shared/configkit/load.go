@@ -0,0 +1,151 @@
+package configkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// dotEnvFile файл с переменными окружения для локального запуска
+const dotEnvFile = ".env"
+
+var durationType = reflect.TypeOf(Duration(0))
+
+// Source переменные окружения процесса поверх значений из .env.
+// Файл .env перечитывается при каждой загрузке, поэтому его изменения тоже подхватываются по SIGHUP.
+type Source map[string]string
+
+// NewSource читает .env и переменные окружения процесса
+func NewSource() (Source, error) {
+	values, err := godotenv.Read(dotEnvFile)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%s: %w", dotEnvFile, err)
+		}
+		values = map[string]string{}
+	}
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			values[key] = value
+		}
+	}
+	return Source(values), nil
+}
+
+// Lookup возвращает непустое значение переменной без пробелов по краям
+func (s Source) Lookup(key string) (string, bool) {
+	value, ok := s[key]
+	if !ok || strings.TrimSpace(value) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(value), true
+}
+
+// Load дополняет cfg — указатель на структуру с умолчаниями — значениями из файла CONFIG_FILE,
+// а затем из переменных окружения по тегам env
+func Load(src Source, cfg interface{}) error {
+	if path, ok := src.Lookup("CONFIG_FILE"); ok {
+		if err := loadFile(path, cfg); err != nil {
+			return err
+		}
+	}
+	return applyEnv(src, reflect.ValueOf(cfg).Elem())
+}
+
+// loadFile читает YAML или JSON файл; неизвестные ключи считаются ошибкой, чтобы опечатки не терялись
+func loadFile(path string, cfg interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("CONFIG_FILE: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(cfg)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(cfg)
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+	default:
+		return fmt.Errorf("CONFIG_FILE: unsupported extension %q, expected .json, .yaml or .yml", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("CONFIG_FILE %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv заполняет поля с тегом env из переменных окружения
+func applyEnv(src Source, v reflect.Value) error {
+	var errs []error
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(src, field); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		name := t.Field(i).Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		value, ok := src.Lookup(name)
+		if !ok {
+			continue
+		}
+		if err := setValue(field, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func setValue(field reflect.Value, value string) error {
+	if field.Type() == durationType {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q (expected a value like 30s, 5m or 1h)", value)
+		}
+		field.SetInt(int64(parsed))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		field.SetInt(int64(parsed))
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q (expected true or false)", value)
+		}
+		field.SetBool(parsed)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package configkit
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// Store хранит текущую конфигурацию приложения и заменяет ее при перезагрузке
+type Store[T any] struct {
+	load    func() (*T, error)
+	mutex   sync.RWMutex
+	current *T
+	changed chan struct{}
+}
+
+// NewStore создает хранилище; load собирает и проверяет конфигурацию
+func NewStore[T any](load func() (*T, error)) *Store[T] {
+	return &Store[T]{load: load, changed: make(chan struct{})}
+}
+
+// Init загружает конфигурацию и делает ее текущей
+func (s *Store[T]) Init() (*T, error) {
+	cfg, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	s.current = cfg
+	s.mutex.Unlock()
+	return cfg, nil
+}
+
+// Get возвращает текущую конфигурацию. Возвращаемое значение нельзя изменять:
+// при перезагрузке оно заменяется целиком.
+func (s *Store[T]) Get() *T {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.current == nil {
+		panic("config: Get called before Init")
+	}
+	return s.current
+}
+
+// Changed возвращает канал, который закрывается после применения перезагруженной конфигурации.
+// После срабатывания канал нужно запросить заново.
+func (s *Store[T]) Changed() <-chan struct{} {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.changed
+}
+
+// Reload перечитывает конфигурацию и применяет поля, помеченные reload:"true".
+// Возвращает имена примененных полей и полей, изменения которых требуют перезапуска.
+// При ошибке загрузки текущая конфигурация сохраняется.
+func (s *Store[T]) Reload() (applied, skipped []string, err error) {
+	loaded, err := s.load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.current == nil {
+		return nil, nil, errors.New("config: Reload called before Init")
+	}
+	next := *s.current
+	applied, skipped = mergeReloadable(reflect.ValueOf(&next).Elem(), reflect.ValueOf(loaded).Elem())
+	if len(applied) > 0 {
+		s.current = &next
+		close(s.changed)
+		s.changed = make(chan struct{})
+	}
+	return applied, skipped, nil
+}
+
+// mergeReloadable копирует в dst отличающиеся поля с тегом reload и возвращает имена
+// примененных и пропущенных полей
+func mergeReloadable(dst, src reflect.Value) (applied, skipped []string) {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			a, s := mergeReloadable(dst.Field(i), src.Field(i))
+			applied = append(applied, a...)
+			skipped = append(skipped, s...)
+			continue
+		}
+		if reflect.DeepEqual(dst.Field(i).Interface(), src.Field(i).Interface()) {
+			continue
+		}
+		name := field.Tag.Get("env")
+		if field.Tag.Get("reload") == "true" {
+			dst.Field(i).Set(src.Field(i))
+			applied = append(applied, name)
+		} else {
+			skipped = append(skipped, name)
+		}
+	}
+	return applied, skipped
+}
@@ -0,0 +1,79 @@
+// Package configkit общая для BackendGo и SmallBot загрузка конфигурации: умолчания, файл CONFIG_FILE,
+// переменные окружения и перезагрузка по SIGHUP. Сами структуры настроек и их проверка остаются в приложениях.
+package configkit
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// secretMask заменяет значение секрета при выводе конфигурации
+const secretMask = "******"
+
+// Secret строка, которая не выводится в логи и при сериализации конфигурации
+type Secret string
+
+// Value возвращает значение секрета
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String скрывает значение секрета
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return secretMask
+}
+
+// MarshalJSON скрывает значение секрета
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// Duration длительность, записываемая в файле конфигурации строкой вида 5m или 1h30m
+type Duration time.Duration
+
+// Std возвращает значение как time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalJSON записывает длительность строкой
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON разбирает длительность из строки
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string like \"5m\": %w", err)
+	}
+	return d.parse(value)
+}
+
+// UnmarshalYAML разбирает длительность из строки
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	var value string
+	if err := node.Decode(&value); err != nil {
+		return fmt.Errorf("duration must be a string like \"5m\": %w", err)
+	}
+	return d.parse(value)
+}
+
+func (d *Duration) parse(value string) error {
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Имена общих полей, по которым фильтруются записи
+const (
+	FieldUserID     = "user_id"
+	FieldAccountID  = "account_id"
+	FieldStrategy   = "strategy"
+	FieldStrategyID = "strategy_id"
+	FieldSymbol     = "symbol"
+	FieldOrderID    = "order_id"
+	FieldAPIKeyID   = "api_key_id"
+	FieldRequestID  = "request_id"
+)
+
+type fieldsKey struct{}
+
+// WithFields возвращает контекст, в котором к записям логов добавляются поля.
+// Поля передаются парами ключ-значение; поля родительского контекста сохраняются,
+// а повторный ключ перекрывает прежнее значение.
+func WithFields(ctx context.Context, keyvals ...interface{}) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	parent := fieldsFromContext(ctx)
+	fields := make([]slog.Attr, 0, len(parent)+len(keyvals)/2)
+	fields = append(fields, parent...)
+
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		var value interface{} = "!MISSING"
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		fields = setField(fields, slog.Any(key, value))
+	}
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+func setField(fields []slog.Attr, field slog.Attr) []slog.Attr {
+	for i := range fields {
+		if fields[i].Key == field.Key {
+			fields[i] = field
+			return fields
+		}
+	}
+	return append(fields, field)
+}
+
+func fieldsFromContext(ctx context.Context) []slog.Attr {
+	fields, _ := ctx.Value(fieldsKey{}).([]slog.Attr)
+	return fields
+}
@@ -0,0 +1,188 @@
+// Package logger общий для BackendGo и SmallBot JSON-логгер с полями из контекста и ротацией файла
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options настройки логгера
+type Options struct {
+	FilePath   string // Путь к файлу лога; пустая строка отключает запись в файл
+	Level      string // debug, info, warn или error
+	MaxSizeMB  int    // Размер файла, после которого он ротируется; 0 отключает ротацию
+	MaxBackups int    // Сколько ротированных файлов хранить
+}
+
+var (
+	// Log совместим со стандартным log.Logger; записи попадают в JSON-лог с уровнем info
+	Log *log.Logger
+
+	level   = new(slog.LevelVar)
+	handler slog.Handler
+)
+
+func init() {
+	// До вызова Init записи идут в stdout, чтобы ранние ошибки не терялись
+	setOutput(os.Stdout)
+}
+
+// Init настраивает вывод JSON-лога в stdout и файл с ротацией
+func Init(opts Options) error {
+	if opts.Level != "" {
+		if err := SetLevel(opts.Level); err != nil {
+			return err
+		}
+	}
+
+	if opts.FilePath == "" {
+		setOutput(os.Stdout)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(opts.FilePath), 0750); err != nil {
+		return err
+	}
+	file, err := openRotatingFile(opts.FilePath, int64(opts.MaxSizeMB)*1024*1024, opts.MaxBackups)
+	if err != nil {
+		return err
+	}
+	setOutput(io.MultiWriter(os.Stdout, file))
+	return nil
+}
+
+func setOutput(w io.Writer) {
+	handler = slog.NewJSONHandler(w, &slog.HandlerOptions{
+		AddSource:   true,
+		Level:       level,
+		ReplaceAttr: replaceAttr,
+	})
+	Log = slog.NewLogLogger(handler, slog.LevelInfo)
+}
+
+// replaceAttr приводит служебные поля записи к виду ts, level, caller, msg
+func replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.TimeKey:
+		return slog.String("ts", a.Value.Time().UTC().Format(time.RFC3339Nano))
+	case slog.LevelKey:
+		return slog.String(slog.LevelKey, levelName(a.Value.Any().(slog.Level)))
+	case slog.SourceKey:
+		source, ok := a.Value.Any().(*slog.Source)
+		if !ok || source == nil {
+			return a
+		}
+		return slog.String("caller", filepath.Base(source.File)+":"+strconv.Itoa(source.Line))
+	}
+	return a
+}
+
+// SetLevel меняет уровень логирования во время работы
+func SetLevel(name string) error {
+	parsed, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.Set(parsed)
+	return nil
+}
+
+// GetLevel возвращает текущий уровень логирования
+func GetLevel() string {
+	return levelName(level.Level())
+}
+
+// ParseLevel разбирает название уровня логирования
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+}
+
+func levelName(l slog.Level) string {
+	switch {
+	case l < slog.LevelInfo:
+		return "debug"
+	case l < slog.LevelWarn:
+		return "info"
+	case l < slog.LevelError:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+// write формирует запись с полями из контекста. Записывается место вызова публичной функции пакета.
+func write(ctx context.Context, l slog.Level, format string, v ...interface{}) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !handler.Enabled(ctx, l) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	record := slog.NewRecord(time.Now(), l, fmt.Sprintf(format, v...), pcs[0])
+	record.AddAttrs(fieldsFromContext(ctx)...)
+	_ = handler.Handle(ctx, record)
+}
+
+// LogError логирует ошибку с информацией о файле и строке
+func LogError(format string, v ...interface{}) {
+	write(context.Background(), slog.LevelError, format, v...)
+}
+
+// LogInfo логирует информационное сообщение
+func LogInfo(format string, v ...interface{}) {
+	write(context.Background(), slog.LevelInfo, format, v...)
+}
+
+// LogDebug логирует отладочное сообщение, если включен уровень debug
+func LogDebug(format string, v ...interface{}) {
+	write(context.Background(), slog.LevelDebug, format, v...)
+}
+
+func LogWarn(format string, v ...interface{}) {
+	write(context.Background(), slog.LevelWarn, format, v...)
+}
+
+// ErrorCtx логирует ошибку с полями из контекста
+func ErrorCtx(ctx context.Context, format string, v ...interface{}) {
+	write(ctx, slog.LevelError, format, v...)
+}
+
+// InfoCtx логирует информационное сообщение с полями из контекста
+func InfoCtx(ctx context.Context, format string, v ...interface{}) {
+	write(ctx, slog.LevelInfo, format, v...)
+}
+
+// DebugCtx логирует отладочное сообщение с полями из контекста
+func DebugCtx(ctx context.Context, format string, v ...interface{}) {
+	write(ctx, slog.LevelDebug, format, v...)
+}
+
+// WarnCtx логирует предупреждение с полями из контекста
+func WarnCtx(ctx context.Context, format string, v ...interface{}) {
+	write(ctx, slog.LevelWarn, format, v...)
+}
@@ -1,23 +1,25 @@
 package main
 
 import (
+	"CryptoLens_Shared/logger"
+	"SmallBot/config"
 	"SmallBot/container"
-	"SmallBot/env"
 	"SmallBot/handlers"
 	"SmallBot/initialization"
-	"SmallBot/logger"
 	"SmallBot/metrics"
 	"context"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 )
 
 func main() {
 	initialization.Initialize()
+	port := strconv.Itoa(config.Get().Server.Port)
 
 	ctr := container.NewContainer()
 
@@ -29,42 +31,59 @@ func main() {
 	metricsHandler := handlers.NewMetricsHandler()
 	http.Handle("/metrics", metricsHandler)
 	http.Handle("/metrics/summary", metricsHandler)
+	http.Handle("/admin/log-level", handlers.NewLogLevelHandler())
 
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	go func() {
-		log.Println("Server starting on " + env.GetServerPort())
-		log.Println("📊 Метрики доступны на http://localhost:" + env.GetServerPort() + "/metrics")
-		log.Println("📊 Сводка метрик: http://localhost:" + env.GetServerPort() + "/metrics/summary")
+		log.Println("Server starting on " + port)
+		log.Println("📊 Метрики доступны на http://localhost:" + port + "/metrics")
+		log.Println("📊 Сводка метрик: http://localhost:" + port + "/metrics/summary")
 
-		log.Println("Server starting on " + env.GetServerPort())
-		if err := http.ListenAndServe(":"+env.GetServerPort(), nil); err != nil {
+		log.Println("Server starting on " + port)
+		if err := http.ListenAndServe(":"+port, nil); err != nil {
 			log.Fatal(err)
 		}
 	}()
 
 	// Периодический вывод метрик в лог
 	go func() {
-		ticker := time.NewTicker(5 * time.Minute)
+		interval := config.Get().Metrics.SummaryInterval.Std()
+		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 
+		changed := config.Changed()
 		for {
 			select {
 			case <-ctx.Done():
 				return
+			case <-changed:
+				changed = config.Changed()
+				if next := config.Get().Metrics.SummaryInterval.Std(); next != interval {
+					interval = next
+					ticker.Reset(interval)
+				}
 			case <-ticker.C:
-				logger.LogInfo(metrics.GetInstance().GetSummary())
+				logger.LogInfo("%s", metrics.GetInstance().GetSummary())
 			}
 		}
 	}()
 
-	<-sigChan
+	// SIGHUP перечитывает конфигурацию, остальные сигналы завершают работу
+	for sig := range sigChan {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		if err := config.Reload(); err != nil {
+			logger.LogError("Ошибка перезагрузки конфигурации, продолжаем со старой: %v", err)
+		}
+	}
 	log.Println("Shutting down gracefully...")
 
 	// Выводим финальные метрики
 	logger.LogInfo("ФИНАЛЬНЫЕ МЕТРИКИ:")
-	logger.LogInfo(metrics.GetInstance().GetSummary())
+	logger.LogInfo("%s", metrics.GetInstance().GetSummary())
 
 	cancel()
 	if err := ctr.Close(); err != nil {
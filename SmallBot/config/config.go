@@ -0,0 +1,192 @@
+package config
+
+import (
+	"CryptoLens_Shared/logger"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var symbolPattern = regexp.MustCompile(`^[A-Z0-9]+$`)
+
+// Config настройки бота.
+// Значения берутся из умолчаний, затем из файла CONFIG_FILE, затем из переменных окружения.
+// Поля с тегом reload:"true" применяются без перезапуска по SIGHUP.
+type Config struct {
+	Server  ServerConfig  `json:"server" yaml:"server"`
+	Log     LogConfig     `json:"log" yaml:"log"`
+	Admin   AdminConfig   `json:"admin" yaml:"admin"`
+	Bybit   BybitConfig   `json:"bybit" yaml:"bybit"`
+	Trading TradingConfig `json:"trading" yaml:"trading"`
+	Metrics MetricsConfig `json:"metrics" yaml:"metrics"`
+}
+
+type ServerConfig struct {
+	Port int `json:"port" yaml:"port" env:"SERVER_PORT"`
+}
+
+type LogConfig struct {
+	Level      string `json:"level" yaml:"level" env:"LOG_LEVEL" reload:"true"`
+	File       string `json:"file" yaml:"file" env:"LOG_FILE"`
+	MaxSizeMB  int    `json:"max_size_mb" yaml:"max_size_mb" env:"LOG_MAX_SIZE_MB"`
+	MaxBackups int    `json:"max_backups" yaml:"max_backups" env:"LOG_MAX_BACKUPS"`
+}
+
+type AdminConfig struct {
+	// Token пустое значение отключает /admin/log-level
+	Token Secret `json:"token" yaml:"token" env:"ADMIN_TOKEN"`
+}
+
+type BybitConfig struct {
+	APIURL        string `json:"api_url" yaml:"api_url" env:"BYBIT_API_URL"`
+	APITestURL    string `json:"api_test_url" yaml:"api_test_url" env:"BYBIT_API_TEST_URL"`
+	WSURL         string `json:"ws_url" yaml:"ws_url" env:"BYBIT_WS_URL"`
+	WSTestURL     string `json:"ws_test_url" yaml:"ws_test_url" env:"BYBIT_WS_TEST_URL"`
+	RecvWindow    int    `json:"recv_window" yaml:"recv_window" env:"BYBIT_RECV_WINDOW"`
+	APIMode       string `json:"api_mode" yaml:"api_mode" env:"BYBIT_API_MODE"`
+	APIToken      Secret `json:"api_token" yaml:"api_token" env:"BYBIT_API_TOKEN"`
+	APISecret     Secret `json:"api_secret" yaml:"api_secret" env:"BYBIT_API_SECRET"`
+	APITokenTest  Secret `json:"api_token_test" yaml:"api_token_test" env:"BYBIT_API_TOKEN_TEST"`
+	APISecretTest Secret `json:"api_secret_test" yaml:"api_secret_test" env:"BYBIT_API_SECRET_TEST"`
+}
+
+type TradingConfig struct {
+	Symbol                 string   `json:"symbol" yaml:"symbol" env:"SYMBOL"`
+	CancelOrdersOnStart    bool     `json:"cancel_orders_on_start" yaml:"cancel_orders_on_start" env:"CANCEL_ORDERS_ON_START"`
+	CancelOrdersOnShutdown bool     `json:"cancel_orders_on_shutdown" yaml:"cancel_orders_on_shutdown" env:"CANCEL_ORDERS_ON_SHUTDOWN"`
+	BuyOrderTimeout        Duration `json:"buy_order_timeout" yaml:"buy_order_timeout" env:"BUY_ORDER_TIMEOUT" reload:"true"`
+	SellOrderTimeout       Duration `json:"sell_order_timeout" yaml:"sell_order_timeout" env:"SELL_ORDER_TIMEOUT" reload:"true"`
+}
+
+type MetricsConfig struct {
+	SummaryInterval Duration `json:"summary_interval" yaml:"summary_interval" env:"METRICS_SUMMARY_INTERVAL" reload:"true"`
+}
+
+// Default возвращает конфигурацию со значениями по умолчанию.
+// Уровень логирования не задается: он зависит от DEBUG и выбирается после чтения всех источников.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{Port: 2600},
+		Log: LogConfig{
+			File:       "logs/app.log",
+			MaxSizeMB:  100,
+			MaxBackups: 5,
+		},
+		Bybit: BybitConfig{
+			APIURL:     "https://api.bybit.com",
+			APITestURL: "https://api-testnet.bybit.com",
+			WSURL:      "wss://stream.bybit.com",
+			WSTestURL:  "wss://stream-testnet.bybit.com",
+			RecvWindow: 5000,
+			APIMode:    "main",
+		},
+		Trading: TradingConfig{
+			Symbol: "BTCUSDT",
+			// По умолчанию ордера отменяются при завершении для безопасности
+			CancelOrdersOnShutdown: true,
+			BuyOrderTimeout:        Duration(5 * time.Minute),
+			SellOrderTimeout:       Duration(15 * time.Minute),
+		},
+		Metrics: MetricsConfig{
+			SummaryInterval: Duration(5 * time.Minute),
+		},
+	}
+}
+
+// IsTestnet сообщает, работает ли бот с тестовой сетью Bybit
+func (c BybitConfig) IsTestnet() bool {
+	return c.APIMode == "test"
+}
+
+// RESTURL возвращает адрес REST API для выбранного режима
+func (c BybitConfig) RESTURL() string {
+	if c.IsTestnet() {
+		return c.APITestURL
+	}
+	return c.APIURL
+}
+
+// StreamURL возвращает адрес WebSocket для выбранного режима без пути потока
+func (c BybitConfig) StreamURL() string {
+	if c.IsTestnet() {
+		return c.WSTestURL
+	}
+	return c.WSURL
+}
+
+// Credentials возвращает ключ и секрет API для выбранного режима
+func (c BybitConfig) Credentials() (apiKey, apiSecret string) {
+	if c.IsTestnet() {
+		return c.APITokenTest.Value(), c.APISecretTest.Value()
+	}
+	return c.APIToken.Value(), c.APISecret.Value()
+}
+
+// Validate проверяет конфигурацию и возвращает все найденные ошибки сразу
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "SERVER_PORT: port must be between 1 and 65535, got %d", c.Server.Port)
+
+	if _, err := logger.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL: %w (expected debug, info, warn or error)", err))
+	}
+	check(c.Log.MaxSizeMB >= 0, "LOG_MAX_SIZE_MB: must not be negative, got %d", c.Log.MaxSizeMB)
+	check(c.Log.MaxBackups >= 0, "LOG_MAX_BACKUPS: must not be negative, got %d", c.Log.MaxBackups)
+
+	check(c.Bybit.APIMode == "main" || c.Bybit.APIMode == "test", "BYBIT_API_MODE: must be main or test, got %q", c.Bybit.APIMode)
+	if c.Bybit.IsTestnet() {
+		errs = append(errs, validURL("BYBIT_API_TEST_URL", c.Bybit.APITestURL, "http", "https")...)
+		errs = append(errs, validURL("BYBIT_WS_TEST_URL", c.Bybit.WSTestURL, "ws", "wss")...)
+		check(c.Bybit.APITokenTest != "", "BYBIT_API_TOKEN_TEST: is required in test mode")
+		check(c.Bybit.APISecretTest != "", "BYBIT_API_SECRET_TEST: is required in test mode")
+	} else {
+		errs = append(errs, validURL("BYBIT_API_URL", c.Bybit.APIURL, "http", "https")...)
+		errs = append(errs, validURL("BYBIT_WS_URL", c.Bybit.WSURL, "ws", "wss")...)
+		check(c.Bybit.APIToken != "", "BYBIT_API_TOKEN: is required in main mode")
+		check(c.Bybit.APISecret != "", "BYBIT_API_SECRET: is required in main mode")
+	}
+	check(c.Bybit.RecvWindow > 0, "BYBIT_RECV_WINDOW: must be positive, got %d", c.Bybit.RecvWindow)
+
+	check(symbolPattern.MatchString(c.Trading.Symbol), "SYMBOL: must be an uppercase trading pair like BTCUSDT, got %q", c.Trading.Symbol)
+	check(c.Trading.BuyOrderTimeout > 0, "BUY_ORDER_TIMEOUT: must be positive, got %s", c.Trading.BuyOrderTimeout)
+	check(c.Trading.SellOrderTimeout > 0, "SELL_ORDER_TIMEOUT: must be positive, got %s", c.Trading.SellOrderTimeout)
+
+	check(c.Metrics.SummaryInterval > 0, "METRICS_SUMMARY_INTERVAL: must be positive, got %s", c.Metrics.SummaryInterval)
+
+	return errors.Join(errs...)
+}
+
+// Redacted возвращает конфигурацию в виде JSON со скрытыми секретами
+func (c *Config) Redacted() string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Sprintf("<failed to marshal config: %v>", err)
+	}
+	return string(data)
+}
+
+func validURL(name, value string, schemes ...string) []error {
+	if value == "" {
+		return []error{fmt.Errorf("%s: is required", name)}
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" {
+		return []error{fmt.Errorf("%s: invalid URL %q", name, value)}
+	}
+	for _, scheme := range schemes {
+		if parsed.Scheme == scheme {
+			return nil
+		}
+	}
+	return []error{fmt.Errorf("%s: scheme must be one of %s, got %q", name, strings.Join(schemes, ", "), parsed.Scheme)}
+}
@@ -1,15 +1,14 @@
 package container
 
 import (
-	"SmallBot/env"
+	"CryptoLens_Shared/logger"
+	"SmallBot/config"
 	"SmallBot/handlers"
 	"SmallBot/integration/bybit"
-	"SmallBot/logger"
 	"SmallBot/services"
 	"SmallBot/types"
 	"context"
 	"fmt"
-	"strconv"
 )
 
 type Container struct {
@@ -19,10 +18,9 @@ type Container struct {
 }
 
 func NewContainer() *Container {
-	recvWindow, _ := strconv.Atoi(env.GetBybitRecvWindow())
-	apiMode := env.GetBybitApiMode()
-	apiUrl := env.GetBybitApiUrl()
-	bybitClient := bybit.NewClient(apiUrl, recvWindow, apiMode == "test")
+	cfg := config.Get()
+	apiKey, apiSecret := cfg.Bybit.Credentials()
+	bybitClient := bybit.NewClient(cfg.Bybit.RESTURL(), cfg.Bybit.RecvWindow, cfg.Bybit.IsTestnet(), apiKey, apiSecret)
 
 	// Сначала создаём bybitService с временным nil wsHandler
 	var bybitService *services.BybitService
@@ -32,9 +30,9 @@ func NewContainer() *Container {
 	wsHandler := handlers.NewBybitWebSocketHandler(bybitService)
 	bybitService.SetWebSocketHandler(wsHandler)
 
-	symbol := env.GetSymbol()
+	symbol := cfg.Trading.Symbol
 	ctx := context.Background()
-	if env.GetCancelOrdersOnStart() {
+	if cfg.Trading.CancelOrdersOnStart {
 		// Отменяем все ордера при старте
 		logger.LogWarn("⚠️  Отмена всех ордеров при старте включена!")
 
@@ -73,12 +71,13 @@ func (c *Container) StartBackgroundTasks(ctx context.Context) {
 }
 
 func (c *Container) Close() error {
-	if env.GetCancelOrdersOnShutdown() {
+	cfg := config.Get()
+	if cfg.Trading.CancelOrdersOnShutdown {
 		ctx := context.Background()
 
 		logger.LogInfo("🛑 Отмена всех ордеров при завершении...")
 
-		symbol := env.GetSymbol()
+		symbol := cfg.Trading.Symbol
 		cancelled, err := c.BybitService.CancelAllOrders(ctx, symbol)
 		if err != nil {
 			logger.LogError("Ошибка при отмене ордеров %s: %v", symbol, err)
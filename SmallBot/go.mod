@@ -3,7 +3,14 @@ module SmallBot
 go 1.24
 
 require (
+	CryptoLens_Shared v0.0.0
 	github.com/gorilla/websocket v1.5.3
-	github.com/joho/godotenv v1.5.1
 	github.com/shopspring/decimal v1.4.0
 )
+
+require (
+	github.com/joho/godotenv v1.5.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace CryptoLens_Shared => ../Shared
@@ -1,9 +1,9 @@
 package handlers
 
 import (
-	"SmallBot/env"
+	"CryptoLens_Shared/logger"
+	"SmallBot/config"
 	"SmallBot/integration/bybit"
-	"SmallBot/logger"
 	"SmallBot/metrics"
 	"SmallBot/types"
 	"context"
@@ -18,8 +18,6 @@ const (
 	EntryOffsetPercent = 0.01 // 0.05%
 	ProfitMultiplier   = 1.5  // 1.5x волатильности
 	OrderSizePercent   = 20.0 // 80% от баланса
-	BuyOrderTimeout    = 5 * time.Minute
-	SellOrderTimeout   = 15 * time.Minute
 )
 
 type BybitWebSocketHandler struct {
@@ -139,32 +137,32 @@ func (h *BybitWebSocketHandler) prepareOrderParams(
 	// Получаем волатильность
 	volatility, err = h.service.GetVolatility(ctx, symbol)
 	if err != nil {
-		logger.LogError("[TradeLogic] Ошибка получения волатильности: %v", err)
+		logger.ErrorCtx(ctx, "[TradeLogic] Ошибка получения волатильности: %v", err)
 		return
 	}
 
 	// Получаем комиссию
 	fee, err = h.service.GetTradingFee(ctx, symbol)
 	if err != nil {
-		logger.LogError("[TradeLogic] Ошибка получения комиссии: %v", err)
+		logger.ErrorCtx(ctx, "[TradeLogic] Ошибка получения комиссии: %v", err)
 		return
 	}
 
 	// Получаем баланс USDT
 	usdBalance, err = h.service.GetUSDTBalance(ctx)
 	if err != nil {
-		logger.LogError("[TradeLogic] Ошибка получения баланса: %v", err)
+		logger.ErrorCtx(ctx, "[TradeLogic] Ошибка получения баланса: %v", err)
 		return
 	}
-	logger.LogInfo("[TradeLogic] Текущий баланс USD: %s", usdBalance.String())
+	logger.InfoCtx(ctx, "[TradeLogic] Текущий баланс USD: %s", usdBalance.String())
 
 	// Проверяем баланс BTC
 	btcBalance, err = h.service.GetBTCBalance(ctx)
 	if err != nil {
-		logger.LogError("[TradeLogic] Ошибка получения баланса BTC: %v", err)
+		logger.ErrorCtx(ctx, "[TradeLogic] Ошибка получения баланса BTC: %v", err)
 		return
 	}
-	logger.LogInfo("[TradeLogic] Текущий баланс BTC: %s", btcBalance.String())
+	logger.InfoCtx(ctx, "[TradeLogic] Текущий баланс BTC: %s", btcBalance.String())
 
 	// Рассчитываем цены для ордеров
 	buyPrice, sellPrice, err = h.service.CalculateOrderPrices(
@@ -177,7 +175,7 @@ func (h *BybitWebSocketHandler) prepareOrderParams(
 		decimal.NewFromFloat(ProfitMultiplier),
 	)
 	if err != nil {
-		logger.LogError("[TradeLogic] Ошибка расчета цен: %v", err)
+		logger.ErrorCtx(ctx, "[TradeLogic] Ошибка расчета цен: %v", err)
 		return
 	}
 
@@ -190,39 +188,40 @@ func (h *BybitWebSocketHandler) prepareOrderParams(
 		currentPrice,
 	)
 	if err != nil {
-		logger.LogError("[TradeLogic] Ошибка расчета размера ордера: %v", err)
+		logger.ErrorCtx(ctx, "[TradeLogic] Ошибка расчета размера ордера: %v", err)
 		return
 	}
 	orderSize = orderSize.Round(6)
 
-	logger.LogInfo("[TradeLogic] buyPrice=%s, sellPrice=%s, orderSize=%s, fee=%s, volatility=%s",
+	logger.InfoCtx(ctx, "[TradeLogic] buyPrice=%s, sellPrice=%s, orderSize=%s, fee=%s, volatility=%s",
 		buyPrice.String(), sellPrice.String(), orderSize.String(), fee.String(), volatility.String())
 
 	return
 }
 
 func (h *BybitWebSocketHandler) handleTickerMessage(ctx context.Context, msg bybit.TickerMessage) {
+	ctx = logger.WithFields(ctx, logger.FieldStrategy, "trade_logic", logger.FieldSymbol, msg.Symbol)
 	jsonStr, _ := json.Marshal(msg)
-	logger.LogDebug("handleTickerMessage: %s", string(jsonStr))
+	logger.DebugCtx(ctx, "handleTickerMessage: %s", string(jsonStr))
 
 	// Проверяем, нет ли активного ордера
 	if h.service.IsOrderActive() {
-		logger.LogDebug("[TradeLogic] Есть активный ордер, пропускаем")
+		logger.DebugCtx(ctx, "[TradeLogic] Есть активный ордер, пропускаем")
 		return
 	}
 
 	// Получаем текущую цену
 	currentPrice, err := decimal.NewFromString(msg.LastPrice)
 	if err != nil {
-		logger.LogError("[TradeLogic] Ошибка парсинга цены: %v", err)
+		logger.ErrorCtx(ctx, "[TradeLogic] Ошибка парсинга цены: %v", err)
 		return
 	}
-	logger.LogInfo("[TradeLogic] Текущая цена: %s для символа %s", currentPrice.String(), msg.Symbol)
+	logger.InfoCtx(ctx, "[TradeLogic] Текущая цена: %s для символа %s", currentPrice.String(), msg.Symbol)
 
 	// Получаем параметры для ордера
 	buyPrice, sellPrice, orderSize, _, _, _, btcBalance, err := h.prepareOrderParams(ctx, msg.Symbol, currentPrice)
 	if err != nil {
-		logger.LogError("[TradeLogic] Ошибка подготовки параметров для ордера: %v", err)
+		logger.ErrorCtx(ctx, "[TradeLogic] Ошибка подготовки параметров для ордера: %v", err)
 		return
 	}
 
@@ -258,15 +257,20 @@ func (h *BybitWebSocketHandler) handleTickerMessage(ctx context.Context, msg byb
 			h.addSellOrderTimer(sellOrder.OrderID)
 		}
 	} else {
-		logger.LogInfo("[TradeLogic] Недостаточно BTC для создания ордера на продажу: баланс=%s, требуется=%s. Продолжаем только с ордером на покупку",
+		logger.InfoCtx(ctx, "[TradeLogic] Недостаточно BTC для создания ордера на продажу: баланс=%s, требуется=%s. Продолжаем только с ордером на покупку",
 			btcBalance.String(), orderSize.String())
 	}
 	h.service.SetOrderActive(true)
 }
 
 func (h *BybitWebSocketHandler) handleOrderMessage(ctx context.Context, msg bybit.OrderMessage) {
+	ctx = logger.WithFields(ctx,
+		logger.FieldStrategy, "trade_logic",
+		logger.FieldSymbol, msg.Symbol,
+		logger.FieldOrderID, msg.OrderID,
+	)
 	jsonStr, _ := json.Marshal(msg)
-	logger.LogDebug("handleOrderMessage: %s", string(jsonStr))
+	logger.DebugCtx(ctx, "handleOrderMessage: %s", string(jsonStr))
 
 	// Если ордер исполнен
 	if msg.OrderStatus == "Filled" {
@@ -281,20 +285,20 @@ func (h *BybitWebSocketHandler) handleOrderMessage(ctx context.Context, msg bybi
 		fee := volume.Mul(decimal.NewFromFloat(0.001)) // 0.1% //TODO рассчитать реальную комиссию
 		metrics.GetInstance().AddFees(fee)
 
-		logger.LogInfo("[TradeLogic] Ордер исполнен: Symbol=%s, Side=%s, Price=%s, Size=%s, OrderID=%s",
+		logger.InfoCtx(ctx, "[TradeLogic] Ордер исполнен: Symbol=%s, Side=%s, Price=%s, Size=%s, OrderID=%s",
 			msg.Symbol, msg.Side, msg.Price, msg.Qty, msg.OrderID)
 
 		// Получаем текущую цену
 		currentPrice, err := decimal.NewFromString(msg.Price)
 		if err != nil {
-			logger.LogError("[TradeLogic] Ошибка парсинга цены: %v", err)
+			logger.ErrorCtx(ctx, "[TradeLogic] Ошибка парсинга цены: %v", err)
 			return
 		}
 
 		// Получаем параметры для ордера
 		buyPrice, sellPrice, _, _, _, usdBalance, btcBalance, err := h.prepareOrderParams(ctx, msg.Symbol, currentPrice)
 		if err != nil {
-			logger.LogError("[TradeLogic] Ошибка подготовки параметров для ордера: %v", err)
+			logger.ErrorCtx(ctx, "[TradeLogic] Ошибка подготовки параметров для ордера: %v", err)
 			return
 		}
 
@@ -305,25 +309,25 @@ func (h *BybitWebSocketHandler) handleOrderMessage(ctx context.Context, msg bybi
 				// Отменяем ордер на продажу
 				_, err = h.service.CancelOrder(ctx, msg.Symbol, sellOrderID)
 				if err != nil {
-					logger.LogError("[TradeLogic] Ошибка отмены ордера на продажу: %v", err)
+					logger.ErrorCtx(ctx, "[TradeLogic] Ошибка отмены ордера на продажу: %v", err)
 				} else {
-					logger.LogInfo("[TradeLogic] Ордер на продажу успешно отменен OrderId=%s", sellOrderID)
+					logger.InfoCtx(ctx, "[TradeLogic] Ордер на продажу успешно отменен OrderId=%s", sellOrderID)
 				}
 			} else {
-				logger.LogWarn("[TradeLogic] SellOrderID пуст, нечего отменять")
+				logger.WarnCtx(ctx, "[TradeLogic] SellOrderID пуст, нечего отменять")
 			}
 
 			// Округляем размер ордера до 6 знаков после запятой
 			qty, err := decimal.NewFromString(msg.Qty)
 			if err != nil {
-				logger.LogError("[TradeLogic] Ошибка парсинга размера ордера: %v", err)
+				logger.ErrorCtx(ctx, "[TradeLogic] Ошибка парсинга размера ордера: %v", err)
 				return
 			}
 			qty = qty.Round(6)
 
 			// Проверяем, достаточно ли BTC для продажи
 			if btcBalance.LessThan(qty) {
-				logger.LogError("[TradeLogic] Недостаточно BTC для продажи: баланс=%s, требуется=%s",
+				logger.ErrorCtx(ctx, "[TradeLogic] Недостаточно BTC для продажи: баланс=%s, требуется=%s",
 					btcBalance.String(), qty.String())
 				return
 			}
@@ -347,18 +351,18 @@ func (h *BybitWebSocketHandler) handleOrderMessage(ctx context.Context, msg bybi
 			if buyOrderID != "" {
 				_, err = h.service.CancelOrder(ctx, msg.Symbol, buyOrderID)
 				if err != nil {
-					logger.LogError("[TradeLogic] Ошибка отмены ордера на покупку: %v", err)
+					logger.ErrorCtx(ctx, "[TradeLogic] Ошибка отмены ордера на покупку: %v", err)
 				} else {
-					logger.LogInfo("[TradeLogic] Ордер на покупку успешно отменен OrderId=%s", buyOrderID)
+					logger.InfoCtx(ctx, "[TradeLogic] Ордер на покупку успешно отменен OrderId=%s", buyOrderID)
 				}
 			} else {
-				logger.LogWarn("[TradeLogic] BuyOrderID пуст, нечего отменять")
+				logger.WarnCtx(ctx, "[TradeLogic] BuyOrderID пуст, нечего отменять")
 			}
 
 			// Округляем размер ордера до 6 знаков после запятой
 			qty, err := decimal.NewFromString(msg.Qty)
 			if err != nil {
-				logger.LogError("[TradeLogic] Ошибка парсинга размера ордера: %v", err)
+				logger.ErrorCtx(ctx, "[TradeLogic] Ошибка парсинга размера ордера: %v", err)
 				return
 			}
 			qty = qty.Round(6)
@@ -368,7 +372,7 @@ func (h *BybitWebSocketHandler) handleOrderMessage(ctx context.Context, msg bybi
 
 			// Проверяем, достаточно ли USDT для покупки
 			if usdBalance.LessThan(requiredUSDT) {
-				logger.LogError("[TradeLogic] Недостаточно USDT для покупки: баланс=%s, требуется=%s",
+				logger.ErrorCtx(ctx, "[TradeLogic] Недостаточно USDT для покупки: баланс=%s, требуется=%s",
 					usdBalance.String(), requiredUSDT.String())
 				return
 			}
@@ -393,7 +397,7 @@ func (h *BybitWebSocketHandler) handleOrderMessage(ctx context.Context, msg bybi
 	// Если ордер отменён
 	if msg.OrderStatus == "Cancelled" {
 		metrics.GetInstance().IncrementOrdersCancelled()
-		logger.LogInfo("[TradeLogic] Ордер отменён: Symbol=%s, Side=%s, OrderID=%s", msg.Symbol, msg.Side, msg.OrderID)
+		logger.InfoCtx(ctx, "[TradeLogic] Ордер отменён: Symbol=%s, Side=%s, OrderID=%s", msg.Symbol, msg.Side, msg.OrderID)
 
 		if msg.Side == "Buy" {
 			h.service.SetBuyOrderID("")
@@ -426,9 +430,9 @@ func (h *BybitWebSocketHandler) handleOrderMessage(ctx context.Context, msg bybi
 		}
 		if (!buyOrderIsExists) && (!sellOrderIsExists) {
 			h.service.SetOrderActive(false)
-			logger.LogInfo("[TradeLogic] Нет активных ордеров, SetOrderActive(false)")
+			logger.InfoCtx(ctx, "[TradeLogic] Нет активных ордеров, SetOrderActive(false)")
 		} else {
-			logger.LogInfo("[TradeLogic] Есть активные ордера, пропускаем, buyID=%s, sellID=%s", buyID, sellID)
+			logger.InfoCtx(ctx, "[TradeLogic] Есть активные ордера, пропускаем, buyID=%s, sellID=%s", buyID, sellID)
 		}
 	}
 }
@@ -560,13 +564,15 @@ func (h *BybitWebSocketHandler) buyOrderTimeoutWatcher() {
 		}
 
 		needToSetActiveFalse := false
+		// Настройки читаются на каждой итерации, чтобы таймауты менялись по SIGHUP без перезапуска
+		trading := config.Get().Trading
 
 		for orderID, created := range h.buyOrderTimers {
-			if time.Since(created) > BuyOrderTimeout && buyOrderIsExists && !sellOrderIsExists {
+			if time.Since(created) > trading.BuyOrderTimeout.Std() && buyOrderIsExists && !sellOrderIsExists {
 				metrics.GetInstance().IncrementOrdersTimeout()
 				// Отменяем buy-ордер
 				if orderID != "" {
-					_, err := h.service.CancelOrder(ctx, env.GetSymbol(), orderID)
+					_, err := h.service.CancelOrder(ctx, trading.Symbol, orderID)
 					if err != nil {
 						logger.LogError("[TradeLogic] Не удалось отменить buy-ордер по таймауту orderID=%s: %v", orderID, err)
 						delete(h.buyOrderTimers, orderID)
@@ -582,11 +588,11 @@ func (h *BybitWebSocketHandler) buyOrderTimeoutWatcher() {
 		}
 
 		for orderID, created := range h.sellOrderTimers {
-			if time.Since(created) > SellOrderTimeout && !buyOrderIsExists && sellOrderIsExists {
+			if time.Since(created) > trading.SellOrderTimeout.Std() && !buyOrderIsExists && sellOrderIsExists {
 				metrics.GetInstance().IncrementOrdersTimeout()
 				// Отменяем sell-ордер
 				if orderID != "" {
-					_, err := h.service.CancelOrder(ctx, env.GetSymbol(), orderID)
+					_, err := h.service.CancelOrder(ctx, trading.Symbol, orderID)
 					if err != nil {
 						logger.LogError("[TradeLogic] Не удалось отменить sell-ордер по таймауту orderID=%s: %v", orderID, err)
 						delete(h.sellOrderTimers, orderID)
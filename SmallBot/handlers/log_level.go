@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"CryptoLens_Shared/logger"
+	"SmallBot/config"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+)
+
+// LogLevelHandler показывает и меняет уровень логирования без перезапуска.
+// Эндпоинт доступен только при заданном ADMIN_TOKEN, который передается в заголовке X-Admin-Token.
+type LogLevelHandler struct{}
+
+// NewLogLevelHandler создает обработчик уровня логирования
+func NewLogLevelHandler() *LogLevelHandler {
+	return &LogLevelHandler{}
+}
+
+type logLevelBody struct {
+	Level string `json:"level"`
+}
+
+// ServeHTTP обрабатывает HTTP запросы
+func (h *LogLevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := config.Get().Admin.Token.Value()
+	if token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Admin-Token")), []byte(token)) != 1 {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.writeLevel(w)
+	case http.MethodPost, http.MethodPut:
+		var body logLevelBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		previous := logger.GetLevel()
+		if err := logger.SetLevel(body.Level); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		logger.LogInfo("Уровень логирования изменен: %s -> %s", previous, logger.GetLevel())
+		h.writeLevel(w)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *LogLevelHandler) writeLevel(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(logLevelBody{Level: logger.GetLevel()}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
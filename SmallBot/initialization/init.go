@@ -1,19 +1,29 @@
 package initialization
 
 import (
-	"SmallBot/env"
-	"SmallBot/logger"
+	"CryptoLens_Shared/logger"
+	"SmallBot/config"
 	"log"
 )
 
 func Initialize() {
-	initLogger()
-	env.Init()
+	// Конфигурация загружается первой: от нее зависят настройки логгера
+	cfg, err := config.Init()
+	if err != nil {
+		log.Fatal(err)
+	}
+	initLogger(cfg.Log)
+	logger.LogInfo("Конфигурация: %s", cfg.Redacted())
 }
 
-func initLogger() {
-	err := logger.Init("logs/app.log")
-	if err != nil {
+func initLogger(cfg config.LogConfig) {
+	opts := logger.Options{
+		FilePath:   cfg.File,
+		Level:      cfg.Level,
+		MaxSizeMB:  cfg.MaxSizeMB,
+		MaxBackups: cfg.MaxBackups,
+	}
+	if err := logger.Init(opts); err != nil {
 		log.Fatal(err)
 	}
 }
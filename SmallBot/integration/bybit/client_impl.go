@@ -1,8 +1,7 @@
 package bybit
 
 import (
-	"SmallBot/env"
-	"SmallBot/logger"
+	"CryptoLens_Shared/logger"
 	"SmallBot/metrics"
 	"bytes"
 	"context"
@@ -24,15 +23,19 @@ type client struct {
 	baseURL    string
 	recvWindow int
 	isTestMode bool
+	apiKey     string
+	apiSecret  string
 	httpClient *http.Client
 }
 
 // NewClient создает новый клиент Bybit
-func NewClient(baseURL string, recvWindow int, isTestMode bool) Client {
+func NewClient(baseURL string, recvWindow int, isTestMode bool, apiKey, apiSecret string) Client {
 	return &client{
 		baseURL:    baseURL,
 		recvWindow: recvWindow,
 		isTestMode: isTestMode,
+		apiKey:     apiKey,
+		apiSecret:  apiSecret,
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
@@ -53,7 +56,7 @@ func (c *client) GetWalletBalance(ctx context.Context) (*BybitWalletBalance, err
 		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
 	}
 
-	req.Header.Set("X-BAPI-API-KEY", env.GetBybitApiToken())
+	req.Header.Set("X-BAPI-API-KEY", c.apiKey)
 	req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
 	req.Header.Set("X-BAPI-RECV-WINDOW", strconv.Itoa(c.recvWindow))
 	req.Header.Set("X-BAPI-SIGN", signature)
@@ -406,7 +409,7 @@ func (c *client) CreateOrder(
 		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
 	}
 
-	req.Header.Set("X-BAPI-API-KEY", env.GetBybitApiToken())
+	req.Header.Set("X-BAPI-API-KEY", c.apiKey)
 	req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
 	req.Header.Set("X-BAPI-RECV-WINDOW", strconv.Itoa(c.recvWindow))
 	req.Header.Set("X-BAPI-SIGN", signature)
@@ -482,7 +485,7 @@ func (c *client) AmendOrder(
 		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
 	}
 
-	req.Header.Set("X-BAPI-API-KEY", env.GetBybitApiToken())
+	req.Header.Set("X-BAPI-API-KEY", c.apiKey)
 	req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
 	req.Header.Set("X-BAPI-RECV-WINDOW", strconv.Itoa(c.recvWindow))
 	req.Header.Set("X-BAPI-SIGN", signature)
@@ -548,7 +551,7 @@ func (c *client) CancelOrder(
 		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
 	}
 
-	req.Header.Set("X-BAPI-API-KEY", env.GetBybitApiToken())
+	req.Header.Set("X-BAPI-API-KEY", c.apiKey)
 	req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
 	req.Header.Set("X-BAPI-RECV-WINDOW", strconv.Itoa(c.recvWindow))
 	req.Header.Set("X-BAPI-SIGN", signature)
@@ -563,7 +566,7 @@ func (c *client) CancelOrder(
 			"  -H 'X-BAPI-SIGN: %s' \\\n"+
 			"  -H 'Content-Type: application/json' \\\n  -d '%s'",
 		c.baseURL,
-		env.GetBybitApiToken(),
+		c.apiKey,
 		timestamp,
 		c.recvWindow,
 		signature,
@@ -572,7 +575,7 @@ func (c *client) CancelOrder(
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		logger.LogError("Curl запрос: %s", err, curlCmd)
+		logger.LogError("Ошибка запроса: %v, curl запрос: %s", err, curlCmd)
 		metrics.GetInstance().IncrementAPIError()
 		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
 	}
@@ -580,26 +583,26 @@ func (c *client) CancelOrder(
 
 	var bybitResp BybitResponse
 	if err := json.NewDecoder(resp.Body).Decode(&bybitResp); err != nil {
-		logger.LogError("Curl запрос: %s", err, curlCmd)
+		logger.LogError("Ошибка запроса: %v, curl запрос: %s", err, curlCmd)
 		metrics.GetInstance().IncrementAPIError()
 		return nil, fmt.Errorf("ошибка декодирования ответа: %w", err)
 	}
 
 	if !bybitResp.IsSuccess() {
-		logger.LogError("Curl запрос: %s", err, curlCmd)
+		logger.LogError("Ошибка API: %s, curl запрос: %s", bybitResp.RetMsg, curlCmd)
 		metrics.GetInstance().IncrementAPIError()
 		return nil, fmt.Errorf("ошибка API: %s", bybitResp.RetMsg)
 	}
 
 	resultBytes, err := json.Marshal(bybitResp.Result)
 	if err != nil {
-		logger.LogError("Curl запрос: %s", err, curlCmd)
+		logger.LogError("Ошибка запроса: %v, curl запрос: %s", err, curlCmd)
 		metrics.GetInstance().IncrementAPIError()
 		return nil, fmt.Errorf("ошибка маршалинга результата: %w", err)
 	}
 	var result BybitOrderResponse
 	if err := json.Unmarshal(resultBytes, &result); err != nil {
-		logger.LogError("Curl запрос: %s", err, curlCmd)
+		logger.LogError("Ошибка запроса: %v, curl запрос: %s", err, curlCmd)
 		metrics.GetInstance().IncrementAPIError()
 		return nil, fmt.Errorf("ошибка декодирования результата: %w", err)
 	}
@@ -633,7 +636,7 @@ func (c *client) CancelAllOrders(
 		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
 	}
 
-	req.Header.Set("X-BAPI-API-KEY", env.GetBybitApiToken())
+	req.Header.Set("X-BAPI-API-KEY", c.apiKey)
 	req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
 	req.Header.Set("X-BAPI-RECV-WINDOW", strconv.Itoa(c.recvWindow))
 	req.Header.Set("X-BAPI-SIGN", signature)
@@ -696,7 +699,7 @@ func (c *client) GetOpenOrders(
 		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
 	}
 
-	req.Header.Set("X-BAPI-API-KEY", env.GetBybitApiToken())
+	req.Header.Set("X-BAPI-API-KEY", c.apiKey)
 	req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
 	req.Header.Set("X-BAPI-RECV-WINDOW", strconv.Itoa(c.recvWindow))
 	req.Header.Set("X-BAPI-SIGN", signature)
@@ -760,7 +763,7 @@ func (c *client) GetFeeRate(
 		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
 	}
 
-	req.Header.Set("X-BAPI-API-KEY", env.GetBybitApiToken())
+	req.Header.Set("X-BAPI-API-KEY", c.apiKey)
 	req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
 	req.Header.Set("X-BAPI-RECV-WINDOW", strconv.Itoa(c.recvWindow))
 	req.Header.Set("X-BAPI-SIGN", signature)
@@ -836,7 +839,7 @@ func (c *client) GetOrderInfo(
 		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
 	}
 
-	req.Header.Set("X-BAPI-API-KEY", env.GetBybitApiToken())
+	req.Header.Set("X-BAPI-API-KEY", c.apiKey)
 	req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
 	req.Header.Set("X-BAPI-RECV-WINDOW", strconv.Itoa(c.recvWindow))
 	req.Header.Set("X-BAPI-SIGN", signature)
@@ -875,8 +878,8 @@ func (c *client) GetOrderInfo(
 
 // генерирует подпись для запроса
 func (c *client) generateSignature(timestamp string, queryParams string) string {
-	paramStr := timestamp + env.GetBybitApiToken() + strconv.Itoa(c.recvWindow) + queryParams
-	h := hmac.New(sha256.New, []byte(env.GetBybitApiSecret()))
+	paramStr := timestamp + c.apiKey + strconv.Itoa(c.recvWindow) + queryParams
+	h := hmac.New(sha256.New, []byte(c.apiSecret))
 	h.Write([]byte(paramStr))
 	return hex.EncodeToString(h.Sum(nil))
 }
@@ -1,7 +1,7 @@
 package bybit
 
 import (
-	"SmallBot/logger"
+	"CryptoLens_Shared/logger"
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
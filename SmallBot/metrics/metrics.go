@@ -175,9 +175,34 @@ func (m *Metrics) GetSnapshot() *Metrics {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	snapshot := *m
-	snapshot.Uptime = time.Since(m.StartTime).String()
-	return &snapshot
+	// Копируем поля по одному: копирование структуры целиком скопировало бы мьютекс,
+	// а карта ошибок осталась бы общей с изменяемым оригиналом
+	errorsByType := make(map[string]int64, len(m.ErrorsByType))
+	for errType, count := range m.ErrorsByType {
+		errorsByType[errType] = count
+	}
+	return &Metrics{
+		OrdersCreated:    m.OrdersCreated,
+		OrdersFilled:     m.OrdersFilled,
+		OrdersCancelled:  m.OrdersCancelled,
+		OrdersTimeout:    m.OrdersTimeout,
+		Errors:           m.Errors,
+		ErrorsByType:     errorsByType,
+		WebSocketErrors:  m.WebSocketErrors,
+		APIErrors:        m.APIErrors,
+		TotalVolume:      m.TotalVolume,
+		TotalFees:        m.TotalFees,
+		RealizedPnL:      m.RealizedPnL,
+		UnrealizedPnL:    m.UnrealizedPnL,
+		LastOrderTime:    m.LastOrderTime,
+		AverageExecTime:  m.AverageExecTime,
+		WebSocketLatency: m.WebSocketLatency,
+		StartTime:        m.StartTime,
+		Uptime:           time.Since(m.StartTime).String(),
+		ActiveOrders:     m.ActiveOrders,
+		LastTickerPrice:  m.LastTickerPrice,
+		CurrentBalance:   m.CurrentBalance,
+	}
 }
 
 // ToJSON возвращает метрики в формате JSON
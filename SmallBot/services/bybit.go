@@ -1,16 +1,15 @@
 package services
 
 import (
-	"SmallBot/env"
+	"CryptoLens_Shared/logger"
+	"SmallBot/config"
 	"SmallBot/integration/bybit"
-	"SmallBot/logger"
 	"SmallBot/metrics"
 	"SmallBot/types"
 	"context"
 	"fmt"
 	"github.com/shopspring/decimal"
 	"math"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -32,9 +31,8 @@ func NewBybitService(
 	bybitClient bybit.Client,
 	wsHandler types.BybitWebSocketHandlerInterface,
 ) *BybitService {
-	recvWindow, _ := strconv.Atoi(env.GetBybitRecvWindow())
-	wsURL := env.GetBybitWsUrl() + "/v5/public/spot"
-	wsClient := bybit.NewWebSocketClient(wsURL, recvWindow, "", "")
+	cfg := config.Get().Bybit
+	wsClient := bybit.NewWebSocketClient(cfg.StreamURL()+"/v5/public/spot", cfg.RecvWindow, "", "")
 
 	return &BybitService{
 		bybitClient: bybitClient,
@@ -103,7 +101,7 @@ func (s *BybitService) StartWebSocket(ctx context.Context) {
 			case <-ctx.Done():
 				return
 			default:
-				symbol := env.GetSymbol()
+				symbol := config.Get().Trading.Symbol
 				publicChannels := []string{fmt.Sprintf("tickers.%s", symbol)}
 				logger.LogInfo("Подписываемся на каналы для активных инструментов: %v", publicChannels)
 
@@ -191,10 +189,9 @@ func (s *BybitService) StartPrivateWebSocket(ctx context.Context) {
 				}
 
 				// Создаем соединения
-				privateWsURL := env.GetBybitWsUrl() + "/v5/private"
-				recvWindow, _ := strconv.Atoi(env.GetBybitRecvWindow())
-
-				wsClient := bybit.NewWebSocketClient(privateWsURL, recvWindow, env.GetBybitApiToken(), env.GetBybitApiSecret())
+				bybitCfg := config.Get().Bybit
+				apiKey, apiSecret := bybitCfg.Credentials()
+				wsClient := bybit.NewWebSocketClient(bybitCfg.StreamURL()+"/v5/private", bybitCfg.RecvWindow, apiKey, apiSecret)
 
 				// Подключаемся и подписываемся
 				if err := wsClient.Connect(ctx); err != nil {
@@ -259,11 +256,11 @@ func (s *BybitService) CreateLimitOrder(ctx context.Context, symbol string, side
 	}
 
 	if err != nil {
-		logger.LogError("[TradeLogic] Ошибка создания ордера на %s: %v", sSide, err)
+		logger.ErrorCtx(ctx, "[TradeLogic] Ошибка создания ордера на %s: %v", sSide, err)
 		return nil, err
 	}
 
-	logger.LogInfo("[TradeLogic] Создан ордер на %s: Symbol=%s, Price=%s, Size=%s, OrderID=%s",
+	logger.InfoCtx(logger.WithFields(ctx, logger.FieldOrderID, order.OrderID), "[TradeLogic] Создан ордер на %s: Symbol=%s, Price=%s, Size=%s, OrderID=%s",
 		sSide, symbol, price, qty, order.OrderID)
 
 	return order, err